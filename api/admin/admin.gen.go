@@ -0,0 +1,595 @@
+// Package Admin provides primitives to interact with the openapi HTTP API.
+//
+// Code generated by github.com/deepmap/oapi-codegen version v1.9.1 DO NOT EDIT.
+package Admin
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/deepmap/oapi-codegen/pkg/runtime"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	AdminTokenScopes = "adminToken.Scopes"
+)
+
+// Defines values for EventType.
+const (
+	EventTypeAccrualCredited EventType = "accrual.credited"
+
+	EventTypeOrderInvalid EventType = "order.invalid"
+
+	EventTypeOrderProcessed EventType = "order.processed"
+
+	EventTypeOrderUploaded EventType = "order.uploaded"
+
+	EventTypeUserDeleted EventType = "user.deleted"
+
+	EventTypeUserRegistered EventType = "user.registered"
+
+	EventTypeWithdrawalCreated EventType = "withdrawal.created"
+)
+
+// Defines values for ProblemCode.
+const (
+	ProblemCodeBadRequest ProblemCode = "bad_request"
+
+	ProblemCodeFeatureDisabled ProblemCode = "feature_disabled"
+
+	ProblemCodeIdempotencyKeyInProgress ProblemCode = "idempotency_key_in_progress"
+
+	ProblemCodeIdempotencyKeyMismatch ProblemCode = "idempotency_key_mismatch"
+
+	ProblemCodeIdempotencyKeyTooLong ProblemCode = "idempotency_key_too_long"
+
+	ProblemCodeInsufficientFunds ProblemCode = "insufficient_funds"
+
+	ProblemCodeInternalError ProblemCode = "internal_error"
+
+	ProblemCodeInvalidConfig ProblemCode = "invalid_config"
+
+	ProblemCodeInvalidCredentials ProblemCode = "invalid_credentials"
+
+	ProblemCodeInvalidOrderNumber ProblemCode = "invalid_order_number"
+
+	ProblemCodeInvalidWebhook ProblemCode = "invalid_webhook"
+
+	ProblemCodeLoginInUse ProblemCode = "login_in_use"
+
+	ProblemCodeMethodNotAllowed ProblemCode = "method_not_allowed"
+
+	ProblemCodeNotFound ProblemCode = "not_found"
+
+	ProblemCodeOrderOwnedByAnotherUser ProblemCode = "order_owned_by_another_user"
+
+	ProblemCodeRequestTooLarge ProblemCode = "request_too_large"
+
+	ProblemCodeSessionNotFound ProblemCode = "session_not_found"
+
+	ProblemCodeTooManyRequests ProblemCode = "too_many_requests"
+
+	ProblemCodeUnauthorized ProblemCode = "unauthorized"
+
+	ProblemCodeUnsupportedContentEncoding ProblemCode = "unsupported_content_encoding"
+
+	ProblemCodeWebhookDeliveryNotFound ProblemCode = "webhook_delivery_not_found"
+
+	ProblemCodeWebhookNotFound ProblemCode = "webhook_not_found"
+)
+
+// ConfigReloadResponse defines model for ConfigReloadResponse.
+type ConfigReloadResponse struct {
+	// Параметры, примененные без перезапуска
+	Applied []string `json:"applied"`
+
+	// Измененные параметры, которые вступят в силу после перезапуска
+	RestartRequired []string `json:"restart_required"`
+}
+
+// EventType defines model for EventType.
+type EventType string
+
+// Описание ошибки в формате RFC 7807
+type Problem struct {
+	// Стабильный машиночитаемый код ошибки
+	Code ProblemCode `json:"code"`
+
+	// Подробности ошибки для этого запроса
+	Detail *string `json:"detail,omitempty"`
+
+	// Путь запроса
+	Instance string `json:"instance"`
+
+	// HTTP статус ответа
+	Status int `json:"status"`
+
+	// Краткое описание ошибки, не зависит от конкретного запроса
+	Title string `json:"title"`
+
+	// URI типа ошибки
+	Type string `json:"type"`
+}
+
+// Стабильный машиночитаемый код ошибки
+type ProblemCode string
+
+// WebhookDeliveriesResponse defines model for WebhookDeliveriesResponse.
+type WebhookDeliveriesResponse []WebhookDelivery
+
+// WebhookDelivery defines model for WebhookDelivery.
+type WebhookDelivery struct {
+	Attempts     int       `json:"attempts"`
+	CreatedAt    string    `json:"created_at"`
+	EventId      string    `json:"event_id"`
+	EventType    EventType `json:"event_type"`
+	Id           string    `json:"id"`
+	LastError    string    `json:"last_error"`
+	SubscriberId string    `json:"subscriber_id"`
+}
+
+// WebhookSubscriber defines model for WebhookSubscriber.
+type WebhookSubscriber struct {
+	CreatedAt  string      `json:"created_at"`
+	EventTypes []EventType `json:"event_types"`
+	Id         string      `json:"id"`
+	Url        string      `json:"url"`
+}
+
+// WebhookSubscriberRequest defines model for WebhookSubscriberRequest.
+type WebhookSubscriberRequest struct {
+	EventTypes []EventType `json:"event_types"`
+
+	// Ключ для подписи тела запроса HMAC-SHA256 (заголовок X-Gophermart-Signature)
+	Secret string `json:"secret"`
+
+	// Адрес, на который отправляются события методом POST
+	Url string `json:"url"`
+}
+
+// WebhookSubscribersResponse defines model for WebhookSubscribersResponse.
+type WebhookSubscribersResponse []WebhookSubscriber
+
+// AddWebhookSubscriberJSONBody defines parameters for AddWebhookSubscriber.
+type AddWebhookSubscriberJSONBody WebhookSubscriberRequest
+
+// GetWebhookDeadLettersParams defines parameters for GetWebhookDeadLetters.
+type GetWebhookDeadLettersParams struct {
+	Limit *int `json:"limit,omitempty"`
+}
+
+// AddWebhookSubscriberJSONRequestBody defines body for AddWebhookSubscriber for application/json ContentType.
+type AddWebhookSubscriberJSONRequestBody AddWebhookSubscriberJSONBody
+
+// ServerInterface represents all server handlers.
+type ServerInterface interface {
+	// Перечитывание конфигурации
+	// (POST /api/admin/config/reload)
+	ReloadConfig(w http.ResponseWriter, r *http.Request)
+	// Список подписчиков
+	// (GET /api/admin/webhooks)
+	GetWebhookSubscribers(w http.ResponseWriter, r *http.Request)
+	// Регистрация подписчика на события
+	// (POST /api/admin/webhooks)
+	AddWebhookSubscriber(w http.ResponseWriter, r *http.Request)
+	// Доставки, для которых исчерпаны все попытки
+	// (GET /api/admin/webhooks/deliveries/dead)
+	GetWebhookDeadLetters(w http.ResponseWriter, r *http.Request, params GetWebhookDeadLettersParams)
+	// Повторная отправка доставки из dead-letter
+	// (POST /api/admin/webhooks/deliveries/{id}/redeliver)
+	RedeliverWebhook(w http.ResponseWriter, r *http.Request, id string)
+	// Удаление подписчика
+	// (DELETE /api/admin/webhooks/{id})
+	DeleteWebhookSubscriber(w http.ResponseWriter, r *http.Request, id string)
+}
+
+// ServerInterfaceWrapper converts contexts to parameters.
+type ServerInterfaceWrapper struct {
+	Handler            ServerInterface
+	HandlerMiddlewares []MiddlewareFunc
+	ErrorHandlerFunc   func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+type MiddlewareFunc func(http.HandlerFunc) http.HandlerFunc
+
+// ReloadConfig operation middleware
+func (siw *ServerInterfaceWrapper) ReloadConfig(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, AdminTokenScopes, []string{""})
+
+	var handler = func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.ReloadConfig(w, r)
+	}
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler(w, r.WithContext(ctx))
+}
+
+// GetWebhookSubscribers operation middleware
+func (siw *ServerInterfaceWrapper) GetWebhookSubscribers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, AdminTokenScopes, []string{""})
+
+	var handler = func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetWebhookSubscribers(w, r)
+	}
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler(w, r.WithContext(ctx))
+}
+
+// AddWebhookSubscriber operation middleware
+func (siw *ServerInterfaceWrapper) AddWebhookSubscriber(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, AdminTokenScopes, []string{""})
+
+	var handler = func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.AddWebhookSubscriber(w, r)
+	}
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler(w, r.WithContext(ctx))
+}
+
+// GetWebhookDeadLetters operation middleware
+func (siw *ServerInterfaceWrapper) GetWebhookDeadLetters(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	ctx = context.WithValue(ctx, AdminTokenScopes, []string{""})
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetWebhookDeadLettersParams
+
+	// ------------- Optional query parameter "limit" -------------
+	if paramValue := r.URL.Query().Get("limit"); paramValue != "" {
+
+	}
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", r.URL.Query(), &params.Limit)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "limit", Err: err})
+		return
+	}
+
+	var handler = func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetWebhookDeadLetters(w, r, params)
+	}
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler(w, r.WithContext(ctx))
+}
+
+// RedeliverWebhook operation middleware
+func (siw *ServerInterfaceWrapper) RedeliverWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameter("simple", false, "id", chi.URLParam(r, "id"), &id)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	ctx = context.WithValue(ctx, AdminTokenScopes, []string{""})
+
+	var handler = func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.RedeliverWebhook(w, r, id)
+	}
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler(w, r.WithContext(ctx))
+}
+
+// DeleteWebhookSubscriber operation middleware
+func (siw *ServerInterfaceWrapper) DeleteWebhookSubscriber(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameter("simple", false, "id", chi.URLParam(r, "id"), &id)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	ctx = context.WithValue(ctx, AdminTokenScopes, []string{""})
+
+	var handler = func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.DeleteWebhookSubscriber(w, r, id)
+	}
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler(w, r.WithContext(ctx))
+}
+
+type UnescapedCookieParamError struct {
+	ParamName string
+	Err       error
+}
+
+func (e *UnescapedCookieParamError) Error() string {
+	return fmt.Sprintf("error unescaping cookie parameter '%s'", e.ParamName)
+}
+
+func (e *UnescapedCookieParamError) Unwrap() error {
+	return e.Err
+}
+
+type UnmarshalingParamError struct {
+	ParamName string
+	Err       error
+}
+
+func (e *UnmarshalingParamError) Error() string {
+	return fmt.Sprintf("Error unmarshaling parameter %s as JSON: %s", e.ParamName, e.Err.Error())
+}
+
+func (e *UnmarshalingParamError) Unwrap() error {
+	return e.Err
+}
+
+type RequiredParamError struct {
+	ParamName string
+}
+
+func (e *RequiredParamError) Error() string {
+	return fmt.Sprintf("Query argument %s is required, but not found", e.ParamName)
+}
+
+type RequiredHeaderError struct {
+	ParamName string
+	Err       error
+}
+
+func (e *RequiredHeaderError) Error() string {
+	return fmt.Sprintf("Header parameter %s is required, but not found", e.ParamName)
+}
+
+func (e *RequiredHeaderError) Unwrap() error {
+	return e.Err
+}
+
+type InvalidParamFormatError struct {
+	ParamName string
+	Err       error
+}
+
+func (e *InvalidParamFormatError) Error() string {
+	return fmt.Sprintf("Invalid format for parameter %s: %s", e.ParamName, e.Err.Error())
+}
+
+func (e *InvalidParamFormatError) Unwrap() error {
+	return e.Err
+}
+
+type TooManyValuesForParamError struct {
+	ParamName string
+	Count     int
+}
+
+func (e *TooManyValuesForParamError) Error() string {
+	return fmt.Sprintf("Expected one value for %s, got %d", e.ParamName, e.Count)
+}
+
+// Handler creates http.Handler with routing matching OpenAPI spec.
+func Handler(si ServerInterface) http.Handler {
+	return HandlerWithOptions(si, ChiServerOptions{})
+}
+
+type ChiServerOptions struct {
+	BaseURL          string
+	BaseRouter       chi.Router
+	Middlewares      []MiddlewareFunc
+	ErrorHandlerFunc func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// HandlerFromMux creates http.Handler with routing matching OpenAPI spec based on the provided mux.
+func HandlerFromMux(si ServerInterface, r chi.Router) http.Handler {
+	return HandlerWithOptions(si, ChiServerOptions{
+		BaseRouter: r,
+	})
+}
+
+func HandlerFromMuxWithBaseURL(si ServerInterface, r chi.Router, baseURL string) http.Handler {
+	return HandlerWithOptions(si, ChiServerOptions{
+		BaseURL:    baseURL,
+		BaseRouter: r,
+	})
+}
+
+// HandlerWithOptions creates http.Handler with additional options
+func HandlerWithOptions(si ServerInterface, options ChiServerOptions) http.Handler {
+	r := options.BaseRouter
+
+	if r == nil {
+		r = chi.NewRouter()
+	}
+	if options.ErrorHandlerFunc == nil {
+		options.ErrorHandlerFunc = func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+	}
+	wrapper := ServerInterfaceWrapper{
+		Handler:            si,
+		HandlerMiddlewares: options.Middlewares,
+		ErrorHandlerFunc:   options.ErrorHandlerFunc,
+	}
+
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/api/admin/config/reload", wrapper.ReloadConfig)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/api/admin/webhooks", wrapper.GetWebhookSubscribers)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/api/admin/webhooks", wrapper.AddWebhookSubscriber)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/api/admin/webhooks/deliveries/dead", wrapper.GetWebhookDeadLetters)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/api/admin/webhooks/deliveries/{id}/redeliver", wrapper.RedeliverWebhook)
+	})
+	r.Group(func(r chi.Router) {
+		r.Delete(options.BaseURL+"/api/admin/webhooks/{id}", wrapper.DeleteWebhookSubscriber)
+	})
+
+	return r
+}
+
+// Base64 encoded, gzipped, json marshaled Swagger object
+var swaggerSpec = []string{
+
+	"H4sIAAAAAAAC/+xa3W4bxxV+lcW2Fy1CkZTsNAHvXKe1jbaoYSlIAFsgRtwROfHuzmZ2KIc1BIhiUqew",
+	"GxVBixYFmjbwC1CUWK8oiXqFM29UnLNL7g9XsuzGag34wgY5Oztzfr7v/FGP7Zb0AulzX4d247Edtjrc",
+	"Y/TxpvQ3RfsedyVz7vEwkH7IcT1QMuBKC067WBC4gjv40eFhS4lAC+nbDRv+CUOzA0M4gbHZNTvmacWC",
+	"M7MDEa7AKf07NU9hbME+jOGFBWcwNjv4EYZwZgamDxMY2hWbf8G8wOV2477tynbV5VvctdcrttDcIyF0",
+	"L+B2ww61En7b3q7MFphSrIffFQ81U7qp+OddoUrF/Ru8WBTsrESHCUzNLkzNTrxlZPpm1wzgzOyZXQtG",
+	"lulDBMdmgG9PTR+OYXwp1UKutriqMsdRPAxfRT9ScKbY/blLStRen78qNz7jLY1n/WKL+3qNVh/b3O96",
+	"eEY35KqqeFuEmis6i1Yc7nJNX6VyuKp2A0RHZiFQssXDMLMi/C3mCvzOWi3VZW61pbgj4lMeCd1xFHsU",
+	"rzKdEzFV966SGy73Srz2HZxBZPowhFOI0NBT8zVEsA8TiMgZX6Kn4ASGZhfG1r1f3rQ++LD+gV0pwLgl",
+	"HdL/x4pv2g37R7WUFrWEE7VEipu4dbtiO1wz4ZYCfwqHZgemsA+nCAGzC1FBskM4NnuW+SMiCQ5gaiXI",
+	"2MH9hIwFIwg/1Mxv8bIbzcDsmmeLh8zhZddYIGrow9oGc/GY2sz2ZXeFmuluuHjT7bW1uxYpNETQm75F",
+	"ZBghPfIXXq+vzM8VvuZtrgi4QrtlGvwdaWZ2kVzkxQvcWrGQo7GuI9oVIfGm+B++fgoTpJrZhdPzbJua",
+	"5Y4fdjc3RUtwX1ubXd8Jy8yhe0GJ0B/fu2OhazFK5CTM3dBVfqMtgw5XHlO6EcQgaojMxc1zLi7wmp7O",
+	"TDj3USXGbgYfZSTPYnfR+t+j92Cf4tYzin1HFnEGVUIIP0EbwxDGcBI/REMfLiidBI8N5lDI4aHGwOGz",
+	"ru5IJX5HlPelbm7Kro+fPa470mniEnNd+Yg2uLIt/Kbwm90wVoviRxOjBve1YC7qHPIwFNJvZk+jeNOU",
+	"j3zuNDd6TeZL3eEKj1GZc+JdftfbSJZL/CAc7gVSc7/Vaz7kvaaWsulKv13yyBOhx3SrU/JI+M1AyTZF",
+	"8/T+R3yjI+VDDH7xp5wOszWHu2KLq17u4dwUlJnJtGE3CKTSnBY16sD9lnQEyZr4IBafqTahR8qmx/ze",
+	"zEEo2iZnuqt40xEh23B5fJXmymdukyslVWlQ/iQW9aNYUsHDbJEwT10XBdT8Cb2yxF3cslh/aM29QGfT",
+	"ZCbcJEmlyTQ+T0m5Ul+pLy2vLC3X15bfbyyvNOrL79WvNer1MvZzTJBN4ZRm4vjhLEBcpG2aZzGYl5/m",
+	"slAnJi97HHY3kLcbXJWLUwgYlHXz72S0ycleSQ2ZkyJnwrLAkjhodX7Loot+QCfgcnhpfOUsXkTWOR7o",
+	"KveShsWdeble3Vj3kii5YLMfXN2QtxTXZZkXjs035smsJMGiFQ6T9BthfhvDMQwLOdS6/ZsbN5dWb99Y",
+	"ef9n1k/o2QFM4RimMIIpTKxPl27Nc97Sqmj7FGF+WubZxOIFsf5EJdTY9CndD/Nl91FcdpxRbT5Cuc03",
+	"Ztf0saTqY91lnmJiRnWocieVpnBi3f3t6tpL82yZYxPzXcqprx0I0yNKa/yQt7pK6N4qvpVEP8cT/pp8",
+	"yH26Cy3X4cyhzOYzD9//dOkGblqKd6XHBuJXHM+lynJTlrsATqgAiKjH2YGIqtpRWpadwhAdQ3AhpGD5",
+	"AFM4oi4I34pLBguhYfZmz+lBVLXgrymocNMQRrGPIYIXZjB3agFgE/LlgmJxaWmnwLNog3Xj7h27Ym9x",
+	"FcaKLVfraF8ZcJ8Fwm7Y16r16opdsQOmO2RWKpbJtrU419YUtcH4LJChLi36qcGLKyXzlIw0NrvYgAzh",
+	"CI6T2tR8CREcmAGVu7+HCHuBKG0PT7LN5xQLWTOAf9NiZPao+RzChEhKFoYDcgF2m6t3bt3++G71gQ/P",
+	"Ey+N4RR7gmOqgnOuo6OmcGB2ku9P4itgnEiDlkaPRnhhvDDzzBRG+WBAC5Ocw2FskYJ9PBgLR0IK2Yb6",
+	"Ybrr6IGfnQfkOEyCvTADFDNuN+aVaVlXbi29vNlGy/yFdlBjeL47xgT2Z4WegxSbmIH5wxz5CSmmcBT3",
+	"dNSVFHR5gMjEwM4QKHccu2HHE5WbsxpOJcGCgLdSr8fdKNVy8/lKi96ufRZKPx3SvCyolM5viO8LGaDE",
+	"EpQKCtOaIdLmen35AhGT9ua9VxN11t+XSfcPGFN/uZP0JRTNJygPxotihBomKE1EvX6lopbFgIRV56Lt",
+	"tAgmNPSUpF9ZuVLpv8tPTs4T+fJUiOYTtYjCSd88Q73er9evVK9v4ZTGIztJbN0ze1luDzFyod9ikA3j",
+	"VNv1PKZ6r+dUzEWsHWIpcR637HW8JZNmkr6PgkA7rtPyUeMW14uFxpsMHxeUNWVWfm76FHe/JjighWEf",
+	"9YV9KtUmCyXkWxZL3jLUfp9U8FOY5Ep6hDHhdpRF6bcwhn3zlRkge+317cq8ysmD8IbjLJaq8znDz6XT",
+	"uwT85g1gocu5XzJCLp8Pz3oZO7zWUtd00os17I7WQdio1VrKqya3VFvSqxGzaukQjvqh1yPBrGHbzjcN",
+	"WnX59gIZl98cGc/JPwU/E+UIQgdlNXxMwfr/loKZAf27CHGFEeJfOVSkNd9CrIiL90JjfVH0KM9sNWc+",
+	"KKw5nDmXyHQfceb8mmsdZ7qAKeZx+tK4nzS7n3dxKDjvdV3hCQwHqXEdvsm6rrYby/V6xfbYF8LDGfVy",
+	"nb4KP/m6+FPF9vqbz60lo9N3qfX/njh/nreko+TnqGRylplQma+shEF4xBkGW5xvjPDkmGNnxKQJRP8d",
+	"kx4LZ7umeLKSnU8Um85kyyfznx/K+IQTkJROwrGLSS7LreIUbZExKyWDkrz5hknjnizEs4Eh9R/TxHpj",
+	"ODTP3jJIX3XnuWDUWVsJR3CY9u5vXeuVjp1moS8z9iVFD/NspK7Twvyy5FLmeA16Iadi4Lpc80UufUTr",
+	"ZYXwFVDq+nl/cJCr+8wADnFmhnZ/R52XDG2Kxlsgz1tIneep/yGa5ZxCXXchNTK/NxCWs7803F/fXq8k",
+	"f68UIz3tvxq1mitbzO3IUDc+rH9Yt7fXt/8zADZtNw5mJgAA",
+}
+
+// GetSwagger returns the content of the embedded swagger specification file
+// or error if failed to decode
+func decodeSpec() ([]byte, error) {
+	zipped, err := base64.StdEncoding.DecodeString(strings.Join(swaggerSpec, ""))
+	if err != nil {
+		return nil, fmt.Errorf("error base64 decoding spec: %s", err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(zipped))
+	if err != nil {
+		return nil, fmt.Errorf("error decompressing spec: %s", err)
+	}
+	var buf bytes.Buffer
+	_, err = buf.ReadFrom(zr)
+	if err != nil {
+		return nil, fmt.Errorf("error decompressing spec: %s", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+var rawSpec = decodeSpecCached()
+
+// a naive cached of a decoded swagger spec
+func decodeSpecCached() func() ([]byte, error) {
+	data, err := decodeSpec()
+	return func() ([]byte, error) {
+		return data, err
+	}
+}
+
+// Constructs a synthetic filesystem for resolving external references when loading openapi specifications.
+func PathToRawSpec(pathToFile string) map[string]func() ([]byte, error) {
+	var res = make(map[string]func() ([]byte, error))
+	if len(pathToFile) > 0 {
+		res[pathToFile] = rawSpec
+	}
+
+	return res
+}
+
+// GetSwagger returns the Swagger specification corresponding to the generated code
+// in this file. The external references of Swagger specification are resolved.
+// The logic of resolving external references is tightly connected to "import-mapping" feature.
+// Externally referenced files must be embedded in the corresponding golang packages.
+// Urls can be supported but this task was out of the scope.
+func GetSwagger() (swagger *openapi3.T, err error) {
+	var resolvePath = PathToRawSpec("")
+
+	loader := openapi3.NewLoader()
+	loader.IsExternalRefsAllowed = true
+	loader.ReadFromURIFunc = func(loader *openapi3.Loader, url *url.URL) ([]byte, error) {
+		var pathToFile = url.String()
+		pathToFile = path.Clean(pathToFile)
+		getSpec, ok := resolvePath[pathToFile]
+		if !ok {
+			err1 := fmt.Errorf("path not found: %s", pathToFile)
+			return nil, err1
+		}
+		return getSpec()
+	}
+	var specData []byte
+	specData, err = rawSpec()
+	if err != nil {
+		return
+	}
+	swagger, err = loader.LoadFromData(specData)
+	if err != nil {
+		return
+	}
+	return
+}
@@ -6,6 +6,7 @@ package Gophermart
 import (
 	"bytes"
 	"compress/gzip"
+	"context"
 	"encoding/base64"
 	"fmt"
 	"net/http"
@@ -13,10 +14,22 @@ import (
 	"path"
 	"strings"
 
+	"github.com/deepmap/oapi-codegen/pkg/runtime"
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/go-chi/chi/v5"
 )
 
+const (
+	BearerAuthScopes = "bearerAuth.Scopes"
+)
+
+// Defines values for LedgerEntryType.
+const (
+	LedgerEntryTypeAccrual LedgerEntryType = "accrual"
+
+	LedgerEntryTypeWithdrawal LedgerEntryType = "withdrawal"
+)
+
 // Defines values for OrderStatus.
 const (
 	OrderStatusINVALID OrderStatus = "INVALID"
@@ -28,9 +41,79 @@ const (
 	OrderStatusPROCESSING OrderStatus = "PROCESSING"
 )
 
-// Amount defines model for Amount.
+// Defines values for ProblemCode.
+const (
+	ProblemCodeBadRequest ProblemCode = "bad_request"
+
+	ProblemCodeFeatureDisabled ProblemCode = "feature_disabled"
+
+	ProblemCodeIdempotencyKeyInProgress ProblemCode = "idempotency_key_in_progress"
+
+	ProblemCodeIdempotencyKeyMismatch ProblemCode = "idempotency_key_mismatch"
+
+	ProblemCodeIdempotencyKeyTooLong ProblemCode = "idempotency_key_too_long"
+
+	ProblemCodeInsufficientFunds ProblemCode = "insufficient_funds"
+
+	ProblemCodeInternalError ProblemCode = "internal_error"
+
+	ProblemCodeInvalidConfig ProblemCode = "invalid_config"
+
+	ProblemCodeInvalidCredentials ProblemCode = "invalid_credentials"
+
+	ProblemCodeInvalidOrderNumber ProblemCode = "invalid_order_number"
+
+	ProblemCodeInvalidWebhook ProblemCode = "invalid_webhook"
+
+	ProblemCodeLoginInUse ProblemCode = "login_in_use"
+
+	ProblemCodeMethodNotAllowed ProblemCode = "method_not_allowed"
+
+	ProblemCodeNotFound ProblemCode = "not_found"
+
+	ProblemCodeOrderOwnedByAnotherUser ProblemCode = "order_owned_by_another_user"
+
+	ProblemCodeRequestTooLarge ProblemCode = "request_too_large"
+
+	ProblemCodeSessionNotFound ProblemCode = "session_not_found"
+
+	ProblemCodeTooManyRequests ProblemCode = "too_many_requests"
+
+	ProblemCodeUnauthorized ProblemCode = "unauthorized"
+
+	ProblemCodeUnsupportedContentEncoding ProblemCode = "unsupported_content_encoding"
+
+	ProblemCodeWebhookDeliveryNotFound ProblemCode = "webhook_delivery_not_found"
+
+	ProblemCodeWebhookNotFound ProblemCode = "webhook_not_found"
+)
+
+// Сумма баллов с точностью до сотых. multipleOf не используется: при проверке в числах с плавающей точкой ему не соответствуют суммы вроде 0.07
 type Amount float32
 
+// DeleteUserRequest defines model for DeleteUserRequest.
+type DeleteUserRequest struct {
+	Password string `json:"password"`
+}
+
+// LedgerEntry defines model for LedgerEntry.
+type LedgerEntry struct {
+	// Сумма баллов с точностью до сотых. multipleOf не используется: при проверке в числах с плавающей точкой ему не соответствуют суммы вроде 0.07
+	Amount Amount `json:"amount"`
+
+	// Время проведения. Для начисления - время загрузки заказа
+	CreatedAt string `json:"created_at"`
+
+	// Номер заказа
+	Order string `json:"order"`
+
+	// Начисление за заказ или списание в счет заказа
+	Type LedgerEntryType `json:"type"`
+}
+
+// Начисление за заказ или списание в счет заказа
+type LedgerEntryType string
+
 // LoginRequest defines model for LoginRequest.
 type LoginRequest struct {
 	Login    string `json:"login"`
@@ -39,7 +122,7 @@ type LoginRequest struct {
 
 // Order defines model for Order.
 type Order struct {
-	// Начисленные баллы. Может быть 0 или отсутствовать
+	// Начисленные баллы с точностью до сотых. Может быть 0 или отсутствовать
 	Accrual *float32 `json:"accrual,omitempty"`
 
 	// Номер заказа
@@ -58,43 +141,147 @@ type OrderStatus string
 // OrdersResponse defines model for OrdersResponse.
 type OrdersResponse []Order
 
+// Описание ошибки в формате RFC 7807
+type Problem struct {
+	// Стабильный машиночитаемый код ошибки
+	Code ProblemCode `json:"code"`
+
+	// Подробности ошибки для этого запроса
+	Detail *string `json:"detail,omitempty"`
+
+	// Путь запроса
+	Instance string `json:"instance"`
+
+	// HTTP статус ответа
+	Status int `json:"status"`
+
+	// Краткое описание ошибки, не зависит от конкретного запроса
+	Title string `json:"title"`
+
+	// URI типа ошибки
+	Type string `json:"type"`
+}
+
+// Стабильный машиночитаемый код ошибки
+type ProblemCode string
+
 // RegisterRequest defines model for RegisterRequest.
 type RegisterRequest struct {
 	Login    string `json:"login"`
 	Password string `json:"password"`
 }
 
+// Session defines model for Session.
+type Session struct {
+	// Время входа
+	CreatedAt string `json:"created_at"`
+
+	// Сессия, с токеном которой выполнен запрос
+	Current bool `json:"current"`
+
+	// Идентификатор сессии
+	Id string `json:"id"`
+
+	// IP адрес, с которого выполнен вход
+	Ip string `json:"ip"`
+
+	// Время последнего запроса
+	LastSeenAt string `json:"last_seen_at"`
+
+	// User-Agent устройства, с которого выполнен вход
+	UserAgent string `json:"user_agent"`
+}
+
+// SessionsResponse defines model for SessionsResponse.
+type SessionsResponse []Session
+
 // UserBalanceResponse defines model for UserBalanceResponse.
 type UserBalanceResponse struct {
-	Current   Amount `json:"current"`
+	// Сумма баллов с точностью до сотых. multipleOf не используется: при проверке в числах с плавающей точкой ему не соответствуют суммы вроде 0.07
+	Current Amount `json:"current"`
+
+	// Сумма баллов с точностью до сотых. multipleOf не используется: при проверке в числах с плавающей точкой ему не соответствуют суммы вроде 0.07
 	Withdrawn Amount `json:"withdrawn"`
 }
 
 // UserBalanceWithdrawRequest defines model for UserBalanceWithdrawRequest.
 type UserBalanceWithdrawRequest struct {
 	Order string `json:"order"`
-	Sum   Amount `json:"sum"`
+
+	// Сумма списания с точностью до сотых, больше нуля
+	Sum float32 `json:"sum"`
 }
 
 // UserBalanceWithdrawal defines model for UserBalanceWithdrawal.
 type UserBalanceWithdrawal struct {
 	Order       string `json:"order"`
 	ProcessedAt string `json:"processed_at"`
-	Sum         Amount `json:"sum"`
+
+	// Сумма баллов с точностью до сотых. multipleOf не используется: при проверке в числах с плавающей точкой ему не соответствуют суммы вроде 0.07
+	Sum Amount `json:"sum"`
 }
 
 // UserBalanceWithdrawalsResponse defines model for UserBalanceWithdrawalsResponse.
 type UserBalanceWithdrawalsResponse []UserBalanceWithdrawal
 
+// UserExport defines model for UserExport.
+type UserExport struct {
+	Balance UserBalanceResponse `json:"balance"`
+
+	// Время выгрузки
+	ExportedAt  string                         `json:"exported_at"`
+	Ledger      []LedgerEntry                  `json:"ledger"`
+	Orders      OrdersResponse                 `json:"orders"`
+	Sessions    SessionsResponse               `json:"sessions"`
+	User        UserProfile                    `json:"user"`
+	Withdrawals UserBalanceWithdrawalsResponse `json:"withdrawals"`
+}
+
+// UserProfile defines model for UserProfile.
+type UserProfile struct {
+	// Идентификатор пользователя
+	Id    string `json:"id"`
+	Login string `json:"login"`
+}
+
+// IdempotencyKey defines model for IdempotencyKey.
+type IdempotencyKey string
+
+// DeleteUserJSONBody defines parameters for DeleteUser.
+type DeleteUserJSONBody DeleteUserRequest
+
 // UserBalanceWithdrawJSONBody defines parameters for UserBalanceWithdraw.
 type UserBalanceWithdrawJSONBody UserBalanceWithdrawRequest
 
+// UserBalanceWithdrawParams defines parameters for UserBalanceWithdraw.
+type UserBalanceWithdrawParams struct {
+	// Уникальный ключ запроса, не длиннее 255 символов. Повтор запроса с тем же ключом в течение суток не выполняет его повторно, а возвращает сохраненный ответ с заголовком Idempotent-Replayed: true. Ответы 5xx не сохраняются.
+	IdempotencyKey *IdempotencyKey `json:"Idempotency-Key,omitempty"`
+}
+
+// ExportUserDataParams defines parameters for ExportUserData.
+type ExportUserDataParams struct {
+	Format *ExportUserDataParamsFormat `json:"format,omitempty"`
+}
+
+// ExportUserDataParamsFormat defines parameters for ExportUserData.
+type ExportUserDataParamsFormat string
+
 // UserLoginJSONBody defines parameters for UserLogin.
 type UserLoginJSONBody LoginRequest
 
+// UploadOrderParams defines parameters for UploadOrder.
+type UploadOrderParams struct {
+	// Уникальный ключ запроса, не длиннее 255 символов. Повтор запроса с тем же ключом в течение суток не выполняет его повторно, а возвращает сохраненный ответ с заголовком Idempotent-Replayed: true. Ответы 5xx не сохраняются.
+	IdempotencyKey *IdempotencyKey `json:"Idempotency-Key,omitempty"`
+}
+
 // UserRegisterJSONBody defines parameters for UserRegister.
 type UserRegisterJSONBody RegisterRequest
 
+// DeleteUserJSONRequestBody defines body for DeleteUser for application/json ContentType.
+type DeleteUserJSONRequestBody DeleteUserJSONBody
+
 // UserBalanceWithdrawJSONRequestBody defines body for UserBalanceWithdraw for application/json ContentType.
 type UserBalanceWithdrawJSONRequestBody UserBalanceWithdrawJSONBody
 
@@ -106,15 +293,21 @@ type UserRegisterJSONRequestBody UserRegisterJSONBody
 
 // ServerInterface represents all server handlers.
 type ServerInterface interface {
+	// Удаление учетной записи
+	// (DELETE /api/user)
+	DeleteUser(w http.ResponseWriter, r *http.Request)
 	// Получение текущего баланса пользователя
 	// (GET /api/user/balance)
 	GetUserBalance(w http.ResponseWriter, r *http.Request)
 	// Запрос на списание средств
 	// (POST /api/user/balance/withdraw)
-	UserBalanceWithdraw(w http.ResponseWriter, r *http.Request)
+	UserBalanceWithdraw(w http.ResponseWriter, r *http.Request, params UserBalanceWithdrawParams)
 	// Получение информации о выводе средств
 	// (GET /api/user/balance/withdrawals)
 	UserBalanceWithdrawals(w http.ResponseWriter, r *http.Request)
+	// Выгрузка данных пользователя
+	// (GET /api/user/export)
+	ExportUserData(w http.ResponseWriter, r *http.Request, params ExportUserDataParams)
 	// Аутентификация пользователя
 	// (POST /api/user/login)
 	UserLogin(w http.ResponseWriter, r *http.Request)
@@ -123,10 +316,16 @@ type ServerInterface interface {
 	GetUserOrders(w http.ResponseWriter, r *http.Request)
 	// Загрузка номера заказа
 	// (POST /api/user/orders)
-	UploadOrder(w http.ResponseWriter, r *http.Request)
+	UploadOrder(w http.ResponseWriter, r *http.Request, params UploadOrderParams)
 	// Регистрация пользователя в программе лояльности
 	// (POST /api/user/register)
 	UserRegister(w http.ResponseWriter, r *http.Request)
+	// Список активных сессий пользователя
+	// (GET /api/user/sessions)
+	GetUserSessions(w http.ResponseWriter, r *http.Request)
+	// Завершение сессии
+	// (DELETE /api/user/sessions/{id})
+	DeleteUserSession(w http.ResponseWriter, r *http.Request, id string)
 }
 
 // ServerInterfaceWrapper converts contexts to parameters.
@@ -138,10 +337,29 @@ type ServerInterfaceWrapper struct {
 
 type MiddlewareFunc func(http.HandlerFunc) http.HandlerFunc
 
+// DeleteUser operation middleware
+func (siw *ServerInterfaceWrapper) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{""})
+
+	var handler = func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.DeleteUser(w, r)
+	}
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler(w, r.WithContext(ctx))
+}
+
 // GetUserBalance operation middleware
 func (siw *ServerInterfaceWrapper) GetUserBalance(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{""})
+
 	var handler = func(w http.ResponseWriter, r *http.Request) {
 		siw.Handler.GetUserBalance(w, r)
 	}
@@ -157,8 +375,36 @@ func (siw *ServerInterfaceWrapper) GetUserBalance(w http.ResponseWriter, r *http
 func (siw *ServerInterfaceWrapper) UserBalanceWithdraw(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
+	var err error
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{""})
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params UserBalanceWithdrawParams
+
+	headers := r.Header
+
+	// ------------- Optional header parameter "Idempotency-Key" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("Idempotency-Key")]; found {
+		var IdempotencyKey IdempotencyKey
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "Idempotency-Key", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithLocation("simple", false, "Idempotency-Key", runtime.ParamLocationHeader, valueList[0], &IdempotencyKey)
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "Idempotency-Key", Err: err})
+			return
+		}
+
+		params.IdempotencyKey = &IdempotencyKey
+
+	}
+
 	var handler = func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.UserBalanceWithdraw(w, r)
+		siw.Handler.UserBalanceWithdraw(w, r, params)
 	}
 
 	for _, middleware := range siw.HandlerMiddlewares {
@@ -172,6 +418,8 @@ func (siw *ServerInterfaceWrapper) UserBalanceWithdraw(w http.ResponseWriter, r
 func (siw *ServerInterfaceWrapper) UserBalanceWithdrawals(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{""})
+
 	var handler = func(w http.ResponseWriter, r *http.Request) {
 		siw.Handler.UserBalanceWithdrawals(w, r)
 	}
@@ -183,6 +431,39 @@ func (siw *ServerInterfaceWrapper) UserBalanceWithdrawals(w http.ResponseWriter,
 	handler(w, r.WithContext(ctx))
 }
 
+// ExportUserData operation middleware
+func (siw *ServerInterfaceWrapper) ExportUserData(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{""})
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params ExportUserDataParams
+
+	// ------------- Optional query parameter "format" -------------
+	if paramValue := r.URL.Query().Get("format"); paramValue != "" {
+
+	}
+
+	err = runtime.BindQueryParameter("form", true, false, "format", r.URL.Query(), &params.Format)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "format", Err: err})
+		return
+	}
+
+	var handler = func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.ExportUserData(w, r, params)
+	}
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler(w, r.WithContext(ctx))
+}
+
 // UserLogin operation middleware
 func (siw *ServerInterfaceWrapper) UserLogin(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -202,6 +483,8 @@ func (siw *ServerInterfaceWrapper) UserLogin(w http.ResponseWriter, r *http.Requ
 func (siw *ServerInterfaceWrapper) GetUserOrders(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{""})
+
 	var handler = func(w http.ResponseWriter, r *http.Request) {
 		siw.Handler.GetUserOrders(w, r)
 	}
@@ -217,8 +500,36 @@ func (siw *ServerInterfaceWrapper) GetUserOrders(w http.ResponseWriter, r *http.
 func (siw *ServerInterfaceWrapper) UploadOrder(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
+	var err error
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{""})
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params UploadOrderParams
+
+	headers := r.Header
+
+	// ------------- Optional header parameter "Idempotency-Key" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("Idempotency-Key")]; found {
+		var IdempotencyKey IdempotencyKey
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "Idempotency-Key", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithLocation("simple", false, "Idempotency-Key", runtime.ParamLocationHeader, valueList[0], &IdempotencyKey)
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "Idempotency-Key", Err: err})
+			return
+		}
+
+		params.IdempotencyKey = &IdempotencyKey
+
+	}
+
 	var handler = func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.UploadOrder(w, r)
+		siw.Handler.UploadOrder(w, r, params)
 	}
 
 	for _, middleware := range siw.HandlerMiddlewares {
@@ -243,6 +554,51 @@ func (siw *ServerInterfaceWrapper) UserRegister(w http.ResponseWriter, r *http.R
 	handler(w, r.WithContext(ctx))
 }
 
+// GetUserSessions operation middleware
+func (siw *ServerInterfaceWrapper) GetUserSessions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{""})
+
+	var handler = func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetUserSessions(w, r)
+	}
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler(w, r.WithContext(ctx))
+}
+
+// DeleteUserSession operation middleware
+func (siw *ServerInterfaceWrapper) DeleteUserSession(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameter("simple", false, "id", chi.URLParam(r, "id"), &id)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{""})
+
+	var handler = func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.DeleteUserSession(w, r, id)
+	}
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler(w, r.WithContext(ctx))
+}
+
 type UnescapedCookieParamError struct {
 	ParamName string
 	Err       error
@@ -356,6 +712,9 @@ func HandlerWithOptions(si ServerInterface, options ChiServerOptions) http.Handl
 		ErrorHandlerFunc:   options.ErrorHandlerFunc,
 	}
 
+	r.Group(func(r chi.Router) {
+		r.Delete(options.BaseURL+"/api/user", wrapper.DeleteUser)
+	})
 	r.Group(func(r chi.Router) {
 		r.Get(options.BaseURL+"/api/user/balance", wrapper.GetUserBalance)
 	})
@@ -365,6 +724,9 @@ func HandlerWithOptions(si ServerInterface, options ChiServerOptions) http.Handl
 	r.Group(func(r chi.Router) {
 		r.Get(options.BaseURL+"/api/user/balance/withdrawals", wrapper.UserBalanceWithdrawals)
 	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/api/user/export", wrapper.ExportUserData)
+	})
 	r.Group(func(r chi.Router) {
 		r.Post(options.BaseURL+"/api/user/login", wrapper.UserLogin)
 	})
@@ -377,6 +739,12 @@ func HandlerWithOptions(si ServerInterface, options ChiServerOptions) http.Handl
 	r.Group(func(r chi.Router) {
 		r.Post(options.BaseURL+"/api/user/register", wrapper.UserRegister)
 	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/api/user/sessions", wrapper.GetUserSessions)
+	})
+	r.Group(func(r chi.Router) {
+		r.Delete(options.BaseURL+"/api/user/sessions/{id}", wrapper.DeleteUserSession)
+	})
 
 	return r
 }
@@ -384,35 +752,85 @@ func HandlerWithOptions(si ServerInterface, options ChiServerOptions) http.Handl
 // Base64 encoded, gzipped, json marshaled Swagger object
 var swaggerSpec = []string{
 
-	"H4sIAAAAAAAC/9RY3W7bxhJ+FWLPuTuKRcn2ic2rJk0QGDDiwmmbi9QoGGltM5VIZknFMQIDkuw0KZTC",
-	"/bnIRVukQV+AtqVYsSX6FWbfqJjhjyiKsuU0SpMbm5K4s9/OfPPtt/uElayqbZncdB2mPWFOaZNXdXq8",
-	"VrVqpotP1VrFNewKX1lnmjqjFnLM3bY505hZq97ngu3k2LK1YZir/GGNOzTEFpbNhWtwClXBX/EhHOe4",
-	"wjA3cJytO86WJcoZP+7kmOAPa4bgZabdC2MkRqzFMKz7D3jJxXAroszF6Px6qSRqegUfy9wpCcN2Dctk",
-	"GoM/wJPPoCsbcAod6ENftqCjwAF4cAqnsjWjwO/gwxvoyKYCB7Ilm/KFoirQhVPoKuDLpmzIXfrbhEPw",
-	"4RA8fIflGH+sV+0KZ9q8quYmSWL4lIXShx50ZF2BY/DgBDz8n5yCLRavLiwWZ+euqiw3mmbH1d2akxH5",
-	"tWwS3l3ZUMCHA1kHDw5wWXCC60tNZ9aqWIvbN++yHPtideXzm3fuLN2+xXJs6fbX15aXbgy+vnkDKzQA",
-	"GIwZQVazK5Ze5uVvdTcD3i+yDh3oyf0AyZGsy104zoQWT1RUi+qVQvFKQf2yMK8Vippa+J86q6kZiUlR",
-	"LMx/nK5hdGP55qxyx7ZMh+MCDJdXKdH/FXydaew/+UGD5cPuygc03YkD6kLo2/h5lW8YjsvFv99JXzlc",
-	"XNcrulniyeUNoynVhOCBRpy33FBJdnJsy3A3y0LfMicdkoIeTZiMdAH6u+GLY1NqRZox2jXI9nfCGcQM",
-	"IkyIL5CnSaHZwipxx4n7ZkrYUxNNvJTLt0R2RkZaBMEa5rpFazZcavhblr3JRVUXrrJsbesVd1u5w8Uj",
-	"o8RZjj3iwgnEpDCjYjzL5qZuG0xjszPqTJH6wN0kfHndNvI1h4v8/QAJfrnBKcFYFh1laamMU3I3AZhh",
-	"AoP1UpyiqlJ3WKYbdodu2xWjROPzDxzLHGy1l8hLnFPKQkop/5INOIOOfA598FAv02LuBYp5JuvgywZ4",
-	"mIs5tZAhuq/Ah1P5Ao6j3Qw6+FmBPm6NHhzKJviyDt3oDehjsPlg1WkFhz5tj/Vwe90nbPI5dOGAUMkG",
-	"bmxwSH89YqNTq1Z1sT3Asiuf0fAudBSCcyJ35Q/QgSPwo83agz4uS4GzTPj7qP76hoMMh58HI9gazjhS",
-	"+XykL9SUlpPBgQzGsqCTuONet8rb0+BAWs2yqPByUGasGaX4jDyOF+Yw8jeYIbaTzd6Pjl9zajHbwCmy",
-	"gfxAF0MxoI0IAmMDvnwGffDxHaRgO/BpFK+YHa8TchGt4FtF7hGWHgbD6KERi5YbGZBp8f/iWqYWlmD5",
-	"6+Sb1ACXIb5eccbKX7bmfyAZzNpl3psiFtW5bFIE1UfHD22iwRGRKpXi98v5i6QQutBP8PN76BJABQ5l",
-	"i84i7VF+DBc9tpORxKVg/4TcxflkE7pyD7qYNpwI80mJI8TBXF06Be2TAuMfDydWSGWOEGo+/I4ywXIZ",
-	"nFoOnenlRDS2/rE9Zri4dctKmlyN2Z85W2qZfPdkpBs61GZR7BWtxyNJaMq6bCnQxr0mXdGwNixptFxR",
-	"45NJ7zju4KEtZrivgJcuFhWqPqyh6qU1b0JVTwQJ2i0otjeWANOSzPM5e7E5+JN8RRf7BcNHI7sZCR5E",
-	"TqspmWjnIv8YHB+nqZupA+qH0ck26WFftuTTuCF8uh7p4K78yTrPSO3jlAT3EW+imyNc7sAh+HCY9Aj+",
-	"8Ob8MvpBttgaHuiyPSbdP6yEJ7Lxsujyx27eruhGihqj+Q1vutrDGUbYRPgXCvF5T9bT+h6+BW+ppLT1",
-	"tJL3LoXi7Nz8/68uLKqz8aHNMF2+wUUm7bLvtBRM2q/gyz3KYY/04oR403lH+RwzUVA4utOD05FyKvJH",
-	"bHLojZEM6EAv6IPimCkPSUoHfEgt84xojKxs0ppTTSd3pyzXF7TaJFvJ4ntLNrTpw9EECf9kXPvgrtI7",
-	"B8FYRRjaTUR4LXiOU4tbO2ULMBn10R0Nt7Owv2VDPo38WyyyQeqgSwcrUjxyNe+wtSoUAiEcKyXL+s7g",
-	"M9+Yme4vuvz8eAxg+jr2Uh4wO+1T9oDHgetOzJto22wTMzWXmKUPv0VeMJaEYzqO7svm1LpxjKEbx1dU",
-	"46A1jsLC9sJjjNxP7pVUy3/uGhErF4/ILd57wmqiwjS26bq2ls9XrJJe2bQcV1tQF1S2s7bzdwAAAP//",
-	"uSDr/LAbAAA=",
+	"H4sIAAAAAAAC/+xcX3Pb2HX/Khi0D+kEkihZqm0+1dl1Um+dtcd2ug9rDQciryRkSYALgLbpHc2IZBw7",
+	"I8dqtumk03bjJjudfYUp0qIkkv4K536jzjn3Arj4R1FeW+PN6sUWSQD33PPnd/5efKVXnUbTsZnte3r5",
+	"K71pumaD+cylTzdqrNF0fGZX2//C2vhNjXlV12r6lmPrZR2+hQmM4BgCOOHPYcL34EiDYzjhL/hTDQ4h",
+	"gDd8F6a8A4GhwQSGGgzgBEYwwQ8w1FbW1jTegRGMoQ9TOIEp9Bc1eIn/8y5M+W7qMRrvaLwLQxhr8BqG",
+	"0WowxW/69Bt/CkMibKjxDu/hc+BYLt/ne/CGVprwfRjyrgZDOICpBm/iNWECU0ODQCOiDqHPdyHgv4OA",
+	"buAdmPIn+BVtYhLue8q70JdXCKoPwi3BMdEX8dNfuMOadbPNamXNd1tsUYM/h3fzPW3t0SNBrroU3+cv",
+	"eJd3+P7ifVs3dAslsM3MGnN1Q7fNBtPLqsQWUGSG7lW3WcNE2TXMRzeZveVv6+WVtTVD99tNvMXzXcve",
+	"0nd2dgzdZV7TsT1G0r/nOL807fYd9mWLeUI9qo6N1OOfZrNZt6omasJS03U26qzx0197qBZfKWv+vcs2",
+	"9bL+d0uxmi2JX72l2+IusXJKsV7yXRiisPgzZLFGSjOGETL3KQS8Q4JCXiXUYwp93ZBMIYLvmD67aTUs",
+	"f4H+zVHhv5BsUH2PYZp5mgZjmMJrVIiE7iAl/LlQmgHf5fswSPBa8tayfbbFXB13GJNyhzVMy0amn52c",
+	"KW09QLXiHf78LIt6LG//38GQeH2IyqauzTswhGPegwkMVP6jTfCOJGNC2j0SeqlF3CEq+XP+4lT6mO+2",
+	"F65t+sx9e9oUEalGLEWksHA2NaSHUjnxgmsNp2XnqgzvwRjGiA+vSBRk4xKZpvypygAEvKmw4y7f408W",
+	"tUar7lvNOru1KSFpxDuCcfw5HPIeYUCH75c1Insk/psSOOzCMYEYGgHedgIBf4ILwxv8G/oQ8Bf8dzCE",
+	"o5AWZNsRgtyY92JQicGKBNnnPQQXgkvcGtpVn1YdwFArLZYuE+RIltmtxgZyzNA/ZnXms195zJUoQT7E",
+	"dZrM9S0mPYrnPXTcGv7dsOwQgZaz+IPw82XLcllNL38e37ceXels/JpVfVz3JqttMfe67bvt7IpmJLdZ",
+	"6COlu2PoVZeZPqtVzDxZf00KOCblDsWATCEI2F/U4I9wgj9OIIhkIn/UFgQT5e3kEPgu78EhHMNIfIGu",
+	"8xAC3dDZI7PRrOM2V0orpYXllYXl0r3ltfLySrm0/NPSpXKppGd4ZuiOW8s1nW/Q4aDGpNfJPEF8kfOA",
+	"1IZQ8Q4hUJ6nwQhxQSP9xSuD8Do0BvTCvKtcLrZptxooXrNadVtmXTf0h5a/XXPNh2ZdX8+Ql1IK+jXc",
+	"tBFKOiHCXHVxtiy7UEPr+Oup6mm8tSaL5xuzNfpWKMeULks2nS4fDEKGESDxvXnhCP6H0JNE9Yrv4WVa",
+	"KZQsXUYhlIAJ0v8Ar1E1dq1UysEG+dcZVDM2gasrl69cXbm0ejlX5z3f9FteLjB3iboeIuIUXlHg9Ao3",
+	"kWtxUhU/vf6Zbui379z66Prduzc+/YVu6Dc+/ddrN298HH99/WN9XSVQ3JOhrNWsO2ZtDix5H2CQUjvJ",
+	"/4hdSeoKddC7I2NA3IDls4Z3GpLSbTGS6Kbrmm38HAZ4WU78OQ0YU/4MRvBK8KKv8d+QBx+jNGGo3fn5",
+	"R9rlK6XLupEyj6pTY3OGmR/hpRRo+qaVZ1AvRSiHehNaDIxSlA0I7PnvKaU4SAdoufBq2Z5v2tU8iH3J",
+	"e5kQJSX+JbNpLbU85i5tmHV8zFIIl2cxjH++d++2xjsJ6wj9f3LB1dKKkYmMDN23/HreDv6LEqMuBRko",
+	"xRliDfO/QwpT8CoKJqf4D94+gWM0D96FSRFvY7bcsL3W5qZVtZjta5stu+bN79l+decGAuMI3kCQoDCx",
+	"Qsu1y1tOc5u5DdP1yzLDKVvKwpWChfN9lmChYo2ku4p+5FmkqrsFcAevYKRm32gzzyjDnpJ/wEsQdGRq",
+	"PoVBZtMSBzfMWsWVTtLQW7bZ8rcd13rMaphfOn5l02nZ+HeD+dtOrYJfmfW685AuIC9XsexKyxPbemDW",
+	"rVql6rIas33LrOOePeZ5lmNX1KeRM684D21Wq2y0K6bt+NvMxce4ynPEVRGm5crBipPfyhesXfEdp1J3",
+	"7K2cnxqW1zD96nbOT5ZdabrOlss8T1n/IdvYdpwvMF4RfyX2EH5XY3XrAXPbiR8jVjj2prVFrPVazabj",
+	"YsQiM+oKs6tOzSJapQwE+aa7RdrjOJWGabdDASFpm8z0Wy6r1CzP3KgzsZTPXNusV5jrOm5OPIUZ15bl",
+	"+TMC9g8iHLorFCVL3bzBep8/oezle4TW1ZbrsvwEEIaYAWOQb4RxFmZmBF1jMjOZg1LupdYNqJiRTEnl",
+	"whuOU2emTT6jlrPof4q0A6GL/0ZU3cQilA0LemAUP09xQc3s427c1iAgfzfkHdpEguoDmObQLZmat0bd",
+	"9PyKx5h9ehoFUxm0Dui5p0D9mUSGoFExt3KlhlnqwjX8TUMXyLtCPDKwDb43D1JqbtX0BD0khkSmkmJa",
+	"rHAzDOLs0Zm8MS8+Q478TIQV6mNTBhdbwXzpdBig2PPekmJcuKD6pDyOKNR/Ji8sxLQoSz4F07xWY2a9",
+	"J5np8v3Q+GcnWQamZVTfwWqmBhPewziS1Lxab3nWA/ZLy7YauDhWgw29EX7MJlYpboWpMFI+J5fM+gwG",
+	"ZWHedarM8yLILeLZW0hapT210NxbObtB5HOkwDyuP0I/neWXjMbPsFZE5w6KXXr/073Ynpomvj0w1qlg",
+	"NjeP1PpaDmdIcPPlhZ66bxn+zYtZiXspHpyD3bddZ9OqMxWJMPZ8G6VQ1k9prgxOQy1QdhYxJ7l8JIGk",
+	"8IvUPNxERu/OGBnEdeWwcgNDCT1ZFQnjvjn8mrg2Sz3JuNpyLb99FzkrjYWZLnOvtfzt+NPPHbeB2q9/",
+	"8tk9Pd3++eSze4ZQ/gEhrUxtRDmcrOMARtKFB/y3GPdEFauAGn5pdtA1+MxsY26oXZOJDjWzwh4BhWRE",
+	"asysbd9vinaVZW86eXWNRBkj2TsMu3cz6hvaT4q6av8gm6InMNQwcRRF/AG1BF6LtljnHWSDBmlMMgyi",
+	"vsExMldwldJ8EsJAKFPYRR1KCeFC6OMW79vwV7wkE+CpLRvegdeipKj95CORDC1cl8lQWdt6bDUNrcY2",
+	"66bPDG3DNbTHnl9DdvwHRZEj0Q0+kc0f+m6oEc/RVwdwLMU8SjnhAW2jJ8o8RM8BNX1zOr4ktdXlS1om",
+	"MzPCkhBFhyO8kUJxUcQIWQwjvhtRsaCtLq9ps1LAVDOYGDSCcVKDMk8eSot5JRvTkgAU57VqlTVjrqJc",
+	"vot7qBAkpaN0PRNFYeSQfPZIdtgD8fwClHkhbTLJpIx9om3KXUhb508yFEnFvHE7xRxBRJYyWQ1Hpu3y",
+	"Z+hBQ17GVhPwbhYNRlqqZWxoOY1bLXEdtVaNRLtO6V0LdIr6pwVTBULHVq5qmUy/cJpA6Z2iSP83C4sk",
+	"gmzQmu5phyMUsmEqlWtC4HnAezHIGposfA4E1yeyRUDieCoUVFstXdLSpQkxsSCriPovorqadtNpm3W/",
+	"rd1l7gOLPOkD5orMX19eLFGw0WS22bT0sn5psbS4QpUDf5scS1QdFVCMvcjcIZUBNUWiLpZo2ndlR/U1",
+	"DBQRIGaQNuL140UN/ps2PIIJdRSwGY2yJIzrq/cRJzok2lcwTT6VTLMjWq9Rph6WQoeoobFxL2rwp7AX",
+	"wPeMHOmNwi5PABPeEXlGZkYkqlWjECfiUtKdqRxVEe25uNJN9dlpOlMRNPf5PhE7EW0lOAl5gnooLXIs",
+	"+EsqxHtplk9gKPvbcnoFgxpyczdqelnpI8f1r585tfaMmZOzzZpkG9U7ycAG8670AMxKaTVPnSLOBWEn",
+	"hwSU3neAyrtaKp3r3Mw3MBQqJQMANdBIV1mIvOXzHevJdRTPhaKhNYSjG+EVMJFOhC5JbC22VP6c9rJy",
+	"tYi0SKpL6ZmmHUNfO2cRfY2xEWG0cFT7fF+NwQIBErtyr4EIqluNhum289CM92JLhiMpY9FfQcg1tzyM",
+	"2uElPo0gYKJM61EcFEbYQ30d18q0nHCzW2J2KGm1v2C+kjfpGespvTPzzc2jc3j7LaHlkD8LrTPdBA5+",
+	"mEbwI9JvwZ2eOsPZFcNeNNd0IHxU5P5kDFqU5Ibq/4f4jgItjxurmHQ7Xo6+5xQJdCMxMvt5PtviS5ZS",
+	"I7U76+/H380ojubJ7E+xTYiQOjvSo0y6RVNPMqQYy5yH0ijxlyymR0kjBDCWMUagPBlhZywa6zJ/24e+",
+	"MkElzfm32Uw+zvooJtPC1PFeu8niuSTNZ4/8pWbdtGwRur6WySEcYqbMd+MYDckN4Fj75O6tT+9TuTwP",
+	"zd4N3lzEBO8ADksr58zFIBpqk5OUMJDqHsQ9ALyGaiP0Q19QeulcKf1LOmFQc7whBfEzcjxB8dVzpViF",
+	"n9R8f2qgXYMhuoGcOX60YaJ9+Xy5XVDh4p0s6WpqtYyDdyP4g/DsKx8QIEzC8bzErCcd4DghHz7N7Iz3",
+	"SFbJSWZprCcErugOBtTIwLxxHME3jMUaEePu2z+iWOd0t5tCEyWiSVm5bpwpyJEtkdywPr8Xck7hfX73",
+	"5R1F+vkJ/TeizimKWTSUIHr/6crLRa7w4ecK1HmI4Uy2hmSdsy/PM6RddMJSWNTx3WL53dlOKm02lH4J",
+	"fo6JHqEzexKVprtaYbEchvK4x5Q88gl/biTSHCNROjQUYC6sEY5kgX9XfJM5oIDFgszs/lE60P8//nv+",
+	"TK21iO5DojEdHiyhrWHPRZY+FzX4Ot3qemw1RaT9GgbC+YhonHpJmYY3HqFAzg6E2kt/hSE6PjeAI7wH",
+	"S/xPRDk2r7YoWvgIMR+bvplN2Ogc3Zct5rbjY3SbojupHheqsU2zVff1sk7qH49Nyo+PrWbe+YX19wyb",
+	"Ynekw+qDHouZr/g5m2G/dcOyTdpqzvG/lKr/Mdbx4iT7IqG5wOyzYPbXCQsPFCTlT4q17HtWMqPZhrCw",
+	"k9rTvxUNDcTnvkRzV/Rc49OOITIOlabIklqZXtTgO4m6Evvf8D0xL6oMqYpfBmH8gRFysskyb0d2ekob",
+	"ckbnMQ87EWFuynnds5WpojmlaKCYhmY2HUcd/S3rzX/yHpZqdMhgPtVMnOXKN7+AmDKmze3yvbAVljK9",
+	"MN89vQtUKjgtkmfkvKcEplPttHb3BXzOIk9E9sKagiIL+5vHTjlQpZc/X08g6WzQOh1I8wYGROgYFD85",
+	"jazxKOCsFtGtcCbuvcVC6WHDc0kZE65Lgox6sOoi+Pjwm0th/hOJXwQnr2EYizYuiU3DCUJRFJsmazLK",
+	"4Ia+vmNE0UbKqdIxzFty+Pk9to/i5keS+VmViQ5kJJQmnmnXRAuGJkoT0ZC8Co6iF7vwvcSU8vLKpdW1",
+	"f7x85Wrp0lw5R/7hYMo5/52S46F0VfQqBKT0LR14wUJhKfMV34MT8ZOiEOLoJ4VnBWk8jAVqrBQs2SeX",
+	"G2tUapsUu5Fed2nPKYjivYuA4ayQN08AdvWc+fk2qpeonxern1KmV0Xzvfoq9+2LzspFZ+WH1llJVBmK",
+	"eF/svhNhritPzc6oIbyMp88TOaCoceYeWZC+lHdkHYBKxDJGE0ojEnsRnlAK+xYxv0aPEO9RqjrOFxYr",
+	"SvXDs8EfTrafPq18poQ/n+3vOeE/hCC1ruJt8rOrH2BJ4Hw9QUGmmpprgOBDm2uIZ8dDHD+UU9rdH23V",
+	"okCWRdiFEhUweSCNfCyLrXxfzVGS067vpLShnlbcYjPfAyDeWoObEO8CpGNlVKM+lEUC8bKwMx2D18JX",
+	"0eBIXByMhVP5onHGe+JFlW+I9yPpRkKfIQ90yRfW0EkupY48la91CsKXOsnjNBN5t/IewrE40sW7ea5D",
+	"Fnnuxmcg31uZJ3sy9GIK+G8xfgunW6hNEsAxZd19WY9RWuFHc1U6FTstMvKlr6zazsxjRH8N3/GRPLgz",
+	"jM7apTr0fTFxOo7f1ZgaZT4yck7bhBUAceIvtPkZB2akRRT0tfGoVNzWtmqZ4CfnjZgzeters1+GkuXM",
+	"D2uidPVcyfx2htebEEITNh9nlOtHlsep+jRKHaKbYeXJICR5Cv3zdVRuj7kPQmtpuXV5wLu8tFR3qmZ9",
+	"2/H88pXSlZK+s77z/wMAy8tO9yhbAAA=",
 }
 
 // GetSwagger returns the content of the embedded swagger specification file
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"os"
 
 	"github.com/rs/zerolog/log"
@@ -12,7 +14,11 @@ func main() {
 }
 
 func CLI(args []string) int {
-	if err := app.Run(args); err != nil {
+	err := app.Run(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	if err != nil {
 		log.Err(err).Msgf("Runtime error")
 		return 1
 	}
@@ -5,85 +5,424 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
-	"time"
 
+	"github.com/XSAM/otelsql"
+	"github.com/go-chi/chi"
+	"github.com/go-redis/redis/v8"
 	"github.com/rs/zerolog/log"
 	Accrual "github.com/zaz600/go-musthave-diploma/api/accrual"
 	"github.com/zaz600/go-musthave-diploma/internal/app/config"
+	"github.com/zaz600/go-musthave-diploma/internal/controller/grpccontroller"
 	"github.com/zaz600/go-musthave-diploma/internal/controller/httpcontroller"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/eventsink"
 	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/migration"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/pgdb"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/sqlitedb"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/auth"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/health"
 	"github.com/zaz600/go-musthave-diploma/internal/pkg/httpserver"
 	"github.com/zaz600/go-musthave-diploma/internal/pkg/logger"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/metrics"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/tracing"
 	"github.com/zaz600/go-musthave-diploma/internal/service/gophermartservice"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"google.golang.org/grpc"
 )
 
+const (
+	serviceName = "gophermart"
+)
+
+// prepareSchema применяет миграции или без autoMigrate проверяет, что они применены
+func prepareSchema(db *sql.DB, dialect migration.Dialect, autoMigrate bool) error {
+	if autoMigrate {
+		return migration.Migrate(db, dialect)
+	}
+	if err := migration.Check(db, dialect); err != nil {
+		// без схемы репозитории не подготовят запросы, миграции применяются командой migrate
+		return fmt.Errorf("%w, run \"gophermart migrate up\"", err)
+	}
+	return nil
+}
+
+// openDB подключается к БД с трассировкой запросов, применяет миграции или без autoMigrate проверяет,
+// что они применены, и добавляет метрики и проверки готовности
+func openDB(driver string, system attribute.KeyValue, dsn string, dialect migration.Dialect, autoMigrate bool,
+	m *metrics.Metrics, h *health.Health) (*sql.DB, error) {
+	driverName, err := otelsql.Register(driver, system.Value.AsString())
+	if err != nil {
+		return nil, err
+	}
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := prepareSchema(db, dialect, autoMigrate); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	if err := m.RegisterDB(db, serviceName); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	checker, err := migration.NewChecker(db, dialect)
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	h.AddCheck("database", db.PingContext)
+	h.AddCheck("migrations", checker.Check)
+	return db, nil
+}
+
+// openPg создает пул соединений с Postgres так же, как openDB. Миграции работают через database/sql,
+// поэтому вместе с пулом возвращается *sql.DB, который нужно закрыть вместе с пулом
+func openPg(ctx context.Context, cfg config.AppConfig, m *metrics.Metrics, h *health.Health) (*pgdb.Pool, *sql.DB, error) {
+	pool, err := pgdb.Open(ctx, cfg.DatabaseDSN,
+		pgdb.WithMaxConns(cfg.DatabaseMaxConns),
+		pgdb.WithMinConns(cfg.DatabaseMinConns),
+		pgdb.WithMaxConnLifetime(cfg.DatabaseMaxConnLifetime),
+		pgdb.WithMaxConnIdleTime(cfg.DatabaseMaxConnIdleTime),
+		pgdb.WithConnectTimeout(cfg.DatabaseConnectTimeout),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	db := pool.OpenDB()
+	closeAll := func() {
+		_ = db.Close()
+		pool.Close()
+	}
+
+	if err := prepareSchema(db, migration.Postgres, cfg.AutoMigrate); err != nil {
+		closeAll()
+		return nil, nil, err
+	}
+	if err := m.RegisterDBPool(pool.Stats, serviceName); err != nil {
+		closeAll()
+		return nil, nil, err
+	}
+	checker, err := migration.NewChecker(db, migration.Postgres)
+	if err != nil {
+		closeAll()
+		return nil, nil, err
+	}
+	h.AddCheck("database", pool.Ping)
+	h.AddCheck("migrations", checker.Check)
+	return pool, db, nil
+}
+
+// openRedis подключается к Redis для сессий и добавляет проверку готовности
+func openRedis(ctx context.Context, redisURL string, h *health.Health) (*redis.Client, error) {
+	opts, err := redis.ParseURL(redisURL)
+	if err != nil {
+		return nil, err
+	}
+	client := redis.NewClient(opts)
+	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+	h.AddCheck("sessions", func(ctx context.Context) error {
+		return client.Ping(ctx).Err()
+	})
+	return client, nil
+}
+
 func Run(args []string) error {
+	if len(args) > 1 && args[1] == migrateCommand {
+		return Migrate(append([]string{args[0] + " " + migrateCommand}, args[2:]...), os.Stdout)
+	}
+
 	ctxBg := context.Background()
-	ctx, cancel := signal.NotifyContext(ctxBg, os.Interrupt, syscall.SIGINT)
+	ctx, cancel := signal.NotifyContext(ctxBg, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 
-	l := logger.New()
-	cfg := config.Config(args)
+	cfg, err := config.Config(args)
+	if err != nil {
+		return err
+	}
+	if cfg.PrintConfig {
+		return cfg.Print(os.Stdout)
+	}
+	l, err := logger.New(logger.WithLevel(cfg.LogLevel), logger.WithFormat(cfg.LogFormat))
+	if err != nil {
+		return err
+	}
 	l.Info().
+		Str("config", cfg.ConfigFile).
 		Str("addr", cfg.ServerAddress).
-		Str("db", cfg.DatabaseDSN).
 		Str("accrual", cfg.AccrualAddress).
+		Str("repo", string(cfg.RepositoryType())).
 		Msg("config")
+	auth.Configure(auth.WithKey(cfg.JWTKey), auth.WithTokenTTL(cfg.TokenTTL))
 
-	accrualClient, err := Accrual.NewClientWithResponses(cfg.AccrualAddress)
+	shutdownTracing, err := tracing.Setup(ctx, cfg.TraceExporter, serviceName)
 	if err != nil {
 		return err
 	}
 
-	var db *sql.DB
-	var service *gophermartservice.GophermartService
+	// контекст трассировки передается в систему расчета начислений в заголовках W3C traceparent
+	accrualClient, err := Accrual.NewClientWithResponses(cfg.AccrualAddress,
+		Accrual.WithHTTPClient(&http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)}),
+	)
+	if err != nil {
+		return err
+	}
+
+	sinks, err := eventsink.Parse(cfg.EventSinks)
+	if err != nil {
+		return err
+	}
+	m := metrics.New()
+	options := []gophermartservice.Option{
+		gophermartservice.WithEventSinks(sinks...),
+		gophermartservice.WithMetrics(m),
+		gophermartservice.WithAccrualMaxJobs(int64(cfg.AccrualMaxJobs)),
+		gophermartservice.WithAccrualCircuitBreaker(cfg.AccrualCircuitThreshold, cfg.AccrualCircuitCooldown),
+		gophermartservice.WithPasswordHashCost(cfg.BcryptCost),
+		gophermartservice.WithSessionTTL(cfg.TokenTTL),
+		gophermartservice.WithJanitor(cfg.JanitorInterval, cfg.JanitorBatchSize),
+		gophermartservice.WithJanitorRetention(cfg.JanitorIdempotencyRetention, cfg.JanitorRateLimitRetention, cfg.JanitorEventRetention),
+	}
+
+	// ресурсы закрываются в обратном порядке: при ошибке запуска - на выходе из Run,
+	// после запуска - при завершении работы, когда остановлены серверы и фоновые обработчики
+	var closers []func()
+	var closeOnce sync.Once
+	closeResources := func() {
+		closeOnce.Do(func() {
+			for i := len(closers) - 1; i >= 0; i-- {
+				closers[i]()
+			}
+		})
+	}
+	defer closeResources()
+
+	h := health.New()
 	switch cfg.RepositoryType() {
 	case config.MemoryRepo:
-		service, err = gophermartservice.New(accrualClient, gophermartservice.WithMemoryStorage())
+		if cfg.MemoryFile != "" {
+			options = append(options, gophermartservice.WithPersistentMemoryStorage(cfg.MemoryFile, cfg.MemorySnapshotInterval))
+		} else {
+			options = append(options, gophermartservice.WithMemoryStorage())
+		}
+	case config.DatabaseRepo:
+		pool, db, err := openPg(ctx, cfg, m, h)
 		if err != nil {
 			return err
 		}
-	case config.DatabaseRepo:
-		db, err = sql.Open("pgx", cfg.DatabaseDSN)
+		closers = append(closers, func() { _ = db.Close() }, pool.Close)
+		options = append(options, gophermartservice.WithPgStorage(pool))
+	case config.SQLiteRepo:
+		db, err := openDB(sqlitedb.DriverName, semconv.DBSystemSqlite, sqlitedb.DSN(cfg.SQLitePath()), migration.SQLite, cfg.AutoMigrate, m, h)
 		if err != nil {
 			return err
 		}
+		closers = append(closers, func() { _ = db.Close() })
+		options = append(options, gophermartservice.WithSQLiteStorage(db))
+	default:
+		return fmt.Errorf("unknown repo type")
+	}
 
-		err = migration.Migrate(db)
+	if cfg.SessionRedisURL != "" {
+		sessionClient, err := openRedis(ctx, cfg.SessionRedisURL, h)
 		if err != nil {
 			return err
 		}
-		service, err = gophermartservice.New(accrualClient, gophermartservice.WithPgStorage(db))
+		closers = append(closers, func() { _ = sessionClient.Close() })
+		options = append(options, gophermartservice.WithRedisSessions(sessionClient, cfg.TokenTTL))
+	}
+
+	service, err := gophermartservice.New(accrualClient, options...)
+	if err != nil {
+		return err
+	}
+	closers = append(closers, service.Shutdown)
+
+	settings, err := runtimeSettings(cfg)
+	if err != nil {
+		return err
+	}
+	service.ApplySettings(settings)
+	reload := newReloader(args, cfg, service)
+
+	h.AddCheck("accrual", service.CheckAccrual)
+	h.AddCheck("accrual_jobs", service.CheckAccrualJobs)
+
+	if err := service.ResumeAccruals(ctx); err != nil {
+		return err
+	}
+
+	// фоновые обработчики останавливаются после HTTP и gRPC серверов, поэтому у них свой контекст
+	workCtx, stopWork := context.WithCancel(ctxBg)
+	defer stopWork()
+	workers := sync.WaitGroup{}
+	workers.Add(5)
+	go func() {
+		defer workers.Done()
+		service.RunEventRelay(workCtx)
+	}()
+	go func() {
+		defer workers.Done()
+		service.RunSessionActivity(workCtx)
+	}()
+	go func() {
+		defer workers.Done()
+		service.RunJanitor(workCtx)
+	}()
+	go func() {
+		defer workers.Done()
+		service.RunWebhookDispatcher(workCtx)
+	}()
+	go func() {
+		defer workers.Done()
+		reload.watchSIGHUP(workCtx)
+	}()
+
+	router := httpcontroller.NewRouter(service,
+		httpcontroller.WithAdminToken(cfg.AdminToken),
+		httpcontroller.WithResponseValidation(cfg.Debug),
+		httpcontroller.WithMetrics(m),
+		httpcontroller.WithHealth(h),
+		httpcontroller.WithConfigReloader(reload.Reload),
+		httpcontroller.WithMaxDecompressedBodySize(int64(cfg.MaxDecompressedBodySize)),
+	)
+	server := httpserver.New(router,
+		httpserver.WithAddr(cfg.ServerAddress),
+		httpserver.WithReadTimeout(cfg.ReadTimeout),
+		httpserver.WithWriteTimeout(cfg.WriteTimeout),
+	)
+
+	var adminServer *http.Server
+	if cfg.AdminAddress != "" {
+		adminServer = httpserver.New(newAdminRouter(m, h), httpserver.WithAddr(cfg.AdminAddress))
+		go func() {
+			l.Info().Str("addr", cfg.AdminAddress).Msg("starting admin server")
+			if err := adminServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				log.Err(err).Msg("admin server error")
+				cancel()
+			}
+		}()
+	}
+
+	var grpcServer *grpc.Server
+	if cfg.GRPCAddress != "" {
+		listener, err := net.Listen("tcp", cfg.GRPCAddress)
 		if err != nil {
 			return err
 		}
-	default:
-		return fmt.Errorf("unknown repo type")
+		grpcServer = grpccontroller.NewServer(service)
+		go func() {
+			l.Info().Str("addr", cfg.GRPCAddress).Msg("starting grpc server")
+			if err := grpcServer.Serve(listener); err != nil {
+				log.Err(err).Msg("grpc server error")
+				cancel()
+			}
+		}()
 	}
 
-	server := httpserver.New(httpcontroller.NewRouter(service), httpserver.WithAddr(cfg.ServerAddress))
-
+	shutdownDone := make(chan struct{})
 	go func() {
+		defer close(shutdownDone)
 		<-ctx.Done()
 		log.Info().Msg("Shutdown...")
-		service.Shutdown()
-		if db != nil {
-			_ = db.Close()
-		}
-		ctx, cancel := context.WithTimeout(ctxBg, 5*time.Second)
+		h.SetShuttingDown()
+		ctx, cancel := context.WithTimeout(ctxBg, cfg.ShutdownTimeout)
 		defer cancel()
 
+		// 1. HTTP и gRPC серверы перестают принимать запросы и дожидаются завершения текущих
+		wg := sync.WaitGroup{}
+		if grpcServer != nil {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				stopGRPCServer(ctx, grpcServer)
+			}()
+		}
 		if err := server.Shutdown(ctx); err != nil {
 			log.Err(err).Msg("error during shutdown server")
 		}
+		wg.Wait()
+
+		// 2. фоновая обработка прерывается, незавершенные заказы продолжатся после перезапуска
+		if err := service.StopAccruals(ctx); err != nil {
+			log.Err(err).Msg("accrual workers did not stop in time")
+		}
+		stopWork()
+		if err := waitGroup(ctx, &workers); err != nil {
+			log.Err(err).Msg("background workers did not stop in time")
+		}
+
+		// 3. только после этого закрываются репозитории и БД
+		closeResources()
+
+		// 4. служебный listener работает до конца, чтобы были видны метрики и /readyz
+		if adminServer != nil {
+			if err := adminServer.Shutdown(ctx); err != nil {
+				log.Err(err).Msg("error during shutdown admin server")
+			}
+		}
+		if err := shutdownTracing(ctx); err != nil {
+			log.Err(err).Msg("error during shutdown tracing")
+		}
+		log.Info().Msg("Shutdown completed")
 	}()
 
 	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		// ресурсы закрываются тем же порядком, что и при обычном завершении
+		cancel()
+		<-shutdownDone
 		return err
 	}
+	<-shutdownDone
 	return nil
 }
+
+// newAdminRouter роутер служебного listener, недоступного снаружи
+func newAdminRouter(m *metrics.Metrics, h *health.Health) http.Handler {
+	r := chi.NewRouter()
+	r.Handle("/metrics", m.Handler())
+	r.Get("/healthz", h.Liveness)
+	r.Get("/readyz", h.Readiness)
+	return r
+}
+
+// waitGroup дожидается wg, но не дольше, чем до отмены ctx
+func waitGroup(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// stopGRPCServer дожидается завершения текущих вызовов, но не дольше, чем до отмены ctx
+func stopGRPCServer(ctx context.Context, server *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		log.Warn().Msg("grpc graceful stop timeout")
+		server.Stop()
+	}
+}
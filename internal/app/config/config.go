@@ -2,17 +2,117 @@ package config
 
 import (
 	"flag"
+	"fmt"
+	"strings"
+	"time"
 )
 
 const (
 	defaultServerAddress = "localhost:8080"
 )
 
-// AppConfig настройки приложения, заполняются из флагов и/или переменных окружения
+// AppConfig настройки приложения. Значения берутся по возрастанию приоритета:
+// значения по умолчанию -> файл конфигурации -> переменные окружения -> аргументы командной строки
 type AppConfig struct {
-	ServerAddress  string
-	DatabaseDSN    string
+	// ConfigFile путь к файлу конфигурации в формате YAML (.yaml, .yml) или TOML (.toml)
+	ConfigFile string
+	// PrintConfig вывести итоговую конфигурацию и завершиться
+	PrintConfig bool
+
+	ServerAddress string
+	// GRPCAddress адрес gRPC API. Пустой адрес выключает gRPC
+	GRPCAddress string
+	// AdminAddress адрес служебного listener с /metrics, /healthz и /readyz. Пустой адрес выключает listener
+	AdminAddress string
+	// ReadTimeout и WriteTimeout таймауты HTTP сервера
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	// MaxDecompressedBodySize до скольки байт можно распаковать сжатое тело запроса
+	MaxDecompressedBodySize int
+	// ShutdownTimeout сколько ждать завершения запросов и фоновых обработчиков при остановке
+	ShutdownTimeout time.Duration
+
+	// DatabaseDSN строка подключения к Postgres или путь к файлу SQLite в виде sqlite:///path/to/gophermart.db.
+	// Пустая строка - данные хранятся в памяти
+	DatabaseDSN string
+	// MemoryFile журнал изменений данных в памяти, из которого они восстанавливаются при запуске.
+	// Пустая строка - данные в памяти теряются при остановке
+	MemoryFile string
+	// MemorySnapshotInterval как часто данные в памяти записываются в снимок, после чего журнал начинается заново
+	MemorySnapshotInterval time.Duration
+	// AutoMigrate применять миграции при запуске. Без этого схемой управляют командой gophermart migrate
+	AutoMigrate bool
+	// DatabaseMaxConns и DatabaseMinConns размер пула соединений с Postgres
+	DatabaseMaxConns int
+	DatabaseMinConns int
+	// DatabaseMaxConnLifetime и DatabaseMaxConnIdleTime через сколько пул закрывает соединение всегда и без запросов
+	DatabaseMaxConnLifetime time.Duration
+	DatabaseMaxConnIdleTime time.Duration
+	// DatabaseConnectTimeout сколько ждать установки соединения с Postgres
+	DatabaseConnectTimeout time.Duration
+
 	AccrualAddress string
+	// AccrualRetryInterval пауза перед повторным запросом в систему расчета начислений
+	AccrualRetryInterval time.Duration
+	// AccrualMaxRetries после скольких повторов заказ переводится в TOO_MANY_RETRIES
+	AccrualMaxRetries int
+	// AccrualRateLimit сколько запросов в минуту можно отправить в систему расчета начислений
+	AccrualRateLimit int
+	// AccrualMaxJobs число одновременно обрабатываемых заказов, после которого сервис не готов принимать запросы
+	AccrualMaxJobs int
+	// AccrualCircuitThreshold и AccrualCircuitCooldown настройки circuit breaker системы расчета начислений
+	AccrualCircuitThreshold int
+	AccrualCircuitCooldown  time.Duration
+
+	// JWTKey ключ подписи JWT
+	JWTKey string
+	// TokenTTL время жизни JWT
+	TokenTTL time.Duration
+	// SessionRedisURL адрес Redis для сессий в виде redis://[:password@]host:port/db.
+	// Пустая строка - сессии хранятся вместе с остальными данными
+	SessionRedisURL string
+	// BcryptCost сложность хеширования паролей
+	BcryptCost int
+	// AdminToken токен для доступа к административному API. Пустой токен выключает API
+	AdminToken string
+	// LoginLimit сколько попыток входа под одним логином можно сделать за период: "10/15m".
+	// В отличие от RateLimitRules считается по логину, а не по клиенту. Пустая строка выключает ограничение
+	LoginLimit string
+
+	// RateLimitRules лимиты частоты запросов к API через запятую: "POST /api/user/orders=10/1m,* /api/user/*=100/1m".
+	// Пустая строка выключает ограничение
+	RateLimitRules string
+
+	// DisabledFeatures выключенные функции через запятую: registration, withdrawals
+	DisabledFeatures string
+
+	// JanitorInterval как часто удаляются сессии с истекшим JWT, старые ключи идемпотентности и корзины лимитов
+	JanitorInterval time.Duration
+	// JanitorBatchSize сколько записей удаляется за один запрос
+	JanitorBatchSize int
+	// JanitorIdempotencyRetention сколько хранится ключ идемпотентности. Повтор запроса с ключом после этого
+	// выполняется заново
+	JanitorIdempotencyRetention time.Duration
+	// JanitorRateLimitRetention сколько хранится корзина лимита после последнего запроса клиента,
+	// но не меньше самого длинного периода в правилах
+	JanitorRateLimitRetention time.Duration
+	// JanitorEventRetention сколько хранится событие outbox после создания, если оно уже опубликовано во все sinks.
+	// Неопубликованные события не удаляются
+	JanitorEventRetention time.Duration
+
+	// EventSinks получатели доменных событий через запятую: stdout, file:/path, http(s)://host/path
+	EventSinks string
+	// TraceExporter экспортер спанов OpenTelemetry: otlp, stdout, file:/path. Пустая строка выключает трассировку
+	TraceExporter string
+	// LogLevel минимальный уровень логирования: trace, debug, info, warn, error
+	LogLevel string
+	// LogFormat формат логов: json или console
+	LogFormat string
+	// Debug отладочный режим: ответы API проверяются на соответствие спецификации OpenAPI
+	Debug bool
+
+	// Args аргументы командной строки после флагов
+	Args []string
 }
 
 // RepoType тип репозитория
@@ -21,23 +121,137 @@ type RepoType string
 const (
 	MemoryRepo   RepoType = "Memory"
 	DatabaseRepo RepoType = "DB"
+	SQLiteRepo   RepoType = "SQLite"
 )
 
+// sqliteScheme схема DSN, по которой выбирается SQLite. sqlite:///var/lib/gophermart.db - абсолютный путь,
+// sqlite://gophermart.db - относительный
+const sqliteScheme = "sqlite://"
+
 func (s AppConfig) RepositoryType() RepoType {
-	if s.DatabaseDSN != "" {
+	switch {
+	case s.DatabaseDSN == "":
+		return MemoryRepo
+	case strings.HasPrefix(s.DatabaseDSN, sqliteScheme):
+		return SQLiteRepo
+	default:
 		return DatabaseRepo
 	}
-	return MemoryRepo
 }
 
-// Config возвращает конфигурацию приложения, вычитывая в таком порядке
-// аргументы командной строки -> env
-// args - пока не используется
-func Config(args []string) AppConfig {
-	cfg := AppConfig{}
-	flag.StringVar(&cfg.ServerAddress, "a", getEnvOrDefault("RUN_ADDRESS", defaultServerAddress), "listen address. env: RUN_ADDRESS")
-	flag.StringVar(&cfg.DatabaseDSN, "d", getEnvOrDefault("DATABASE_URI", ""), "PG dsn. env: DATABASE_URI")
-	flag.StringVar(&cfg.AccrualAddress, "r", getEnvOrDefault("ACCRUAL_SYSTEM_ADDRESS", ""), "accrual address. env: ACCRUAL_SYSTEM_ADDRESS")
-	flag.Parse()
-	return cfg
+// SQLitePath путь к файлу SQLite из DSN
+func (s AppConfig) SQLitePath() string {
+	return strings.TrimPrefix(s.DatabaseDSN, sqliteScheme)
+}
+
+func defaultConfig() AppConfig {
+	return AppConfig{
+		ServerAddress:               defaultServerAddress,
+		ReadTimeout:                 5 * time.Second,
+		WriteTimeout:                5 * time.Second,
+		ShutdownTimeout:             10 * time.Second,
+		MaxDecompressedBodySize:     1 << 20,
+		MemorySnapshotInterval:      5 * time.Minute,
+		AutoMigrate:                 true,
+		DatabaseMaxConns:            10,
+		DatabaseMaxConnLifetime:     time.Hour,
+		DatabaseMaxConnIdleTime:     30 * time.Minute,
+		DatabaseConnectTimeout:      5 * time.Second,
+		AccrualRetryInterval:        50 * time.Millisecond,
+		AccrualMaxRetries:           5,
+		AccrualRateLimit:            1000,
+		AccrualMaxJobs:              10000,
+		AccrualCircuitThreshold:     5,
+		AccrualCircuitCooldown:      30 * time.Second,
+		JWTKey:                      "secureSecretText",
+		TokenTTL:                    24 * time.Hour,
+		BcryptCost:                  8,
+		JanitorInterval:             10 * time.Minute,
+		JanitorBatchSize:            1000,
+		JanitorIdempotencyRetention: 24 * time.Hour,
+		JanitorRateLimitRetention:   time.Hour,
+		JanitorEventRetention:       7 * 24 * time.Hour,
+		LogLevel:                    "info",
+		LogFormat:                   "json",
+	}
+}
+
+// Config возвращает конфигурацию приложения. args - аргументы командной строки вместе с именем программы.
+// Ошибки в значениях всех источников собираются в *ValidationError
+func Config(args []string) (AppConfig, error) {
+	return load(args, AppConfig.validate)
+}
+
+// MigrateConfig конфигурация команды migrate. Источники те же, что у Config, но проверяются только
+// параметры БД и логов: остальные для миграций не нужны
+func MigrateConfig(args []string) (AppConfig, error) {
+	return load(args, AppConfig.validateMigrate)
+}
+
+func load(args []string, validate func(c AppConfig, errs *ValidationError)) (AppConfig, error) {
+	cfg := defaultConfig()
+
+	name := "gophermart"
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.StringVar(&cfg.ConfigFile, "config", getEnvOrDefault("CONFIG", ""), "config file (.yaml, .yml, .toml). env: CONFIG")
+	fs.BoolVar(&cfg.PrintConfig, "print-config", false, "print effective config with masked secrets and exit")
+	flags := make(map[string]*flagValue, len(settings))
+	for _, s := range settings {
+		v := &flagValue{value: format(s.field(&cfg)), isBool: isBool(s.field(&cfg))}
+		flags[s.flag] = v
+		fs.Var(v, s.flag, fmt.Sprintf("%s. env: %s", s.usage, s.env))
+	}
+	if err := fs.Parse(args); err != nil {
+		return cfg, err
+	}
+	cfg.Args = fs.Args()
+
+	errs := &ValidationError{}
+	if cfg.ConfigFile != "" {
+		if err := loadFile(cfg.ConfigFile, &cfg, errs); err != nil {
+			return cfg, err
+		}
+	}
+	loadEnv(&cfg, errs)
+	fs.Visit(func(f *flag.Flag) {
+		v, ok := flags[f.Name]
+		if !ok {
+			return
+		}
+		s := settingByFlag(f.Name)
+		if err := parse(s.field(&cfg), v.value); err != nil {
+			errs.add(s.key, "flag -"+s.flag, err)
+		}
+	})
+	validate(cfg, errs)
+
+	if len(errs.Fields) > 0 {
+		return cfg, errs
+	}
+	return cfg, nil
+}
+
+// flagValue запоминает значение флага, чтобы применить его после файла и переменных окружения
+type flagValue struct {
+	value  string
+	isBool bool
+}
+
+func (v *flagValue) String() string {
+	if v == nil {
+		return ""
+	}
+	return v.value
+}
+
+func (v *flagValue) Set(value string) error {
+	v.value = value
+	return nil
+}
+
+func (v *flagValue) IsBoolFlag() bool {
+	return v.isBool
 }
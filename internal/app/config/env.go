@@ -1,6 +1,8 @@
 package config
 
-import "os"
+import (
+	"os"
+)
 
 func getEnvOrDefault(key string, defaultValue string) string {
 	value, ok := os.LookupEnv(key)
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrInvalidConfig     = errors.New("invalid config")
+	ErrInvalidValue      = errors.New("invalid value")
+	ErrUnknownKey        = errors.New("unknown key")
+	ErrUnknownFileFormat = errors.New("unknown config file format, expected .yaml, .yml or .toml")
+)
+
+// FieldError ошибка в значении одного параметра
+type FieldError struct {
+	Key string
+	// Source откуда взято значение: file, env, flag. Пусто для итоговой проверки
+	Source string
+	Err    error
+}
+
+func (e FieldError) Error() string {
+	if e.Source == "" {
+		return e.Key + ": " + e.Err.Error()
+	}
+	return e.Key + " (" + e.Source + "): " + e.Err.Error()
+}
+
+func (e FieldError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError все ошибки конфигурации, найденные при запуске
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		messages = append(messages, field.Error())
+	}
+	return ErrInvalidConfig.Error() + ": " + strings.Join(messages, "; ")
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidConfig
+}
+
+func (e *ValidationError) add(key string, source string, err error) {
+	e.Fields = append(e.Fields, FieldError{Key: key, Source: source, Err: err})
+}
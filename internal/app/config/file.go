@@ -0,0 +1,84 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// loadFile читает файл конфигурации. Секции файла соответствуют префиксам ключей:
+//
+//	server:
+//	  address: localhost:8080
+//	  read_timeout: 5s
+//
+// Ошибки в значениях и неизвестные ключи добавляются в errs
+func loadFile(path string, cfg *AppConfig, errs *ValidationError) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+
+	values := map[string]interface{}{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &values)
+	case ".toml":
+		err = toml.Unmarshal(data, &values)
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownFileFormat, path)
+	}
+	if err != nil {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+
+	flat := map[string]string{}
+	flatten("", values, flat)
+	keys := make([]string, 0, len(flat))
+	for key := range flat {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	source := "file " + path
+	for _, key := range keys {
+		s, ok := settingByKey(key)
+		if !ok {
+			errs.add(key, source, ErrUnknownKey)
+			continue
+		}
+		if err := parse(s.field(cfg), flat[key]); err != nil {
+			errs.add(key, source, err)
+		}
+	}
+	return nil
+}
+
+// flatten превращает вложенные секции в ключи вида "server.address".
+// Списки склеиваются через запятую, как в переменных окружения
+func flatten(prefix string, values map[string]interface{}, result map[string]string) {
+	for key, value := range values {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		switch v := value.(type) {
+		case map[string]interface{}:
+			flatten(key, v, result)
+		case []interface{}:
+			items := make([]string, 0, len(v))
+			for _, item := range v {
+				items = append(items, fmt.Sprint(item))
+			}
+			result[key] = strings.Join(items, ",")
+		case nil:
+			result[key] = ""
+		default:
+			result[key] = fmt.Sprint(v)
+		}
+	}
+}
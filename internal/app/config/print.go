@@ -0,0 +1,81 @@
+package config
+
+import (
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const secretMask = "***"
+
+var dsnPassword = regexp.MustCompile(`(password\s*=\s*)('[^']*'|\S+)`)
+
+// Print выводит итоговую конфигурацию в формате файла конфигурации YAML. Секреты маскируются
+func (c AppConfig) Print(w io.Writer) error {
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	sections := map[string]*yaml.Node{}
+	for _, s := range settings {
+		value := format(s.field(&c))
+		if s.secret {
+			value = maskSecret(s.key, value)
+		}
+
+		parent := root
+		key := s.key
+		if i := strings.Index(key, "."); i >= 0 {
+			section := key[:i]
+			key = key[i+1:]
+			node, ok := sections[section]
+			if !ok {
+				node = &yaml.Node{Kind: yaml.MappingNode}
+				sections[section] = node
+				root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: section}, node)
+			}
+			parent = node
+		}
+		parent.Content = append(parent.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: key},
+			&yaml.Node{Kind: yaml.ScalarNode, Value: value, Style: scalarStyle(value)},
+		)
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(root); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// maskSecret скрывает секрет. В DSN скрывается только пароль, чтобы были видны хост и база
+func maskSecret(key string, value string) string {
+	if value == "" {
+		return ""
+	}
+	if key != "database.uri" && key != "auth.session_redis_url" {
+		return secretMask
+	}
+	if u, err := url.Parse(value); err == nil && u.Scheme != "" {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), secretMask)
+		}
+		if q := u.Query(); q.Get("password") != "" {
+			q.Set("password", secretMask)
+			u.RawQuery = q.Encode()
+		}
+		s, _ := url.PathUnescape(u.String())
+		return s
+	}
+	return dsnPassword.ReplaceAllString(value, "${1}"+secretMask)
+}
+
+// scalarStyle берет пустые строки в кавычки, чтобы они не читались как null
+func scalarStyle(value string) yaml.Style {
+	if value == "" {
+		return yaml.DoubleQuotedStyle
+	}
+	return 0
+}
@@ -0,0 +1,29 @@
+package config
+
+// Changes параметры, изменившиеся при перечитывании конфигурации
+type Changes struct {
+	// Applied параметры, примененные к работающему приложению
+	Applied []string
+	// RestartRequired параметры, которые вступят в силу только после перезапуска
+	RestartRequired []string
+}
+
+// Reload возвращает конфигурацию, в которой параметры, изменяемые без перезапуска, взяты из next,
+// а остальные остаются прежними, и список изменившихся параметров
+func (c AppConfig) Reload(next AppConfig) (AppConfig, Changes) {
+	changes := Changes{Applied: []string{}, RestartRequired: []string{}}
+	for _, s := range settings {
+		value := format(s.field(&next))
+		if format(s.field(&c)) == value {
+			continue
+		}
+		if !s.reloadable {
+			changes.RestartRequired = append(changes.RestartRequired, s.key)
+			continue
+		}
+		changes.Applied = append(changes.Applied, s.key)
+		// значение уже проверено при разборе next
+		_ = parse(s.field(&c), value)
+	}
+	return c, changes
+}
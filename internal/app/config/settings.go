@@ -0,0 +1,192 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// setting описывает параметр конфигурации: ключ в файле, флаг и переменную окружения
+type setting struct {
+	key   string
+	flag  string
+	env   string
+	usage string
+	// secret значение маскируется при выводе конфигурации
+	secret bool
+	// reloadable значение применяется к работающему приложению без перезапуска
+	reloadable bool
+	field      func(cfg *AppConfig) interface{}
+}
+
+var settings = []setting{
+	{key: "server.address", flag: "a", env: "RUN_ADDRESS", usage: "listen address",
+		field: func(c *AppConfig) interface{} { return &c.ServerAddress }},
+	{key: "server.grpc_address", flag: "grpc-address", env: "GRPC_ADDRESS", usage: "gRPC listen address, empty disables gRPC API",
+		field: func(c *AppConfig) interface{} { return &c.GRPCAddress }},
+	{key: "server.admin_address", flag: "admin-address", env: "ADMIN_ADDRESS", usage: "admin listen address for /metrics and health checks, empty disables it",
+		field: func(c *AppConfig) interface{} { return &c.AdminAddress }},
+	{key: "server.read_timeout", flag: "read-timeout", env: "SERVER_READ_TIMEOUT", usage: "HTTP server read timeout",
+		field: func(c *AppConfig) interface{} { return &c.ReadTimeout }},
+	{key: "server.write_timeout", flag: "write-timeout", env: "SERVER_WRITE_TIMEOUT", usage: "HTTP server write timeout",
+		field: func(c *AppConfig) interface{} { return &c.WriteTimeout }},
+	{key: "server.max_decompressed_body_size", flag: "max-decompressed-body-size", env: "MAX_DECOMPRESSED_BODY_SIZE", usage: "max size in bytes of decompressed request body",
+		field: func(c *AppConfig) interface{} { return &c.MaxDecompressedBodySize }},
+	{key: "server.shutdown_timeout", flag: "shutdown-timeout", env: "SHUTDOWN_TIMEOUT", usage: "graceful shutdown timeout",
+		field: func(c *AppConfig) interface{} { return &c.ShutdownTimeout }},
+
+	{key: "database.uri", flag: "d", env: "DATABASE_URI", usage: "PG dsn or sqlite:///path/to/file.db", secret: true,
+		field: func(c *AppConfig) interface{} { return &c.DatabaseDSN }},
+	{key: "database.memory_file", flag: "memory-file", env: "MEMORY_FILE", usage: "log file that keeps in-memory data across restarts",
+		field: func(c *AppConfig) interface{} { return &c.MemoryFile }},
+	{key: "database.memory_snapshot_interval", flag: "memory-snapshot-interval", env: "MEMORY_SNAPSHOT_INTERVAL", usage: "how often in-memory data is written to a snapshot",
+		field: func(c *AppConfig) interface{} { return &c.MemorySnapshotInterval }},
+	{key: "database.auto_migrate", flag: "auto-migrate", env: "AUTO_MIGRATE", usage: "apply DB migrations at startup",
+		field: func(c *AppConfig) interface{} { return &c.AutoMigrate }},
+	{key: "database.max_conns", flag: "db-max-conns", env: "DATABASE_MAX_CONNS", usage: "max connections in PG pool",
+		field: func(c *AppConfig) interface{} { return &c.DatabaseMaxConns }},
+	{key: "database.min_conns", flag: "db-min-conns", env: "DATABASE_MIN_CONNS", usage: "connections PG pool keeps open when idle",
+		field: func(c *AppConfig) interface{} { return &c.DatabaseMinConns }},
+	{key: "database.max_conn_lifetime", flag: "db-max-conn-lifetime", env: "DATABASE_MAX_CONN_LIFETIME", usage: "PG connection lifetime",
+		field: func(c *AppConfig) interface{} { return &c.DatabaseMaxConnLifetime }},
+	{key: "database.max_conn_idle_time", flag: "db-max-conn-idle-time", env: "DATABASE_MAX_CONN_IDLE_TIME", usage: "idle time after which PG connection is closed",
+		field: func(c *AppConfig) interface{} { return &c.DatabaseMaxConnIdleTime }},
+	{key: "database.connect_timeout", flag: "db-connect-timeout", env: "DATABASE_CONNECT_TIMEOUT", usage: "PG connect timeout",
+		field: func(c *AppConfig) interface{} { return &c.DatabaseConnectTimeout }},
+
+	{key: "accrual.address", flag: "r", env: "ACCRUAL_SYSTEM_ADDRESS", usage: "accrual address",
+		field: func(c *AppConfig) interface{} { return &c.AccrualAddress }},
+	{key: "accrual.retry_interval", flag: "accrual-retry-interval", env: "ACCRUAL_RETRY_INTERVAL", usage: "pause between accrual requests for an order", reloadable: true,
+		field: func(c *AppConfig) interface{} { return &c.AccrualRetryInterval }},
+	{key: "accrual.max_retries", flag: "accrual-max-retries", env: "ACCRUAL_MAX_RETRIES", usage: "retries before order becomes TOO_MANY_RETRIES", reloadable: true,
+		field: func(c *AppConfig) interface{} { return &c.AccrualMaxRetries }},
+	{key: "accrual.rate_limit", flag: "accrual-rate-limit", env: "ACCRUAL_RATE_LIMIT", usage: "accrual requests per minute", reloadable: true,
+		field: func(c *AppConfig) interface{} { return &c.AccrualRateLimit }},
+	{key: "accrual.max_jobs", flag: "accrual-max-jobs", env: "ACCRUAL_MAX_JOBS", usage: "in-flight orders after which the service is not ready",
+		field: func(c *AppConfig) interface{} { return &c.AccrualMaxJobs }},
+	{key: "accrual.circuit_threshold", flag: "accrual-circuit-threshold", env: "ACCRUAL_CIRCUIT_THRESHOLD", usage: "consecutive failures that open accrual circuit breaker",
+		field: func(c *AppConfig) interface{} { return &c.AccrualCircuitThreshold }},
+	{key: "accrual.circuit_cooldown", flag: "accrual-circuit-cooldown", env: "ACCRUAL_CIRCUIT_COOLDOWN", usage: "pause before probe request when accrual circuit breaker is open",
+		field: func(c *AppConfig) interface{} { return &c.AccrualCircuitCooldown }},
+
+	{key: "auth.jwt_key", flag: "jwt-key", env: "JWT_KEY", usage: "JWT signing key", secret: true,
+		field: func(c *AppConfig) interface{} { return &c.JWTKey }},
+	{key: "auth.token_ttl", flag: "token-ttl", env: "TOKEN_TTL", usage: "JWT lifetime",
+		field: func(c *AppConfig) interface{} { return &c.TokenTTL }},
+	{key: "auth.session_redis_url", flag: "session-redis-url", env: "SESSION_REDIS_URL", usage: "Redis URL for sessions, empty keeps them in the main storage", secret: true,
+		field: func(c *AppConfig) interface{} { return &c.SessionRedisURL }},
+	{key: "auth.bcrypt_cost", flag: "bcrypt-cost", env: "BCRYPT_COST", usage: "bcrypt cost of password hashes",
+		field: func(c *AppConfig) interface{} { return &c.BcryptCost }},
+	{key: "auth.login_limit", flag: "login-limit", env: "LOGIN_LIMIT", usage: "login attempts per login: requests/period, empty disables the limit", reloadable: true,
+		field: func(c *AppConfig) interface{} { return &c.LoginLimit }},
+	{key: "auth.admin_token", flag: "admin-token", env: "ADMIN_TOKEN", usage: "admin API token", secret: true,
+		field: func(c *AppConfig) interface{} { return &c.AdminToken }},
+
+	{key: "ratelimit.rules", flag: "rate-limit-rules", env: "RATE_LIMIT_RULES", usage: "API rate limits: METHOD /path=requests/period,... (* matches any method or path prefix)", reloadable: true,
+		field: func(c *AppConfig) interface{} { return &c.RateLimitRules }},
+
+	{key: "features.disabled", flag: "disabled-features", env: "DISABLED_FEATURES", usage: "features turned off: registration,withdrawals", reloadable: true,
+		field: func(c *AppConfig) interface{} { return &c.DisabledFeatures }},
+
+	{key: "janitor.interval", flag: "janitor-interval", env: "JANITOR_INTERVAL", usage: "how often expired sessions, idempotency keys and rate limit buckets are removed",
+		field: func(c *AppConfig) interface{} { return &c.JanitorInterval }},
+	{key: "janitor.batch_size", flag: "janitor-batch-size", env: "JANITOR_BATCH_SIZE", usage: "records removed per query",
+		field: func(c *AppConfig) interface{} { return &c.JanitorBatchSize }},
+	{key: "janitor.idempotency_retention", flag: "janitor-idempotency-retention", env: "JANITOR_IDEMPOTENCY_RETENTION", usage: "how long idempotency keys are kept",
+		field: func(c *AppConfig) interface{} { return &c.JanitorIdempotencyRetention }},
+	{key: "janitor.rate_limit_retention", flag: "janitor-rate-limit-retention", env: "JANITOR_RATE_LIMIT_RETENTION", usage: "how long idle rate limit buckets are kept",
+		field: func(c *AppConfig) interface{} { return &c.JanitorRateLimitRetention }},
+	{key: "janitor.event_retention", flag: "janitor-event-retention", env: "JANITOR_EVENT_RETENTION", usage: "how long outbox events published to every sink are kept",
+		field: func(c *AppConfig) interface{} { return &c.JanitorEventRetention }},
+
+	{key: "events.sinks", flag: "event-sinks", env: "EVENT_SINKS", usage: "domain event sinks: stdout,file:/path,http://host/path",
+		field: func(c *AppConfig) interface{} { return &c.EventSinks }},
+	{key: "tracing.exporter", flag: "trace-exporter", env: "TRACE_EXPORTER", usage: "OpenTelemetry trace exporter: otlp,stdout,file:/path",
+		field: func(c *AppConfig) interface{} { return &c.TraceExporter }},
+	{key: "log.level", flag: "log-level", env: "LOG_LEVEL", usage: "log level: trace,debug,info,warn,error", reloadable: true,
+		field: func(c *AppConfig) interface{} { return &c.LogLevel }},
+	{key: "log.format", flag: "log-format", env: "LOG_FORMAT", usage: "log format: json,console",
+		field: func(c *AppConfig) interface{} { return &c.LogFormat }},
+	{key: "debug", flag: "debug", env: "DEBUG", usage: "debug mode, validates API responses against the spec",
+		field: func(c *AppConfig) interface{} { return &c.Debug }},
+}
+
+func settingByKey(key string) (setting, bool) {
+	for _, s := range settings {
+		if s.key == key {
+			return s, true
+		}
+	}
+	return setting{}, false
+}
+
+func settingByFlag(name string) setting {
+	for _, s := range settings {
+		if s.flag == name {
+			return s
+		}
+	}
+	panic("unknown flag " + name)
+}
+
+func loadEnv(cfg *AppConfig, errs *ValidationError) {
+	for _, s := range settings {
+		value, ok := os.LookupEnv(s.env)
+		if !ok {
+			continue
+		}
+		if err := parse(s.field(cfg), value); err != nil {
+			errs.add(s.key, "env "+s.env, err)
+		}
+	}
+}
+
+// parse записывает строковое значение в поле конфигурации
+func parse(field interface{}, value string) error {
+	switch p := field.(type) {
+	case *string:
+		*p = value
+	case *bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%w: %q is not a bool", ErrInvalidValue, value)
+		}
+		*p = b
+	case *int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%w: %q is not an integer", ErrInvalidValue, value)
+		}
+		*p = n
+	case *time.Duration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%w: %q is not a duration", ErrInvalidValue, value)
+		}
+		*p = d
+	default:
+		panic(fmt.Sprintf("unsupported config field type %T", field))
+	}
+	return nil
+}
+
+func format(field interface{}) string {
+	switch p := field.(type) {
+	case *string:
+		return *p
+	case *bool:
+		return strconv.FormatBool(*p)
+	case *int:
+		return strconv.Itoa(*p)
+	case *time.Duration:
+		return p.String()
+	default:
+		panic(fmt.Sprintf("unsupported config field type %T", field))
+	}
+}
+
+func isBool(field interface{}) bool {
+	_, ok := field.(*bool)
+	return ok
+}
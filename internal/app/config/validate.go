@@ -0,0 +1,164 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/rs/zerolog"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/feature"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/logger"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/ratelimit"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const minJWTKeyLength = 16
+
+// validate проверяет итоговые значения и добавляет в errs все найденные ошибки
+func (c AppConfig) validate(errs *ValidationError) {
+	check := func(key string, err error) {
+		if err != nil {
+			errs.add(key, "", err)
+		}
+	}
+
+	check("server.address", validateAddress(c.ServerAddress, true))
+	check("server.grpc_address", validateAddress(c.GRPCAddress, false))
+	check("server.admin_address", validateAddress(c.AdminAddress, false))
+	check("server.read_timeout", positive(int64(c.ReadTimeout)))
+	check("server.write_timeout", positive(int64(c.WriteTimeout)))
+	check("server.shutdown_timeout", positive(int64(c.ShutdownTimeout)))
+	check("server.max_decompressed_body_size", positive(int64(c.MaxDecompressedBodySize)))
+
+	c.validateDatabase(errs)
+
+	check("accrual.address", validateURL(c.AccrualAddress))
+	check("accrual.retry_interval", positive(int64(c.AccrualRetryInterval)))
+	if c.AccrualMaxRetries < 0 {
+		check("accrual.max_retries", fmt.Errorf("%w: must not be negative", ErrInvalidValue))
+	}
+	check("accrual.rate_limit", positive(int64(c.AccrualRateLimit)))
+	check("accrual.max_jobs", positive(int64(c.AccrualMaxJobs)))
+	check("accrual.circuit_threshold", positive(int64(c.AccrualCircuitThreshold)))
+	check("accrual.circuit_cooldown", positive(int64(c.AccrualCircuitCooldown)))
+
+	if len(c.JWTKey) < minJWTKeyLength {
+		check("auth.jwt_key", fmt.Errorf("%w: must be at least %d bytes", ErrInvalidValue, minJWTKeyLength))
+	}
+	check("auth.token_ttl", positive(int64(c.TokenTTL)))
+	if c.SessionRedisURL != "" {
+		if _, err := redis.ParseURL(c.SessionRedisURL); err != nil {
+			check("auth.session_redis_url", fmt.Errorf("%w: %v", ErrInvalidValue, err))
+		}
+	}
+	if c.BcryptCost < bcrypt.MinCost || c.BcryptCost > bcrypt.MaxCost {
+		check("auth.bcrypt_cost", fmt.Errorf("%w: must be in [%d, %d]", ErrInvalidValue, bcrypt.MinCost, bcrypt.MaxCost))
+	}
+
+	if c.LoginLimit != "" {
+		if _, err := ratelimit.ParseLimit(c.LoginLimit); err != nil {
+			check("auth.login_limit", err)
+		}
+	}
+	if _, err := ratelimit.ParseRules(c.RateLimitRules); err != nil {
+		check("ratelimit.rules", err)
+	}
+	if _, err := feature.ParseDisabled(c.DisabledFeatures); err != nil {
+		check("features.disabled", err)
+	}
+
+	check("janitor.interval", positive(int64(c.JanitorInterval)))
+	check("janitor.batch_size", positive(int64(c.JanitorBatchSize)))
+	check("janitor.idempotency_retention", positive(int64(c.JanitorIdempotencyRetention)))
+	check("janitor.rate_limit_retention", positive(int64(c.JanitorRateLimitRetention)))
+	check("janitor.event_retention", positive(int64(c.JanitorEventRetention)))
+
+	check("tracing.exporter", validateTraceExporter(c.TraceExporter))
+	c.validateLog(errs)
+}
+
+// validateMigrate проверяет параметры, которые нужны команде migrate
+func (c AppConfig) validateMigrate(errs *ValidationError) {
+	c.validateDatabase(errs)
+	c.validateLog(errs)
+}
+
+func (c AppConfig) validateDatabase(errs *ValidationError) {
+	if c.RepositoryType() == SQLiteRepo && c.SQLitePath() == "" {
+		errs.add("database.uri", "", fmt.Errorf("%w: sqlite DSN without file path", ErrInvalidValue))
+	}
+	if c.MemoryFile != "" && c.RepositoryType() != MemoryRepo {
+		errs.add("database.memory_file", "", fmt.Errorf("%w: used only without database.uri", ErrInvalidValue))
+	}
+	if err := positive(int64(c.MemorySnapshotInterval)); err != nil {
+		errs.add("database.memory_snapshot_interval", "", err)
+	}
+	if err := positive(int64(c.DatabaseMaxConns)); err != nil {
+		errs.add("database.max_conns", "", err)
+	}
+	if c.DatabaseMinConns < 0 || c.DatabaseMinConns > c.DatabaseMaxConns {
+		errs.add("database.min_conns", "", fmt.Errorf("%w: must be in [0, database.max_conns]", ErrInvalidValue))
+	}
+	if err := positive(int64(c.DatabaseMaxConnLifetime)); err != nil {
+		errs.add("database.max_conn_lifetime", "", err)
+	}
+	if err := positive(int64(c.DatabaseMaxConnIdleTime)); err != nil {
+		errs.add("database.max_conn_idle_time", "", err)
+	}
+	if err := positive(int64(c.DatabaseConnectTimeout)); err != nil {
+		errs.add("database.connect_timeout", "", err)
+	}
+}
+
+func (c AppConfig) validateLog(errs *ValidationError) {
+	if level, err := zerolog.ParseLevel(strings.ToLower(c.LogLevel)); err != nil || level == zerolog.NoLevel {
+		errs.add("log.level", "", fmt.Errorf("%w: %q", logger.ErrUnknownLevel, c.LogLevel))
+	}
+	if c.LogFormat != logger.FormatJSON && c.LogFormat != logger.FormatConsole {
+		errs.add("log.format", "", fmt.Errorf("%w: %q", logger.ErrUnknownFormat, c.LogFormat))
+	}
+}
+
+func positive(value int64) error {
+	if value <= 0 {
+		return fmt.Errorf("%w: must be positive", ErrInvalidValue)
+	}
+	return nil
+}
+
+func validateAddress(address string, required bool) error {
+	if address == "" {
+		if required {
+			return fmt.Errorf("%w: required", ErrInvalidValue)
+		}
+		return nil
+	}
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		return fmt.Errorf("%w: %q is not host:port", ErrInvalidValue, address)
+	}
+	return nil
+}
+
+func validateURL(address string) error {
+	if address == "" {
+		return fmt.Errorf("%w: required", ErrInvalidValue)
+	}
+	u, err := url.Parse(address)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: %q is not http(s) URL", ErrInvalidValue, address)
+	}
+	return nil
+}
+
+func validateTraceExporter(exporter string) error {
+	switch {
+	case exporter == "", exporter == "otlp", exporter == "stdout":
+		return nil
+	case strings.HasPrefix(exporter, "file:") && len(exporter) > len("file:"):
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown trace exporter %q", ErrInvalidValue, exporter)
+	}
+}
@@ -0,0 +1,8 @@
+package app
+
+import "errors"
+
+var (
+	ErrMigrateUsage           = errors.New("usage: gophermart migrate [flags] up|down|redo|status|version|preflight")
+	ErrMigrateWithoutDatabase = errors.New("migrations require database.uri")
+)
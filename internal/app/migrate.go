@@ -0,0 +1,112 @@
+package app
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	_ "github.com/jackc/pgx/v4/stdlib"
+	"github.com/zaz600/go-musthave-diploma/internal/app/config"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/migration"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/sqlitedb"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/logger"
+)
+
+// migrateCommand подкоманда управления схемой БД: gophermart migrate [flags] up|down|redo|status|version|preflight
+const migrateCommand = "migrate"
+
+// Migrate выполняет команду migrate. args - аргументы командной строки вместе с именем команды,
+// флаги те же, что у сервиса. Результат status, version и preflight выводится в w
+func Migrate(args []string, w io.Writer) error {
+	cfg, err := config.MigrateConfig(args)
+	if err != nil {
+		return err
+	}
+	if _, err := logger.New(logger.WithLevel(cfg.LogLevel), logger.WithFormat(cfg.LogFormat)); err != nil {
+		return err
+	}
+	if len(cfg.Args) != 1 {
+		return ErrMigrateUsage
+	}
+
+	var db *sql.DB
+	var dialect migration.Dialect
+	switch cfg.RepositoryType() {
+	case config.DatabaseRepo:
+		db, err = sql.Open("pgx", cfg.DatabaseDSN)
+		dialect = migration.Postgres
+	case config.SQLiteRepo:
+		db, err = sql.Open(sqlitedb.DriverName, sqlitedb.DSN(cfg.SQLitePath()))
+		dialect = migration.SQLite
+	default:
+		return ErrMigrateWithoutDatabase
+	}
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	switch cfg.Args[0] {
+	case "up":
+		return migration.Migrate(db, dialect)
+	case "down":
+		return migration.Down(db, dialect)
+	case "redo":
+		return migration.Redo(db, dialect)
+	case "version":
+		version, err := migration.Version(db, dialect)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, version)
+		return err
+	case "status":
+		statuses, err := migration.Statuses(db, dialect)
+		if err != nil {
+			return err
+		}
+		return printMigrations(w, statuses)
+	case "preflight":
+		violations, err := migration.Preflight(db, dialect)
+		if err != nil {
+			return err
+		}
+		return printViolations(w, violations)
+	default:
+		return fmt.Errorf("%w: unknown command %q", ErrMigrateUsage, cfg.Args[0])
+	}
+}
+
+func printMigrations(w io.Writer, statuses []migration.Status) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tMIGRATION\tAPPLIED AT")
+	for _, s := range statuses {
+		appliedAt := "pending"
+		if s.Applied() {
+			appliedAt = s.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\n", s.Version, s.Name, appliedAt)
+	}
+	return tw.Flush()
+}
+
+func printViolations(w io.Writer, violations []migration.Violation) error {
+	if len(violations) == 0 {
+		_, err := fmt.Fprintln(w, "no constraint violations")
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CHECK\tROWS\tSAMPLE IDS")
+	for _, v := range violations {
+		ids := make([]string, 0, len(v.Samples))
+		for _, id := range v.Samples {
+			ids = append(ids, strconv.FormatInt(id, 10))
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\n", v.Check, v.Count, strings.Join(ids, ","))
+	}
+	return tw.Flush()
+}
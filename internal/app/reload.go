@@ -0,0 +1,103 @@
+package app
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/zaz600/go-musthave-diploma/internal/app/config"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/feature"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/logger"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/ratelimit"
+	"github.com/zaz600/go-musthave-diploma/internal/service/gophermartservice"
+)
+
+// reloader перечитывает конфигурацию из тех же источников, что и при запуске,
+// и применяет к работающему приложению параметры, изменяемые без перезапуска
+type reloader struct {
+	mu      sync.Mutex
+	args    []string
+	current config.AppConfig
+	service *gophermartservice.GophermartService
+}
+
+func newReloader(args []string, cfg config.AppConfig, service *gophermartservice.GophermartService) *reloader {
+	return &reloader{args: args, current: cfg, service: service}
+}
+
+// Reload применяет новую конфигурацию. Если в ней есть ошибки, текущие настройки не меняются
+func (r *reloader) Reload(_ context.Context) (config.Changes, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next, err := config.Config(r.args)
+	if err != nil {
+		return config.Changes{}, err
+	}
+	cfg, changes := r.current.Reload(next)
+	// все настройки проверяются до применения, чтобы ошибка не оставила их примененными наполовину
+	level, err := logger.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		return config.Changes{}, err
+	}
+	settings, err := runtimeSettings(cfg)
+	if err != nil {
+		return config.Changes{}, err
+	}
+	zerolog.SetGlobalLevel(level)
+	r.service.ApplySettings(settings)
+	r.current = cfg
+
+	log.Info().
+		Strs("applied", changes.Applied).
+		Strs("restart_required", changes.RestartRequired).
+		Msg("config reloaded")
+	return changes, nil
+}
+
+// watchSIGHUP перечитывает конфигурацию по сигналу SIGHUP до отмены ctx
+func (r *reloader) watchSIGHUP(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			if _, err := r.Reload(ctx); err != nil {
+				log.Err(err).Msg("config reload error, current config is kept")
+			}
+		}
+	}
+}
+
+func runtimeSettings(cfg config.AppConfig) (gophermartservice.RuntimeSettings, error) {
+	rules, err := ratelimit.ParseRules(cfg.RateLimitRules)
+	if err != nil {
+		return gophermartservice.RuntimeSettings{}, err
+	}
+	var loginLimit ratelimit.Limit
+	if cfg.LoginLimit != "" {
+		loginLimit, err = ratelimit.ParseLimit(cfg.LoginLimit)
+		if err != nil {
+			return gophermartservice.RuntimeSettings{}, err
+		}
+	}
+	disabled, err := feature.ParseDisabled(cfg.DisabledFeatures)
+	if err != nil {
+		return gophermartservice.RuntimeSettings{}, err
+	}
+	return gophermartservice.RuntimeSettings{
+		AccrualRateLimit:     cfg.AccrualRateLimit,
+		AccrualRetryInterval: cfg.AccrualRetryInterval,
+		AccrualMaxRetries:    cfg.AccrualMaxRetries,
+		RateLimitRules:       rules,
+		LoginLimit:           loginLimit,
+		DisabledFeatures:     disabled,
+	}, nil
+}
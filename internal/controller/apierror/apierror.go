@@ -0,0 +1,122 @@
+// Package apierror каталог ошибок API. Код ошибки - стабильный машиночитаемый идентификатор,
+// на который могут опираться клиенты вместо текста ошибки
+package apierror
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/zaz600/go-musthave-diploma/internal/app/config"
+	"github.com/zaz600/go-musthave-diploma/internal/service/gophermartservice"
+)
+
+type Code string
+
+const (
+	CodeBadRequest               Code = "bad_request"
+	CodeUnauthorized             Code = "unauthorized"
+	CodeNotFound                 Code = "not_found"
+	CodeMethodNotAllowed         Code = "method_not_allowed"
+	CodeLoginInUse               Code = "login_in_use"
+	CodeInvalidCredentials       Code = "invalid_credentials"
+	CodeSessionNotFound          Code = "session_not_found"
+	CodeOrderOwnedByAnotherUser  Code = "order_owned_by_another_user"
+	CodeInvalidOrderNumber       Code = "invalid_order_number"
+	CodeInsufficientFunds        Code = "insufficient_funds"
+	CodeIdempotencyKeyTooLong    Code = "idempotency_key_too_long"
+	CodeIdempotencyKeyMismatch   Code = "idempotency_key_mismatch"
+	CodeIdempotencyKeyInProgress Code = "idempotency_key_in_progress"
+	CodeInvalidWebhook           Code = "invalid_webhook"
+	CodeWebhookNotFound          Code = "webhook_not_found"
+	CodeDeliveryNotFound         Code = "webhook_delivery_not_found"
+	CodeInvalidConfig            Code = "invalid_config"
+	CodeUnsupportedEncoding      Code = "unsupported_content_encoding"
+	CodeRequestTooLarge          Code = "request_too_large"
+	CodeTooManyRequests          Code = "too_many_requests"
+	CodeFeatureDisabled          Code = "feature_disabled"
+	CodeInternal                 Code = "internal_error"
+)
+
+// Entry описание ошибки в каталоге
+type Entry struct {
+	Code   Code
+	Status int
+	Title  string
+}
+
+var catalog = map[Code]Entry{
+	CodeBadRequest:               {Status: http.StatusBadRequest, Title: "Invalid request format"},
+	CodeUnauthorized:             {Status: http.StatusUnauthorized, Title: "User is not authenticated"},
+	CodeNotFound:                 {Status: http.StatusNotFound, Title: "Resource not found"},
+	CodeMethodNotAllowed:         {Status: http.StatusMethodNotAllowed, Title: "Method not allowed"},
+	CodeLoginInUse:               {Status: http.StatusConflict, Title: "Login already in use"},
+	CodeInvalidCredentials:       {Status: http.StatusUnauthorized, Title: "Invalid login or password"},
+	CodeSessionNotFound:          {Status: http.StatusNotFound, Title: "Session not found"},
+	CodeOrderOwnedByAnotherUser:  {Status: http.StatusConflict, Title: "Order was uploaded by another user"},
+	CodeInvalidOrderNumber:       {Status: http.StatusUnprocessableEntity, Title: "Invalid order number, luhn check failed"},
+	CodeInsufficientFunds:        {Status: http.StatusPaymentRequired, Title: "Insufficient funds"},
+	CodeIdempotencyKeyTooLong:    {Status: http.StatusBadRequest, Title: "Idempotency key is too long"},
+	CodeIdempotencyKeyMismatch:   {Status: http.StatusUnprocessableEntity, Title: "Idempotency key reused with different request"},
+	CodeIdempotencyKeyInProgress: {Status: http.StatusConflict, Title: "Request with this idempotency key is in progress"},
+	CodeInvalidWebhook:           {Status: http.StatusBadRequest, Title: "Invalid webhook subscriber"},
+	CodeWebhookNotFound:          {Status: http.StatusNotFound, Title: "Webhook subscriber not found"},
+	CodeDeliveryNotFound:         {Status: http.StatusNotFound, Title: "Webhook delivery not found"},
+	CodeInvalidConfig:            {Status: http.StatusUnprocessableEntity, Title: "Invalid configuration"},
+	CodeUnsupportedEncoding:      {Status: http.StatusUnsupportedMediaType, Title: "Unsupported request content encoding"},
+	CodeRequestTooLarge:          {Status: http.StatusRequestEntityTooLarge, Title: "Request body is too large"},
+	CodeTooManyRequests:          {Status: http.StatusTooManyRequests, Title: "Too many requests"},
+	CodeFeatureDisabled:          {Status: http.StatusForbidden, Title: "Feature is disabled"},
+	CodeInternal:                 {Status: http.StatusInternalServerError, Title: "Internal server error"},
+}
+
+// serviceErrors соответствие ошибок сервиса кодам каталога
+var serviceErrors = []struct {
+	err  error
+	code Code
+}{
+	{gophermartservice.ErrUserExists, CodeLoginInUse},
+	{gophermartservice.ErrReservedLogin, CodeBadRequest},
+	{gophermartservice.ErrAuth, CodeInvalidCredentials},
+	{gophermartservice.ErrLoginThrottled, CodeTooManyRequests},
+	{gophermartservice.ErrFeatureDisabled, CodeFeatureDisabled},
+	{gophermartservice.ErrSessionNotFound, CodeSessionNotFound},
+	{gophermartservice.ErrOrderOwnedByAnotherUser, CodeOrderOwnedByAnotherUser},
+	{gophermartservice.ErrInvalidOrderFormat, CodeInvalidOrderNumber},
+	{gophermartservice.ErrInsufficientFunds, CodeInsufficientFunds},
+	{gophermartservice.ErrIdempotencyKeyMismatch, CodeIdempotencyKeyMismatch},
+	{gophermartservice.ErrIdempotencyKeyInProgress, CodeIdempotencyKeyInProgress},
+	{gophermartservice.ErrInvalidWebhook, CodeInvalidWebhook},
+	{gophermartservice.ErrWebhookNotFound, CodeWebhookNotFound},
+	{gophermartservice.ErrDeliveryNotFound, CodeDeliveryNotFound},
+	{config.ErrInvalidConfig, CodeInvalidConfig},
+}
+
+// Lookup возвращает описание ошибки по коду. Для неизвестного кода - описание внутренней ошибки
+func Lookup(code Code) Entry {
+	entry, ok := catalog[code]
+	if !ok {
+		code = CodeInternal
+		entry = catalog[code]
+	}
+	entry.Code = code
+	return entry
+}
+
+// FromError возвращает код для ошибки сервиса. Неизвестные ошибки считаются внутренними
+func FromError(err error) Code {
+	for _, e := range serviceErrors {
+		if errors.Is(err, e.err) {
+			return e.code
+		}
+	}
+	return CodeInternal
+}
+
+// Codes все коды каталога
+func Codes() []Code {
+	codes := make([]Code, 0, len(catalog))
+	for code := range catalog {
+		codes = append(codes, code)
+	}
+	return codes
+}
@@ -0,0 +1,46 @@
+package apierror
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	Gophermart "github.com/zaz600/go-musthave-diploma/api"
+	Admin "github.com/zaz600/go-musthave-diploma/api/admin"
+	"github.com/zaz600/go-musthave-diploma/internal/service/gophermartservice"
+)
+
+func TestFromError(t *testing.T) {
+	assert.Equal(t, CodeLoginInUse, FromError(gophermartservice.ErrUserExists))
+	assert.Equal(t, CodeInvalidWebhook, FromError(fmt.Errorf("%w: empty secret", gophermartservice.ErrInvalidWebhook)))
+	assert.Equal(t, CodeInternal, FromError(fmt.Errorf("connection refused")))
+}
+
+func TestLookup(t *testing.T) {
+	entry := Lookup(CodeInsufficientFunds)
+	assert.Equal(t, CodeInsufficientFunds, entry.Code)
+	assert.Equal(t, http.StatusPaymentRequired, entry.Status)
+
+	entry = Lookup("unknown")
+	assert.Equal(t, CodeInternal, entry.Code)
+	assert.Equal(t, http.StatusInternalServerError, entry.Status)
+}
+
+// TestCodesDocumented проверяет, что все коды каталога описаны в спецификациях API
+func TestCodesDocumented(t *testing.T) {
+	for name, getSwagger := range map[string]func() (*openapi3.T, error){
+		"gophermart": Gophermart.GetSwagger,
+		"admin":      Admin.GetSwagger,
+	} {
+		swagger, err := getSwagger()
+		require.NoError(t, err, name)
+		schema, ok := swagger.Components.Schemas["ProblemCode"]
+		require.True(t, ok, name)
+		for _, code := range Codes() {
+			assert.Contains(t, schema.Value.Enum, string(code), "%s: code %s is not documented", name, code)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package grpccontroller
+
+import (
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zaz600/go-musthave-diploma/internal/controller/apierror"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errorInfoDomain домен кодов ошибок в деталях google.rpc.ErrorInfo
+const errorInfoDomain = "gophermart"
+
+// grpcCodes соответствие HTTP статусов каталога ошибок кодам gRPC
+var grpcCodes = map[int]codes.Code{
+	http.StatusBadRequest:          codes.InvalidArgument,
+	http.StatusUnauthorized:        codes.Unauthenticated,
+	http.StatusPaymentRequired:     codes.FailedPrecondition,
+	http.StatusForbidden:           codes.PermissionDenied,
+	http.StatusNotFound:            codes.NotFound,
+	http.StatusConflict:            codes.AlreadyExists,
+	http.StatusUnprocessableEntity: codes.InvalidArgument,
+	http.StatusTooManyRequests:     codes.ResourceExhausted,
+}
+
+// problemStatus ошибка gRPC для кода из каталога. Код передается в детали ErrorInfo.Reason
+func problemStatus(code apierror.Code, detail string) error {
+	entry := apierror.Lookup(code)
+	grpcCode, ok := grpcCodes[entry.Status]
+	if !ok {
+		grpcCode = codes.Internal
+	}
+	msg := entry.Title
+	if detail != "" {
+		msg += ": " + detail
+	}
+
+	st := status.New(grpcCode, msg)
+	withDetails, err := st.WithDetails(&errdetails.ErrorInfo{Reason: string(entry.Code), Domain: errorInfoDomain})
+	if err != nil {
+		return st.Err()
+	}
+	return withDetails.Err()
+}
+
+// serviceError ошибка gRPC для ошибки сервиса. Неизвестные ошибки логируются и возвращаются без подробностей
+func serviceError(err error, msg string) error {
+	code := apierror.FromError(err)
+	if code == apierror.CodeInternal {
+		log.Err(err).Msg(msg)
+		return problemStatus(code, "")
+	}
+	return problemStatus(code, err.Error())
+}
@@ -0,0 +1,155 @@
+package grpccontroller
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	gophermartpb "github.com/zaz600/go-musthave-diploma/api/grpc"
+	"github.com/zaz600/go-musthave-diploma/internal/controller/apierror"
+	"github.com/zaz600/go-musthave-diploma/internal/entity"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/auth"
+	"github.com/zaz600/go-musthave-diploma/internal/service/gophermartservice"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var _ gophermartpb.GophermartServer = &GophermartController{}
+
+// GophermartController обработчики gRPC API. Вызывает те же методы сервиса, что и HTTP API
+type GophermartController struct {
+	gophermartpb.UnimplementedGophermartServer
+	gophermartService *gophermartservice.GophermartService
+}
+
+func (c *GophermartController) Register(ctx context.Context, request *gophermartpb.RegisterRequest) (*gophermartpb.AuthResponse, error) {
+	if request.GetLogin() == "" || request.GetPassword() == "" {
+		return nil, problemStatus(apierror.CodeBadRequest, "login and password are required")
+	}
+	session, err := c.gophermartService.RegisterUser(ctx, request.GetLogin(), request.GetPassword(), sessionDevice(ctx))
+	if err != nil {
+		return nil, serviceError(err, "user register error")
+	}
+	return authResponse(session)
+}
+
+func (c *GophermartController) Login(ctx context.Context, request *gophermartpb.LoginRequest) (*gophermartpb.AuthResponse, error) {
+	if request.GetLogin() == "" || request.GetPassword() == "" {
+		return nil, problemStatus(apierror.CodeBadRequest, "login and password are required")
+	}
+	session, err := c.gophermartService.LoginUser(ctx, request.GetLogin(), request.GetPassword(), sessionDevice(ctx))
+	if err != nil {
+		return nil, serviceError(err, "user login error")
+	}
+	return authResponse(session)
+}
+
+func (c *GophermartController) UploadOrder(ctx context.Context, request *gophermartpb.UploadOrderRequest) (*gophermartpb.UploadOrderResponse, error) {
+	userID := userIDFromContext(ctx)
+	if request.GetNumber() == "" {
+		return nil, problemStatus(apierror.CodeBadRequest, "order number is required")
+	}
+
+	err := c.gophermartService.UploadOrder(ctx, userID, request.GetNumber())
+	if err != nil {
+		if errors.Is(err, gophermartservice.ErrOrderExists) {
+			return &gophermartpb.UploadOrderResponse{AlreadyUploaded: true}, nil
+		}
+		return nil, serviceError(err, "upload order error")
+	}
+
+	go c.gophermartService.GetAccruals(ctx, request.GetNumber())
+
+	return &gophermartpb.UploadOrderResponse{}, nil
+}
+
+func (c *GophermartController) GetOrders(ctx context.Context, _ *gophermartpb.GetOrdersRequest) (*gophermartpb.GetOrdersResponse, error) {
+	orders, err := c.gophermartService.GetUserOrders(ctx, userIDFromContext(ctx))
+	if err != nil {
+		return nil, serviceError(err, "get user orders error")
+	}
+
+	resp := &gophermartpb.GetOrdersResponse{Orders: make([]*gophermartpb.Order, 0, len(orders))}
+	for _, order := range orders {
+		respOrder := &gophermartpb.Order{
+			Number:     order.OrderID,
+			Status:     toOrderStatus(order.Status),
+			UploadedAt: timestamppb.New(order.UploadedAt),
+		}
+		if order.Status == entity.OrderStatusProcessed {
+			respOrder.Accrual = float64(order.Accrual)
+		}
+		resp.Orders = append(resp.Orders, respOrder)
+	}
+	return resp, nil
+}
+
+func (c *GophermartController) GetBalance(ctx context.Context, _ *gophermartpb.GetBalanceRequest) (*gophermartpb.GetBalanceResponse, error) {
+	current, withdrawn, err := c.gophermartService.GetUserBalance(ctx, userIDFromContext(ctx))
+	if err != nil {
+		return nil, serviceError(err, "get user balance error")
+	}
+	return &gophermartpb.GetBalanceResponse{
+		Current:   float64(current),
+		Withdrawn: float64(withdrawn),
+	}, nil
+}
+
+func (c *GophermartController) Withdraw(ctx context.Context, request *gophermartpb.WithdrawRequest) (*gophermartpb.WithdrawResponse, error) {
+	if request.GetOrder() == "" || request.GetSum() <= 0 {
+		return nil, problemStatus(apierror.CodeBadRequest, "order and positive sum are required")
+	}
+	err := c.gophermartService.UploadWithdrawal(ctx, userIDFromContext(ctx), request.GetOrder(), float32(request.GetSum()))
+	if err != nil {
+		return nil, serviceError(err, "user balance withdraw error")
+	}
+	return &gophermartpb.WithdrawResponse{}, nil
+}
+
+func (c *GophermartController) GetWithdrawals(ctx context.Context, _ *gophermartpb.GetWithdrawalsRequest) (*gophermartpb.GetWithdrawalsResponse, error) {
+	withdrawals, err := c.gophermartService.GetUserWithdrawals(ctx, userIDFromContext(ctx))
+	if err != nil {
+		return nil, serviceError(err, "user balance withdrawals error")
+	}
+
+	resp := &gophermartpb.GetWithdrawalsResponse{Withdrawals: make([]*gophermartpb.Withdrawal, 0, len(withdrawals))}
+	for _, withdrawal := range withdrawals {
+		resp.Withdrawals = append(resp.Withdrawals, &gophermartpb.Withdrawal{
+			Order:       withdrawal.OrderID,
+			Sum:         float64(withdrawal.Sum),
+			ProcessedAt: timestamppb.New(withdrawal.ProcessedAt),
+		})
+	}
+	return resp, nil
+}
+
+// NewServer создает gRPC сервер с аутентификацией по JWT и reflection
+func NewServer(gophermartService *gophermartservice.GophermartService, opts ...grpc.ServerOption) *grpc.Server {
+	opts = append(opts, grpc.ChainUnaryInterceptor(recoveryInterceptor, authInterceptor(gophermartService)))
+	server := grpc.NewServer(opts...)
+	gophermartpb.RegisterGophermartServer(server, &GophermartController{gophermartService: gophermartService})
+	reflection.Register(server)
+	return server
+}
+
+func authResponse(session *entity.Session) (*gophermartpb.AuthResponse, error) {
+	token, err := auth.NewToken(session)
+	if err != nil {
+		return nil, serviceError(err, "create token error")
+	}
+	return &gophermartpb.AuthResponse{Token: token}, nil
+}
+
+func toOrderStatus(status entity.OrderStatus) gophermartpb.OrderStatus {
+	if value, ok := gophermartpb.OrderStatus_value["ORDER_STATUS_"+strings.ToUpper(string(status))]; ok {
+		return gophermartpb.OrderStatus(value)
+	}
+	// TOO_MANY_RETRIES для клиента означает, что заказ еще не обработан
+	if status == entity.OrderStatusTooManyRetries {
+		return gophermartpb.OrderStatus_ORDER_STATUS_PROCESSING
+	}
+	log.Warn().Str("status", string(status)).Msg("unknown order status")
+	return gophermartpb.OrderStatus_ORDER_STATUS_UNSPECIFIED
+}
@@ -0,0 +1,194 @@
+package grpccontroller_test
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	Accrual "github.com/zaz600/go-musthave-diploma/api/accrual"
+	gophermartpb "github.com/zaz600/go-musthave-diploma/api/grpc"
+	"github.com/zaz600/go-musthave-diploma/internal/controller/grpccontroller"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/random"
+	"github.com/zaz600/go-musthave-diploma/internal/service/gophermartservice"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type GRPCControllerTestSuite struct {
+	suite.Suite
+	server  *grpc.Server
+	accrual *httptest.Server
+	conn    *grpc.ClientConn
+	client  gophermartpb.GophermartClient
+}
+
+func (suite *GRPCControllerTestSuite) SetupTest() {
+	t := suite.T()
+	suite.accrual = newAccrualMock()
+	accrualClient, err := Accrual.NewClientWithResponses(suite.accrual.URL)
+	require.NoError(t, err)
+
+	service, err := gophermartservice.New(accrualClient,
+		gophermartservice.WithMemoryStorage(),
+		gophermartservice.WithAccrualRetryInterval(20*time.Millisecond),
+	)
+	require.NoError(t, err)
+
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpccontroller.NewServer(service)
+	suite.server = server
+	go func() {
+		_ = server.Serve(listener)
+	}()
+
+	suite.conn, err = grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithInsecure(),
+	)
+	require.NoError(t, err)
+	suite.client = gophermartpb.NewGophermartClient(suite.conn)
+}
+
+func (suite *GRPCControllerTestSuite) TearDownTest() {
+	_ = suite.conn.Close()
+	suite.server.Stop()
+	suite.accrual.Close()
+}
+
+func (suite *GRPCControllerTestSuite) register() context.Context {
+	resp, err := suite.client.Register(context.Background(), &gophermartpb.RegisterRequest{
+		Login:    random.String(10),
+		Password: random.String(10),
+	})
+	suite.Require().NoError(err)
+	suite.Require().NotEmpty(resp.GetToken())
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+resp.GetToken())
+}
+
+func (suite *GRPCControllerTestSuite) TestRegisterLogin() {
+	ctx := context.Background()
+	login, password := random.String(10), random.String(10)
+
+	_, err := suite.client.Register(ctx, &gophermartpb.RegisterRequest{Login: login, Password: password})
+	suite.Require().NoError(err)
+
+	_, err = suite.client.Register(ctx, &gophermartpb.RegisterRequest{Login: login, Password: password})
+	assertProblem(suite.T(), err, codes.AlreadyExists, "login_in_use")
+
+	resp, err := suite.client.Login(ctx, &gophermartpb.LoginRequest{Login: login, Password: password})
+	suite.Require().NoError(err)
+	suite.NotEmpty(resp.GetToken())
+
+	_, err = suite.client.Login(ctx, &gophermartpb.LoginRequest{Login: login, Password: "wrong"})
+	assertProblem(suite.T(), err, codes.Unauthenticated, "invalid_credentials")
+
+	_, err = suite.client.Register(ctx, &gophermartpb.RegisterRequest{Login: login})
+	assertProblem(suite.T(), err, codes.InvalidArgument, "bad_request")
+}
+
+func (suite *GRPCControllerTestSuite) TestUnauthenticated() {
+	_, err := suite.client.GetBalance(context.Background(), &gophermartpb.GetBalanceRequest{})
+	assertProblem(suite.T(), err, codes.Unauthenticated, "unauthorized")
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer 1234")
+	_, err = suite.client.GetOrders(ctx, &gophermartpb.GetOrdersRequest{})
+	assertProblem(suite.T(), err, codes.Unauthenticated, "unauthorized")
+}
+
+func (suite *GRPCControllerTestSuite) TestSuccessPath() {
+	t := suite.T()
+	ctx := suite.register()
+
+	orderID := random.OrderID()
+	uploadResp, err := suite.client.UploadOrder(ctx, &gophermartpb.UploadOrderRequest{Number: orderID})
+	suite.Require().NoError(err)
+	suite.False(uploadResp.GetAlreadyUploaded())
+
+	uploadResp, err = suite.client.UploadOrder(ctx, &gophermartpb.UploadOrderRequest{Number: orderID})
+	suite.Require().NoError(err)
+	suite.True(uploadResp.GetAlreadyUploaded())
+
+	_, err = suite.client.UploadOrder(ctx, &gophermartpb.UploadOrderRequest{Number: "12345"})
+	assertProblem(t, err, codes.InvalidArgument, "invalid_order_number")
+
+	g := NewGomegaWithT(t)
+	g.Eventually(func(g Gomega) {
+		orders, err := suite.client.GetOrders(ctx, &gophermartpb.GetOrdersRequest{})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(orders.GetOrders()).To(HaveLen(1))
+		g.Expect(orders.GetOrders()[0].GetStatus()).To(Equal(gophermartpb.OrderStatus_ORDER_STATUS_PROCESSED))
+		g.Expect(orders.GetOrders()[0].GetAccrual()).To(Equal(50.0))
+	}, 2*time.Second, 10*time.Millisecond).Should(Succeed())
+
+	_, err = suite.client.Withdraw(ctx, &gophermartpb.WithdrawRequest{Order: random.OrderID(), Sum: 100})
+	assertProblem(t, err, codes.FailedPrecondition, "insufficient_funds")
+
+	withdrawOrderID := random.OrderID()
+	_, err = suite.client.Withdraw(ctx, &gophermartpb.WithdrawRequest{Order: withdrawOrderID, Sum: 10.5})
+	suite.Require().NoError(err)
+
+	balance, err := suite.client.GetBalance(ctx, &gophermartpb.GetBalanceRequest{})
+	suite.Require().NoError(err)
+	suite.InDelta(39.5, balance.GetCurrent(), 0.001)
+	suite.InDelta(10.5, balance.GetWithdrawn(), 0.001)
+
+	withdrawals, err := suite.client.GetWithdrawals(ctx, &gophermartpb.GetWithdrawalsRequest{})
+	suite.Require().NoError(err)
+	suite.Require().Len(withdrawals.GetWithdrawals(), 1)
+	suite.Equal(withdrawOrderID, withdrawals.GetWithdrawals()[0].GetOrder())
+	suite.InDelta(10.5, withdrawals.GetWithdrawals()[0].GetSum(), 0.001)
+}
+
+func (suite *GRPCControllerTestSuite) TestReflection() {
+	services := suite.server.GetServiceInfo()
+	suite.Contains(services, "grpc.reflection.v1alpha.ServerReflection")
+	suite.Contains(services, gophermartpb.Gophermart_ServiceDesc.ServiceName)
+}
+
+func TestGRPCControllerSuite(t *testing.T) {
+	suite.Run(t, new(GRPCControllerTestSuite))
+}
+
+func assertProblem(t *testing.T, err error, code codes.Code, reason string) {
+	t.Helper()
+	require.Error(t, err)
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	require.Equal(t, code, st.Code(), st.Message())
+	require.Len(t, st.Details(), 1)
+	info, ok := st.Details()[0].(*errdetails.ErrorInfo)
+	require.True(t, ok)
+	require.Equal(t, reason, info.GetReason())
+}
+
+func newAccrualMock() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/api/orders/") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var accrual float32 = 50.0
+		resp := Accrual.Response{
+			Accrual: &accrual,
+			Order:   Accrual.Order(strings.TrimPrefix(r.URL.Path, "/api/orders/")),
+			Status:  Accrual.ResponseStatusPROCESSED,
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+}
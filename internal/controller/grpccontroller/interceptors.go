@@ -0,0 +1,98 @@
+package grpccontroller
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"github.com/rs/zerolog/log"
+	gophermartpb "github.com/zaz600/go-musthave-diploma/api/grpc"
+	"github.com/zaz600/go-musthave-diploma/internal/controller/apierror"
+	"github.com/zaz600/go-musthave-diploma/internal/entity"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/auth"
+	"github.com/zaz600/go-musthave-diploma/internal/service/gophermartservice"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+type key int
+
+const (
+	userIDKey key = iota
+)
+
+const (
+	authorizationMetadata = "authorization"
+	userAgentMetadata     = "user-agent"
+)
+
+// maxUserAgentLength сколько символов user-agent сохраняется в сессии
+const maxUserAgentLength = 512
+
+// publicMethods методы, доступные без аутентификации
+var publicMethods = map[string]struct{}{
+	"/" + gophermartpb.Gophermart_ServiceDesc.ServiceName + "/Register": {},
+	"/" + gophermartpb.Gophermart_ServiceDesc.ServiceName + "/Login":    {},
+}
+
+// authInterceptor проверяет JWT из метаданных authorization и действующую сессию, кладет ID пользователя в контекст
+func authInterceptor(gophermartService *gophermartservice.GophermartService) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if _, ok := publicMethods[info.FullMethod]; ok {
+			return handler(ctx, req)
+		}
+
+		md, _ := metadata.FromIncomingContext(ctx)
+		values := md.Get(authorizationMetadata)
+		if len(values) == 0 {
+			return nil, problemStatus(apierror.CodeUnauthorized, "")
+		}
+		claims, err := auth.ParseAuthorization(values[0])
+		if err != nil {
+			return nil, problemStatus(apierror.CodeUnauthorized, "")
+		}
+		if err := gophermartService.CheckSession(ctx, claims.UserID, claims.SessionID); err != nil {
+			if errors.Is(err, gophermartservice.ErrSessionNotFound) {
+				return nil, problemStatus(apierror.CodeUnauthorized, "")
+			}
+			return nil, serviceError(err, "check session error")
+		}
+		return handler(context.WithValue(ctx, userIDKey, claims.UserID), req)
+	}
+}
+
+// sessionDevice устройство, с которого выполняется вход: user-agent из метаданных и адрес клиента
+func sessionDevice(ctx context.Context) entity.SessionOption {
+	var userAgent, ip string
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(userAgentMetadata); len(values) > 0 {
+		userAgent = values[0]
+		if len(userAgent) > maxUserAgentLength {
+			userAgent = userAgent[:maxUserAgentLength]
+		}
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		ip = p.Addr.String()
+		if host, _, err := net.SplitHostPort(ip); err == nil {
+			ip = host
+		}
+	}
+	return entity.WithDevice(userAgent, ip)
+}
+
+// recoveryInterceptor превращает панику в обработчике во внутреннюю ошибку, как middleware.Recoverer в HTTP API
+func recoveryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Error().Interface("panic", r).Str("method", info.FullMethod).Msg("grpc handler panic")
+			err = problemStatus(apierror.CodeInternal, "")
+		}
+	}()
+	return handler(ctx, req)
+}
+
+func userIDFromContext(ctx context.Context) string {
+	userID, _ := ctx.Value(userIDKey).(string)
+	return userID
+}
@@ -0,0 +1,56 @@
+package httpcontroller
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// AccessLog кладет в контекст логгер запроса с request_id и пишет по строке лога на каждый запрос.
+// Должен стоять после middleware.RequestID
+func AccessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l := log.With().Str("request_id", middleware.GetReqID(r.Context())).Logger()
+		r = r.WithContext(l.WithContext(r.Context()))
+		r, route := withRoutePattern(r)
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		start := time.Now()
+
+		next.ServeHTTP(ww, r)
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		logger := zerolog.Ctx(r.Context())
+		event := logger.Info()
+		if status >= http.StatusInternalServerError {
+			event = logger.Error()
+		}
+		event.
+			Str("method", r.Method).
+			Str("path", r.URL.Path).
+			Str("route", route.pattern).
+			Int("status", status).
+			Int("bytes", ww.BytesWritten()).
+			Dur("duration", time.Since(start)).
+			Str("remote_ip", r.RemoteAddr).
+			Str("user_agent", r.UserAgent()).
+			Msg("http request")
+	})
+}
+
+// addLogUserID добавляет user_id в логгер запроса, чтобы он попал во все логи запроса и в строку access log
+func addLogUserID(r *http.Request, userID string) {
+	l := zerolog.Ctx(r.Context())
+	if l == zerolog.DefaultContextLogger {
+		// логгер запроса не создан, глобальный логгер не меняем
+		return
+	}
+	l.UpdateContext(func(c zerolog.Context) zerolog.Context {
+		return c.Str("user_id", userID)
+	})
+}
@@ -0,0 +1,106 @@
+package httpcontroller_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gavv/httpexpect/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/require"
+	Accrual "github.com/zaz600/go-musthave-diploma/api/accrual"
+	"github.com/zaz600/go-musthave-diploma/internal/controller/httpcontroller"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/random"
+	"github.com/zaz600/go-musthave-diploma/internal/service/gophermartservice"
+)
+
+// syncBuffer буфер для логов, в который пишут несколько горутин
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) entries() []map[string]interface{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var result []map[string]interface{}
+	scanner := bufio.NewScanner(bytes.NewReader(b.buf.Bytes()))
+	for scanner.Scan() {
+		entry := map[string]interface{}{}
+		if json.Unmarshal(scanner.Bytes(), &entry) == nil {
+			result = append(result, entry)
+		}
+	}
+	return result
+}
+
+func TestAccessLog(t *testing.T) {
+	out := &syncBuffer{}
+	prev := log.Logger
+	log.Logger = zerolog.New(out)
+	defer func() { log.Logger = prev }()
+
+	accrualServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var accrual float32 = 50.0
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(Accrual.Response{
+			Accrual: &accrual,
+			Order:   Accrual.Order(strings.TrimPrefix(r.URL.Path, "/api/orders/")),
+			Status:  Accrual.ResponseStatusPROCESSED,
+		})
+	}))
+	defer accrualServer.Close()
+
+	accrualClient, err := Accrual.NewClientWithResponses(accrualServer.URL)
+	require.NoError(t, err)
+	service, err := gophermartservice.New(accrualClient, gophermartservice.WithMemoryStorage())
+	require.NoError(t, err)
+	server := httptest.NewServer(httpcontroller.NewRouter(service))
+	defer server.Close()
+
+	e := httpexpect.New(t, server.URL)
+	authHeader := register(t, e, NewUser())
+	orderID := random.OrderID()
+	e.POST("/api/user/orders").
+		WithHeader("Authorization", authHeader).
+		WithHeader("X-Request-Id", "test-request-id").
+		WithText(orderID).
+		Expect().
+		Status(http.StatusAccepted)
+
+	g := NewGomegaWithT(t)
+	g.Eventually(func(g Gomega) {
+		var accessEntry, accrualEntry map[string]interface{}
+		for _, entry := range out.entries() {
+			if entry["message"] == "http request" && entry["route"] == "/api/user/orders" {
+				accessEntry = entry
+			}
+			if entry["orderID"] == orderID && entry["message"] == "get accrual result" {
+				accrualEntry = entry
+			}
+		}
+		g.Expect(accessEntry).NotTo(BeNil())
+		g.Expect(accessEntry).To(HaveKeyWithValue("request_id", "test-request-id"))
+		g.Expect(accessEntry).To(HaveKeyWithValue("user_id", Not(BeEmpty())))
+		g.Expect(accessEntry).To(HaveKeyWithValue("status", BeNumerically("==", http.StatusAccepted)))
+		g.Expect(accessEntry).To(HaveKey("duration"))
+
+		// логи обработки начислений несут request_id и user_id исходного запроса
+		g.Expect(accrualEntry).NotTo(BeNil())
+		g.Expect(accrualEntry).To(HaveKeyWithValue("request_id", "test-request-id"))
+		g.Expect(accrualEntry).To(HaveKeyWithValue("user_id", accessEntry["user_id"]))
+	}).Should(Succeed())
+}
@@ -0,0 +1,165 @@
+package httpcontroller
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	Admin "github.com/zaz600/go-musthave-diploma/api/admin"
+	"github.com/zaz600/go-musthave-diploma/internal/controller/apierror"
+	"github.com/zaz600/go-musthave-diploma/internal/entity"
+	"github.com/zaz600/go-musthave-diploma/internal/service/gophermartservice"
+)
+
+const (
+	adminTokenHeader       = "X-Admin-Token"
+	defaultDeadLetterLimit = 100
+)
+
+var _ Admin.ServerInterface = &AdminController{}
+
+// AdminController обработчики административного API
+type AdminController struct {
+	gophermartService *gophermartservice.GophermartService
+	adminToken        string
+	reloadConfig      ConfigReloader
+}
+
+func (c *AdminController) AddWebhookSubscriber(w http.ResponseWriter, r *http.Request) {
+	var request Admin.WebhookSubscriberRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		writeProblem(w, r, apierror.CodeBadRequest, "")
+		return
+	}
+
+	eventTypes := make([]entity.EventType, 0, len(request.EventTypes))
+	for _, eventType := range request.EventTypes {
+		eventTypes = append(eventTypes, entity.EventType(eventType))
+	}
+	subscriber, err := c.gophermartService.AddWebhookSubscriber(r.Context(), request.Url, eventTypes, request.Secret)
+	if err != nil {
+		writeServiceError(w, r, err, "add webhook subscriber error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(toWebhookSubscriber(subscriber))
+}
+
+func (c *AdminController) GetWebhookSubscribers(w http.ResponseWriter, r *http.Request) {
+	subscribers, err := c.gophermartService.GetWebhookSubscribers(r.Context())
+	if err != nil {
+		log.Ctx(r.Context()).Err(err).Msg("get webhook subscribers error")
+		writeProblem(w, r, apierror.CodeInternal, "")
+		return
+	}
+
+	resp := Admin.WebhookSubscribersResponse{}
+	for _, subscriber := range subscribers {
+		resp = append(resp, toWebhookSubscriber(subscriber))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (c *AdminController) DeleteWebhookSubscriber(w http.ResponseWriter, r *http.Request, id string) {
+	err := c.gophermartService.DeleteWebhookSubscriber(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, r, err, "delete webhook subscriber error")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *AdminController) GetWebhookDeadLetters(w http.ResponseWriter, r *http.Request, params Admin.GetWebhookDeadLettersParams) {
+	limit := defaultDeadLetterLimit
+	if params.Limit != nil && *params.Limit > 0 {
+		limit = *params.Limit
+	}
+
+	deliveries, err := c.gophermartService.GetWebhookDeadLetters(r.Context(), limit)
+	if err != nil {
+		log.Ctx(r.Context()).Err(err).Msg("get webhook dead letters error")
+		writeProblem(w, r, apierror.CodeInternal, "")
+		return
+	}
+
+	resp := Admin.WebhookDeliveriesResponse{}
+	for _, delivery := range deliveries {
+		resp = append(resp, Admin.WebhookDelivery{
+			Id:           delivery.ID,
+			SubscriberId: delivery.SubscriberID,
+			EventId:      delivery.Event.EventID,
+			EventType:    Admin.EventType(delivery.Event.Type),
+			Attempts:     delivery.Attempts,
+			LastError:    delivery.LastError,
+			CreatedAt:    delivery.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (c *AdminController) RedeliverWebhook(w http.ResponseWriter, r *http.Request, id string) {
+	err := c.gophermartService.RedeliverWebhook(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, r, err, "redeliver webhook error")
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (c *AdminController) ReloadConfig(w http.ResponseWriter, r *http.Request) {
+	if c.reloadConfig == nil {
+		writeProblem(w, r, apierror.CodeNotFound, "config reload is not configured")
+		return
+	}
+	changes, err := c.reloadConfig(r.Context())
+	if err != nil {
+		writeServiceError(w, r, err, "reload config error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(Admin.ConfigReloadResponse{
+		Applied:         changes.Applied,
+		RestartRequired: changes.RestartRequired,
+	})
+}
+
+// AdminAuth пропускает запросы с правильным токеном администратора.
+// Если токен не задан, административное API выключено
+func (c *AdminController) AdminAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c.adminToken == "" {
+			writeProblem(w, r, apierror.CodeNotFound, "")
+			return
+		}
+		token := r.Header.Get(adminTokenHeader)
+		if subtle.ConstantTimeCompare([]byte(token), []byte(c.adminToken)) != 1 {
+			writeProblem(w, r, apierror.CodeUnauthorized, "")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func toWebhookSubscriber(subscriber entity.WebhookSubscriber) Admin.WebhookSubscriber {
+	eventTypes := make([]Admin.EventType, 0, len(subscriber.EventTypes))
+	for _, eventType := range subscriber.EventTypes {
+		eventTypes = append(eventTypes, Admin.EventType(eventType))
+	}
+	return Admin.WebhookSubscriber{
+		Id:         subscriber.ID,
+		Url:        subscriber.URL,
+		EventTypes: eventTypes,
+		CreatedAt:  subscriber.CreatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,97 @@
+package httpcontroller
+
+import (
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/klauspost/compress/zstd"
+)
+
+const compressLevel = 5
+
+// responseEncodings поддерживаемые кодировки ответа в порядке предпочтения сервера
+var responseEncodings = []string{"zstd", "br", "gzip", "deflate"}
+
+var compressibleContentTypes = []string{
+	"text/plain",
+	"application/json",
+	problemContentType,
+}
+
+// Compress сжимает ответ в кодировке, выбранной по Accept-Encoding с учетом q-значений.
+// При равных q выбирается кодировка, более предпочтительная для сервера
+func Compress() func(next http.Handler) http.Handler {
+	compressor := middleware.NewCompressor(compressLevel, compressibleContentTypes...)
+	compressor.SetEncoder("br", func(w io.Writer, level int) io.Writer {
+		return brotli.NewWriterLevel(w, level)
+	})
+	compressor.SetEncoder("zstd", func(w io.Writer, level int) io.Writer {
+		encoder, err := zstd.NewWriter(w,
+			zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)),
+			zstd.WithEncoderConcurrency(1),
+		)
+		if err != nil {
+			return nil
+		}
+		return encoder
+	})
+
+	return func(next http.Handler) http.Handler {
+		compress := compressor.Handler(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// chi выбирает кодировку без учета q-значений, поэтому ему передается только выбранная
+			if encoding := negotiateEncoding(r.Header.Get("Accept-Encoding")); encoding != "" {
+				r.Header.Set("Accept-Encoding", encoding)
+			} else {
+				r.Header.Del("Accept-Encoding")
+			}
+			compress.ServeHTTP(w, r)
+		})
+	}
+}
+
+// negotiateEncoding выбирает кодировку ответа по Accept-Encoding. Пустая строка - ответ без сжатия
+func negotiateEncoding(header string) string {
+	if header == "" {
+		return ""
+	}
+	weights := map[string]float64{}
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		name := strings.ToLower(strings.TrimSpace(fields[0]))
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		weights[name] = q
+	}
+
+	candidates := make([]string, 0, len(responseEncodings))
+	for _, encoding := range responseEncodings {
+		q, ok := weights[encoding]
+		if !ok {
+			q, ok = weights["*"]
+		}
+		if ok && q > 0 {
+			candidates = append(candidates, encoding)
+			weights[encoding] = q
+		}
+	}
+	if len(candidates) == 0 {
+		return ""
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return weights[candidates[i]] > weights[candidates[j]]
+	})
+	return candidates[0]
+}
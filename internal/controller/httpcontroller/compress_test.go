@@ -0,0 +1,169 @@
+package httpcontroller_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zaz600/go-musthave-diploma/internal/controller/httpcontroller"
+)
+
+func compress(t *testing.T, encoding string, data []byte) []byte {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	var w io.WriteCloser
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(buf)
+	case "deflate":
+		w = zlib.NewWriter(buf)
+	case "br":
+		w = brotli.NewWriter(buf)
+	case "zstd":
+		encoder, err := zstd.NewWriter(buf)
+		require.NoError(t, err)
+		w = encoder
+	}
+	_, err := w.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func decompress(t *testing.T, encoding string, data []byte) []byte {
+	t.Helper()
+	var r io.Reader
+	var err error
+	switch encoding {
+	case "gzip":
+		r, err = gzip.NewReader(bytes.NewReader(data))
+	case "br":
+		r = brotli.NewReader(bytes.NewReader(data))
+	case "zstd":
+		r, err = zstd.NewReader(bytes.NewReader(data))
+	default:
+		r = bytes.NewReader(data)
+	}
+	require.NoError(t, err)
+	result, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return result
+}
+
+func TestDecompressRequest(t *testing.T) {
+	server := httptest.NewServer(newRouter(t))
+	defer server.Close()
+
+	for _, encoding := range []string{"gzip", "deflate", "br", "zstd", "gzip, zstd"} {
+		t.Run(encoding, func(t *testing.T) {
+			body, err := json.Marshal(NewUser())
+			require.NoError(t, err)
+			if encoding == "gzip, zstd" {
+				body = compress(t, "zstd", compress(t, "gzip", body))
+			} else {
+				body = compress(t, encoding, body)
+			}
+
+			req, err := http.NewRequest(http.MethodPost, server.URL+"/api/user/register", bytes.NewReader(body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Content-Encoding", encoding)
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.NotEmpty(t, resp.Header.Get("Authorization"))
+		})
+	}
+}
+
+func TestDecompressRequest_Errors(t *testing.T) {
+	server := httptest.NewServer(newRouter(t))
+	defer server.Close()
+
+	post := func(encoding string, body []byte) *http.Response {
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/api/user/register", bytes.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Content-Encoding", encoding)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = resp.Body.Close() })
+		return resp
+	}
+	problemCode := func(resp *http.Response) string {
+		var problem struct{ Code string }
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&problem))
+		return problem.Code
+	}
+
+	resp := post("compress", []byte("{}"))
+	assert.Equal(t, http.StatusUnsupportedMediaType, resp.StatusCode)
+	assert.Equal(t, "unsupported_content_encoding", problemCode(resp))
+
+	// 10 МБ нулей сжимаются в несколько килобайт
+	bomb := compress(t, "gzip", make([]byte, 10*httpcontroller.DefaultMaxDecompressedBodySize))
+	require.Less(t, len(bomb), 100*1024)
+	resp = post("gzip", bomb)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+	assert.Equal(t, "request_too_large", problemCode(resp))
+
+	resp = post("gzip", []byte("not gzip"))
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "bad_request", problemCode(resp))
+}
+
+func TestCompressResponse(t *testing.T) {
+	tests := []struct {
+		acceptEncoding string
+		want           string
+	}{
+		{"", ""},
+		{"identity", ""},
+		{"gzip", "gzip"},
+		{"br", "br"},
+		{"zstd", "zstd"},
+		{"gzip, br, zstd", "zstd"},
+		{"gzip;q=1.0, zstd;q=0.5", "gzip"},
+		{"br;q=0, gzip", "gzip"},
+		{"*", "zstd"},
+		{"*;q=0.1, br", "br"},
+		{"*;q=0", ""},
+	}
+
+	server := httptest.NewServer(newRouter(t))
+	defer server.Close()
+	// Transport сам добавляет gzip в Accept-Encoding, если заголовок не задан
+	client := &http.Client{Transport: &http.Transport{DisableCompression: true}}
+
+	for _, tt := range tests {
+		t.Run(tt.acceptEncoding, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, server.URL+"/api/user/balance", nil)
+			require.NoError(t, err)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			// без авторизации ответ - problem+json, он тоже сжимается
+			assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+			assert.Equal(t, tt.want, resp.Header.Get("Content-Encoding"))
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			var problem struct{ Code string }
+			require.NoError(t, json.Unmarshal(decompress(t, tt.want, body), &problem))
+			assert.Equal(t, "unauthorized", problem.Code)
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package httpcontroller
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"github.com/zaz600/go-musthave-diploma/internal/controller/apierror"
+)
+
+// DefaultMaxDecompressedBodySize ограничение размера тела запроса после распаковки по умолчанию
+const DefaultMaxDecompressedBodySize = 1 << 20
+
+var (
+	errUnsupportedEncoding = errors.New("unsupported content encoding")
+	errBodyTooLarge        = errors.New("decompressed body is too large")
+)
+
+// Decompress распаковывает тело запроса с Content-Encoding gzip, deflate, br или zstd.
+// Тело распаковывается целиком до передачи обработчику, чтобы запрос, распаковывающийся больше чем в limit байт
+// (zip-бомба), отклонялся с 413 до начала обработки
+func Decompress(limit int64) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			encodings := contentEncodings(r.Header.Get("Content-Encoding"))
+			if len(encodings) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			body, err := decompressBody(r.Body, encodings, limit)
+			_ = r.Body.Close()
+			switch {
+			case errors.Is(err, errUnsupportedEncoding):
+				writeProblem(w, r, apierror.CodeUnsupportedEncoding, err.Error())
+				return
+			case errors.Is(err, errBodyTooLarge):
+				writeProblem(w, r, apierror.CodeRequestTooLarge, err.Error())
+				return
+			case err != nil:
+				writeProblem(w, r, apierror.CodeBadRequest, "decompress body: "+err.Error())
+				return
+			}
+
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			r.ContentLength = int64(len(body))
+			r.Header.Set("Content-Length", strconv.Itoa(len(body)))
+			r.Header.Del("Content-Encoding")
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// contentEncodings кодировки тела в порядке применения, identity пропускается
+func contentEncodings(header string) []string {
+	var encodings []string
+	for _, encoding := range strings.Split(header, ",") {
+		encoding = strings.ToLower(strings.TrimSpace(encoding))
+		if encoding == "" || encoding == "identity" {
+			continue
+		}
+		encodings = append(encodings, encoding)
+	}
+	return encodings
+}
+
+// decompressBody снимает кодировки в обратном порядке и читает не больше limit байт результата
+func decompressBody(body io.Reader, encodings []string, limit int64) ([]byte, error) {
+	reader := body
+	for i := len(encodings) - 1; i >= 0; i-- {
+		decoder, err := newDecoder(encodings[i], reader)
+		if err != nil {
+			return nil, err
+		}
+		defer decoder.Close()
+		reader = decoder
+	}
+
+	data, err := io.ReadAll(io.LimitReader(reader, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%w: limit is %d bytes", errBodyTooLarge, limit)
+	}
+	return data, nil
+}
+
+func newDecoder(encoding string, r io.Reader) (io.ReadCloser, error) {
+	switch encoding {
+	case "gzip", "x-gzip":
+		return gzip.NewReader(r)
+	case "deflate":
+		// deflate в HTTP - поток zlib (RFC 9110)
+		return zlib.NewReader(r)
+	case "br":
+		return io.NopCloser(brotli.NewReader(r)), nil
+	case "zstd":
+		decoder, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1), zstd.WithDecoderLowmem(true))
+		if err != nil {
+			return nil, err
+		}
+		return decoder.IOReadCloser(), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", errUnsupportedEncoding, encoding)
+	}
+}
@@ -0,0 +1,135 @@
+package httpcontroller
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	Gophermart "github.com/zaz600/go-musthave-diploma/api"
+	"github.com/zaz600/go-musthave-diploma/internal/controller/apierror"
+	"github.com/zaz600/go-musthave-diploma/internal/service/gophermartservice"
+)
+
+const (
+	exportFormatJSON = "json"
+	exportFormatZIP  = "zip"
+
+	exportArchiveName = "gophermart-export.zip"
+)
+
+// ExportUserData выгружает данные пользователя одним JSON документом или zip архивом с файлом на каждый раздел
+func (c *GophermartController) ExportUserData(w http.ResponseWriter, r *http.Request, params Gophermart.ExportUserDataParams) {
+	userID, ok := r.Context().Value(userIDKey).(string)
+	if !ok {
+		writeProblem(w, r, apierror.CodeUnauthorized, "")
+		return
+	}
+	format := exportFormatJSON
+	if params.Format != nil {
+		format = string(*params.Format)
+	}
+	if format != exportFormatJSON && format != exportFormatZIP {
+		writeProblem(w, r, apierror.CodeBadRequest, "unknown export format")
+		return
+	}
+	currentID, _ := r.Context().Value(sessionIDKey).(string)
+
+	export, err := c.gophermartService.ExportUserData(r.Context(), userID)
+	if err != nil {
+		log.Ctx(r.Context()).Err(err).Msg("export user data error")
+		writeProblem(w, r, apierror.CodeInternal, "")
+		return
+	}
+	resp := userExportResponse(export, currentID)
+
+	if format == exportFormatZIP {
+		archive, err := userExportArchive(resp, export.ExportedAt)
+		if err != nil {
+			log.Ctx(r.Context()).Err(err).Msg("export user data error")
+			writeProblem(w, r, apierror.CodeInternal, "")
+			return
+		}
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+exportArchiveName+`"`)
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(archive)
+		return
+	}
+
+	body, err := json.Marshal(resp)
+	if err != nil {
+		log.Ctx(r.Context()).Err(err).Msg("export user data error")
+		writeProblem(w, r, apierror.CodeInternal, "")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(body)
+}
+
+func userExportResponse(export *gophermartservice.UserExport, currentID string) Gophermart.UserExport {
+	ledger := make([]Gophermart.LedgerEntry, 0, len(export.Ledger))
+	for _, entry := range export.Ledger {
+		ledger = append(ledger, Gophermart.LedgerEntry{
+			Type:      Gophermart.LedgerEntryType(entry.Type),
+			Order:     entry.OrderID,
+			Amount:    Gophermart.Amount(entry.Amount),
+			CreatedAt: entry.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	return Gophermart.UserExport{
+		User: Gophermart.UserProfile{
+			Id:    export.UID,
+			Login: export.Login,
+		},
+		Balance: Gophermart.UserBalanceResponse{
+			Current:   Gophermart.Amount(export.Balance),
+			Withdrawn: Gophermart.Amount(export.Withdrawals),
+		},
+		Sessions:    sessionsResponse(export.Sessions, currentID),
+		Orders:      ordersResponse(export.Orders),
+		Withdrawals: withdrawalsResponse(export.Withdrawn),
+		Ledger:      ledger,
+		ExportedAt:  export.ExportedAt.Format(time.RFC3339),
+	}
+}
+
+// userExportArchive zip архив выгрузки: каждый раздел в отдельном JSON файле с временем выгрузки
+func userExportArchive(export Gophermart.UserExport, exportedAt time.Time) ([]byte, error) {
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"user.json", export.User},
+		{"balance.json", export.Balance},
+		{"sessions.json", export.Sessions},
+		{"orders.json", export.Orders},
+		{"withdrawals.json", export.Withdrawals},
+		{"ledger.json", export.Ledger},
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for _, file := range files {
+		fw, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     file.name,
+			Method:   zip.Deflate,
+			Modified: exportedAt,
+		})
+		if err != nil {
+			return nil, err
+		}
+		encoder := json.NewEncoder(fw)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.data); err != nil {
+			return nil, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
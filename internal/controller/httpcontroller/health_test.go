@@ -0,0 +1,43 @@
+package httpcontroller_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gavv/httpexpect/v2"
+	"github.com/stretchr/testify/require"
+	Accrual "github.com/zaz600/go-musthave-diploma/api/accrual"
+	"github.com/zaz600/go-musthave-diploma/internal/controller/httpcontroller"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/health"
+	"github.com/zaz600/go-musthave-diploma/internal/service/gophermartservice"
+)
+
+func TestHealthEndpoints(t *testing.T) {
+	accrualClient, err := Accrual.NewClientWithResponses(newAccrualMock().URL)
+	require.NoError(t, err)
+	service, err := gophermartservice.New(accrualClient, gophermartservice.WithMemoryStorage())
+	require.NoError(t, err)
+
+	h := health.New()
+	h.AddCheck("accrual", service.CheckAccrual)
+	h.AddCheck("accrual_jobs", service.CheckAccrualJobs)
+	server := httptest.NewServer(httpcontroller.NewRouter(service, httpcontroller.WithHealth(h)))
+	defer server.Close()
+
+	e := httpexpect.New(t, server.URL)
+	e.GET("/healthz").Expect().Status(http.StatusOK).JSON().Object().ValueEqual("status", "ok")
+
+	e.GET("/readyz").Expect().Status(http.StatusOK).JSON().Object().Equal(map[string]interface{}{"status": "ok"})
+
+	// публичный /readyz не раскрывает ошибки зависимостей
+	h.AddCheck("database", func(context.Context) error { return errors.New("connection refused") })
+	e.GET("/readyz").Expect().Status(http.StatusServiceUnavailable).
+		JSON().Object().Equal(map[string]interface{}{"status": "fail"})
+
+	h.SetShuttingDown()
+	e.GET("/readyz").Expect().Status(http.StatusServiceUnavailable)
+	e.GET("/healthz").Expect().Status(http.StatusOK)
+}
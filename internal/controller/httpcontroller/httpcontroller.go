@@ -12,9 +12,10 @@ import (
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/rs/zerolog/log"
 	Gophermart "github.com/zaz600/go-musthave-diploma/api"
+	Admin "github.com/zaz600/go-musthave-diploma/api/admin"
+	"github.com/zaz600/go-musthave-diploma/internal/controller/apierror"
 	"github.com/zaz600/go-musthave-diploma/internal/entity"
 	"github.com/zaz600/go-musthave-diploma/internal/pkg/auth"
-	"github.com/zaz600/go-musthave-diploma/internal/pkg/luhn"
 	"github.com/zaz600/go-musthave-diploma/internal/service/gophermartservice"
 )
 
@@ -22,8 +23,12 @@ type key int
 
 const (
 	userIDKey key = iota
+	sessionIDKey
 )
 
+// maxUserAgentLength сколько символов User-Agent сохраняется в сессии
+const maxUserAgentLength = 512
+
 var _ Gophermart.ServerInterface = &GophermartController{}
 
 type GophermartController struct {
@@ -32,26 +37,22 @@ type GophermartController struct {
 
 func (c GophermartController) UserRegister(w http.ResponseWriter, r *http.Request) { //nolint:revive
 	var request Gophermart.RegisterRequest
+	// пустые логин и пароль отклоняет specValidator
 	err := json.NewDecoder(r.Body).Decode(&request)
-	if err != nil || request.Login == "" || request.Password == "" {
-		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+	if err != nil {
+		writeProblem(w, r, apierror.CodeBadRequest, "")
 		return
 	}
 
-	session, err := c.gophermartService.RegisterUser(r.Context(), request.Login, request.Password)
+	session, err := c.gophermartService.RegisterUser(r.Context(), request.Login, request.Password, sessionDevice(r))
 	if err != nil {
-		if errors.Is(err, gophermartservice.ErrUserExists) {
-			http.Error(w, "login already in use", http.StatusConflict)
-			return
-		}
-		log.Err(err).Msg("user register error")
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		writeServiceError(w, r, err, "user register error")
 		return
 	}
 	err = auth.SetJWT(w, session)
 	if err != nil {
-		log.Err(err).Msg("user register error")
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		log.Ctx(r.Context()).Err(err).Msg("user register error")
+		writeProblem(w, r, apierror.CodeInternal, "")
 		return
 	}
 
@@ -62,27 +63,23 @@ func (c GophermartController) UserRegister(w http.ResponseWriter, r *http.Reques
 
 func (c GophermartController) UserLogin(w http.ResponseWriter, r *http.Request) { //nolint:revive
 	var request Gophermart.LoginRequest
+	// пустые логин и пароль отклоняет specValidator
 	err := json.NewDecoder(r.Body).Decode(&request)
-	if err != nil || request.Login == "" || request.Password == "" {
-		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+	if err != nil {
+		writeProblem(w, r, apierror.CodeBadRequest, "")
 		return
 	}
 
-	session, err := c.gophermartService.LoginUser(r.Context(), request.Login, request.Password)
+	session, err := c.gophermartService.LoginUser(r.Context(), request.Login, request.Password, sessionDevice(r))
 	if err != nil {
-		if errors.Is(err, gophermartservice.ErrAuth) {
-			http.Error(w, "invalid login/password", http.StatusUnauthorized)
-			return
-		}
-		log.Err(err).Msg("user login error")
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		writeServiceError(w, r, err, "user login error")
 		return
 	}
 
 	err = auth.SetJWT(w, session)
 	if err != nil {
-		log.Err(err).Msg("user login error")
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		log.Ctx(r.Context()).Err(err).Msg("user login error")
+		writeProblem(w, r, apierror.CodeInternal, "")
 		return
 	}
 
@@ -91,41 +88,33 @@ func (c GophermartController) UserLogin(w http.ResponseWriter, r *http.Request)
 	_, _ = w.Write([]byte(`{"status": "success"}`))
 }
 
-func (c GophermartController) UploadOrder(w http.ResponseWriter, r *http.Request) {
+// UploadOrder загрузка номера заказа. Idempotency-Key обрабатывается в middleware Idempotency
+func (c GophermartController) UploadOrder(w http.ResponseWriter, r *http.Request, _ Gophermart.UploadOrderParams) {
 	userID, ok := r.Context().Value(userIDKey).(string)
 	if !ok {
-		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		writeProblem(w, r, apierror.CodeUnauthorized, "")
 		return
 	}
 
 	bytes, err := io.ReadAll(r.Body)
 	if err != nil || len(bytes) == 0 {
-		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		writeProblem(w, r, apierror.CodeBadRequest, "")
 		return
 	}
 	orderID := string(bytes)
 
-	log.Info().Str("uid", userID).Str("orderID", orderID).Msg("UploadOrder")
+	log.Ctx(r.Context()).Info().Str("orderID", orderID).Msg("UploadOrder")
 	err = c.gophermartService.UploadOrder(r.Context(), userID, orderID)
 	if err != nil {
 		if errors.Is(err, gophermartservice.ErrOrderExists) {
 			w.WriteHeader(http.StatusOK)
 			return
 		}
-		if errors.Is(err, gophermartservice.ErrOrderOwnedByAnotherUser) {
-			w.WriteHeader(http.StatusConflict)
-			return
-		}
-		if errors.Is(err, gophermartservice.ErrInvalidOrderFormat) {
-			http.Error(w, "invalid orderID format, check luhn mismatch", http.StatusUnprocessableEntity)
-			return
-		}
-		log.Err(err).Msg("upload order error")
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		writeServiceError(w, r, err, "upload order error")
 		return
 	}
 
-	go c.gophermartService.GetAccruals(orderID)
+	go c.gophermartService.GetAccruals(r.Context(), orderID)
 
 	w.Header().Set("Content-Type", "application/ json")
 	w.WriteHeader(http.StatusAccepted)
@@ -134,38 +123,25 @@ func (c GophermartController) UploadOrder(w http.ResponseWriter, r *http.Request
 func (c *GophermartController) GetUserOrders(w http.ResponseWriter, r *http.Request) {
 	userID, ok := r.Context().Value(userIDKey).(string)
 	if !ok {
-		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		writeProblem(w, r, apierror.CodeUnauthorized, "")
 		return
 	}
 
 	orders, err := c.gophermartService.GetUserOrders(r.Context(), userID)
 	if err != nil {
-		log.Err(err).Msg("get user orders error")
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		log.Ctx(r.Context()).Err(err).Msg("get user orders error")
+		writeProblem(w, r, apierror.CodeInternal, "")
 		return
 	}
 	if len(orders) == 0 {
-		http.Error(w, http.StatusText(http.StatusNoContent), http.StatusNoContent)
+		w.WriteHeader(http.StatusNoContent)
 		return
 	}
 
-	var resp = Gophermart.OrdersResponse{}
-	for _, order := range orders {
-		respOrder := Gophermart.Order{
-			Number:     order.OrderID,
-			Status:     Gophermart.OrderStatus(order.Status),
-			UploadedAt: order.UploadedAt.Format(time.RFC3339),
-		}
-		if order.Status == entity.OrderStatusProcessed {
-			respOrder.Accrual = &order.Accrual
-		}
-		resp = append(resp, respOrder)
-	}
-
-	bytes, err := json.Marshal(resp)
+	bytes, err := json.Marshal(ordersResponse(orders))
 	if err != nil {
-		log.Err(err).Msg("get user orders error")
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		log.Ctx(r.Context()).Err(err).Msg("get user orders error")
+		writeProblem(w, r, apierror.CodeInternal, "")
 		return
 	}
 
@@ -177,14 +153,14 @@ func (c *GophermartController) GetUserOrders(w http.ResponseWriter, r *http.Requ
 func (c *GophermartController) GetUserBalance(w http.ResponseWriter, r *http.Request) {
 	userID, ok := r.Context().Value(userIDKey).(string)
 	if !ok {
-		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		writeProblem(w, r, apierror.CodeUnauthorized, "")
 		return
 	}
 
 	currentBalance, withdrawalsSum, err := c.gophermartService.GetUserBalance(r.Context(), userID)
 	if err != nil {
-		log.Err(err).Msg("get user balance error")
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		log.Ctx(r.Context()).Err(err).Msg("get user balance error")
+		writeProblem(w, r, apierror.CodeInternal, "")
 		return
 	}
 
@@ -197,108 +173,232 @@ func (c *GophermartController) GetUserBalance(w http.ResponseWriter, r *http.Req
 	_ = json.NewEncoder(w).Encode(balance)
 }
 
-func (c *GophermartController) UserBalanceWithdraw(w http.ResponseWriter, r *http.Request) {
+// UserBalanceWithdraw запрос на списание. Idempotency-Key обрабатывается в middleware Idempotency
+func (c *GophermartController) UserBalanceWithdraw(w http.ResponseWriter, r *http.Request, _ Gophermart.UserBalanceWithdrawParams) {
 	userID, ok := r.Context().Value(userIDKey).(string)
 	if !ok {
-		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		writeProblem(w, r, apierror.CodeUnauthorized, "")
 		return
 	}
 
 	var request Gophermart.UserBalanceWithdrawRequest
+	// пустой номер заказа и неположительную сумму отклоняет specValidator
 	err := json.NewDecoder(r.Body).Decode(&request)
-	if err != nil || request.Order == "" || request.Sum <= 0.0 {
-		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+	if err != nil {
+		writeProblem(w, r, apierror.CodeBadRequest, "")
 		return
 	}
-	if ok := luhn.CheckLuhn(request.Order); !ok {
-		http.Error(w, "invalid orderID format, check luhn mismatch", http.StatusUnprocessableEntity)
+
+	err = c.gophermartService.UploadWithdrawal(r.Context(), userID, request.Order, request.Sum)
+	if err != nil {
+		writeServiceError(w, r, err, "user balance withdraw error")
 		return
 	}
 
-	currentBalance, _, err := c.gophermartService.GetUserBalance(r.Context(), userID)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+}
+
+func (c *GophermartController) UserBalanceWithdrawals(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(userIDKey).(string)
+	if !ok {
+		writeProblem(w, r, apierror.CodeUnauthorized, "")
+		return
+	}
+
+	withdrawals, err := c.gophermartService.GetUserWithdrawals(r.Context(), userID)
 	if err != nil {
-		log.Err(err).Msg("user balance withdraw error")
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		log.Ctx(r.Context()).Err(err).Msg("user balance withdrawals error")
+		writeProblem(w, r, apierror.CodeInternal, "")
 		return
 	}
-	if currentBalance < float32(request.Sum) {
-		http.Error(w, "insufficient funds", http.StatusPaymentRequired)
+	if len(withdrawals) == 0 {
+		w.WriteHeader(http.StatusNoContent)
 		return
 	}
 
-	err = c.gophermartService.UploadWithdrawal(r.Context(), userID, request.Order, float32(request.Sum))
+	bytes, err := json.Marshal(withdrawalsResponse(withdrawals))
 	if err != nil {
-		log.Err(err).Msg("user balance withdraw error")
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		log.Ctx(r.Context()).Err(err).Msg("user balance withdrawals error")
+		writeProblem(w, r, apierror.CodeInternal, "")
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(bytes)
 }
 
-func (c *GophermartController) UserBalanceWithdrawals(w http.ResponseWriter, r *http.Request) {
+func (c *GophermartController) GetUserSessions(w http.ResponseWriter, r *http.Request) {
 	userID, ok := r.Context().Value(userIDKey).(string)
 	if !ok {
-		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		writeProblem(w, r, apierror.CodeUnauthorized, "")
 		return
 	}
+	currentID, _ := r.Context().Value(sessionIDKey).(string)
 
-	withdrawals, err := c.gophermartService.GetUserWithdrawals(r.Context(), userID)
+	sessions, err := c.gophermartService.GetUserSessions(r.Context(), userID)
 	if err != nil {
-		log.Err(err).Msg("user balance withdrawals error")
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		log.Ctx(r.Context()).Err(err).Msg("get user sessions error")
+		writeProblem(w, r, apierror.CodeInternal, "")
 		return
 	}
-	if len(withdrawals) == 0 {
-		http.Error(w, http.StatusText(http.StatusNoContent), http.StatusNoContent)
+
+	bytes, err := json.Marshal(sessionsResponse(sessions, currentID))
+	if err != nil {
+		log.Ctx(r.Context()).Err(err).Msg("get user sessions error")
+		writeProblem(w, r, apierror.CodeInternal, "")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(bytes)
+}
+
+func (c *GophermartController) DeleteUserSession(w http.ResponseWriter, r *http.Request, id string) {
+	userID, ok := r.Context().Value(userIDKey).(string)
+	if !ok {
+		writeProblem(w, r, apierror.CodeUnauthorized, "")
 		return
 	}
 
-	var resp Gophermart.UserBalanceWithdrawalsResponse
+	if err := c.gophermartService.DeleteUserSession(r.Context(), userID, id); err != nil {
+		writeServiceError(w, r, err, "delete user session error")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeleteUser удаляет учетную запись пользователя после проверки пароля
+func (c *GophermartController) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(userIDKey).(string)
+	if !ok {
+		writeProblem(w, r, apierror.CodeUnauthorized, "")
+		return
+	}
+
+	var request Gophermart.DeleteUserRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil || request.Password == "" {
+		writeProblem(w, r, apierror.CodeBadRequest, "")
+		return
+	}
+
+	if err := c.gophermartService.DeleteUser(r.Context(), userID, request.Password); err != nil {
+		writeServiceError(w, r, err, "delete user error")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func ordersResponse(orders []entity.Order) Gophermart.OrdersResponse {
+	resp := Gophermart.OrdersResponse{}
+	for _, order := range orders {
+		respOrder := Gophermart.Order{
+			Number:     order.OrderID,
+			Status:     Gophermart.OrderStatus(order.Status),
+			UploadedAt: order.UploadedAt.Format(time.RFC3339),
+		}
+		if order.Status == entity.OrderStatusProcessed {
+			accrual := order.Accrual
+			respOrder.Accrual = &accrual
+		}
+		resp = append(resp, respOrder)
+	}
+	return resp
+}
+
+func withdrawalsResponse(withdrawals []entity.Withdrawal) Gophermart.UserBalanceWithdrawalsResponse {
+	resp := Gophermart.UserBalanceWithdrawalsResponse{}
 	for _, withdrawal := range withdrawals {
-		respWithdrawal := Gophermart.UserBalanceWithdrawal{
+		resp = append(resp, Gophermart.UserBalanceWithdrawal{
 			Order:       withdrawal.OrderID,
 			ProcessedAt: withdrawal.ProcessedAt.Format(time.RFC3339),
 			Sum:         Gophermart.Amount(withdrawal.Sum),
-		}
-		resp = append(resp, respWithdrawal)
+		})
 	}
+	return resp
+}
 
-	bytes, err := json.Marshal(resp)
-	if err != nil {
-		log.Err(err).Msg("user balance withdrawals error")
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
-		return
+// sessionsResponse currentID - сессия, с токеном которой выполнен запрос
+func sessionsResponse(sessions []*entity.Session, currentID string) Gophermart.SessionsResponse {
+	resp := Gophermart.SessionsResponse{}
+	for _, session := range sessions {
+		resp = append(resp, Gophermart.Session{
+			Id:         session.SessionID,
+			UserAgent:  session.UserAgent,
+			Ip:         session.IP,
+			CreatedAt:  session.CreatedAt.Format(time.RFC3339),
+			LastSeenAt: session.LastSeenAt.Format(time.RFC3339),
+			Current:    session.SessionID == currentID,
+		})
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	_, _ = w.Write(bytes)
+	return resp
 }
 
-func NewRouter(gophermartService *gophermartservice.GophermartService) *chi.Mux {
+func NewRouter(gophermartService *gophermartservice.GophermartService, opts ...Option) *chi.Mux {
+	o := &options{maxDecompressed: DefaultMaxDecompressedBodySize}
+	for _, opt := range opts {
+		opt(o)
+	}
+
 	c := &GophermartController{
 		gophermartService: gophermartService,
 	}
+	ac := &AdminController{
+		gophermartService: gophermartService,
+		adminToken:        o.adminToken,
+		reloadConfig:      o.reloadConfig,
+	}
 
 	r := chi.NewRouter()
 	r.Use(middleware.RequestID)
+	r.Use(Metrics(o.metrics))
+	r.Use(Tracing)
 	r.Use(middleware.RealIP)
-	r.Use(middleware.Logger)
+	r.Use(AccessLog)
 	r.Use(middleware.Recoverer)
 	r.Use(middleware.Timeout(10 * time.Second))
-	r.Use(middleware.Compress(5))
-	// TODO gzip
+	r.Use(Decompress(o.maxDecompressed))
+	r.Use(Compress())
+
+	if o.health != nil {
+		r.Get("/healthz", o.health.Liveness)
+		// результаты проверок отдает только служебный listener
+		r.Get("/readyz", o.health.ReadinessStatus)
+	}
+
+	r.Route("/api/admin", func(r chi.Router) {
+		r.Use(ac.AdminAuth)
+		r.Use(mustSpecValidator(Admin.GetSwagger, func(*http.Request) bool { return true }, o.validateResponses).Middleware)
+		r.Mount("/", Admin.HandlerWithOptions(ac, Admin.ChiServerOptions{
+			BaseRouter:       newAPIRouter(),
+			ErrorHandlerFunc: paramErrorHandler,
+		}))
+	})
 
 	r.Route("/", func(r chi.Router) {
 		r.Use(c.AuthCtx)
-		r.Mount("/", Gophermart.Handler(c))
+		r.Use(c.RateLimit)
+		r.Use(mustSpecValidator(Gophermart.GetSwagger, authenticated, o.validateResponses).Middleware)
+		r.Use(c.Idempotency)
+		r.Mount("/", Gophermart.HandlerWithOptions(c, Gophermart.ChiServerOptions{
+			BaseRouter:       newAPIRouter(),
+			ErrorHandlerFunc: paramErrorHandler,
+		}))
 	})
 
 	return r
 }
 
+func authenticated(r *http.Request) bool {
+	_, ok := r.Context().Value(userIDKey).(string)
+	return ok
+}
+
+// AuthCtx кладет в контекст пользователя и сессию из JWT, если сессия не завершена.
+// Запрос без действующей сессии обрабатывается как неаутентифицированный
 func (c GophermartController) AuthCtx(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		claims, err := auth.GetClaims(r)
@@ -306,7 +406,27 @@ func (c GophermartController) AuthCtx(next http.Handler) http.Handler {
 			next.ServeHTTP(w, r)
 			return
 		}
+		if err := c.gophermartService.CheckSession(r.Context(), claims.UserID, claims.SessionID); err != nil {
+			if errors.Is(err, gophermartservice.ErrSessionNotFound) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			log.Ctx(r.Context()).Err(err).Msg("check session error")
+			writeProblem(w, r, apierror.CodeInternal, "")
+			return
+		}
+		addLogUserID(r, claims.UserID)
 		ctx := context.WithValue(r.Context(), userIDKey, claims.UserID)
+		ctx = context.WithValue(ctx, sessionIDKey, claims.SessionID)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// sessionDevice устройство, с которого выполняется вход
+func sessionDevice(r *http.Request) entity.SessionOption {
+	userAgent := r.UserAgent()
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+	return entity.WithDevice(userAgent, clientIP(r))
+}
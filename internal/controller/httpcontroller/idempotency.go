@@ -0,0 +1,156 @@
+package httpcontroller
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/rs/zerolog/log"
+	"github.com/zaz600/go-musthave-diploma/internal/controller/apierror"
+)
+
+const (
+	idempotencyKeyHeader      = "Idempotency-Key"
+	idempotentReplayedHeader  = "Idempotent-Replayed"
+	idempotencyKeyMaxLength   = 255
+	idempotencyMaxRequestBody = 1 << 20
+	// idempotencyStoreTimeout сколько ждать сохранения или освобождения ключа после ответа обработчика
+	idempotencyStoreTimeout = 5 * time.Second
+)
+
+// idempotentPaths запросы, для которых поддерживается заголовок Idempotency-Key
+var idempotentPaths = map[string]struct{}{
+	"/api/user/orders":           {},
+	"/api/user/balance/withdraw": {},
+}
+
+// Idempotency сохраняет ответ на запрос с заголовком Idempotency-Key и возвращает его при повторе запроса.
+// Повтор ключа с другим телом запроса отклоняется с 422. Ответы 5xx не сохраняются, чтобы запрос можно было повторить
+func (c GophermartController) Idempotency(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" || r.Method != http.MethodPost {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if _, ok := idempotentPaths[r.URL.Path]; !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		userID, ok := r.Context().Value(userIDKey).(string)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > idempotencyKeyMaxLength {
+			writeProblem(w, r, apierror.CodeIdempotencyKeyTooLong, "")
+			return
+		}
+
+		// на байт больше лимита, чтобы отличить слишком большое тело от тела ровно в лимит
+		body, err := io.ReadAll(io.LimitReader(r.Body, idempotencyMaxRequestBody+1))
+		if err != nil {
+			writeProblem(w, r, apierror.CodeBadRequest, "")
+			return
+		}
+		if len(body) > idempotencyMaxRequestBody {
+			writeProblem(w, r, apierror.CodeRequestTooLarge, fmt.Sprintf("limit is %d bytes", idempotencyMaxRequestBody))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		record, err := c.gophermartService.StartIdempotentRequest(r.Context(), userID, key, requestFingerprint(r, body))
+		if err != nil {
+			writeServiceError(w, r, err, "idempotency key error")
+			return
+		}
+
+		if record.Completed() {
+			if record.ContentType != "" {
+				w.Header().Set("Content-Type", record.ContentType)
+			}
+			w.Header().Set(idempotentReplayedHeader, "true")
+			w.WriteHeader(record.StatusCode)
+			_, _ = w.Write(record.Body)
+			return
+		}
+
+		var response bytes.Buffer
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		ww.Tee(&response)
+		completed := false
+		defer func() {
+			if completed {
+				return
+			}
+			// обработчик упал, освобождаем ключ
+			ctx, cancel := idempotencyStoreContext(r)
+			defer cancel()
+			if err := c.gophermartService.CancelIdempotentRequest(ctx, userID, key); err != nil {
+				log.Ctx(r.Context()).Err(err).Msg("idempotency key cancel error")
+			}
+		}()
+
+		next.ServeHTTP(ww, r)
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		if status >= http.StatusInternalServerError {
+			return
+		}
+		record.StatusCode = status
+		record.ContentType = ww.Header().Get("Content-Type")
+		record.Body = response.Bytes()
+		ctx, cancel := idempotencyStoreContext(r)
+		defer cancel()
+		if err := c.gophermartService.CompleteIdempotentRequest(ctx, record); err != nil {
+			log.Ctx(r.Context()).Err(err).Msg("idempotency key save error")
+			return
+		}
+		completed = true
+	})
+}
+
+// idempotencyStoreContext контекст сохранения ключа, который не отменяется вместе с запросом.
+// Ключ нужен как раз тогда, когда клиент не дождался ответа и отключился: без него повтор
+// получил бы 409 на весь срок хранения ключа или выполнил бы запрос второй раз
+func idempotencyStoreContext(r *http.Request) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(detachedContext{parent: r.Context()}, idempotencyStoreTimeout)
+}
+
+// detachedContext передает значения контекста запроса (логгер, трейс), но не отменяется вместе с ним
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
+
+// requestFingerprint хеш запроса, по которому проверяется, что ключ повторно используется для того же запроса
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,27 @@
+package httpcontroller
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testCtxKey struct{}
+
+func TestIdempotencyStoreContext(t *testing.T) {
+	r := httptest.NewRequest("POST", "/api/user/orders", nil)
+	reqCtx, cancel := context.WithCancel(context.WithValue(r.Context(), testCtxKey{}, "value"))
+	r = r.WithContext(reqCtx)
+
+	ctx, storeCancel := idempotencyStoreContext(r)
+	defer storeCancel()
+	cancel()
+
+	// клиент отключился, но ключ еще можно сохранить
+	assert.NoError(t, ctx.Err())
+	assert.Equal(t, "value", ctx.Value(testCtxKey{}))
+	_, ok := ctx.Deadline()
+	assert.True(t, ok)
+}
@@ -0,0 +1,35 @@
+package httpcontroller
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/metrics"
+)
+
+// unmatchedRoute метка для запросов, не дошедших до роутера API
+const unmatchedRoute = "unmatched"
+
+// Metrics собирает количество и длительность запросов по шаблонам маршрутов
+func Metrics(m *metrics.Metrics) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r, route := withRoutePattern(r)
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			start := time.Now()
+
+			next.ServeHTTP(ww, r)
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			pattern := route.pattern
+			if pattern == "" {
+				pattern = unmatchedRoute
+			}
+			m.ObserveHTTPRequest(r.Method, pattern, status, time.Since(start))
+		})
+	}
+}
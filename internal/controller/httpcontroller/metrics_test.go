@@ -0,0 +1,58 @@
+package httpcontroller_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gavv/httpexpect/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/require"
+	Accrual "github.com/zaz600/go-musthave-diploma/api/accrual"
+	"github.com/zaz600/go-musthave-diploma/internal/controller/httpcontroller"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/metrics"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/random"
+	"github.com/zaz600/go-musthave-diploma/internal/service/gophermartservice"
+)
+
+func TestMetrics(t *testing.T) {
+	accrualClient, err := Accrual.NewClientWithResponses(newAccrualMock().URL)
+	require.NoError(t, err)
+
+	m := metrics.New()
+	service, err := gophermartservice.New(accrualClient,
+		gophermartservice.WithMemoryStorage(),
+		gophermartservice.WithAccrualRetryInterval(20*time.Millisecond),
+		gophermartservice.WithMetrics(m),
+	)
+	require.NoError(t, err)
+
+	server := httptest.NewServer(httpcontroller.NewRouter(service, httpcontroller.WithMetrics(m)))
+	defer server.Close()
+	metricsServer := httptest.NewServer(m.Handler())
+	defer metricsServer.Close()
+
+	e := httpexpect.New(t, server.URL)
+	authHeader := register(t, e, NewUser())
+	e.POST("/api/user/orders").
+		WithHeader("Authorization", authHeader).
+		WithText(random.OrderID()).
+		Expect().
+		Status(http.StatusAccepted)
+	e.GET("/api/user/unknown").
+		WithHeader("Authorization", authHeader).
+		Expect().
+		Status(http.StatusNotFound)
+
+	g := NewGomegaWithT(t)
+	g.Eventually(func(g Gomega) {
+		body := httpexpect.New(t, metricsServer.URL).GET("/").Expect().Status(http.StatusOK).Body().Raw()
+		g.Expect(body).To(ContainSubstring(`gophermart_http_requests_total{code="200",method="POST",route="/api/user/register"} 1`))
+		g.Expect(body).To(ContainSubstring(`gophermart_http_requests_total{code="202",method="POST",route="/api/user/orders"} 1`))
+		g.Expect(body).To(ContainSubstring(`gophermart_http_requests_total{code="404",method="GET",route="unmatched"} 1`))
+		g.Expect(body).To(ContainSubstring(`gophermart_accrual_requests_total{outcome="200"} 1`))
+		g.Expect(body).To(ContainSubstring(`gophermart_accrual_pending_jobs{status="NEW"} 0`))
+		g.Expect(body).To(ContainSubstring(`gophermart_accrual_pending_jobs{status="PROCESSING"} 0`))
+	}, 2*time.Second, 20*time.Millisecond).Should(Succeed())
+}
@@ -0,0 +1,66 @@
+package httpcontroller
+
+import (
+	"context"
+
+	"github.com/zaz600/go-musthave-diploma/internal/app/config"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/health"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/metrics"
+)
+
+type Option func(*options)
+
+type options struct {
+	adminToken        string
+	validateResponses bool
+	metrics           *metrics.Metrics
+	health            *health.Health
+	reloadConfig      ConfigReloader
+	maxDecompressed   int64
+}
+
+// ConfigReloader перечитывает конфигурацию и применяет параметры, изменяемые без перезапуска
+type ConfigReloader func(ctx context.Context) (config.Changes, error)
+
+// WithAdminToken включает административное API с авторизацией по токену
+func WithAdminToken(token string) Option {
+	return func(o *options) {
+		o.adminToken = token
+	}
+}
+
+// WithResponseValidation включает проверку ответов на соответствие спецификации OpenAPI.
+// Ответ, не соответствующий спецификации, заменяется ошибкой 500. Предназначено для отладки и тестов
+func WithResponseValidation(enabled bool) Option {
+	return func(o *options) {
+		o.validateResponses = enabled
+	}
+}
+
+// WithMetrics включает сбор метрик HTTP запросов
+func WithMetrics(m *metrics.Metrics) Option {
+	return func(o *options) {
+		o.metrics = m
+	}
+}
+
+// WithConfigReloader включает перечитывание конфигурации через административное API
+func WithConfigReloader(reload ConfigReloader) Option {
+	return func(o *options) {
+		o.reloadConfig = reload
+	}
+}
+
+// WithMaxDecompressedBodySize задает, до скольки байт можно распаковать сжатое тело запроса
+func WithMaxDecompressedBodySize(size int64) Option {
+	return func(o *options) {
+		o.maxDecompressed = size
+	}
+}
+
+// WithHealth добавляет /healthz и /readyz. /readyz отвечает только общим статусом без результатов проверок
+func WithHealth(h *health.Health) Option {
+	return func(o *options) {
+		o.health = h
+	}
+}
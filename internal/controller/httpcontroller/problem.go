@@ -0,0 +1,70 @@
+package httpcontroller
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+	Gophermart "github.com/zaz600/go-musthave-diploma/api"
+	"github.com/zaz600/go-musthave-diploma/internal/controller/apierror"
+)
+
+const (
+	problemContentType = "application/problem+json"
+	problemTypePrefix  = "urn:gophermart:problem:"
+)
+
+// writeProblem отвечает ошибкой в формате RFC 7807 (application/problem+json)
+func writeProblem(w http.ResponseWriter, r *http.Request, code apierror.Code, detail string) {
+	entry := apierror.Lookup(code)
+	problem := Gophermart.Problem{
+		Type:     problemTypePrefix + string(entry.Code),
+		Title:    entry.Title,
+		Status:   entry.Status,
+		Code:     Gophermart.ProblemCode(entry.Code),
+		Instance: r.URL.Path,
+	}
+	if detail != "" {
+		problem.Detail = &detail
+	}
+
+	w.Header().Set("Content-Type", problemContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(entry.Status)
+	_ = json.NewEncoder(w).Encode(problem)
+}
+
+// writeServiceError отвечает ошибкой из каталога, соответствующей ошибке сервиса.
+// Неизвестные ошибки логируются и возвращаются клиенту как внутренняя ошибка без подробностей
+func writeServiceError(w http.ResponseWriter, r *http.Request, err error, msg string) {
+	code := apierror.FromError(err)
+	if code == apierror.CodeInternal {
+		log.Ctx(r.Context()).Err(err).Msg(msg)
+		writeProblem(w, r, code, "")
+		return
+	}
+	writeProblem(w, r, code, err.Error())
+}
+
+// paramErrorHandler ошибки разбора параметров запроса в сгенерированных обработчиках
+func paramErrorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	writeProblem(w, r, apierror.CodeBadRequest, err.Error())
+}
+
+// newAPIRouter роутер для сгенерированных обработчиков, отвечающий на неизвестные пути в формате problem+json
+func newAPIRouter() chi.Router {
+	r := chi.NewRouter()
+	r.Use(recordRoutePattern)
+	r.NotFound(notFoundHandler)
+	r.MethodNotAllowed(methodNotAllowedHandler)
+	return r
+}
+
+func notFoundHandler(w http.ResponseWriter, r *http.Request) {
+	writeProblem(w, r, apierror.CodeNotFound, "")
+}
+
+func methodNotAllowedHandler(w http.ResponseWriter, r *http.Request) {
+	writeProblem(w, r, apierror.CodeMethodNotAllowed, "")
+}
@@ -0,0 +1,62 @@
+package httpcontroller
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/zaz600/go-musthave-diploma/internal/controller/apierror"
+)
+
+const (
+	rateLimitLimitHeader     = "RateLimit-Limit"
+	rateLimitRemainingHeader = "RateLimit-Remaining"
+	rateLimitResetHeader     = "RateLimit-Reset"
+	retryAfterHeader         = "Retry-After"
+)
+
+// RateLimit ограничивает частоту запросов по правилам сервиса. Аутентифицированные запросы
+// учитываются по пользователю, остальные - по IP клиента, определенному middleware.RealIP
+func (c GophermartController) RateLimit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result, ok := c.gophermartService.TakeRateLimit(r.Context(), r.Method, r.URL.Path, rateLimitClient(r))
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set(rateLimitLimitHeader, strconv.Itoa(result.Limit))
+		w.Header().Set(rateLimitRemainingHeader, strconv.Itoa(result.Remaining))
+		w.Header().Set(rateLimitResetHeader, headerSeconds(result.Reset))
+		if !result.Allowed {
+			w.Header().Set(retryAfterHeader, headerSeconds(result.RetryAfter))
+			writeProblem(w, r, apierror.CodeTooManyRequests, "")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// rateLimitClient идентификатор клиента, по которому считается лимит
+func rateLimitClient(r *http.Request) string {
+	if userID, ok := r.Context().Value(userIDKey).(string); ok {
+		return "user:" + userID
+	}
+	return "ip:" + clientIP(r)
+}
+
+// clientIP адрес клиента. RealIP заменяет RemoteAddr адресом без порта, без прокси адрес остается с портом
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// headerSeconds округляет время вверх до целых секунд, чтобы клиент не повторил запрос раньше времени
+func headerSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
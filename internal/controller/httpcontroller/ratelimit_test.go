@@ -0,0 +1,71 @@
+package httpcontroller_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gavv/httpexpect/v2"
+	"github.com/stretchr/testify/require"
+	Accrual "github.com/zaz600/go-musthave-diploma/api/accrual"
+	"github.com/zaz600/go-musthave-diploma/internal/controller/httpcontroller"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/ratelimit"
+	"github.com/zaz600/go-musthave-diploma/internal/service/gophermartservice"
+)
+
+func newRateLimitServer(t *testing.T, rules string) *httptest.Server {
+	t.Helper()
+	parsed, err := ratelimit.ParseRules(rules)
+	require.NoError(t, err)
+	accrualClient, err := Accrual.NewClientWithResponses(newAccrualMock().URL)
+	require.NoError(t, err)
+	service, err := gophermartservice.New(accrualClient,
+		gophermartservice.WithMemoryStorage(),
+		gophermartservice.WithRateLimitRules(parsed),
+	)
+	require.NoError(t, err)
+	server := httptest.NewServer(httpcontroller.NewRouter(service, httpcontroller.WithResponseValidation(true)))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRateLimit_PerUser(t *testing.T) {
+	server := newRateLimitServer(t, "GET /api/user/balance=2/1h")
+	e := httpexpect.New(t, server.URL)
+	token := register(t, e, NewUser())
+
+	resp := e.GET("/api/user/balance").WithHeader("Authorization", token).Expect().Status(http.StatusOK)
+	resp.Header("RateLimit-Limit").Equal("2")
+	resp.Header("RateLimit-Remaining").Equal("1")
+	resp.Header("RateLimit-Reset").Equal("1800")
+
+	e.GET("/api/user/balance").WithHeader("Authorization", token).Expect().Status(http.StatusOK).
+		Header("RateLimit-Remaining").Equal("0")
+
+	resp = e.GET("/api/user/balance").WithHeader("Authorization", token).Expect().Status(http.StatusTooManyRequests)
+	resp.Header("Retry-After").Equal("1800")
+	resp.JSON(httpexpect.ContentOpts{MediaType: "application/problem+json"}).Object().
+		ValueEqual("code", "too_many_requests")
+
+	// у другого пользователя свой лимит
+	token2 := register(t, e, NewUser())
+	e.GET("/api/user/balance").WithHeader("Authorization", token2).Expect().Status(http.StatusOK)
+
+	// маршруты без правил не ограничиваются
+	e.GET("/api/user/balance/withdrawals").WithHeader("Authorization", token).Expect().
+		Status(http.StatusNoContent).Header("RateLimit-Limit").Empty()
+}
+
+func TestRateLimit_PerIP(t *testing.T) {
+	server := newRateLimitServer(t, "POST /api/user/login=1/1m")
+	e := httpexpect.New(t, server.URL)
+	user := NewUser()
+	register(t, e, user)
+
+	e.POST("/api/user/login").WithJSON(user).WithHeader("X-Real-IP", "10.0.0.1").
+		Expect().Status(http.StatusOK)
+	e.POST("/api/user/login").WithJSON(user).WithHeader("X-Real-IP", "10.0.0.1").
+		Expect().Status(http.StatusTooManyRequests).Header("Retry-After").Equal("60")
+	e.POST("/api/user/login").WithJSON(user).WithHeader("X-Real-IP", "10.0.0.2").
+		Expect().Status(http.StatusOK)
+}
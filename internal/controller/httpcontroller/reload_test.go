@@ -0,0 +1,119 @@
+package httpcontroller_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gavv/httpexpect/v2"
+	"github.com/stretchr/testify/require"
+	Accrual "github.com/zaz600/go-musthave-diploma/api/accrual"
+	"github.com/zaz600/go-musthave-diploma/internal/app/config"
+	"github.com/zaz600/go-musthave-diploma/internal/controller/httpcontroller"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/feature"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/ratelimit"
+	"github.com/zaz600/go-musthave-diploma/internal/service/gophermartservice"
+)
+
+func TestReloadConfig(t *testing.T) {
+	accrualClient, err := Accrual.NewClientWithResponses(newAccrualMock().URL)
+	require.NoError(t, err)
+	service, err := gophermartservice.New(accrualClient, gophermartservice.WithMemoryStorage())
+	require.NoError(t, err)
+
+	var reloadErr error
+	reload := func(context.Context) (config.Changes, error) {
+		if reloadErr != nil {
+			return config.Changes{}, reloadErr
+		}
+		return config.Changes{Applied: []string{"log.level"}, RestartRequired: []string{}}, nil
+	}
+	server := httptest.NewServer(httpcontroller.NewRouter(service,
+		httpcontroller.WithAdminToken(adminToken),
+		httpcontroller.WithResponseValidation(true),
+		httpcontroller.WithConfigReloader(reload),
+	))
+	defer server.Close()
+
+	e := httpexpect.New(t, server.URL)
+	e.POST("/api/admin/config/reload").Expect().Status(http.StatusUnauthorized)
+
+	resp := e.POST("/api/admin/config/reload").WithHeader("X-Admin-Token", adminToken).
+		Expect().Status(http.StatusOK).JSON().Object()
+	resp.ValueEqual("applied", []string{"log.level"})
+	resp.ValueEqual("restart_required", []string{})
+
+	reloadErr = &config.ValidationError{Fields: []config.FieldError{
+		{Key: "accrual.rate_limit", Err: fmt.Errorf("%w: must be positive", config.ErrInvalidValue)},
+	}}
+	problem := e.POST("/api/admin/config/reload").WithHeader("X-Admin-Token", adminToken).
+		Expect().Status(http.StatusUnprocessableEntity).
+		JSON(httpexpect.ContentOpts{MediaType: "application/problem+json"}).Object()
+	problem.ValueEqual("code", "invalid_config")
+	problem.Value("detail").String().Contains("accrual.rate_limit")
+}
+
+func TestReloadConfig_NotConfigured(t *testing.T) {
+	accrualClient, err := Accrual.NewClientWithResponses(newAccrualMock().URL)
+	require.NoError(t, err)
+	service, err := gophermartservice.New(accrualClient, gophermartservice.WithMemoryStorage())
+	require.NoError(t, err)
+	server := httptest.NewServer(httpcontroller.NewRouter(service, httpcontroller.WithAdminToken(adminToken)))
+	defer server.Close()
+
+	httpexpect.New(t, server.URL).POST("/api/admin/config/reload").WithHeader("X-Admin-Token", adminToken).
+		Expect().Status(http.StatusNotFound).
+		JSON(httpexpect.ContentOpts{MediaType: "application/problem+json"}).Object().ValueEqual("code", "not_found")
+}
+
+// newSettingsServer сервер с настройками, примененными так же, как при перезагрузке конфигурации
+func newSettingsServer(t *testing.T, settings gophermartservice.RuntimeSettings) *httptest.Server {
+	t.Helper()
+	accrualClient, err := Accrual.NewClientWithResponses(newAccrualMock().URL)
+	require.NoError(t, err)
+	service, err := gophermartservice.New(accrualClient, gophermartservice.WithMemoryStorage())
+	require.NoError(t, err)
+	settings.AccrualRateLimit = 100
+	settings.AccrualRetryInterval = time.Second
+	settings.AccrualMaxRetries = 1
+	service.ApplySettings(settings)
+	server := httptest.NewServer(httpcontroller.NewRouter(service, httpcontroller.WithResponseValidation(true)))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestReloadConfig_LoginLimit(t *testing.T) {
+	server := newSettingsServer(t, gophermartservice.RuntimeSettings{
+		LoginLimit: ratelimit.Limit{Requests: 1, Period: time.Hour},
+	})
+	e := httpexpect.New(t, server.URL)
+	user := NewUser()
+	register(t, e, user)
+
+	login(t, e, user, "agent")
+	e.POST("/api/user/login").
+		WithJSON(map[string]string{"login": user.Login, "password": user.Password}).
+		Expect().
+		Status(http.StatusTooManyRequests).
+		JSON(httpexpect.ContentOpts{MediaType: "application/problem+json"}).Object().
+		ValueEqual("code", "too_many_requests")
+}
+
+func TestReloadConfig_DisabledFeatures(t *testing.T) {
+	server := newSettingsServer(t, gophermartservice.RuntimeSettings{
+		DisabledFeatures: feature.Disabled{feature.Withdrawals: {}},
+	})
+	e := httpexpect.New(t, server.URL)
+	token := register(t, e, NewUser())
+
+	e.POST("/api/user/balance/withdraw").
+		WithHeader("Authorization", token).
+		WithJSON(map[string]interface{}{"order": "2377225624", "sum": 10}).
+		Expect().
+		Status(http.StatusForbidden).
+		JSON(httpexpect.ContentOpts{MediaType: "application/problem+json"}).Object().
+		ValueEqual("code", "feature_disabled")
+}
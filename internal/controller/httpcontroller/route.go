@@ -0,0 +1,39 @@
+package httpcontroller
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type routePatternKey struct{}
+
+// routePattern шаблон маршрута, найденный роутером API.
+// Роутеры API вложены в корневой роутер, поэтому шаблон передается наверх через контекст
+type routePattern struct {
+	pattern string
+}
+
+// withRoutePattern добавляет в запрос место для шаблона маршрута, если его еще нет
+func withRoutePattern(r *http.Request) (*http.Request, *routePattern) {
+	if route, ok := r.Context().Value(routePatternKey{}).(*routePattern); ok {
+		return r, route
+	}
+	route := &routePattern{}
+	return r.WithContext(context.WithValue(r.Context(), routePatternKey{}, route)), route
+}
+
+// recordRoutePattern сохраняет шаблон маршрута после обработки запроса роутером API
+func recordRoutePattern(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r)
+		route, ok := r.Context().Value(routePatternKey{}).(*routePattern)
+		if !ok {
+			return
+		}
+		if rctx := chi.RouteContext(r.Context()); rctx != nil {
+			route.pattern = rctx.RoutePattern()
+		}
+	})
+}
@@ -0,0 +1,29 @@
+package httpcontroller
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracing открывает серверный спан на каждый запрос, продолжая трассировку из заголовка traceparent.
+// Имя спана уточняется шаблоном маршрута после обработки запроса
+func Tracing(next http.Handler) http.Handler {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r, route := withRoutePattern(r)
+		next.ServeHTTP(w, r)
+		if route.pattern == "" {
+			return
+		}
+		span := trace.SpanFromContext(r.Context())
+		span.SetName(r.Method + " " + route.pattern)
+		span.SetAttributes(semconv.HTTPRouteKey.String(route.pattern))
+	})
+	return otelhttp.NewHandler(handler, "http.server",
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return "HTTP " + r.Method
+		}),
+	)
+}
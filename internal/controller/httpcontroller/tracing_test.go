@@ -0,0 +1,92 @@
+package httpcontroller_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gavv/httpexpect/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/require"
+	Accrual "github.com/zaz600/go-musthave-diploma/api/accrual"
+	"github.com/zaz600/go-musthave-diploma/internal/controller/httpcontroller"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/random"
+	"github.com/zaz600/go-musthave-diploma/internal/service/gophermartservice"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const testTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+func TestTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTracerProvider(trace.NewNoopTracerProvider())
+
+	mu := sync.Mutex{}
+	var accrualTraceparent string
+	accrualServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		accrualTraceparent = r.Header.Get("traceparent")
+		mu.Unlock()
+		var accrual float32 = 50.0
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(Accrual.Response{
+			Accrual: &accrual,
+			Order:   Accrual.Order(strings.TrimPrefix(r.URL.Path, "/api/orders/")),
+			Status:  Accrual.ResponseStatusPROCESSED,
+		})
+	}))
+	defer accrualServer.Close()
+
+	accrualClient, err := Accrual.NewClientWithResponses(accrualServer.URL,
+		Accrual.WithHTTPClient(&http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)}),
+	)
+	require.NoError(t, err)
+	service, err := gophermartservice.New(accrualClient, gophermartservice.WithMemoryStorage())
+	require.NoError(t, err)
+	server := httptest.NewServer(httpcontroller.NewRouter(service))
+	defer server.Close()
+
+	e := httpexpect.New(t, server.URL)
+	authHeader := register(t, e, NewUser())
+	e.POST("/api/user/orders").
+		WithHeader("Authorization", authHeader).
+		WithHeader("traceparent", "00-"+testTraceID+"-00f067aa0ba902b7-01").
+		WithText(random.OrderID()).
+		Expect().
+		Status(http.StatusAccepted)
+
+	g := NewGomegaWithT(t)
+	g.Eventually(func(g Gomega) {
+		spans := map[string]sdktrace.ReadOnlySpan{}
+		for _, span := range recorder.Ended() {
+			spans[span.Name()] = span
+		}
+		g.Expect(spans).To(HaveKey("POST /api/user/orders"))
+		g.Expect(spans["POST /api/user/orders"].SpanContext().TraceID().String()).To(Equal(testTraceID))
+		g.Expect(spans).To(HaveKey("GophermartService.UploadOrder"))
+		g.Expect(spans["GophermartService.UploadOrder"].SpanContext().TraceID().String()).To(Equal(testTraceID))
+
+		// обработка начислений идет в отдельной трассировке со ссылкой на загрузку заказа
+		g.Expect(spans).To(HaveKey("GophermartService.GetAccruals"))
+		accrualSpan := spans["GophermartService.GetAccruals"]
+		g.Expect(accrualSpan.SpanContext().TraceID().String()).NotTo(Equal(testTraceID))
+		g.Expect(accrualSpan.Links()).To(HaveLen(1))
+		g.Expect(accrualSpan.Links()[0].SpanContext.TraceID().String()).To(Equal(testTraceID))
+		g.Expect(spans).To(HaveKey("AccrualProvider.GetAccrual"))
+
+		mu.Lock()
+		defer mu.Unlock()
+		g.Expect(accrualTraceparent).To(ContainSubstring(accrualSpan.SpanContext().TraceID().String()))
+	}, 2*time.Second, 20*time.Millisecond).Should(Succeed())
+}
@@ -0,0 +1,183 @@
+package httpcontroller
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/rs/zerolog/log"
+	"github.com/zaz600/go-musthave-diploma/internal/controller/apierror"
+)
+
+func init() {
+	// выгрузка данных пользователя отдается zip архивом, тело которого проверяется только на наличие
+	openapi3filter.RegisterBodyDecoder("application/zip", openapi3filter.FileBodyDecoder)
+}
+
+// specValidator проверяет запросы (и, в отладочном режиме, ответы) на соответствие спецификации OpenAPI
+type specValidator struct {
+	router routers.Router
+	// authenticated проверяет, что запрос аутентифицирован.
+	// Неаутентифицированные запросы к защищенным операциям не проверяются, на них ответит обработчик
+	authenticated     func(r *http.Request) bool
+	validateResponses bool
+	options           *openapi3filter.Options
+}
+
+func newSpecValidator(swagger *openapi3.T, authenticated func(r *http.Request) bool, validateResponses bool) (*specValidator, error) {
+	// роутер спецификации сопоставляет запросы и с адресом сервера, а сервис может быть запущен на любом адресе
+	swagger.Servers = nil
+	router, err := gorillamux.NewRouter(swagger)
+	if err != nil {
+		return nil, err
+	}
+	return &specValidator{
+		router:            router,
+		authenticated:     authenticated,
+		validateResponses: validateResponses,
+		options: &openapi3filter.Options{
+			// аутентификация проверяется в AuthCtx и AdminAuth
+			AuthenticationFunc:    openapi3filter.NoopAuthenticationFunc,
+			IncludeResponseStatus: true,
+		},
+	}, nil
+}
+
+// mustSpecValidator создает валидатор для встроенной спецификации. Спецификация генерируется вместе с кодом,
+// поэтому ошибка ее загрузки - ошибка сборки
+func mustSpecValidator(getSwagger func() (*openapi3.T, error), authenticated func(r *http.Request) bool, validateResponses bool) *specValidator {
+	swagger, err := getSwagger()
+	if err != nil {
+		panic(fmt.Sprintf("error loading embedded openapi spec: %v", err))
+	}
+	v, err := newSpecValidator(swagger, authenticated, validateResponses)
+	if err != nil {
+		panic(fmt.Sprintf("error creating openapi validator: %v", err))
+	}
+	return v
+}
+
+func (v *specValidator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route, pathParams, err := v.router.FindRoute(r)
+		if err != nil {
+			// неизвестный путь или метод, ответит роутер
+			next.ServeHTTP(w, r)
+			return
+		}
+		if requiresAuth(route) && !v.authenticated(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		input := &openapi3filter.RequestValidationInput{
+			Request:    legacyJSONRequest(r, route),
+			PathParams: pathParams,
+			Route:      route,
+			Options:    v.options,
+		}
+		if err := openapi3filter.ValidateRequest(r.Context(), input); err != nil {
+			writeProblem(w, r, apierror.CodeBadRequest, firstLine(err.Error()))
+			return
+		}
+		// валидатор вычитывает тело и подменяет его копией, ее и читает обработчик
+		r.Body = input.Request.Body
+
+		if !v.validateResponses {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rec := &responseRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+
+		responseInput := &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: input,
+			Status:                 rec.status,
+			Header:                 w.Header(),
+			Options:                v.options,
+		}
+		responseInput.SetBodyBytes(rec.body.Bytes())
+		if err := openapi3filter.ValidateResponse(context.Background(), responseInput); err != nil {
+			log.Ctx(r.Context()).Error().Err(err).Str("method", r.Method).Str("path", r.URL.Path).Int("status", rec.status).
+				Msg("response does not match API specification")
+			writeProblem(w, r, apierror.CodeInternal, "response does not match API specification: "+firstLine(err.Error()))
+			return
+		}
+
+		w.WriteHeader(rec.status)
+		_, _ = w.Write(rec.body.Bytes())
+	})
+}
+
+// legacyJSONRequest до проверки по спецификации JSON тела принимались с любым Content-Type,
+// и клиенты отправляют их как text/plain или вовсе без заголовка. Для операций, принимающих только JSON,
+// такие запросы проверяются как application/json. Заголовок меняется в копии запроса, обработчик видит исходный
+func legacyJSONRequest(r *http.Request, route *routers.Route) *http.Request {
+	body := route.Operation.RequestBody
+	if body == nil || body.Value == nil || len(body.Value.Content) != 1 || body.Value.Content.Get("application/json") == nil {
+		return r
+	}
+	contentType := r.Header.Get("Content-Type")
+	if mediaType, _, err := mime.ParseMediaType(contentType); contentType != "" && (err != nil || mediaType != "text/plain") {
+		return r
+	}
+	legacy := r.Clone(r.Context())
+	legacy.Header.Set("Content-Type", "application/json")
+	return legacy
+}
+
+// requiresAuth операция требует аутентификации, если у нее есть хотя бы одно непустое требование безопасности
+func requiresAuth(route *routers.Route) bool {
+	security := route.Operation.Security
+	if security == nil {
+		security = &route.Spec.Security
+	}
+	if len(*security) == 0 {
+		return false
+	}
+	for _, requirement := range *security {
+		if len(requirement) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+// responseRecorder буферизует ответ обработчика, чтобы проверить его до отправки клиенту.
+// Заголовки пишутся сразу в исходный ResponseWriter
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.body.Write(b)
+}
@@ -0,0 +1,162 @@
+package httpcontroller
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	Gophermart "github.com/zaz600/go-musthave-diploma/api"
+)
+
+func newTestValidator(t *testing.T, validateResponses bool) *specValidator {
+	t.Helper()
+	swagger, err := Gophermart.GetSwagger()
+	require.NoError(t, err)
+	v, err := newSpecValidator(swagger, authenticated, validateResponses)
+	require.NoError(t, err)
+	return v
+}
+
+func newRequest(method string, target string, body string, contentType string) *http.Request {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	if contentType != "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+	return r
+}
+
+func authenticatedRequest(method string, target string, body string, contentType string) *http.Request {
+	r := newRequest(method, target, body, contentType)
+	return r.WithContext(context.WithValue(r.Context(), userIDKey, "uid"))
+}
+
+func TestSpecValidator_Request(t *testing.T) {
+	v := newTestValidator(t, false)
+	handler := v.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name    string
+		request *http.Request
+		status  int
+	}{
+		{
+			name:    "valid withdraw",
+			request: authenticatedRequest(http.MethodPost, "/api/user/balance/withdraw", `{"order": "2377225624", "sum": 0.07}`, "application/json"),
+			status:  http.StatusOK,
+		},
+		{
+			name:    "withdraw sum is string",
+			request: authenticatedRequest(http.MethodPost, "/api/user/balance/withdraw", `{"order": "2377225624", "sum": "10"}`, "application/json"),
+			status:  http.StatusBadRequest,
+		},
+		{
+			name:    "withdraw zero sum",
+			request: authenticatedRequest(http.MethodPost, "/api/user/balance/withdraw", `{"order": "2377225624", "sum": 0}`, "application/json"),
+			status:  http.StatusBadRequest,
+		},
+		{
+			name:    "withdraw empty order",
+			request: authenticatedRequest(http.MethodPost, "/api/user/balance/withdraw", `{"order": "", "sum": 10}`, "application/json"),
+			status:  http.StatusBadRequest,
+		},
+		{
+			name:    "withdraw legacy text/plain content type",
+			request: authenticatedRequest(http.MethodPost, "/api/user/balance/withdraw", `{"order": "2377225624", "sum": 10}`, "text/plain; charset=utf-8"),
+			status:  http.StatusOK,
+		},
+		{
+			name:    "withdraw without content type",
+			request: authenticatedRequest(http.MethodPost, "/api/user/balance/withdraw", `{"order": "2377225624", "sum": 10}`, ""),
+			status:  http.StatusOK,
+		},
+		{
+			name:    "withdraw legacy content type is validated as json",
+			request: authenticatedRequest(http.MethodPost, "/api/user/balance/withdraw", `{"order": "2377225624", "sum": "10"}`, "text/plain"),
+			status:  http.StatusBadRequest,
+		},
+		{
+			name:    "withdraw wrong content type",
+			request: authenticatedRequest(http.MethodPost, "/api/user/balance/withdraw", `{"order": "2377225624", "sum": 10}`, "application/xml"),
+			status:  http.StatusBadRequest,
+		},
+		{
+			name:    "register without password",
+			request: newRequest(http.MethodPost, "/api/user/register", `{"login": "user"}`, "application/json"),
+			status:  http.StatusBadRequest,
+		},
+		{
+			name:    "not authenticated request is passed to handler",
+			request: newRequest(http.MethodPost, "/api/user/orders", "", ""),
+			status:  http.StatusOK,
+		},
+		{
+			name:    "unknown path is passed to router",
+			request: newRequest(http.MethodGet, "/api/unknown", "", ""),
+			status:  http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, tt.request)
+			assert.Equal(t, tt.status, w.Code)
+			if tt.status == http.StatusBadRequest {
+				assert.Equal(t, problemContentType, w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestSpecValidator_Response(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		contentType string
+		body        string
+		want        int
+	}{
+		{
+			name:        "valid balance",
+			status:      http.StatusOK,
+			contentType: "application/json",
+			body:        `{"current": 10.5, "withdrawn": 0}`,
+			want:        http.StatusOK,
+		},
+		{
+			name:        "balance without required field",
+			status:      http.StatusOK,
+			contentType: "application/json",
+			body:        `{"current": 10.5}`,
+			want:        http.StatusInternalServerError,
+		},
+		{
+			name:        "undocumented status",
+			status:      http.StatusTeapot,
+			contentType: "application/json",
+			body:        `{}`,
+			want:        http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := newTestValidator(t, true)
+			handler := v.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, authenticatedRequest(http.MethodGet, "/api/user/balance", "", ""))
+			assert.Equal(t, tt.want, w.Code)
+			if tt.want == tt.status {
+				assert.JSONEq(t, tt.body, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,253 @@
+package httpcontroller_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gavv/httpexpect/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	Accrual "github.com/zaz600/go-musthave-diploma/api/accrual"
+	"github.com/zaz600/go-musthave-diploma/internal/controller/httpcontroller"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/random"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/signature"
+	"github.com/zaz600/go-musthave-diploma/internal/service/gophermartservice"
+)
+
+const (
+	adminToken    = "admin-secret-token"
+	webhookSecret = "webhook-secret"
+)
+
+type webhookEvent struct {
+	ID   string          `json:"id"`
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// webhookReceiver принимает вебхуки и проверяет их подпись
+type webhookReceiver struct {
+	mu     sync.Mutex
+	status int
+	events []webhookEvent
+	calls  int
+}
+
+func (rcv *webhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+	rcv.calls++
+
+	body, _ := io.ReadAll(r.Body)
+	timestamp, _ := strconv.ParseInt(r.Header.Get("X-Gophermart-Timestamp"), 10, 64)
+	if !signature.Verify(webhookSecret, timestamp, body, r.Header.Get("X-Gophermart-Signature")) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	if rcv.status != http.StatusOK {
+		w.WriteHeader(rcv.status)
+		return
+	}
+	var event webhookEvent
+	_ = json.Unmarshal(body, &event)
+	rcv.events = append(rcv.events, event)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (rcv *webhookReceiver) setStatus(status int) {
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+	rcv.status = status
+}
+
+func (rcv *webhookReceiver) eventTypes() []string {
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+	types := make([]string, 0, len(rcv.events))
+	for _, event := range rcv.events {
+		types = append(types, event.Type)
+	}
+	return types
+}
+
+type WebhookTestSuite struct {
+	suite.Suite
+	server   *httptest.Server
+	receiver *webhookReceiver
+	hook     *httptest.Server
+	cancel   context.CancelFunc
+}
+
+func (suite *WebhookTestSuite) SetupTest() {
+	t := suite.T()
+	accrualClient, err := Accrual.NewClientWithResponses(newAccrualMock().URL)
+	require.NoError(t, err)
+
+	service, err := gophermartservice.New(accrualClient,
+		gophermartservice.WithMemoryStorage(),
+		gophermartservice.WithAccrualRetryInterval(20*time.Millisecond),
+		gophermartservice.WithWebhookPollInterval(10*time.Millisecond),
+		gophermartservice.WithWebhookRetryInterval(10*time.Millisecond),
+		gophermartservice.WithWebhookMaxAttempts(2),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	suite.cancel = cancel
+	go service.RunWebhookDispatcher(ctx)
+
+	suite.server = httptest.NewServer(httpcontroller.NewRouter(service, httpcontroller.WithAdminToken(adminToken), httpcontroller.WithResponseValidation(true)))
+	suite.receiver = &webhookReceiver{status: http.StatusOK}
+	suite.hook = httptest.NewServer(suite.receiver)
+}
+
+func (suite *WebhookTestSuite) TearDownTest() {
+	suite.cancel()
+	suite.server.Close()
+	suite.hook.Close()
+}
+
+func (suite *WebhookTestSuite) TestAdminAuth() {
+	e := httpexpect.New(suite.T(), suite.server.URL)
+
+	e.GET("/api/admin/webhooks").
+		Expect().
+		Status(http.StatusUnauthorized)
+
+	e.GET("/api/admin/webhooks").
+		WithHeader("X-Admin-Token", "wrong").
+		Expect().
+		Status(http.StatusUnauthorized)
+
+	e.GET("/api/admin/webhooks").
+		WithHeader("X-Admin-Token", adminToken).
+		Expect().
+		Status(http.StatusOK).
+		JSON().Array().Empty()
+}
+
+func (suite *WebhookTestSuite) TestAddSubscriber_BadRequest() {
+	e := httpexpect.New(suite.T(), suite.server.URL)
+
+	e.POST("/api/admin/webhooks").
+		WithHeader("X-Admin-Token", adminToken).
+		WithJSON(map[string]interface{}{"url": "ftp://foo", "event_types": []string{"order.processed"}, "secret": "s"}).
+		Expect().
+		Status(http.StatusBadRequest)
+
+	e.POST("/api/admin/webhooks").
+		WithHeader("X-Admin-Token", adminToken).
+		WithJSON(map[string]interface{}{"url": suite.hook.URL, "event_types": []string{"order.unknown"}, "secret": "s"}).
+		Expect().
+		Status(http.StatusBadRequest)
+}
+
+func (suite *WebhookTestSuite) TestSubscriberLifecycle() {
+	e := httpexpect.New(suite.T(), suite.server.URL)
+
+	id := addSubscriber(suite.T(), e, suite.hook.URL, "order.processed")
+
+	e.GET("/api/admin/webhooks").
+		WithHeader("X-Admin-Token", adminToken).
+		Expect().
+		Status(http.StatusOK).
+		JSON().Array().Length().Equal(1)
+
+	e.DELETE("/api/admin/webhooks/{id}", id).
+		WithHeader("X-Admin-Token", adminToken).
+		Expect().
+		Status(http.StatusNoContent)
+
+	e.DELETE("/api/admin/webhooks/{id}", id).
+		WithHeader("X-Admin-Token", adminToken).
+		Expect().
+		Status(http.StatusNotFound)
+}
+
+func (suite *WebhookTestSuite) TestDelivery() {
+	t := suite.T()
+	e := httpexpect.New(t, suite.server.URL)
+
+	addSubscriber(t, e, suite.hook.URL, "order.processed", "withdrawal.created")
+
+	token := register(t, e, NewUser())
+	uploadOrder(t, e, random.OrderID(), token)
+	assertBalance(t, e, 50.0, 0, token)
+
+	e.POST("/api/user/balance/withdraw").
+		WithHeader("Authorization", token).
+		WithHeader("Content-Type", "application/json").
+		WithBytes([]byte(fmt.Sprintf(`{"order": "%s", "sum": 10.50}`, random.OrderID()))).
+		Expect().
+		Status(http.StatusOK)
+
+	g := NewGomegaWithT(t)
+	g.Eventually(suite.receiver.eventTypes, 2*time.Second, 10*time.Millisecond).
+		Should(Equal([]string{"order.processed", "withdrawal.created"}))
+}
+
+func (suite *WebhookTestSuite) TestDeadLetter() {
+	t := suite.T()
+	e := httpexpect.New(t, suite.server.URL)
+
+	suite.receiver.setStatus(http.StatusInternalServerError)
+	addSubscriber(t, e, suite.hook.URL, "order.processed")
+
+	token := register(t, e, NewUser())
+	uploadOrder(t, e, random.OrderID(), token)
+
+	var deliveryID string
+	g := NewGomegaWithT(t)
+	g.Eventually(func(g Gomega) {
+		dead := e.GET("/api/admin/webhooks/deliveries/dead").
+			WithHeader("X-Admin-Token", adminToken).
+			Expect().
+			Status(http.StatusOK).
+			JSON().Array()
+		g.Expect(dead.Length().Raw()).To(Equal(float64(1)))
+		delivery := dead.Element(0).Object()
+		g.Expect(delivery.Value("attempts").Number().Raw()).To(Equal(float64(2)))
+		g.Expect(delivery.Value("event_type").String().Raw()).To(Equal("order.processed"))
+		deliveryID = delivery.Value("id").String().Raw()
+	}, 2*time.Second, 10*time.Millisecond).Should(Succeed())
+
+	suite.receiver.setStatus(http.StatusOK)
+	e.POST("/api/admin/webhooks/deliveries/{id}/redeliver", deliveryID).
+		WithHeader("X-Admin-Token", adminToken).
+		Expect().
+		Status(http.StatusAccepted)
+
+	g.Eventually(suite.receiver.eventTypes, 2*time.Second, 10*time.Millisecond).
+		Should(Equal([]string{"order.processed"}))
+
+	e.GET("/api/admin/webhooks/deliveries/dead").
+		WithHeader("X-Admin-Token", adminToken).
+		Expect().
+		Status(http.StatusOK).
+		JSON().Array().Empty()
+}
+
+func TestWebhookSuite(t *testing.T) {
+	suite.Run(t, new(WebhookTestSuite))
+}
+
+func addSubscriber(t *testing.T, e *httpexpect.Expect, url string, eventTypes ...string) string {
+	t.Helper()
+
+	return e.POST("/api/admin/webhooks").
+		WithHeader("X-Admin-Token", adminToken).
+		WithJSON(map[string]interface{}{"url": url, "event_types": eventTypes, "secret": webhookSecret}).
+		Expect().
+		Status(http.StatusCreated).
+		JSON().Object().
+		Value("id").String().NotEmpty().Raw()
+}
@@ -0,0 +1,91 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/random"
+)
+
+type EventType string
+
+const (
+	EventUserRegistered    EventType = "user.registered"
+	EventUserDeleted       EventType = "user.deleted"
+	EventOrderUploaded     EventType = "order.uploaded"
+	EventOrderProcessed    EventType = "order.processed"
+	EventOrderInvalid      EventType = "order.invalid"
+	EventAccrualCredited   EventType = "accrual.credited"
+	EventWithdrawalCreated EventType = "withdrawal.created"
+)
+
+// EventTypes все типы событий, на которые можно подписаться
+var EventTypes = []EventType{
+	EventUserRegistered,
+	EventUserDeleted,
+	EventOrderUploaded,
+	EventOrderProcessed,
+	EventOrderInvalid,
+	EventAccrualCredited,
+	EventWithdrawalCreated,
+}
+
+// Event событие об изменении состояния, которое сохраняется в outbox
+// в одной транзакции с самим изменением
+type Event struct {
+	// Seq порядковый номер события в outbox, назначается хранилищем
+	Seq       int64           `json:"-"`
+	EventID   string          `json:"id"`
+	Type      EventType       `json:"type"`
+	UID       string          `json:"user_id"`
+	Payload   json.RawMessage `json:"data"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// UserEventPayload данные событий по пользователю
+type UserEventPayload struct {
+	Login string `json:"login"`
+}
+
+// OrderEventPayload данные событий по заказу
+type OrderEventPayload struct {
+	OrderID string      `json:"order"`
+	Status  OrderStatus `json:"status"`
+	Accrual float32     `json:"accrual"`
+}
+
+// AccrualEventPayload данные события о зачислении баллов на счет
+type AccrualEventPayload struct {
+	OrderID string  `json:"order"`
+	Amount  float32 `json:"amount"`
+}
+
+// WithdrawalEventPayload данные событий по списанию
+type WithdrawalEventPayload struct {
+	OrderID     string    `json:"order"`
+	Sum         float32   `json:"sum"`
+	ProcessedAt time.Time `json:"processed_at"`
+}
+
+func NewEvent(eventType EventType, userID string, payload interface{}) (Event, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Event{}, err
+	}
+	return Event{
+		EventID:   random.String(24),
+		Type:      eventType,
+		UID:       userID,
+		Payload:   data,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+func IsKnownEventType(eventType EventType) bool {
+	for _, t := range EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
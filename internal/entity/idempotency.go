@@ -0,0 +1,30 @@
+package entity
+
+import "time"
+
+// IdempotencyRecord сохраненный результат запроса с заголовком Idempotency-Key.
+// Пока запрос выполняется, StatusCode равен 0
+type IdempotencyRecord struct {
+	UID string
+	Key string
+	// Fingerprint хеш метода, пути и тела запроса
+	Fingerprint string
+	StatusCode  int
+	ContentType string
+	Body        []byte
+	CreatedAt   time.Time
+}
+
+func NewIdempotencyRecord(uid string, key string, fingerprint string) IdempotencyRecord {
+	return IdempotencyRecord{
+		UID:         uid,
+		Key:         key,
+		Fingerprint: fingerprint,
+		CreatedAt:   time.Now(),
+	}
+}
+
+// Completed ответ на запрос уже сохранен
+func (r IdempotencyRecord) Completed() bool {
+	return r.StatusCode != 0
+}
@@ -0,0 +1,19 @@
+package entity
+
+import "time"
+
+type LedgerEntryType string
+
+const (
+	LedgerEntryAccrual    LedgerEntryType = "accrual"
+	LedgerEntryWithdrawal LedgerEntryType = "withdrawal"
+)
+
+// LedgerEntry движение баллов по счету пользователя: начисление за заказ или списание в счет заказа
+type LedgerEntry struct {
+	Type    LedgerEntryType `json:"type"`
+	OrderID string          `json:"order"`
+	// Amount сумма движения, всегда положительная. Направление задает Type
+	Amount    float32   `json:"amount"`
+	CreatedAt time.Time `json:"created_at"`
+}
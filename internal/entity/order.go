@@ -16,6 +16,24 @@ const (
 	OrderStatusTooManyRetries OrderStatus = "TOO_MANY_RETRIES"
 )
 
+// OrderStatuses все статусы заказа. Хранилища в БД проверяют статус ограничением на этот же список
+var OrderStatuses = []OrderStatus{
+	OrderStatusNew,
+	OrderStatusProcessing,
+	OrderStatusInvalid,
+	OrderStatusProcessed,
+	OrderStatusTooManyRetries,
+}
+
+func IsKnownOrderStatus(status OrderStatus) bool {
+	for _, s := range OrderStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
 // Order заказ, загружаемый пользователем, за который могут быть начислены баллы лояльности
 type Order struct {
 	ID         string      `json:",omitempty"`
@@ -11,6 +11,11 @@ type Session struct {
 	UID       string
 	SessionID string
 	CreatedAt time.Time
+	// LastSeenAt время последнего запроса с токеном сессии. Обновляется не сразу, а пакетами
+	LastSeenAt time.Time
+	// UserAgent и IP устройства, с которого пользователь вошел
+	UserAgent string
+	IP        string
 }
 
 type SessionOption func(session *Session)
@@ -24,6 +29,9 @@ func New(uid string, opts ...SessionOption) *Session {
 	for _, opt := range opts {
 		opt(session)
 	}
+	if session.LastSeenAt.IsZero() {
+		session.LastSeenAt = session.CreatedAt
+	}
 	return session
 }
 
@@ -42,3 +50,11 @@ func WithCreatedAt(createdAt time.Time) SessionOption {
 		o.CreatedAt = createdAt
 	}
 }
+
+// WithDevice сохраняет в сессии устройство, с которого пользователь вошел
+func WithDevice(userAgent string, ip string) SessionOption {
+	return func(o *Session) {
+		o.UserAgent = userAgent
+		o.IP = ip
+	}
+}
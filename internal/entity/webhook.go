@@ -0,0 +1,70 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/random"
+)
+
+// WebhookSubscriber внешняя система, которая получает события на свой URL
+type WebhookSubscriber struct {
+	ID         string
+	URL        string
+	EventTypes []EventType
+	// Secret ключ для подписи тела запроса HMAC-SHA256
+	Secret    string
+	CreatedAt time.Time
+}
+
+func NewWebhookSubscriber(url string, eventTypes []EventType, secret string) WebhookSubscriber {
+	return WebhookSubscriber{
+		ID:         random.String(16),
+		URL:        url,
+		EventTypes: eventTypes,
+		Secret:     secret,
+		CreatedAt:  time.Now(),
+	}
+}
+
+// Subscribed проверяет, подписан ли подписчик на события типа eventType
+func (s WebhookSubscriber) Subscribed(eventType EventType) bool {
+	for _, t := range s.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryStatusPending   DeliveryStatus = "PENDING"
+	DeliveryStatusDelivered DeliveryStatus = "DELIVERED"
+	// DeliveryStatusDead попытки доставки исчерпаны
+	DeliveryStatusDead DeliveryStatus = "DEAD"
+)
+
+// WebhookDelivery доставка одного события одному подписчику
+type WebhookDelivery struct {
+	ID            string
+	SubscriberID  string
+	Event         Event
+	Status        DeliveryStatus
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     string
+	CreatedAt     time.Time
+}
+
+func NewWebhookDelivery(subscriberID string, event Event) WebhookDelivery {
+	now := time.Now()
+	return WebhookDelivery{
+		ID:            random.String(16),
+		SubscriberID:  subscriberID,
+		Event:         event,
+		Status:        DeliveryStatusPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+}
@@ -0,0 +1,57 @@
+package eventsink
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/zaz600/go-musthave-diploma/internal/entity"
+)
+
+// Sink получатель доменных событий из outbox.
+// Publish должен быть идемпотентным со стороны получателя: при ошибке пачка событий будет отправлена повторно
+type Sink interface {
+	// Name уникальное имя sink. По нему отслеживается, какие события уже опубликованы
+	Name() string
+	Publish(ctx context.Context, events []entity.Event) error
+	io.Closer
+}
+
+// New создает sink по строке вида:
+//
+//	stdout - события пишутся в stdout в формате JSON Lines
+//	file:/path/to/events.jsonl - события дописываются в файл в формате JSON Lines
+//	http://host/path, https://host/path - события отправляются POST запросом пачками в виде JSON массива
+func New(dsn string) (Sink, error) {
+	switch {
+	case dsn == "stdout":
+		return NewStdoutSink(), nil
+	case strings.HasPrefix(dsn, "file:"):
+		return NewFileSink(strings.TrimPrefix(dsn, "file:"))
+	case strings.HasPrefix(dsn, "http://"), strings.HasPrefix(dsn, "https://"):
+		return NewHTTPSink(dsn), nil
+	default:
+		return nil, fmt.Errorf("unknown event sink %q", dsn)
+	}
+}
+
+// Parse создает sinks по списку, разделенному запятыми
+func Parse(dsns string) ([]Sink, error) {
+	var sinks []Sink
+	for _, dsn := range strings.Split(dsns, ",") {
+		dsn = strings.TrimSpace(dsn)
+		if dsn == "" {
+			continue
+		}
+		sink, err := New(dsn)
+		if err != nil {
+			for _, s := range sinks {
+				_ = s.Close()
+			}
+			return nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+	return sinks, nil
+}
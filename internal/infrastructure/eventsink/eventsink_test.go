@@ -0,0 +1,95 @@
+package eventsink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zaz600/go-musthave-diploma/internal/entity"
+)
+
+func newEvents(t *testing.T) []entity.Event {
+	t.Helper()
+	event1, err := entity.NewEvent(entity.EventUserRegistered, "uid1", entity.UserEventPayload{Login: "foo"})
+	require.NoError(t, err)
+	event2, err := entity.NewEvent(entity.EventOrderUploaded, "uid1", entity.OrderEventPayload{OrderID: "1", Status: entity.OrderStatusNew})
+	require.NoError(t, err)
+	return []entity.Event{event1, event2}
+}
+
+func TestNew(t *testing.T) {
+	sink, err := New("stdout")
+	require.NoError(t, err)
+	assert.Equal(t, "stdout", sink.Name())
+
+	sink, err = New("https://crm.local/events")
+	require.NoError(t, err)
+	assert.Equal(t, "http:https://crm.local/events", sink.Name())
+
+	_, err = New("kafka://localhost")
+	assert.Error(t, err)
+
+	sinks, err := Parse("stdout, https://crm.local/events,")
+	require.NoError(t, err)
+	assert.Len(t, sinks, 2)
+}
+
+func TestJSONLSink_Publish(t *testing.T) {
+	buf := &bytes.Buffer{}
+	sink := NewWriterSink("buf", buf)
+	events := newEvents(t)
+
+	require.NoError(t, sink.Publish(context.Background(), events))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	var actual entity.Event
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &actual))
+	assert.Equal(t, events[1].EventID, actual.EventID)
+	assert.Equal(t, entity.EventOrderUploaded, actual.Type)
+	assert.Equal(t, "uid1", actual.UID)
+}
+
+func TestFileSink_Publish(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	sink, err := New("file:" + path)
+	require.NoError(t, err)
+
+	require.NoError(t, sink.Publish(context.Background(), newEvents(t)))
+	require.NoError(t, sink.Publish(context.Background(), newEvents(t)[:1]))
+	require.NoError(t, sink.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Len(t, strings.Split(strings.TrimSpace(string(data)), "\n"), 3)
+}
+
+func TestHTTPSink_Publish(t *testing.T) {
+	var received []entity.Event
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var events []entity.Event
+		_ = json.NewDecoder(r.Body).Decode(&events)
+		if status == http.StatusOK {
+			received = append(received, events...)
+		}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	sink := NewHTTPSink(server.URL)
+	require.NoError(t, sink.Publish(context.Background(), newEvents(t)))
+	assert.Len(t, received, 2)
+
+	status = http.StatusServiceUnavailable
+	assert.Error(t, sink.Publish(context.Background(), newEvents(t)))
+	assert.Len(t, received, 2)
+}
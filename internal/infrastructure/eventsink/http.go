@@ -0,0 +1,58 @@
+package eventsink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/zaz600/go-musthave-diploma/internal/entity"
+)
+
+const httpSinkTimeout = 10 * time.Second
+
+// HTTPSink отправляет пачку событий POST запросом в виде JSON массива.
+// Пачка считается доставленной, если получатель ответил 2xx
+type HTTPSink struct {
+	url    string
+	client *http.Client
+}
+
+func (s HTTPSink) Name() string {
+	return "http:" + s.url
+}
+
+func (s HTTPSink) Publish(ctx context.Context, events []entity.Event) error {
+	body, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("event sink %s: unexpected status code %d", s.url, resp.StatusCode)
+	}
+	return nil
+}
+
+func (s HTTPSink) Close() error {
+	return nil
+}
+
+func NewHTTPSink(url string) *HTTPSink {
+	return &HTTPSink{url: url, client: &http.Client{Timeout: httpSinkTimeout}}
+}
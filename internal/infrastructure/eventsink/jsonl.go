@@ -0,0 +1,68 @@
+package eventsink
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/zaz600/go-musthave-diploma/internal/entity"
+)
+
+// JSONLSink пишет события в формате JSON Lines
+type JSONLSink struct {
+	mu   sync.Mutex
+	name string
+	w    io.Writer
+	// file заполнен, если sink пишет в файл, который надо синхронизировать и закрывать
+	file *os.File
+}
+
+func (s *JSONLSink) Name() string {
+	return s.name
+}
+
+func (s *JSONLSink) Publish(_ context.Context, events []entity.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	buf := bufio.NewWriter(s.w)
+	enc := json.NewEncoder(buf)
+	for _, event := range events {
+		if err := enc.Encode(event); err != nil {
+			return err
+		}
+	}
+	if err := buf.Flush(); err != nil {
+		return err
+	}
+	if s.file != nil {
+		return s.file.Sync()
+	}
+	return nil
+}
+
+func (s *JSONLSink) Close() error {
+	if s.file != nil {
+		return s.file.Close()
+	}
+	return nil
+}
+
+func NewStdoutSink() *JSONLSink {
+	return &JSONLSink{name: "stdout", w: os.Stdout}
+}
+
+func NewWriterSink(name string, w io.Writer) *JSONLSink {
+	return &JSONLSink{name: name, w: w}
+}
+
+func NewFileSink(path string) (*JSONLSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONLSink{name: "file:" + path, w: file, file: file}, nil
+}
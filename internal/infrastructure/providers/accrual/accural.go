@@ -8,11 +8,24 @@ import (
 
 	"github.com/rs/zerolog/log"
 	Accrual "github.com/zaz600/go-musthave-diploma/api/accrual"
-	"go.uber.org/ratelimit"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/metrics"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// defaultRateLimit запросов в минуту к системе расчета начислений
+const defaultRateLimit = 1000
+
+var tracer = otel.Tracer("github.com/zaz600/go-musthave-diploma/internal/infrastructure/providers/accrual")
+
 type Provider interface {
 	GetAccrual(ctx context.Context, orderID string) chan *GetAccrualResponse
+	// CircuitState состояние доступности системы расчета начислений
+	CircuitState() CircuitState
+	// SetRateLimit меняет число запросов в минуту к системе расчета начислений на работающем клиенте
+	SetRateLimit(perMinute int)
 }
 
 type GetAccrualResponse struct {
@@ -23,25 +36,87 @@ type GetAccrualResponse struct {
 
 type Client struct {
 	accrualAPIClient Accrual.ClientWithResponsesInterface
-	rateLimiter      ratelimit.Limiter
+	rateLimiter      *rateLimiter
+	metrics          *metrics.Metrics
+	breaker          *circuitBreaker
+}
+
+type Option func(*Client)
+
+// WithCircuitBreaker задает, после скольких ошибок подряд запросы перестают отправляться
+// и через сколько выполняется пробный запрос
+func WithCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(c *Client) {
+		c.breaker = newCircuitBreaker(threshold, cooldown)
+	}
+}
+
+// WithRateLimit задает, сколько запросов в минуту можно отправить в систему расчета начислений
+func WithRateLimit(perMinute int) Option {
+	return func(c *Client) {
+		c.rateLimiter.set(perMinute)
+	}
+}
+
+// WithMetrics включает сбор метрик запросов к системе расчета начислений
+func WithMetrics(m *metrics.Metrics) Option {
+	return func(c *Client) {
+		c.metrics = m
+	}
 }
 
 func (c Client) GetAccrual(ctx context.Context, orderID string) chan *GetAccrualResponse {
-	resultCh := make(chan *GetAccrualResponse)
+	// буфер, чтобы горутина завершилась, даже если результат уже не ждут
+	resultCh := make(chan *GetAccrualResponse, 1)
 	go func() {
-		c.rateLimiter.Take()
+		ctx, span := tracer.Start(ctx, "AccrualProvider.GetAccrual", trace.WithAttributes(attribute.String("order.id", orderID)))
+		defer span.End()
+
+		start := time.Now()
+		c.rateLimiter.take()
+		wait := time.Since(start)
+		c.metrics.ObserveAccrualRateLimitWait(wait)
+		span.AddEvent("rate limiter passed", trace.WithAttributes(attribute.String("wait", wait.String())))
+
 		resp := c.getAccrual(ctx, orderID)
+		if resp.Err != nil {
+			span.RecordError(resp.Err)
+			span.SetStatus(codes.Error, resp.Err.Error())
+		} else {
+			span.SetAttributes(attribute.String("accrual.status", string(resp.Status)))
+		}
 		resultCh <- resp
 		close(resultCh)
 	}()
 	return resultCh
 }
 
+func (c Client) CircuitState() CircuitState {
+	return c.breaker.State()
+}
+
+func (c Client) SetRateLimit(perMinute int) {
+	c.rateLimiter.set(perMinute)
+}
+
 func (c Client) getAccrual(ctx context.Context, orderID string) *GetAccrualResponse {
+	if ok, retryAfter := c.breaker.Allow(); !ok {
+		return &GetAccrualResponse{Err: &CircuitOpenError{RetryAfter: retryAfter}}
+	}
+
+	start := time.Now()
 	resp, err := c.accrualAPIClient.GetOrderAccrualWithResponse(ctx, Accrual.Order(orderID))
 	if err != nil {
+		c.metrics.ObserveAccrualRequest(0, time.Since(start))
+		c.breaker.Failure()
 		return &GetAccrualResponse{Err: err}
 	}
+	c.metrics.ObserveAccrualRequest(resp.StatusCode(), time.Since(start))
+	if resp.StatusCode() >= http.StatusInternalServerError {
+		c.breaker.Failure()
+	} else {
+		c.breaker.Success()
+	}
 
 	if resp.StatusCode() == http.StatusTooManyRequests {
 		retryAfter := resp.HTTPResponse.Header.Get("Retry-After")
@@ -56,8 +131,8 @@ func (c Client) getAccrual(ctx context.Context, orderID string) *GetAccrualRespo
 		return &GetAccrualResponse{Err: ErrWrongStatusCode}
 	}
 
-	log.Info().
-		Str("orderID", orderID).
+	// orderID уже есть в логгере обработки заказа
+	log.Ctx(ctx).Info().
 		Str("accrualStatus", string(resp.JSON200.Status)).
 		Float32("accrual", *resp.JSON200.Accrual).
 		Msg("get accrual result")
@@ -65,7 +140,14 @@ func (c Client) getAccrual(ctx context.Context, orderID string) *GetAccrualRespo
 	return &GetAccrualResponse{Accrual: resp.JSON200.Accrual, Status: resp.JSON200.Status}
 }
 
-func NewProvider(accrualAPIClient Accrual.ClientWithResponsesInterface) *Client {
-	rl := ratelimit.New(1000, ratelimit.Per(1*time.Minute)) // per second
-	return &Client{accrualAPIClient: accrualAPIClient, rateLimiter: rl}
+func NewProvider(accrualAPIClient Accrual.ClientWithResponsesInterface, opts ...Option) *Client {
+	c := &Client{
+		accrualAPIClient: accrualAPIClient,
+		rateLimiter:      newRateLimiter(defaultRateLimit),
+		breaker:          newCircuitBreaker(circuitDefaultThreshold, circuitDefaultCooldown),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
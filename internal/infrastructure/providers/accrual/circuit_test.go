@@ -0,0 +1,64 @@
+package accrual
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	Accrual "github.com/zaz600/go-musthave-diploma/api/accrual"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Now()
+	b := newCircuitBreaker(2, time.Minute)
+	b.now = func() time.Time { return now }
+
+	ok, _ := b.Allow()
+	assert.True(t, ok)
+	b.Failure()
+	assert.Equal(t, CircuitClosed, b.State())
+	b.Failure()
+	assert.Equal(t, CircuitOpen, b.State())
+
+	ok, retryAfter := b.Allow()
+	assert.False(t, ok)
+	assert.Equal(t, time.Minute, retryAfter)
+
+	now = now.Add(time.Minute)
+	assert.Equal(t, CircuitHalfOpen, b.State())
+	ok, _ = b.Allow()
+	assert.True(t, ok)
+	ok, _ = b.Allow()
+	assert.False(t, ok, "only one probe request in half-open state")
+
+	b.Failure()
+	assert.Equal(t, CircuitOpen, b.State())
+
+	now = now.Add(time.Minute)
+	ok, _ = b.Allow()
+	assert.True(t, ok)
+	b.Success()
+	assert.Equal(t, CircuitClosed, b.State())
+}
+
+func TestClient_CircuitOpen(t *testing.T) {
+	accrualAPIClient := new(mockAccrualClient)
+	accrualAPIClient.On("GetOrderAccrualWithResponse", mock.Anything, Accrual.Order("1")).Return(&Accrual.GetOrderAccrualResponse{
+		HTTPResponse: &http.Response{StatusCode: http.StatusServiceUnavailable},
+	}, nil).Twice()
+
+	accrualProvider := NewProvider(accrualAPIClient, WithCircuitBreaker(2, time.Minute))
+	assert.ErrorIs(t, accrualProvider.getAccrual(context.TODO(), "1").Err, ErrWrongStatusCode)
+	assert.ErrorIs(t, accrualProvider.getAccrual(context.TODO(), "1").Err, ErrWrongStatusCode)
+	assert.Equal(t, CircuitOpen, accrualProvider.CircuitState())
+
+	result := accrualProvider.getAccrual(context.TODO(), "1")
+	var circuitErr *CircuitOpenError
+	assert.True(t, errors.As(result.Err, &circuitErr))
+	assert.ErrorIs(t, result.Err, ErrCircuitOpen)
+	accrualAPIClient.AssertExpectations(t)
+}
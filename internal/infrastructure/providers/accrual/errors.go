@@ -2,6 +2,7 @@ package accrual
 
 import (
 	"errors"
+	"time"
 )
 
 var ErrWrongStatusCode = errors.New("wrong http status code")
@@ -28,3 +29,18 @@ func NewTooManyRequestsError(retryAfterSec int) *TooManyRequestsError {
 		err:           ErrTooManyRedirects,
 	}
 }
+
+var ErrCircuitOpen = errors.New("accrual circuit is open")
+
+// CircuitOpenError запрос не выполнялся, потому что система расчета начислений недоступна
+type CircuitOpenError struct {
+	RetryAfter time.Duration
+}
+
+func (e CircuitOpenError) Error() string {
+	return ErrCircuitOpen.Error()
+}
+
+func (e CircuitOpenError) Unwrap() error {
+	return ErrCircuitOpen
+}
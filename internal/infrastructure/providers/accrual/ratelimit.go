@@ -0,0 +1,39 @@
+package accrual
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/ratelimit"
+)
+
+// rateLimiter ограничитель запросов, который можно перенастроить без перезапуска.
+// Запросы, уже ожидающие старый ограничитель, дожидаются его
+type rateLimiter struct {
+	mu        sync.RWMutex
+	limiter   ratelimit.Limiter
+	perMinute int
+}
+
+func newRateLimiter(perMinute int) *rateLimiter {
+	l := &rateLimiter{}
+	l.set(perMinute)
+	return l
+}
+
+func (l *rateLimiter) take() {
+	l.mu.RLock()
+	limiter := l.limiter
+	l.mu.RUnlock()
+	limiter.Take()
+}
+
+func (l *rateLimiter) set(perMinute int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.limiter != nil && l.perMinute == perMinute {
+		return
+	}
+	l.limiter = ratelimit.New(perMinute, ratelimit.Per(time.Minute))
+	l.perMinute = perMinute
+}
@@ -0,0 +1,24 @@
+package accrual
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter_Set(t *testing.T) {
+	l := newRateLimiter(60)
+	l.take()
+	start := time.Now()
+	l.set(60 * 600)
+	for i := 0; i < 10; i++ {
+		l.take()
+	}
+	// при 60 запросах в минуту 10 запросов заняли бы 10 секунд
+	assert.Less(t, int64(time.Since(start)), int64(time.Second))
+
+	previous := l.limiter
+	l.set(60 * 600)
+	assert.Same(t, previous, l.limiter, "same rate keeps limiter")
+}
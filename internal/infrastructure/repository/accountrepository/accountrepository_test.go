@@ -0,0 +1,42 @@
+package accountrepository_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/accountrepository"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/repositorytest"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/userrepository"
+)
+
+func TestAccountRepository(t *testing.T) {
+	t.Run("inmemory", func(t *testing.T) {
+		repositorytest.AccountRepository(t, func(t *testing.T) (accountrepository.AccountRepository, userrepository.UserRepository) {
+			return accountrepository.NewInmemoryAccountRepository(), userrepository.NewInmemoryUserRepository()
+		})
+	})
+	t.Run("journaled", func(t *testing.T) {
+		repositorytest.AccountRepository(t, func(t *testing.T) (accountrepository.AccountRepository, userrepository.UserRepository) {
+			repo := accountrepository.NewInmemoryAccountRepository()
+			repositorytest.Journaled(t, repo)
+			return repo, userrepository.NewInmemoryUserRepository()
+		})
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		repositorytest.AccountRepository(t, func(t *testing.T) (accountrepository.AccountRepository, userrepository.UserRepository) {
+			db := repositorytest.SQLite(t)
+			repo, err := accountrepository.NewSQLiteAccountRepository(db)
+			require.NoError(t, err)
+			users, err := userrepository.NewSQLiteUserRepository(db)
+			require.NoError(t, err)
+			return repo, users
+		})
+	})
+	t.Run("postgres", func(t *testing.T) {
+		db := repositorytest.Postgres(t)
+		repositorytest.AccountRepository(t, func(t *testing.T) (accountrepository.AccountRepository, userrepository.UserRepository) {
+			repositorytest.Truncate(t, db)
+			return accountrepository.NewPgAccountRepository(db), userrepository.NewPgUserRepository(db)
+		})
+	})
+}
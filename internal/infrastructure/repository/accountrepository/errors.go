@@ -0,0 +1,13 @@
+package accountrepository
+
+import "errors"
+
+var (
+	ErrAccountExists       = errors.New("account already exists")
+	ErrUserAccountNotFound = errors.New("user account not found")
+	ErrInvalidAmount       = errors.New("invalid amount, must be positive")
+	// ErrInsufficientBalance списание сделало бы баланс отрицательным
+	ErrInsufficientBalance = errors.New("insufficient balance")
+	// ErrUserNotFound счет нельзя открыть без пользователя, которому он принадлежит
+	ErrUserNotFound = errors.New("account owner not found")
+)
@@ -2,20 +2,22 @@ package accountrepository
 
 import (
 	"context"
-	"errors"
+	"encoding/json"
 	"fmt"
 	"sync"
 
 	"github.com/zaz600/go-musthave-diploma/internal/entity"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/memorystore"
 )
 
-var ErrAccountExists = errors.New("account already exists")
-var ErrUserAccountNotFound = errors.New("user account not found")
+// opPutAccount в журнал пишется счет после изменения
+const opPutAccount = "put"
 
 type InmemoryAccountRepository struct {
 	mu           *sync.RWMutex
 	db           map[string]entity.Account
 	userAccounts map[string]entity.Account
+	journal      *memorystore.Journal
 }
 
 func (r InmemoryAccountRepository) GetAccount(_ context.Context, userID string) (entity.Account, error) {
@@ -28,57 +30,137 @@ func (r InmemoryAccountRepository) GetAccount(_ context.Context, userID string)
 	return entity.Account{}, ErrUserAccountNotFound
 }
 
-func (r InmemoryAccountRepository) AddAccount(_ context.Context, account entity.Account) error {
+func (r InmemoryAccountRepository) AddAccount(ctx context.Context, account entity.Account) error {
+	defer r.journal.Lock(ctx)()
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	if _, ok := r.db[account.AccountID]; ok {
 		return ErrAccountExists
 	}
-	r.db[account.AccountID] = account
-	r.userAccounts[account.UID] = account
-	return nil
+	if _, ok := r.userAccounts[account.UID]; ok {
+		return ErrAccountExists
+	}
+	return r.put(ctx, account)
 }
 
-func (r InmemoryAccountRepository) RefillAmount(_ context.Context, userID string, diff float32) error {
+func (r InmemoryAccountRepository) RefillAmount(ctx context.Context, userID string, diff float32) error {
 	if diff <= 0 {
-		return fmt.Errorf("invalid refill amount. Must be >= 0")
+		return ErrInvalidAmount
 	}
 
+	defer r.journal.Lock(ctx)()
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if account, ok := r.userAccounts[userID]; ok {
-		account.Balance += diff
-		r.db[account.AccountID] = account
-		r.userAccounts[account.UID] = account
-		return nil
+	account, ok := r.userAccounts[userID]
+	if !ok {
+		return ErrUserAccountNotFound
 	}
-	return ErrUserAccountNotFound
+	account.Balance += diff
+	return r.put(ctx, account)
 }
 
-func (r InmemoryAccountRepository) WithdrawalAmount(_ context.Context, userID string, diff float32) error {
+func (r InmemoryAccountRepository) WithdrawalAmount(ctx context.Context, userID string, diff float32) error {
 	if diff <= 0 {
-		return fmt.Errorf("invalid withdrawal amount. Must be >= 0")
+		return ErrInvalidAmount
 	}
 
+	defer r.journal.Lock(ctx)()
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if account, ok := r.userAccounts[userID]; ok {
-		account.Balance -= diff
-		account.Withdrawals += diff
-		r.db[account.AccountID] = account
-		r.userAccounts[account.UID] = account
-		return nil
+	account, ok := r.userAccounts[userID]
+	if !ok {
+		return ErrUserAccountNotFound
+	}
+	if account.Balance < diff {
+		return ErrInsufficientBalance
 	}
-	return ErrUserAccountNotFound
+	account.Balance -= diff
+	account.Withdrawals += diff
+	return r.put(ctx, account)
+}
+
+// put записывает счет в журнал и сохраняет его. При откате транзакции возвращается прежний счет
+func (r InmemoryAccountRepository) put(ctx context.Context, account entity.Account) error {
+	if err := r.journal.Append(ctx, opPutAccount, account); err != nil {
+		return err
+	}
+	prev, existed := r.db[account.AccountID]
+	r.set(account)
+	memorystore.OnRollback(ctx, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if existed {
+			r.set(prev)
+			return
+		}
+		delete(r.db, account.AccountID)
+		delete(r.userAccounts, account.UID)
+	})
+	return nil
+}
+
+func (r InmemoryAccountRepository) set(account entity.Account) {
+	r.db[account.AccountID] = account
+	r.userAccounts[account.UID] = account
 }
 
 func (r InmemoryAccountRepository) Close() error {
 	return nil
 }
 
+func (r *InmemoryAccountRepository) SetJournal(j *memorystore.Journal) {
+	r.journal = j
+}
+
+func (r InmemoryAccountRepository) Snapshot() (interface{}, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	accounts := make([]entity.Account, 0, len(r.db))
+	for _, account := range r.db {
+		accounts = append(accounts, account)
+	}
+	return accounts, nil
+}
+
+func (r InmemoryAccountRepository) Restore(state json.RawMessage) error {
+	var accounts []entity.Account
+	if err := json.Unmarshal(state, &accounts); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for key := range r.db {
+		delete(r.db, key)
+	}
+	for key := range r.userAccounts {
+		delete(r.userAccounts, key)
+	}
+	for _, account := range accounts {
+		r.set(account)
+	}
+	return nil
+}
+
+func (r InmemoryAccountRepository) Apply(op string, data json.RawMessage) error {
+	if op != opPutAccount {
+		return fmt.Errorf("%w: %s", memorystore.ErrUnknownOp, op)
+	}
+	var account entity.Account
+	if err := json.Unmarshal(data, &account); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.set(account)
+	return nil
+}
+
 func NewInmemoryAccountRepository() *InmemoryAccountRepository {
 	return &InmemoryAccountRepository{
 		mu:           &sync.RWMutex{},
@@ -2,15 +2,17 @@ package accountrepository
 
 import (
 	"context"
-	"database/sql"
-	"fmt"
+	"errors"
 
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
 	"github.com/zaz600/go-musthave-diploma/internal/entity"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/pgdb"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/transaction"
 )
 
 type PgAccountRepository struct {
-	db         *sql.DB
-	statements map[queryType]*sql.Stmt
+	db *pgdb.Pool
 }
 
 type queryType string
@@ -29,19 +31,18 @@ var queries = map[queryType]string{
 	queryWithdrawalAccount: "update gophermart.accounts set balance=balance-$1, withdrawals=withdrawals+$1 where uid=$2",
 }
 
-func (p PgAccountRepository) AddAccount(ctx context.Context, account entity.Account) (err error) {
-	tx, err := p.db.Begin()
+func (p PgAccountRepository) AddAccount(ctx context.Context, account entity.Account) error {
+	_, err := transaction.PgQuerier(ctx, p.db).Exec(ctx, queries[queryInsertAccount], account.UID, account.AccountID, account.Balance, account.Withdrawals)
 	if err != nil {
-		return err
-	}
-	defer tx.Rollback() //nolint:errcheck
-
-	stmt := tx.Stmt(p.statements[queryInsertAccount])
-	_, err = stmt.ExecContext(ctx, account.UID, account.AccountID, account.Balance, account.Withdrawals)
-	if err != nil {
-		return err
-	}
-	if err = tx.Commit(); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			switch pgErr.Code {
+			case pgerrcode.UniqueViolation:
+				return ErrAccountExists
+			case pgerrcode.ForeignKeyViolation:
+				return ErrUserNotFound
+			}
+		}
 		return err
 	}
 	return nil
@@ -49,7 +50,8 @@ func (p PgAccountRepository) AddAccount(ctx context.Context, account entity.Acco
 
 func (p PgAccountRepository) GetAccount(ctx context.Context, userID string) (entity.Account, error) {
 	var account entity.Account
-	err := p.statements[querySelectAccount].QueryRowContext(ctx, userID).Scan(&account.UID, &account.AccountID, &account.Balance, &account.Withdrawals)
+	err := transaction.PgQuerier(ctx, p.db).QueryRow(ctx, queries[querySelectAccount], userID).
+		Scan(&account.UID, &account.AccountID, &account.Balance, &account.Withdrawals)
 	if err != nil {
 		return entity.Account{}, ErrUserAccountNotFound
 	}
@@ -57,76 +59,42 @@ func (p PgAccountRepository) GetAccount(ctx context.Context, userID string) (ent
 }
 
 func (p PgAccountRepository) RefillAmount(ctx context.Context, userID string, amount float32) error {
-	tx, err := p.db.Begin()
-	if err != nil {
-		return err
+	if amount <= 0 {
+		return ErrInvalidAmount
 	}
-	defer tx.Rollback() //nolint:errcheck
-
-	stmt := tx.Stmt(p.statements[queryRefillAccount])
-	result, err := stmt.ExecContext(ctx, amount, userID)
+	result, err := transaction.PgQuerier(ctx, p.db).Exec(ctx, queries[queryRefillAccount], amount, userID)
 	if err != nil {
 		return err
 	}
-	affected, err := result.RowsAffected()
-	if err != nil {
-		return err
-	}
-	if affected == 0 {
+	if result.RowsAffected() == 0 {
 		return ErrUserAccountNotFound
 	}
-	if err = tx.Commit(); err != nil {
-		return err
-	}
 	return nil
 }
 
 func (p PgAccountRepository) WithdrawalAmount(ctx context.Context, userID string, amount float32) error {
-	tx, err := p.db.Begin()
-	if err != nil {
-		return err
+	if amount <= 0 {
+		return ErrInvalidAmount
 	}
-	defer tx.Rollback() //nolint:errcheck
-	stmt := tx.Stmt(p.statements[queryWithdrawalAccount])
-	result, err := stmt.ExecContext(ctx, amount, userID)
-	if err != nil {
-		return err
-	}
-	affected, err := result.RowsAffected()
+	result, err := transaction.PgQuerier(ctx, p.db).Exec(ctx, queries[queryWithdrawalAccount], amount, userID)
 	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.CheckViolation {
+			return ErrInsufficientBalance
+		}
 		return err
 	}
-	if affected == 0 {
+	if result.RowsAffected() == 0 {
 		return ErrUserAccountNotFound
 	}
-	if err = tx.Commit(); err != nil {
-		return err
-	}
 	return nil
 }
 
+// Close ничего не делает: пул соединений общий для всех репозиториев
 func (p PgAccountRepository) Close() error {
-	for name, stmt := range p.statements {
-		err := stmt.Close()
-		if err != nil {
-			return fmt.Errorf("error close stmt %s: %w", name, err)
-		}
-	}
 	return nil
 }
 
-func NewPgAccountRepository(db *sql.DB) (*PgAccountRepository, error) {
-	statements := make(map[queryType]*sql.Stmt, len(queries))
-	for name, query := range queries {
-		stmt, err := db.Prepare(query)
-		if err != nil {
-			return nil, fmt.Errorf("error prepare statement for %s: %w", name, err)
-		}
-		statements[name] = stmt
-	}
-
-	return &PgAccountRepository{
-		db:         db,
-		statements: statements,
-	}, nil
+func NewPgAccountRepository(db *pgdb.Pool) *PgAccountRepository {
+	return &PgAccountRepository{db: db}
 }
@@ -0,0 +1,105 @@
+package accountrepository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/zaz600/go-musthave-diploma/internal/entity"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/sqlitedb"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/transaction"
+)
+
+type SQLiteAccountRepository struct {
+	db         *sql.DB
+	statements map[queryType]*sql.Stmt
+}
+
+var sqliteQueries = map[queryType]string{
+	queryInsertAccount:     "insert into accounts(uid, account_id, balance, withdrawals) values($1, $2, $3, $4)",
+	querySelectAccount:     "select uid, account_id, balance, withdrawals from accounts where uid=$1",
+	queryRefillAccount:     "update accounts set balance=balance+$1 where uid=$2",
+	queryWithdrawalAccount: "update accounts set balance=balance-$1, withdrawals=withdrawals+$1 where uid=$2",
+}
+
+func (p SQLiteAccountRepository) AddAccount(ctx context.Context, account entity.Account) error {
+	err := p.exec(ctx, queryInsertAccount, account.UID, account.AccountID, account.Balance, account.Withdrawals)
+	switch {
+	case sqlitedb.IsUniqueViolation(err):
+		return ErrAccountExists
+	case sqlitedb.IsForeignKeyViolation(err):
+		return ErrUserNotFound
+	}
+	return err
+}
+
+func (p SQLiteAccountRepository) GetAccount(ctx context.Context, userID string) (entity.Account, error) {
+	var account entity.Account
+	err := p.statements[querySelectAccount].QueryRowContext(ctx, userID).Scan(&account.UID, &account.AccountID, &account.Balance, &account.Withdrawals)
+	if err != nil {
+		return entity.Account{}, ErrUserAccountNotFound
+	}
+	return account, nil
+}
+
+func (p SQLiteAccountRepository) RefillAmount(ctx context.Context, userID string, amount float32) error {
+	if amount <= 0 {
+		return ErrInvalidAmount
+	}
+	return p.exec(ctx, queryRefillAccount, amount, userID)
+}
+
+func (p SQLiteAccountRepository) WithdrawalAmount(ctx context.Context, userID string, amount float32) error {
+	if amount <= 0 {
+		return ErrInvalidAmount
+	}
+	err := p.exec(ctx, queryWithdrawalAccount, amount, userID)
+	if sqlitedb.IsCheckViolation(err) {
+		return ErrInsufficientBalance
+	}
+	return err
+}
+
+// exec выполняет запрос, изменяющий один счет
+func (p SQLiteAccountRepository) exec(ctx context.Context, query queryType, args ...interface{}) error {
+	tx, err := transaction.Begin(ctx, p.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+	result, err := tx.Stmt(p.statements[query]).ExecContext(ctx, args...)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrUserAccountNotFound
+	}
+	return tx.Commit()
+}
+
+func (p SQLiteAccountRepository) Close() error {
+	for name, stmt := range p.statements {
+		err := stmt.Close()
+		if err != nil {
+			return fmt.Errorf("error close stmt %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func NewSQLiteAccountRepository(db *sql.DB) (*SQLiteAccountRepository, error) {
+	statements := make(map[queryType]*sql.Stmt, len(sqliteQueries))
+	for name, query := range sqliteQueries {
+		stmt, err := db.Prepare(query)
+		if err != nil {
+			return nil, fmt.Errorf("error prepare statement for %s: %w", name, err)
+		}
+		statements[name] = stmt
+	}
+
+	return &SQLiteAccountRepository{db: db, statements: statements}, nil
+}
@@ -0,0 +1,5 @@
+package eventrepository
+
+import "errors"
+
+var ErrEventExists = errors.New("event already exists")
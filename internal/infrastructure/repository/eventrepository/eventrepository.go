@@ -0,0 +1,26 @@
+package eventrepository
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/zaz600/go-musthave-diploma/internal/entity"
+)
+
+// EventRepository outbox доменных событий
+type EventRepository interface {
+	AddEvent(ctx context.Context, event entity.Event) error
+	// GetUnpublishedEvents возвращает события, еще не опубликованные в sink, в порядке их появления
+	GetUnpublishedEvents(ctx context.Context, sink string, limit int) ([]entity.Event, error)
+	MarkPublished(ctx context.Context, sink string, seqs []int64) error
+	// DelPublishedEventsCreatedBefore удаляет не больше limit событий, созданных до before
+	// и опубликованных во все sinks, вместе с отметками о публикации. Возвращает число удаленных событий
+	DelPublishedEventsCreatedBefore(ctx context.Context, sinks []string, before time.Time, limit int) (int, error)
+	// GetUserEvents возвращает события пользователя указанных типов в порядке их появления
+	GetUserEvents(ctx context.Context, uid string, types []entity.EventType) ([]entity.Event, error)
+	// RedactUserEvents заменяет данные событий пользователя типа eventType на payload
+	RedactUserEvents(ctx context.Context, uid string, eventType entity.EventType, payload json.RawMessage) error
+	io.Closer
+}
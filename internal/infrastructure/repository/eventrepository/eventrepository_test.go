@@ -0,0 +1,38 @@
+package eventrepository_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/eventrepository"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/repositorytest"
+)
+
+func TestEventRepository(t *testing.T) {
+	t.Run("inmemory", func(t *testing.T) {
+		repositorytest.EventRepository(t, func(t *testing.T) eventrepository.EventRepository {
+			return eventrepository.NewInmemoryEventRepository()
+		})
+	})
+	t.Run("journaled", func(t *testing.T) {
+		repositorytest.EventRepository(t, func(t *testing.T) eventrepository.EventRepository {
+			repo := eventrepository.NewInmemoryEventRepository()
+			repositorytest.Journaled(t, repo)
+			return repo
+		})
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		repositorytest.EventRepository(t, func(t *testing.T) eventrepository.EventRepository {
+			repo, err := eventrepository.NewSQLiteEventRepository(repositorytest.SQLite(t))
+			require.NoError(t, err)
+			return repo
+		})
+	})
+	t.Run("postgres", func(t *testing.T) {
+		db := repositorytest.Postgres(t)
+		repositorytest.EventRepository(t, func(t *testing.T) eventrepository.EventRepository {
+			repositorytest.Truncate(t, db)
+			return eventrepository.NewPgEventRepository(db)
+		})
+	})
+}
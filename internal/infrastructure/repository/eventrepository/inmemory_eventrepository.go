@@ -0,0 +1,378 @@
+package eventrepository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/zaz600/go-musthave-diploma/internal/entity"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/memorystore"
+)
+
+const (
+	opAddEvent      = "add"
+	opMarkPublished = "published"
+	opRedactEvents  = "redact"
+	opPurgeEvents   = "purge"
+)
+
+// storedEvent событие вместе с порядковым номером, который не попадает в JSON события
+type storedEvent struct {
+	Seq   int64
+	Event entity.Event
+}
+
+// publication данные записи журнала об опубликованных событиях
+type publication struct {
+	Sink string
+	Seqs []int64
+}
+
+// redaction данные записи журнала о замене данных событий пользователя
+type redaction struct {
+	UID     string
+	Type    entity.EventType
+	Payload json.RawMessage
+}
+
+// eventState снимок репозитория
+type eventState struct {
+	Seq       int64
+	Events    []storedEvent
+	Published map[string][]int64
+}
+
+type InmemoryEventRepository struct {
+	mu       sync.RWMutex
+	seq      int64
+	events   []entity.Event
+	eventIDs map[string]struct{}
+	// published номера опубликованных событий по каждому sink
+	published map[string]map[int64]struct{}
+	journal   *memorystore.Journal
+}
+
+func (r *InmemoryEventRepository) AddEvent(ctx context.Context, event entity.Event) error {
+	defer r.journal.Lock(ctx)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.eventIDs[event.EventID]; ok {
+		return ErrEventExists
+	}
+	event.Seq = r.seq + 1
+	if err := r.journal.Append(ctx, opAddEvent, storedEvent{Seq: event.Seq, Event: event}); err != nil {
+		return err
+	}
+	r.add(event)
+	memorystore.OnRollback(ctx, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.purge([]int64{event.Seq})
+	})
+	return nil
+}
+
+func (r *InmemoryEventRepository) add(event entity.Event) {
+	r.seq = event.Seq
+	r.events = append(r.events, event)
+	r.eventIDs[event.EventID] = struct{}{}
+}
+
+func (r *InmemoryEventRepository) GetUnpublishedEvents(_ context.Context, sink string, limit int) ([]entity.Event, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var events []entity.Event
+	for _, event := range r.events {
+		if len(events) >= limit {
+			break
+		}
+		if _, ok := r.published[sink][event.Seq]; !ok {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func (r *InmemoryEventRepository) MarkPublished(ctx context.Context, sink string, seqs []int64) error {
+	defer r.journal.Lock(ctx)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.journal.Append(ctx, opMarkPublished, publication{Sink: sink, Seqs: seqs}); err != nil {
+		return err
+	}
+	var marked []int64
+	for _, seq := range seqs {
+		if _, ok := r.published[sink][seq]; !ok {
+			marked = append(marked, seq)
+		}
+	}
+	r.markPublished(sink, seqs)
+	memorystore.OnRollback(ctx, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		for _, seq := range marked {
+			delete(r.published[sink], seq)
+		}
+	})
+	return nil
+}
+
+func (r *InmemoryEventRepository) markPublished(sink string, seqs []int64) {
+	published, ok := r.published[sink]
+	if !ok {
+		published = make(map[int64]struct{}, len(seqs))
+		r.published[sink] = published
+	}
+	for _, seq := range seqs {
+		published[seq] = struct{}{}
+	}
+}
+
+func (r *InmemoryEventRepository) DelPublishedEventsCreatedBefore(ctx context.Context, sinks []string, before time.Time, limit int) (int, error) {
+	defer r.journal.Lock(ctx)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var seqs []int64
+	for _, event := range r.events {
+		if len(seqs) == limit {
+			break
+		}
+		if event.CreatedAt.Before(before) && r.publishedToAll(sinks, event.Seq) {
+			seqs = append(seqs, event.Seq)
+		}
+	}
+	if len(seqs) == 0 {
+		return 0, nil
+	}
+	if err := r.journal.Append(ctx, opPurgeEvents, seqs); err != nil {
+		return 0, err
+	}
+	purged := make(map[int64]struct{}, len(seqs))
+	for _, seq := range seqs {
+		purged[seq] = struct{}{}
+	}
+	var events []entity.Event
+	for _, event := range r.events {
+		if _, ok := purged[event.Seq]; ok {
+			events = append(events, event)
+		}
+	}
+	r.purge(seqs)
+	memorystore.OnRollback(ctx, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.restore(sinks, events)
+	})
+	return len(seqs), nil
+}
+
+func (r *InmemoryEventRepository) publishedToAll(sinks []string, seq int64) bool {
+	for _, sink := range sinks {
+		if _, ok := r.published[sink][seq]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *InmemoryEventRepository) purge(seqs []int64) {
+	purged := make(map[int64]struct{}, len(seqs))
+	for _, seq := range seqs {
+		purged[seq] = struct{}{}
+		for _, published := range r.published {
+			delete(published, seq)
+		}
+	}
+	events := r.events[:0]
+	for _, event := range r.events {
+		if _, ok := purged[event.Seq]; ok {
+			delete(r.eventIDs, event.EventID)
+			continue
+		}
+		events = append(events, event)
+	}
+	r.events = events
+}
+
+// restore возвращает удаленные опубликованные во все sinks события на их место по порядковому номеру
+func (r *InmemoryEventRepository) restore(sinks []string, events []entity.Event) {
+	for _, event := range events {
+		r.events = append(r.events, event)
+		r.eventIDs[event.EventID] = struct{}{}
+	}
+	for _, sink := range sinks {
+		r.markPublished(sink, seqsOf(events))
+	}
+	sort.Slice(r.events, func(i, j int) bool {
+		return r.events[i].Seq < r.events[j].Seq
+	})
+}
+
+func seqsOf(events []entity.Event) []int64 {
+	seqs := make([]int64, 0, len(events))
+	for _, event := range events {
+		seqs = append(seqs, event.Seq)
+	}
+	return seqs
+}
+
+func (r *InmemoryEventRepository) GetUserEvents(_ context.Context, uid string, types []entity.EventType) ([]entity.Event, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var events []entity.Event
+	for _, event := range r.events {
+		if event.UID == uid && hasType(types, event.Type) {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func (r *InmemoryEventRepository) RedactUserEvents(ctx context.Context, uid string, eventType entity.EventType, payload json.RawMessage) error {
+	defer r.journal.Lock(ctx)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.journal.Append(ctx, opRedactEvents, redaction{UID: uid, Type: eventType, Payload: payload}); err != nil {
+		return err
+	}
+	payloads := make(map[int64]json.RawMessage)
+	for _, event := range r.events {
+		if event.UID == uid && event.Type == eventType {
+			payloads[event.Seq] = event.Payload
+		}
+	}
+	r.redact(uid, eventType, payload)
+	memorystore.OnRollback(ctx, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		for i, event := range r.events {
+			if p, ok := payloads[event.Seq]; ok {
+				r.events[i].Payload = p
+			}
+		}
+	})
+	return nil
+}
+
+func (r *InmemoryEventRepository) redact(uid string, eventType entity.EventType, payload json.RawMessage) {
+	for i, event := range r.events {
+		if event.UID == uid && event.Type == eventType {
+			r.events[i].Payload = payload
+		}
+	}
+}
+
+func hasType(types []entity.EventType, eventType entity.EventType) bool {
+	for _, t := range types {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *InmemoryEventRepository) Close() error {
+	return nil
+}
+
+func (r *InmemoryEventRepository) SetJournal(j *memorystore.Journal) {
+	r.journal = j
+}
+
+func (r *InmemoryEventRepository) Snapshot() (interface{}, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	state := eventState{
+		Seq:       r.seq,
+		Events:    make([]storedEvent, 0, len(r.events)),
+		Published: make(map[string][]int64, len(r.published)),
+	}
+	for _, event := range r.events {
+		state.Events = append(state.Events, storedEvent{Seq: event.Seq, Event: event})
+	}
+	for sink, published := range r.published {
+		seqs := make([]int64, 0, len(published))
+		for seq := range published {
+			seqs = append(seqs, seq)
+		}
+		state.Published[sink] = seqs
+	}
+	return state, nil
+}
+
+func (r *InmemoryEventRepository) Restore(data json.RawMessage) error {
+	var state eventState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = make([]entity.Event, 0, len(state.Events))
+	r.eventIDs = make(map[string]struct{}, len(state.Events))
+	r.published = make(map[string]map[int64]struct{}, len(state.Published))
+	for _, stored := range state.Events {
+		stored.Event.Seq = stored.Seq
+		r.add(stored.Event)
+	}
+	r.seq = state.Seq
+	for sink, seqs := range state.Published {
+		r.markPublished(sink, seqs)
+	}
+	return nil
+}
+
+func (r *InmemoryEventRepository) Apply(op string, data json.RawMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch op {
+	case opAddEvent:
+		var stored storedEvent
+		if err := json.Unmarshal(data, &stored); err != nil {
+			return err
+		}
+		stored.Event.Seq = stored.Seq
+		r.add(stored.Event)
+	case opMarkPublished:
+		var p publication
+		if err := json.Unmarshal(data, &p); err != nil {
+			return err
+		}
+		r.markPublished(p.Sink, p.Seqs)
+	case opPurgeEvents:
+		var seqs []int64
+		if err := json.Unmarshal(data, &seqs); err != nil {
+			return err
+		}
+		r.purge(seqs)
+	case opRedactEvents:
+		var rd redaction
+		if err := json.Unmarshal(data, &rd); err != nil {
+			return err
+		}
+		r.redact(rd.UID, rd.Type, rd.Payload)
+	default:
+		return fmt.Errorf("%w: %s", memorystore.ErrUnknownOp, op)
+	}
+	return nil
+}
+
+func NewInmemoryEventRepository() *InmemoryEventRepository {
+	return &InmemoryEventRepository{
+		mu:        sync.RWMutex{},
+		events:    make([]entity.Event, 0, 100),
+		eventIDs:  make(map[string]struct{}, 100),
+		published: make(map[string]map[int64]struct{}, 4),
+	}
+}
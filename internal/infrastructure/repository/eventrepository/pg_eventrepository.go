@@ -0,0 +1,120 @@
+package eventrepository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+	"github.com/zaz600/go-musthave-diploma/internal/entity"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/pgdb"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/transaction"
+)
+
+type PgEventRepository struct {
+	db *pgdb.Pool
+}
+
+type queryType string
+
+const (
+	queryAddEvent             queryType = "addEvent"
+	queryGetUnpublishedEvents queryType = "getUnpublishedEvents"
+	queryMarkPublished        queryType = "markPublished"
+	queryPurgeEvents          queryType = "purgeEvents"
+	queryGetUserEvents        queryType = "getUserEvents"
+	queryRedactUserEvents     queryType = "redactUserEvents"
+)
+
+var queries = map[queryType]string{
+	queryAddEvent: "insert into gophermart.events(event_id, type, uid, payload, created_at) values($1, $2, $3, $4, $5)",
+	queryGetUnpublishedEvents: "select e.seq, e.event_id, e.type, e.uid, e.payload, e.created_at from gophermart.events e " +
+		"where not exists (select 1 from gophermart.event_publications p where p.sink=$1 and p.seq=e.seq) " +
+		"order by e.seq limit $2",
+	queryMarkPublished: "insert into gophermart.event_publications(sink, seq) select $1, unnest($2::bigint[]) on conflict do nothing",
+	// отметки удаляются вместе с событиями, в том числе отметки sinks, которых уже нет в конфигурации
+	queryPurgeEvents: "with purged as (delete from gophermart.events where seq in (" +
+		"select e.seq from gophermart.events e where e.created_at < $2 and not exists (" +
+		"select 1 from unnest($1::text[]) s(sink) where not exists (" +
+		"select 1 from gophermart.event_publications p where p.sink=s.sink and p.seq=e.seq)) " +
+		"order by e.seq limit $3) returning seq), " +
+		"publications as (delete from gophermart.event_publications where seq in (select seq from purged)) " +
+		"select count(*) from purged",
+	queryGetUserEvents: "select seq, event_id, type, uid, payload, created_at from gophermart.events " +
+		"where uid=$1 and type = any($2::text[]) order by seq",
+	queryRedactUserEvents: "update gophermart.events set payload=$3 where uid=$1 and type=$2",
+}
+
+func (p PgEventRepository) AddEvent(ctx context.Context, event entity.Event) error {
+	_, err := transaction.PgQuerier(ctx, p.db).Exec(ctx, queries[queryAddEvent],
+		event.EventID, event.Type, event.UID, []byte(event.Payload), event.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return ErrEventExists
+		}
+		return err
+	}
+	return nil
+}
+
+func (p PgEventRepository) GetUnpublishedEvents(ctx context.Context, sink string, limit int) ([]entity.Event, error) {
+	return p.queryEvents(ctx, queries[queryGetUnpublishedEvents], sink, limit)
+}
+
+func (p PgEventRepository) GetUserEvents(ctx context.Context, uid string, types []entity.EventType) ([]entity.Event, error) {
+	names := make([]string, 0, len(types))
+	for _, t := range types {
+		names = append(names, string(t))
+	}
+	return p.queryEvents(ctx, queries[queryGetUserEvents], uid, names)
+}
+
+func (p PgEventRepository) queryEvents(ctx context.Context, query string, args ...interface{}) ([]entity.Event, error) {
+	rows, err := transaction.PgQuerier(ctx, p.db).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var events []entity.Event
+	for rows.Next() {
+		var event entity.Event
+		var payload []byte
+		if err := rows.Scan(&event.Seq, &event.EventID, &event.Type, &event.UID, &payload, &event.CreatedAt); err != nil {
+			return nil, err
+		}
+		event.Payload = payload
+		events = append(events, event)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return events, nil
+}
+
+func (p PgEventRepository) MarkPublished(ctx context.Context, sink string, seqs []int64) error {
+	_, err := transaction.PgQuerier(ctx, p.db).Exec(ctx, queries[queryMarkPublished], sink, seqs)
+	return err
+}
+
+func (p PgEventRepository) DelPublishedEventsCreatedBefore(ctx context.Context, sinks []string, before time.Time, limit int) (int, error) {
+	var purged int
+	err := transaction.PgQuerier(ctx, p.db).QueryRow(ctx, queries[queryPurgeEvents], sinks, before, limit).Scan(&purged)
+	return purged, err
+}
+
+func (p PgEventRepository) RedactUserEvents(ctx context.Context, uid string, eventType entity.EventType, payload json.RawMessage) error {
+	_, err := transaction.PgQuerier(ctx, p.db).Exec(ctx, queries[queryRedactUserEvents], uid, eventType, []byte(payload))
+	return err
+}
+
+// Close ничего не делает: пул соединений общий для всех репозиториев
+func (p PgEventRepository) Close() error {
+	return nil
+}
+
+func NewPgEventRepository(db *pgdb.Pool) *PgEventRepository {
+	return &PgEventRepository{db: db}
+}
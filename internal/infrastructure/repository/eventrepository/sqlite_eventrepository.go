@@ -0,0 +1,195 @@
+package eventrepository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/zaz600/go-musthave-diploma/internal/entity"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/sqlitedb"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/transaction"
+)
+
+type SQLiteEventRepository struct {
+	db         *sql.DB
+	statements map[queryType]*sql.Stmt
+}
+
+// sqliteQueries в SQLite нет массивов, номера событий передаются JSON-массивом.
+// where true нужен SQLite, чтобы отличить on conflict от условия соединения в insert ... select
+// в SQLite нельзя удалять в with, поэтому события для удаления сначала выбираются отдельным запросом
+const (
+	queryGetPurgeableEvents queryType = "getPurgeableEvents"
+	queryDelPublications    queryType = "delPublications"
+	queryDelEvents          queryType = "delEvents"
+)
+
+var sqliteQueries = map[queryType]string{
+	queryAddEvent: "insert into events(event_id, type, uid, payload, created_at) values($1, $2, $3, $4, $5)",
+	queryGetUnpublishedEvents: "select e.seq, e.event_id, e.type, e.uid, e.payload, e.created_at from events e " +
+		"where not exists (select 1 from event_publications p where p.sink=$1 and p.seq=e.seq) " +
+		"order by e.seq limit $2",
+	queryMarkPublished: "insert into event_publications(sink, seq) select $1, value from json_each($2) where true " +
+		"on conflict do nothing",
+	queryGetPurgeableEvents: "select e.seq from events e where e.created_at < $2 and not exists (" +
+		"select 1 from json_each($1) s where not exists (" +
+		"select 1 from event_publications p where p.sink=s.value and p.seq=e.seq)) " +
+		"order by e.seq limit $3",
+	queryDelPublications: "delete from event_publications where seq in (select value from json_each($1))",
+	queryDelEvents:       "delete from events where seq in (select value from json_each($1))",
+	queryGetUserEvents: "select seq, event_id, type, uid, payload, created_at from events " +
+		"where uid=$1 and type in (select value from json_each($2)) order by seq",
+	queryRedactUserEvents: "update events set payload=$3 where uid=$1 and type=$2",
+}
+
+func (p SQLiteEventRepository) AddEvent(ctx context.Context, event entity.Event) error {
+	tx, err := transaction.Begin(ctx, p.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+	stmt := tx.Stmt(p.statements[queryAddEvent])
+	_, err = stmt.ExecContext(ctx, event.EventID, event.Type, event.UID, string(event.Payload), event.CreatedAt.UTC())
+	if err != nil {
+		if sqlitedb.IsUniqueViolation(err) {
+			return ErrEventExists
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+func (p SQLiteEventRepository) GetUnpublishedEvents(ctx context.Context, sink string, limit int) ([]entity.Event, error) {
+	return p.queryEvents(ctx, queryGetUnpublishedEvents, sink, limit)
+}
+
+func (p SQLiteEventRepository) GetUserEvents(ctx context.Context, uid string, types []entity.EventType) ([]entity.Event, error) {
+	values, err := json.Marshal(types)
+	if err != nil {
+		return nil, err
+	}
+	return p.queryEvents(ctx, queryGetUserEvents, uid, string(values))
+}
+
+func (p SQLiteEventRepository) queryEvents(ctx context.Context, query queryType, args ...interface{}) ([]entity.Event, error) {
+	rows, err := p.statements[query].QueryContext(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var events []entity.Event
+	for rows.Next() {
+		var event entity.Event
+		var payload string
+		if err := rows.Scan(&event.Seq, &event.EventID, &event.Type, &event.UID, &payload, &event.CreatedAt); err != nil {
+			return nil, err
+		}
+		event.Payload = json.RawMessage(payload)
+		events = append(events, event)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return events, nil
+}
+
+func (p SQLiteEventRepository) MarkPublished(ctx context.Context, sink string, seqs []int64) error {
+	values, err := json.Marshal(seqs)
+	if err != nil {
+		return err
+	}
+	tx, err := transaction.Begin(ctx, p.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+	stmt := tx.Stmt(p.statements[queryMarkPublished])
+	if _, err = stmt.ExecContext(ctx, sink, string(values)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (p SQLiteEventRepository) DelPublishedEventsCreatedBefore(ctx context.Context, sinks []string, before time.Time, limit int) (int, error) {
+	names, err := json.Marshal(sinks)
+	if err != nil {
+		return 0, err
+	}
+	tx, err := transaction.Begin(ctx, p.db)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	rows, err := tx.Stmt(p.statements[queryGetPurgeableEvents]).QueryContext(ctx, string(names), before.UTC(), limit)
+	if err != nil {
+		return 0, err
+	}
+	var seqs []int64
+	for rows.Next() {
+		var seq int64
+		if err := rows.Scan(&seq); err != nil {
+			_ = rows.Close()
+			return 0, err
+		}
+		seqs = append(seqs, seq)
+	}
+	if err := rows.Close(); err != nil {
+		return 0, err
+	}
+	if rows.Err() != nil {
+		return 0, rows.Err()
+	}
+	if len(seqs) == 0 {
+		return 0, nil
+	}
+
+	values, err := json.Marshal(seqs)
+	if err != nil {
+		return 0, err
+	}
+	for _, query := range []queryType{queryDelPublications, queryDelEvents} {
+		if _, err := tx.Stmt(p.statements[query]).ExecContext(ctx, string(values)); err != nil {
+			return 0, err
+		}
+	}
+	return len(seqs), tx.Commit()
+}
+
+func (p SQLiteEventRepository) RedactUserEvents(ctx context.Context, uid string, eventType entity.EventType, payload json.RawMessage) error {
+	tx, err := transaction.Begin(ctx, p.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+	stmt := tx.Stmt(p.statements[queryRedactUserEvents])
+	if _, err = stmt.ExecContext(ctx, uid, eventType, string(payload)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (p SQLiteEventRepository) Close() error {
+	for name, stmt := range p.statements {
+		err := stmt.Close()
+		if err != nil {
+			return fmt.Errorf("error close stmt %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func NewSQLiteEventRepository(db *sql.DB) (*SQLiteEventRepository, error) {
+	statements := make(map[queryType]*sql.Stmt, len(sqliteQueries))
+	for name, query := range sqliteQueries {
+		stmt, err := db.Prepare(query)
+		if err != nil {
+			return nil, fmt.Errorf("error prepare statement for %s: %w", name, err)
+		}
+		statements[name] = stmt
+	}
+
+	return &SQLiteEventRepository{db: db, statements: statements}, nil
+}
@@ -0,0 +1,8 @@
+package idempotencyrepository
+
+import "errors"
+
+var (
+	ErrRecordExists   = errors.New("idempotency key already exists")
+	ErrRecordNotFound = errors.New("idempotency key not found")
+)
@@ -0,0 +1,23 @@
+package idempotencyrepository
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/zaz600/go-musthave-diploma/internal/entity"
+)
+
+// IdempotencyRepository хранит ключи идемпотентности. Ключ уникален в рамках пользователя
+type IdempotencyRepository interface {
+	AddRecord(ctx context.Context, record entity.IdempotencyRecord) error
+	GetRecord(ctx context.Context, uid string, key string) (entity.IdempotencyRecord, error)
+	// UpdateRecord сохраняет ответ на запрос
+	UpdateRecord(ctx context.Context, record entity.IdempotencyRecord) error
+	DelRecord(ctx context.Context, uid string, key string) error
+	// DelRecordsCreatedBefore удаляет не больше limit ключей, созданных раньше before, и возвращает, сколько удалено
+	DelRecordsCreatedBefore(ctx context.Context, before time.Time, limit int) (int, error)
+	// DelUserRecords удаляет все ключи пользователя и возвращает, сколько удалено
+	DelUserRecords(ctx context.Context, uid string) (int, error)
+	io.Closer
+}
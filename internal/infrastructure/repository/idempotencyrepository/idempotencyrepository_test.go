@@ -0,0 +1,38 @@
+package idempotencyrepository_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/idempotencyrepository"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/repositorytest"
+)
+
+func TestIdempotencyRepository(t *testing.T) {
+	t.Run("inmemory", func(t *testing.T) {
+		repositorytest.IdempotencyRepository(t, func(t *testing.T) idempotencyrepository.IdempotencyRepository {
+			return idempotencyrepository.NewInmemoryIdempotencyRepository()
+		})
+	})
+	t.Run("journaled", func(t *testing.T) {
+		repositorytest.IdempotencyRepository(t, func(t *testing.T) idempotencyrepository.IdempotencyRepository {
+			repo := idempotencyrepository.NewInmemoryIdempotencyRepository()
+			repositorytest.Journaled(t, repo)
+			return repo
+		})
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		repositorytest.IdempotencyRepository(t, func(t *testing.T) idempotencyrepository.IdempotencyRepository {
+			repo, err := idempotencyrepository.NewSQLiteIdempotencyRepository(repositorytest.SQLite(t))
+			require.NoError(t, err)
+			return repo
+		})
+	})
+	t.Run("postgres", func(t *testing.T) {
+		db := repositorytest.Postgres(t)
+		repositorytest.IdempotencyRepository(t, func(t *testing.T) idempotencyrepository.IdempotencyRepository {
+			repositorytest.Truncate(t, db)
+			return idempotencyrepository.NewPgIdempotencyRepository(db)
+		})
+	})
+}
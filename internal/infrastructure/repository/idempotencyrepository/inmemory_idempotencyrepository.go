@@ -0,0 +1,239 @@
+package idempotencyrepository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/zaz600/go-musthave-diploma/internal/entity"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/memorystore"
+)
+
+const (
+	opPutRecord = "put"
+	opDelRecord = "del"
+	opPurge     = "purge"
+)
+
+// recordID данные записи журнала об удалении записи
+type recordID struct {
+	UID string
+	Key string
+}
+
+type InmemoryIdempotencyRepository struct {
+	mu sync.RWMutex
+	// db ключ - uid + idempotency key
+	db      map[string]entity.IdempotencyRecord
+	journal *memorystore.Journal
+}
+
+func recordKey(uid string, key string) string {
+	return uid + "/" + key
+}
+
+func (r *InmemoryIdempotencyRepository) AddRecord(ctx context.Context, record entity.IdempotencyRecord) error {
+	defer r.journal.Lock(ctx)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	k := recordKey(record.UID, record.Key)
+	if _, ok := r.db[k]; ok {
+		return ErrRecordExists
+	}
+	return r.put(ctx, record)
+}
+
+func (r *InmemoryIdempotencyRepository) GetRecord(_ context.Context, uid string, key string) (entity.IdempotencyRecord, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if record, ok := r.db[recordKey(uid, key)]; ok {
+		return record, nil
+	}
+	return entity.IdempotencyRecord{}, ErrRecordNotFound
+}
+
+func (r *InmemoryIdempotencyRepository) UpdateRecord(ctx context.Context, record entity.IdempotencyRecord) error {
+	defer r.journal.Lock(ctx)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	k := recordKey(record.UID, record.Key)
+	if _, ok := r.db[k]; !ok {
+		return ErrRecordNotFound
+	}
+	return r.put(ctx, record)
+}
+
+func (r *InmemoryIdempotencyRepository) DelRecord(ctx context.Context, uid string, key string) error {
+	defer r.journal.Lock(ctx)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	k := recordKey(uid, key)
+	if _, ok := r.db[k]; !ok {
+		return ErrRecordNotFound
+	}
+	if err := r.journal.Append(ctx, opDelRecord, recordID{UID: uid, Key: key}); err != nil {
+		return err
+	}
+	r.del(ctx, []recordID{{UID: uid, Key: key}})
+	return nil
+}
+
+func (r *InmemoryIdempotencyRepository) DelRecordsCreatedBefore(ctx context.Context, before time.Time, limit int) (int, error) {
+	defer r.journal.Lock(ctx)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var expired []recordID
+	for _, record := range r.db {
+		if len(expired) == limit {
+			break
+		}
+		if record.CreatedAt.Before(before) {
+			expired = append(expired, recordID{UID: record.UID, Key: record.Key})
+		}
+	}
+	if len(expired) == 0 {
+		return 0, nil
+	}
+	if err := r.journal.Append(ctx, opPurge, expired); err != nil {
+		return 0, err
+	}
+	r.del(ctx, expired)
+	return len(expired), nil
+}
+
+func (r *InmemoryIdempotencyRepository) DelUserRecords(ctx context.Context, uid string) (int, error) {
+	defer r.journal.Lock(ctx)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var ids []recordID
+	for _, record := range r.db {
+		if record.UID == uid {
+			ids = append(ids, recordID{UID: record.UID, Key: record.Key})
+		}
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	if err := r.journal.Append(ctx, opPurge, ids); err != nil {
+		return 0, err
+	}
+	r.del(ctx, ids)
+	return len(ids), nil
+}
+
+// put записывает запись в журнал и сохраняет ее. При откате транзакции возвращается прежняя запись
+func (r *InmemoryIdempotencyRepository) put(ctx context.Context, record entity.IdempotencyRecord) error {
+	if err := r.journal.Append(ctx, opPutRecord, record); err != nil {
+		return err
+	}
+	k := recordKey(record.UID, record.Key)
+	prev, existed := r.db[k]
+	r.db[k] = record
+	memorystore.OnRollback(ctx, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if existed {
+			r.db[k] = prev
+			return
+		}
+		delete(r.db, k)
+	})
+	return nil
+}
+
+// del удаляет записи. При откате транзакции они возвращаются
+func (r *InmemoryIdempotencyRepository) del(ctx context.Context, ids []recordID) {
+	removed := make(map[string]entity.IdempotencyRecord, len(ids))
+	for _, id := range ids {
+		k := recordKey(id.UID, id.Key)
+		removed[k] = r.db[k]
+		delete(r.db, k)
+	}
+	memorystore.OnRollback(ctx, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		for k, record := range removed {
+			r.db[k] = record
+		}
+	})
+}
+
+func (r *InmemoryIdempotencyRepository) Close() error {
+	return nil
+}
+
+func (r *InmemoryIdempotencyRepository) SetJournal(j *memorystore.Journal) {
+	r.journal = j
+}
+
+func (r *InmemoryIdempotencyRepository) Snapshot() (interface{}, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	records := make([]entity.IdempotencyRecord, 0, len(r.db))
+	for _, record := range r.db {
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func (r *InmemoryIdempotencyRepository) Restore(state json.RawMessage) error {
+	var records []entity.IdempotencyRecord
+	if err := json.Unmarshal(state, &records); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.db = make(map[string]entity.IdempotencyRecord, len(records))
+	for _, record := range records {
+		r.db[recordKey(record.UID, record.Key)] = record
+	}
+	return nil
+}
+
+func (r *InmemoryIdempotencyRepository) Apply(op string, data json.RawMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch op {
+	case opPutRecord:
+		var record entity.IdempotencyRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			return err
+		}
+		r.db[recordKey(record.UID, record.Key)] = record
+	case opDelRecord:
+		var id recordID
+		if err := json.Unmarshal(data, &id); err != nil {
+			return err
+		}
+		delete(r.db, recordKey(id.UID, id.Key))
+	case opPurge:
+		var expired []recordID
+		if err := json.Unmarshal(data, &expired); err != nil {
+			return err
+		}
+		for _, id := range expired {
+			delete(r.db, recordKey(id.UID, id.Key))
+		}
+	default:
+		return fmt.Errorf("%w: %s", memorystore.ErrUnknownOp, op)
+	}
+	return nil
+}
+
+func NewInmemoryIdempotencyRepository() *InmemoryIdempotencyRepository {
+	return &InmemoryIdempotencyRepository{
+		mu: sync.RWMutex{},
+		db: make(map[string]entity.IdempotencyRecord, 100),
+	}
+}
@@ -0,0 +1,113 @@
+package idempotencyrepository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v4"
+	"github.com/zaz600/go-musthave-diploma/internal/entity"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/pgdb"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/transaction"
+)
+
+type PgIdempotencyRepository struct {
+	db *pgdb.Pool
+}
+
+type queryType string
+
+const (
+	queryAddRecord      queryType = "addRecord"
+	queryGetRecord      queryType = "getRecord"
+	queryUpdateRecord   queryType = "updateRecord"
+	queryDelRecord      queryType = "delRecord"
+	queryPurgeRecords   queryType = "purgeRecords"
+	queryDelUserRecords queryType = "delUserRecords"
+)
+
+var queries = map[queryType]string{
+	queryAddRecord: "insert into gophermart.idempotency_keys(uid, idempotency_key, fingerprint, status_code, content_type, body, created_at) " +
+		"values($1, $2, $3, $4, $5, $6, $7)",
+	queryGetRecord: "select uid, idempotency_key, fingerprint, status_code, content_type, body, created_at " +
+		"from gophermart.idempotency_keys where uid=$1 and idempotency_key=$2",
+	queryUpdateRecord: "update gophermart.idempotency_keys set status_code=$1, content_type=$2, body=$3 " +
+		"where uid=$4 and idempotency_key=$5",
+	queryDelRecord: "delete from gophermart.idempotency_keys where uid=$1 and idempotency_key=$2",
+	queryPurgeRecords: "delete from gophermart.idempotency_keys where id in " +
+		"(select id from gophermart.idempotency_keys where created_at < $1 order by created_at limit $2)",
+	queryDelUserRecords: "delete from gophermart.idempotency_keys where uid=$1",
+}
+
+func (p PgIdempotencyRepository) AddRecord(ctx context.Context, record entity.IdempotencyRecord) error {
+	_, err := transaction.PgQuerier(ctx, p.db).Exec(ctx, queries[queryAddRecord], record.UID, record.Key, record.Fingerprint,
+		record.StatusCode, record.ContentType, record.Body, record.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return ErrRecordExists
+		}
+		return err
+	}
+	return nil
+}
+
+func (p PgIdempotencyRepository) GetRecord(ctx context.Context, uid string, key string) (entity.IdempotencyRecord, error) {
+	var record entity.IdempotencyRecord
+	err := transaction.PgQuerier(ctx, p.db).QueryRow(ctx, queries[queryGetRecord], uid, key).Scan(&record.UID, &record.Key,
+		&record.Fingerprint, &record.StatusCode, &record.ContentType, &record.Body, &record.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return record, ErrRecordNotFound
+		}
+		return record, err
+	}
+	return record, nil
+}
+
+func (p PgIdempotencyRepository) UpdateRecord(ctx context.Context, record entity.IdempotencyRecord) error {
+	return p.exec(ctx, queryUpdateRecord, record.StatusCode, record.ContentType, record.Body, record.UID, record.Key)
+}
+
+func (p PgIdempotencyRepository) DelRecord(ctx context.Context, uid string, key string) error {
+	return p.exec(ctx, queryDelRecord, uid, key)
+}
+
+func (p PgIdempotencyRepository) DelRecordsCreatedBefore(ctx context.Context, before time.Time, limit int) (int, error) {
+	result, err := transaction.PgQuerier(ctx, p.db).Exec(ctx, queries[queryPurgeRecords], before, limit)
+	if err != nil {
+		return 0, err
+	}
+	return int(result.RowsAffected()), nil
+}
+
+func (p PgIdempotencyRepository) DelUserRecords(ctx context.Context, uid string) (int, error) {
+	result, err := transaction.PgQuerier(ctx, p.db).Exec(ctx, queries[queryDelUserRecords], uid)
+	if err != nil {
+		return 0, err
+	}
+	return int(result.RowsAffected()), nil
+}
+
+// exec выполняет запрос, изменяющий одну запись
+func (p PgIdempotencyRepository) exec(ctx context.Context, query queryType, args ...interface{}) error {
+	result, err := transaction.PgQuerier(ctx, p.db).Exec(ctx, queries[query], args...)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// Close ничего не делает: пул соединений общий для всех репозиториев
+func (p PgIdempotencyRepository) Close() error {
+	return nil
+}
+
+func NewPgIdempotencyRepository(db *pgdb.Pool) *PgIdempotencyRepository {
+	return &PgIdempotencyRepository{db: db}
+}
@@ -0,0 +1,140 @@
+package idempotencyrepository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/zaz600/go-musthave-diploma/internal/entity"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/sqlitedb"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/transaction"
+)
+
+type SQLiteIdempotencyRepository struct {
+	db         *sql.DB
+	statements map[queryType]*sql.Stmt
+}
+
+var sqliteQueries = map[queryType]string{
+	queryAddRecord: "insert into idempotency_keys(uid, idempotency_key, fingerprint, status_code, content_type, body, created_at) " +
+		"values($1, $2, $3, $4, $5, $6, $7)",
+	queryGetRecord: "select uid, idempotency_key, fingerprint, status_code, content_type, body, created_at " +
+		"from idempotency_keys where uid=$1 and idempotency_key=$2",
+	queryUpdateRecord: "update idempotency_keys set status_code=$1, content_type=$2, body=$3 " +
+		"where uid=$4 and idempotency_key=$5",
+	queryDelRecord: "delete from idempotency_keys where uid=$1 and idempotency_key=$2",
+	queryPurgeRecords: "delete from idempotency_keys where id in " +
+		"(select id from idempotency_keys where created_at < $1 order by created_at limit $2)",
+	queryDelUserRecords: "delete from idempotency_keys where uid=$1",
+}
+
+func (p SQLiteIdempotencyRepository) AddRecord(ctx context.Context, record entity.IdempotencyRecord) error {
+	tx, err := transaction.Begin(ctx, p.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+	stmt := tx.Stmt(p.statements[queryAddRecord])
+	_, err = stmt.ExecContext(ctx, record.UID, record.Key, record.Fingerprint, record.StatusCode, record.ContentType,
+		record.Body, record.CreatedAt.UTC())
+	if err != nil {
+		if sqlitedb.IsUniqueViolation(err) {
+			return ErrRecordExists
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+func (p SQLiteIdempotencyRepository) GetRecord(ctx context.Context, uid string, key string) (entity.IdempotencyRecord, error) {
+	var record entity.IdempotencyRecord
+	err := p.statements[queryGetRecord].QueryRowContext(ctx, uid, key).Scan(&record.UID, &record.Key, &record.Fingerprint,
+		&record.StatusCode, &record.ContentType, &record.Body, &record.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return record, ErrRecordNotFound
+		}
+		return record, err
+	}
+	return record, nil
+}
+
+func (p SQLiteIdempotencyRepository) UpdateRecord(ctx context.Context, record entity.IdempotencyRecord) error {
+	return p.exec(ctx, queryUpdateRecord, record.StatusCode, record.ContentType, record.Body, record.UID, record.Key)
+}
+
+func (p SQLiteIdempotencyRepository) DelRecord(ctx context.Context, uid string, key string) error {
+	return p.exec(ctx, queryDelRecord, uid, key)
+}
+
+func (p SQLiteIdempotencyRepository) DelRecordsCreatedBefore(ctx context.Context, before time.Time, limit int) (int, error) {
+	result, err := p.statements[queryPurgeRecords].ExecContext(ctx, before.UTC(), limit)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	return int(affected), err
+}
+
+func (p SQLiteIdempotencyRepository) DelUserRecords(ctx context.Context, uid string) (int, error) {
+	tx, err := transaction.Begin(ctx, p.db)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback() //nolint:errcheck
+	result, err := tx.Stmt(p.statements[queryDelUserRecords]).ExecContext(ctx, uid)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(affected), tx.Commit()
+}
+
+// exec выполняет запрос, изменяющий одну запись
+func (p SQLiteIdempotencyRepository) exec(ctx context.Context, query queryType, args ...interface{}) error {
+	tx, err := transaction.Begin(ctx, p.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+	result, err := tx.Stmt(p.statements[query]).ExecContext(ctx, args...)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrRecordNotFound
+	}
+	return tx.Commit()
+}
+
+func (p SQLiteIdempotencyRepository) Close() error {
+	for name, stmt := range p.statements {
+		err := stmt.Close()
+		if err != nil {
+			return fmt.Errorf("error close stmt %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func NewSQLiteIdempotencyRepository(db *sql.DB) (*SQLiteIdempotencyRepository, error) {
+	statements := make(map[queryType]*sql.Stmt, len(sqliteQueries))
+	for name, query := range sqliteQueries {
+		stmt, err := db.Prepare(query)
+		if err != nil {
+			return nil, fmt.Errorf("error prepare statement for %s: %w", name, err)
+		}
+		statements[name] = stmt
+	}
+
+	return &SQLiteIdempotencyRepository{db: db, statements: statements}, nil
+}
@@ -0,0 +1,11 @@
+package memorystore
+
+import "errors"
+
+var (
+	ErrCorruptedLog  = errors.New("memory store log is corrupted")
+	ErrUnknownRepo   = errors.New("unknown repository in memory store")
+	ErrUnknownOp     = errors.New("unknown operation in memory store log")
+	ErrStoreClosed   = errors.New("memory store is closed")
+	ErrJournalFailed = errors.New("memory store journal write failed")
+)
@@ -0,0 +1,413 @@
+package memorystore
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// snapshotSuffix снимок лежит рядом с журналом: gophermart.log -> gophermart.log.snapshot
+const snapshotSuffix = ".snapshot"
+
+// Repository in-memory репозиторий, состояние которого сохраняется в Store
+type Repository interface {
+	// SetJournal подключает журнал, в который репозиторий записывает изменения до их применения
+	SetJournal(j *Journal)
+	// Snapshot состояние репозитория для записи в снимок
+	Snapshot() (interface{}, error)
+	// Restore заменяет состояние репозитория состоянием из снимка
+	Restore(state json.RawMessage) error
+	// Apply повторяет изменение из журнала
+	Apply(op string, data json.RawMessage) error
+}
+
+// record запись журнала. lsn растет на единицу с каждой записью и не сбрасывается при снимке
+type record struct {
+	LSN  int64           `json:"lsn"`
+	Repo string          `json:"repo"`
+	Op   string          `json:"op"`
+	Data json.RawMessage `json:"data"`
+}
+
+// snapshot состояние всех репозиториев после применения записи журнала с номером LSN
+type snapshot struct {
+	LSN   int64                      `json:"lsn"`
+	Repos map[string]json.RawMessage `json:"repos"`
+}
+
+// Store сохраняет состояние in-memory репозиториев на диск. Каждое изменение до применения дописывается
+// в журнал и сбрасывается на диск, периодически все состояние записывается в снимок, и журнал начинается заново.
+// При загрузке состояние восстанавливается из снимка и записей журнала после него.
+// Изменения транзакции (Tx) записываются в журнал одной записью при ее Commit
+type Store struct {
+	path     string
+	interval time.Duration
+
+	// gate изменения репозиториев берут на чтение, снимок - на запись.
+	// Так в снимок не попадает изменение, которое уже есть в журнале, но еще не применено
+	gate  sync.RWMutex
+	mu    sync.Mutex
+	repos map[string]Repository
+	file  *os.File
+	size  int64
+	lsn   int64
+	// err после ошибки записи неизвестно, что осталось на диске, поэтому журнал больше не пишется
+	err error
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+type Option func(*Store)
+
+// WithSnapshotInterval как часто записывается снимок. 0 - только при закрытии
+func WithSnapshotInterval(interval time.Duration) Option {
+	return func(s *Store) {
+		s.interval = interval
+	}
+}
+
+// New хранилище с журналом в файле path. Перед Load нужно зарегистрировать все репозитории
+func New(path string, opts ...Option) *Store {
+	s := &Store{
+		path:     path,
+		interval: 5 * time.Minute,
+		repos:    make(map[string]Repository, 10),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Register подключает репозиторий к хранилищу под именем name
+func (s *Store) Register(name string, repo Repository) {
+	if _, ok := s.repos[name]; ok {
+		panic("memorystore: repository " + name + " is already registered")
+	}
+	s.repos[name] = repo
+	repo.SetJournal(&Journal{store: s, repo: name})
+}
+
+// Load восстанавливает состояние репозиториев и открывает журнал на запись
+func (s *Store) Load() error {
+	snapshotLSN, err := s.loadSnapshot()
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(s.path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	if err := s.replay(file, snapshotLSN); err != nil {
+		_ = file.Close()
+		return err
+	}
+	s.file = file
+
+	if s.interval > 0 {
+		s.stop = make(chan struct{})
+		s.done = make(chan struct{})
+		go s.runSnapshots()
+	}
+	return nil
+}
+
+func (s *Store) loadSnapshot() (int64, error) {
+	data, err := os.ReadFile(s.path + snapshotSuffix)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return 0, fmt.Errorf("%w: snapshot: %v", ErrCorruptedLog, err)
+	}
+	for name, state := range snap.Repos {
+		repo, ok := s.repos[name]
+		if !ok {
+			return 0, fmt.Errorf("%w: %s", ErrUnknownRepo, name)
+		}
+		if err := repo.Restore(state); err != nil {
+			return 0, fmt.Errorf("restore %s: %w", name, err)
+		}
+	}
+	s.lsn = snap.LSN
+	return snap.LSN, nil
+}
+
+// replay применяет записи журнала после снимка. Недописанная последняя запись - след аварийной остановки,
+// она отрезается. Испорченная запись в середине журнала - ошибка
+func (s *Store) replay(file *os.File, snapshotLSN int64) error {
+	reader := bufio.NewReader(file)
+	var offset int64
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(line) > 0 {
+				log.Warn().Str("path", s.path).Int64("offset", offset).Msg("truncate incomplete memory store log record")
+				if err := file.Truncate(offset); err != nil {
+					return err
+				}
+			}
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		var rec record
+		if err := json.Unmarshal(bytes.TrimSpace(line), &rec); err != nil {
+			if _, peekErr := reader.Peek(1); errors.Is(peekErr, io.EOF) {
+				log.Warn().Str("path", s.path).Int64("offset", offset).Msg("truncate incomplete memory store log record")
+				if err := file.Truncate(offset); err != nil {
+					return err
+				}
+				break
+			}
+			return fmt.Errorf("%w: offset %d: %v", ErrCorruptedLog, offset, err)
+		}
+		offset += int64(len(line))
+
+		// журнал не успели обрезать после записи снимка
+		if rec.LSN <= snapshotLSN {
+			continue
+		}
+		if rec.LSN != s.lsn+1 {
+			return fmt.Errorf("%w: lsn %d after %d", ErrCorruptedLog, rec.LSN, s.lsn)
+		}
+		if err := s.apply(rec); err != nil {
+			return err
+		}
+		s.lsn = rec.LSN
+	}
+	s.size = offset
+	return nil
+}
+
+// apply повторяет изменение из записи журнала. Запись транзакции содержит все ее изменения по порядку
+func (s *Store) apply(rec record) error {
+	changes := []change{{Repo: rec.Repo, Op: rec.Op, Data: rec.Data}}
+	if rec.Repo == "" && rec.Op == txOp {
+		changes = nil
+		if err := json.Unmarshal(rec.Data, &changes); err != nil {
+			return fmt.Errorf("%w: lsn %d: %v", ErrCorruptedLog, rec.LSN, err)
+		}
+	}
+	for _, c := range changes {
+		repo, ok := s.repos[c.Repo]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownRepo, c.Repo)
+		}
+		if err := repo.Apply(c.Op, c.Data); err != nil {
+			return fmt.Errorf("replay %s.%s lsn %d: %w", c.Repo, c.Op, rec.LSN, err)
+		}
+	}
+	return nil
+}
+
+func (s *Store) append(repo string, op string, data interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return s.err
+	}
+	if s.file == nil {
+		return ErrStoreClosed
+	}
+	line, err := json.Marshal(record{LSN: s.lsn + 1, Repo: repo, Op: op, Data: raw})
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+	if _, err := s.file.Write(line); err != nil {
+		return s.fail(err)
+	}
+	if err := s.file.Sync(); err != nil {
+		return s.fail(err)
+	}
+	s.size += int64(len(line))
+	s.lsn++
+	return nil
+}
+
+// fail пытается отрезать недописанную запись и останавливает журнал
+func (s *Store) fail(err error) error {
+	_ = s.file.Truncate(s.size)
+	s.err = fmt.Errorf("%w: %v", ErrJournalFailed, err)
+	log.Err(err).Str("path", s.path).Msg("memory store journal write failed")
+	return s.err
+}
+
+// Snapshot записывает состояние всех репозиториев в снимок и начинает журнал заново
+func (s *Store) Snapshot() error {
+	s.gate.Lock()
+	defer s.gate.Unlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return s.err
+	}
+	if s.file == nil {
+		return ErrStoreClosed
+	}
+	if s.size == 0 {
+		// с прошлого снимка ничего не изменилось
+		if _, err := os.Stat(s.path + snapshotSuffix); err == nil {
+			return nil
+		}
+	}
+
+	snap := snapshot{LSN: s.lsn, Repos: make(map[string]json.RawMessage, len(s.repos))}
+	for name, repo := range s.repos {
+		state, err := repo.Snapshot()
+		if err != nil {
+			return fmt.Errorf("snapshot %s: %w", name, err)
+		}
+		raw, err := json.Marshal(state)
+		if err != nil {
+			return fmt.Errorf("snapshot %s: %w", name, err)
+		}
+		snap.Repos[name] = raw
+	}
+	if err := writeFile(s.path+snapshotSuffix, snap); err != nil {
+		return err
+	}
+
+	// записи до снимка больше не нужны. Если остановиться до обрезки, при загрузке они будут пропущены по lsn
+	if err := s.file.Truncate(0); err != nil {
+		return s.fail(err)
+	}
+	if err := s.file.Sync(); err != nil {
+		return s.fail(err)
+	}
+	s.size = 0
+	return nil
+}
+
+// writeFile атомарно заменяет файл: пишет во временный файл и переименовывает его
+func writeFile(path string, v interface{}) error {
+	tmp := path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(file).Encode(v); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	return syncDir(filepath.Dir(path))
+}
+
+// syncDir сбрасывает на диск каталог, чтобы переименование пережило аварийную остановку
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+func (s *Store) runSnapshots() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			if err := s.Snapshot(); err != nil {
+				log.Err(err).Str("path", s.path).Msg("memory store snapshot failed")
+			}
+		}
+	}
+}
+
+// Close записывает снимок и закрывает журнал. Вызывается после закрытия репозиториев
+func (s *Store) Close() error {
+	if s.stop != nil {
+		close(s.stop)
+		<-s.done
+		s.stop = nil
+	}
+	err := s.Snapshot()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return err
+	}
+	if closeErr := s.file.Close(); err == nil {
+		err = closeErr
+	}
+	s.file = nil
+	return err
+}
+
+// Journal журнал изменений одного репозитория. У in-memory репозитория без хранилища журнал nil,
+// тогда методы ничего не делают
+type Journal struct {
+	store *Store
+	repo  string
+}
+
+// Lock не дает записать снимок, пока репозиторий меняет состояние. Возвращает функцию снятия блокировки:
+//
+//	defer r.journal.Lock(ctx)()
+//
+// Транзакция из ctx держит блокировку до Commit или Rollback, тогда повторно она не берется
+func (j *Journal) Lock(ctx context.Context) func() {
+	if j == nil {
+		return func() {}
+	}
+	if tx, ok := TxFromContext(ctx); ok && tx.store == j.store {
+		return func() {}
+	}
+	j.store.gate.RLock()
+	return j.store.gate.RUnlock
+}
+
+// Append записывает изменение op с данными data в журнал. Вызывается под Lock до применения изменения,
+// чтобы изменение, которое не удалось сохранить, не применялось.
+// Внутри транзакции изменение записывается в журнал при ее Commit
+func (j *Journal) Append(ctx context.Context, op string, data interface{}) error {
+	if j == nil {
+		return nil
+	}
+	if tx, ok := TxFromContext(ctx); ok && tx.store == j.store {
+		return tx.add(j.repo, op, data)
+	}
+	return j.store.append(j.repo, op, data)
+}
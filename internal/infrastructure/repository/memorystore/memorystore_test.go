@@ -0,0 +1,218 @@
+package memorystore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// counters репозиторий для тестов: счетчики, которые увеличиваются операцией "inc"
+type counters struct {
+	journal *Journal
+	values  map[string]int
+}
+
+func (c *counters) inc(ctx context.Context, key string) error {
+	defer c.journal.Lock(ctx)()
+	if err := c.journal.Append(ctx, "inc", key); err != nil {
+		return err
+	}
+	c.values[key]++
+	OnRollback(ctx, func() {
+		c.values[key]--
+	})
+	return nil
+}
+
+func (c *counters) SetJournal(j *Journal) {
+	c.journal = j
+}
+
+func (c *counters) Snapshot() (interface{}, error) {
+	return c.values, nil
+}
+
+func (c *counters) Restore(state json.RawMessage) error {
+	return json.Unmarshal(state, &c.values)
+}
+
+func (c *counters) Apply(_ string, data json.RawMessage) error {
+	var key string
+	if err := json.Unmarshal(data, &key); err != nil {
+		return err
+	}
+	c.values[key]++
+	return nil
+}
+
+func open(t *testing.T, path string) (*Store, *counters) {
+	t.Helper()
+	store := New(path, WithSnapshotInterval(0))
+	repo := &counters{values: map[string]int{}}
+	store.Register("counters", repo)
+	require.NoError(t, store.Load())
+	return store, repo
+}
+
+func TestStore_ReplayAndSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.log")
+
+	store, repo := open(t, path)
+	require.NoError(t, repo.inc(context.Background(), "a"))
+	require.NoError(t, repo.inc(context.Background(), "a"))
+
+	// без снимка состояние восстанавливается из журнала
+	store, repo = open(t, path)
+	assert.Equal(t, map[string]int{"a": 2}, repo.values)
+
+	require.NoError(t, repo.inc(context.Background(), "b"))
+	require.NoError(t, store.Close())
+	_, err := os.Stat(path + snapshotSuffix)
+	require.NoError(t, err)
+
+	// снимок, а потом журнал после него
+	store, repo = open(t, path)
+	assert.Equal(t, map[string]int{"a": 2, "b": 1}, repo.values)
+	require.NoError(t, repo.inc(context.Background(), "b"))
+	require.NoError(t, store.Snapshot())
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Zero(t, info.Size())
+	require.NoError(t, repo.inc(context.Background(), "c"))
+	require.NoError(t, store.Close())
+
+	_, repo = open(t, path)
+	assert.Equal(t, map[string]int{"a": 2, "b": 2, "c": 1}, repo.values)
+}
+
+func TestStore_SkipsRecordsBeforeSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.log")
+	store, repo := open(t, path)
+	require.NoError(t, repo.inc(context.Background(), "a"))
+	require.NoError(t, repo.inc(context.Background(), "a"))
+	require.NoError(t, store.Close())
+
+	// остановка между записью снимка и обрезкой журнала
+	log := `{"lsn":1,"repo":"counters","op":"inc","data":"a"}` + "\n" +
+		`{"lsn":2,"repo":"counters","op":"inc","data":"a"}` + "\n" +
+		`{"lsn":3,"repo":"counters","op":"inc","data":"b"}` + "\n"
+	require.NoError(t, os.WriteFile(path, []byte(log), 0o600))
+
+	_, repo = open(t, path)
+	assert.Equal(t, map[string]int{"a": 2, "b": 1}, repo.values)
+}
+
+func TestStore_TruncatesIncompleteRecord(t *testing.T) {
+	tests := []struct {
+		name string
+		tail string
+	}{
+		{name: "without newline", tail: `{"lsn":2,"repo":"coun`},
+		{name: "broken last record", tail: `{"lsn":2,"repo":"coun` + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "store.log")
+			record := `{"lsn":1,"repo":"counters","op":"inc","data":"a"}` + "\n"
+			require.NoError(t, os.WriteFile(path, []byte(record+tt.tail), 0o600))
+
+			store, repo := open(t, path)
+			assert.Equal(t, map[string]int{"a": 1}, repo.values)
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, record, string(data))
+
+			// следующая запись дописывается после отрезанной
+			require.NoError(t, repo.inc(context.Background(), "b"))
+			require.NoError(t, store.Close())
+			_, repo = open(t, path)
+			assert.Equal(t, map[string]int{"a": 1, "b": 1}, repo.values)
+		})
+	}
+}
+
+func TestStore_CorruptedLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.log")
+	log := `{"lsn":1,"repo":"counters","op":"inc","data":"a"}` + "\n" +
+		"garbage\n" +
+		`{"lsn":2,"repo":"counters","op":"inc","data":"a"}` + "\n"
+	require.NoError(t, os.WriteFile(path, []byte(log), 0o600))
+
+	store := New(path)
+	store.Register("counters", &counters{values: map[string]int{}})
+	assert.ErrorIs(t, store.Load(), ErrCorruptedLog)
+
+	log = `{"lsn":1,"repo":"unknown","op":"inc","data":"a"}` + "\n"
+	require.NoError(t, os.WriteFile(path, []byte(log), 0o600))
+	store = New(path)
+	store.Register("counters", &counters{values: map[string]int{}})
+	assert.ErrorIs(t, store.Load(), ErrUnknownRepo)
+}
+
+func TestTx_CommitWritesOneRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.log")
+	store, repo := open(t, path)
+
+	tx := store.Begin()
+	ctx := WithTx(context.Background(), tx)
+	require.NoError(t, repo.inc(ctx, "a"))
+	require.NoError(t, repo.inc(ctx, "b"))
+	// до Commit в журнале ничего нет
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Empty(t, data)
+
+	require.NoError(t, tx.Commit())
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 1, bytes.Count(data, []byte("\n")))
+
+	_, repo = open(t, path)
+	assert.Equal(t, map[string]int{"a": 1, "b": 1}, repo.values)
+}
+
+func TestTx_Rollback(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.log")
+	store, repo := open(t, path)
+	require.NoError(t, repo.inc(context.Background(), "a"))
+
+	tx := store.Begin()
+	ctx := WithTx(context.Background(), tx)
+	require.NoError(t, repo.inc(ctx, "a"))
+	require.NoError(t, repo.inc(ctx, "b"))
+	tx.Rollback()
+	assert.Equal(t, map[string]int{"a": 1, "b": 0}, repo.values)
+
+	// снимок не ждет завершенную транзакцию, в журнал она не попала
+	require.NoError(t, store.Snapshot())
+	require.NoError(t, store.Close())
+	_, repo = open(t, path)
+	assert.Equal(t, 1, repo.values["a"])
+	assert.Zero(t, repo.values["b"])
+
+	// без хранилища изменения тоже отменяются
+	tx = (*Store)(nil).Begin()
+	ctx = WithTx(context.Background(), tx)
+	repo = &counters{values: map[string]int{}}
+	require.NoError(t, repo.inc(ctx, "a"))
+	tx.Rollback()
+	assert.Zero(t, repo.values["a"])
+}
+
+func TestTx_CommitFailureRollsBack(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.log")
+	store, repo := open(t, path)
+
+	tx := store.Begin()
+	ctx := WithTx(context.Background(), tx)
+	require.NoError(t, repo.inc(ctx, "a"))
+	require.NoError(t, store.file.Close())
+	assert.ErrorIs(t, tx.Commit(), ErrJournalFailed)
+	assert.Zero(t, repo.values["a"])
+}
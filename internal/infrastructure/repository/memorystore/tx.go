@@ -0,0 +1,118 @@
+package memorystore
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+// txOp операция записи журнала, в которой лежат все изменения одной транзакции
+const txOp = "tx"
+
+type txKey struct{}
+
+// change изменение репозитория внутри транзакции
+type change struct {
+	Repo string          `json:"repo"`
+	Op   string          `json:"op"`
+	Data json.RawMessage `json:"data"`
+}
+
+// Tx транзакция in-memory репозиториев. Изменения применяются сразу, в журнал они записываются
+// одной записью при Commit, поэтому после перезапуска транзакция восстанавливается целиком или не восстанавливается совсем.
+// Для отката репозитории регистрируют отмену каждого изменения через OnRollback
+type Tx struct {
+	store  *Store
+	unlock func()
+
+	mu      sync.Mutex
+	changes []change
+	undo    []func()
+	done    bool
+}
+
+// Begin начинает транзакцию. До Commit или Rollback снимок не записывается.
+// У хранилища nil транзакция только отменяет изменения при откате
+func (s *Store) Begin() *Tx {
+	if s == nil {
+		return &Tx{unlock: func() {}}
+	}
+	s.gate.RLock()
+	return &Tx{store: s, unlock: s.gate.RUnlock}
+}
+
+// Commit записывает изменения транзакции в журнал. Если записать не удалось, изменения отменяются
+func (tx *Tx) Commit() error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if tx.done {
+		return nil
+	}
+	tx.done = true
+	defer tx.unlock()
+
+	if tx.store == nil || len(tx.changes) == 0 {
+		return nil
+	}
+	if err := tx.store.append("", txOp, tx.changes); err != nil {
+		tx.rollback()
+		return err
+	}
+	return nil
+}
+
+// Rollback отменяет изменения транзакции в обратном порядке. После Commit ничего не делает
+func (tx *Tx) Rollback() {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if tx.done {
+		return
+	}
+	tx.done = true
+	defer tx.unlock()
+
+	tx.rollback()
+}
+
+func (tx *Tx) rollback() {
+	for i := len(tx.undo) - 1; i >= 0; i-- {
+		tx.undo[i]()
+	}
+	tx.undo = nil
+	tx.changes = nil
+}
+
+func (tx *Tx) add(repo string, op string, data interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	tx.changes = append(tx.changes, change{Repo: repo, Op: op, Data: raw})
+	return nil
+}
+
+// WithTx передает транзакцию репозиториям через контекст
+func WithTx(ctx context.Context, tx *Tx) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// TxFromContext транзакция из контекста
+func TxFromContext(ctx context.Context) (*Tx, bool) {
+	tx, ok := ctx.Value(txKey{}).(*Tx)
+	return tx, ok
+}
+
+// OnRollback регистрирует отмену изменения, которое репозиторий только что применил.
+// undo вызывается при откате транзакции из контекста и сам берет блокировки репозитория.
+// Вне транзакции ничего не делает
+func OnRollback(ctx context.Context, undo func()) {
+	tx, ok := TxFromContext(ctx)
+	if !ok {
+		return
+	}
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	tx.undo = append(tx.undo, undo)
+}
@@ -60,3 +60,13 @@ CREATE TABLE IF NOT EXISTS accounts
 );
 ALTER TABLE accounts ALTER COLUMN created_at SET DEFAULT now();
 CREATE UNIQUE INDEX accounts_account_id_uniq_idx ON accounts USING btree (account_id);
+
+-- +goose Down
+SET SEARCH_PATH TO gophermart;
+
+DROP TABLE IF EXISTS accounts;
+DROP TABLE IF EXISTS withdrawals;
+DROP TABLE IF EXISTS orders;
+DROP TABLE IF EXISTS sessions;
+DROP TABLE IF EXISTS users;
+DROP SCHEMA IF EXISTS gophermart;
@@ -0,0 +1,55 @@
+-- +goose Up
+SET SEARCH_PATH TO gophermart;
+
+CREATE TABLE IF NOT EXISTS events
+(
+    seq          bigserial primary key,
+    event_id     varchar,
+    type         varchar,
+    uid          varchar,
+    payload      jsonb,
+    created_at   TIMESTAMP,
+    published_at TIMESTAMP
+);
+ALTER TABLE events ALTER COLUMN created_at SET DEFAULT now();
+CREATE UNIQUE INDEX events_event_id_uniq_idx ON events USING btree (event_id);
+CREATE INDEX events_unpublished_idx ON events USING btree (seq) WHERE published_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS webhook_subscribers
+(
+    id             serial primary key,
+    subscriber_id  varchar,
+    url            varchar,
+    event_types    varchar,
+    secret         varchar,
+    created_at     TIMESTAMP
+);
+ALTER TABLE webhook_subscribers ALTER COLUMN created_at SET DEFAULT now();
+CREATE UNIQUE INDEX webhook_subscribers_subscriber_id_uniq_idx ON webhook_subscribers USING btree (subscriber_id);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries
+(
+    id                serial primary key,
+    delivery_id       varchar,
+    subscriber_id     varchar,
+    event_id          varchar,
+    event_type        varchar,
+    payload           jsonb,
+    event_created_at  TIMESTAMP,
+    status            varchar,
+    attempts          int,
+    next_attempt_at   TIMESTAMP,
+    last_error        varchar,
+    created_at        TIMESTAMP
+);
+ALTER TABLE webhook_deliveries ALTER COLUMN created_at SET DEFAULT now();
+CREATE UNIQUE INDEX webhook_deliveries_delivery_id_uniq_idx ON webhook_deliveries USING btree (delivery_id);
+CREATE UNIQUE INDEX webhook_deliveries_subscriber_event_uniq_idx ON webhook_deliveries USING btree (subscriber_id, event_id);
+CREATE INDEX webhook_deliveries_due_idx ON webhook_deliveries USING btree (next_attempt_at) WHERE status = 'PENDING';
+
+-- +goose Down
+SET SEARCH_PATH TO gophermart;
+
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhook_subscribers;
+DROP TABLE IF EXISTS events;
@@ -0,0 +1,34 @@
+-- +goose Up
+SET SEARCH_PATH TO gophermart;
+
+-- публикации событий отслеживаются отдельно для каждого sink,
+-- чтобы недоступность одного получателя не задерживала остальных
+CREATE TABLE IF NOT EXISTS event_publications
+(
+    sink          varchar,
+    seq           bigint,
+    published_at  TIMESTAMP,
+    primary key (sink, seq)
+);
+ALTER TABLE event_publications ALTER COLUMN published_at SET DEFAULT now();
+
+INSERT INTO event_publications(sink, seq, published_at)
+SELECT 'webhooks', seq, published_at FROM events WHERE published_at IS NOT NULL;
+
+DROP INDEX IF EXISTS events_unpublished_idx;
+ALTER TABLE events DROP COLUMN IF EXISTS published_at;
+
+ALTER TABLE webhook_deliveries ADD COLUMN IF NOT EXISTS event_uid varchar;
+
+-- +goose Down
+SET SEARCH_PATH TO gophermart;
+
+ALTER TABLE webhook_deliveries DROP COLUMN IF EXISTS event_uid;
+
+-- до появления event_publications события публиковались только в webhooks
+ALTER TABLE events ADD COLUMN IF NOT EXISTS published_at TIMESTAMP;
+UPDATE events e SET published_at = p.published_at
+FROM event_publications p WHERE p.sink = 'webhooks' AND p.seq = e.seq;
+CREATE INDEX IF NOT EXISTS events_unpublished_idx ON events USING btree (seq) WHERE published_at IS NULL;
+
+DROP TABLE IF EXISTS event_publications;
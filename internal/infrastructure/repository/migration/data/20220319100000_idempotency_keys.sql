@@ -0,0 +1,21 @@
+-- +goose Up
+SET SEARCH_PATH TO gophermart;
+
+CREATE TABLE IF NOT EXISTS idempotency_keys
+(
+    id               serial primary key,
+    uid              varchar,
+    idempotency_key  varchar,
+    fingerprint      varchar,
+    status_code      int,
+    content_type     varchar,
+    body             bytea,
+    created_at       TIMESTAMP
+);
+ALTER TABLE idempotency_keys ALTER COLUMN created_at SET DEFAULT now();
+CREATE UNIQUE INDEX idempotency_keys_uid_key_uniq_idx ON idempotency_keys USING btree (uid, idempotency_key);
+
+-- +goose Down
+SET SEARCH_PATH TO gophermart;
+
+DROP TABLE IF EXISTS idempotency_keys;
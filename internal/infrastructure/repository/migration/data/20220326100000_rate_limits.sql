@@ -0,0 +1,14 @@
+-- +goose Up
+SET SEARCH_PATH TO gophermart;
+
+CREATE TABLE IF NOT EXISTS rate_limits
+(
+    key         varchar primary key,
+    tokens      double precision not null,
+    updated_at  TIMESTAMP
+);
+
+-- +goose Down
+SET SEARCH_PATH TO gophermart;
+
+DROP TABLE IF EXISTS rate_limits;
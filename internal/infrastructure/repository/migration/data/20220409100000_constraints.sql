@@ -0,0 +1,92 @@
+-- +goose Up
+-- перед миграцией строки, которые нарушают ограничения, выводит migration.Preflight (gophermart migrate preflight)
+SET SEARCH_PATH TO gophermart;
+
+ALTER TABLE users
+    ALTER COLUMN uid SET NOT NULL,
+    ALTER COLUMN login SET NOT NULL,
+    ALTER COLUMN password SET NOT NULL,
+    ALTER COLUMN created_at SET NOT NULL;
+CREATE UNIQUE INDEX users_uid_uniq_idx ON users USING btree (uid);
+
+ALTER TABLE sessions
+    ALTER COLUMN uid SET NOT NULL,
+    ALTER COLUMN sid SET NOT NULL,
+    ALTER COLUMN created_at SET NOT NULL,
+    ADD CONSTRAINT sessions_uid_fkey FOREIGN KEY (uid) REFERENCES users (uid);
+CREATE INDEX sessions_uid_idx ON sessions USING btree (uid);
+
+ALTER TABLE orders
+    ALTER COLUMN uid SET NOT NULL,
+    ALTER COLUMN order_id SET NOT NULL,
+    ALTER COLUMN uploaded_at SET NOT NULL,
+    ALTER COLUMN status SET NOT NULL,
+    ALTER COLUMN accrual SET NOT NULL,
+    ALTER COLUMN retry_count SET NOT NULL,
+    ADD CONSTRAINT orders_status_check CHECK (status IN ('NEW', 'PROCESSING', 'INVALID', 'PROCESSED', 'TOO_MANY_RETRIES')),
+    ADD CONSTRAINT orders_uid_fkey FOREIGN KEY (uid) REFERENCES users (uid);
+CREATE INDEX orders_uid_idx ON orders USING btree (uid);
+
+ALTER TABLE withdrawals
+    ALTER COLUMN uid SET NOT NULL,
+    ALTER COLUMN order_id SET NOT NULL,
+    ALTER COLUMN processed_at SET NOT NULL,
+    ALTER COLUMN amount SET NOT NULL,
+    ADD CONSTRAINT withdrawals_uid_fkey FOREIGN KEY (uid) REFERENCES users (uid);
+CREATE INDEX withdrawals_uid_idx ON withdrawals USING btree (uid);
+
+ALTER TABLE accounts
+    ALTER COLUMN uid SET NOT NULL,
+    ALTER COLUMN account_id SET NOT NULL,
+    ALTER COLUMN balance SET NOT NULL,
+    ALTER COLUMN withdrawals SET NOT NULL,
+    ALTER COLUMN created_at SET NOT NULL,
+    ADD CONSTRAINT accounts_balance_check CHECK (balance >= 0),
+    ADD CONSTRAINT accounts_uid_fkey FOREIGN KEY (uid) REFERENCES users (uid);
+CREATE UNIQUE INDEX accounts_uid_uniq_idx ON accounts USING btree (uid);
+
+-- +goose Down
+SET SEARCH_PATH TO gophermart;
+
+DROP INDEX IF EXISTS accounts_uid_uniq_idx;
+ALTER TABLE accounts
+    DROP CONSTRAINT IF EXISTS accounts_uid_fkey,
+    DROP CONSTRAINT IF EXISTS accounts_balance_check,
+    ALTER COLUMN uid DROP NOT NULL,
+    ALTER COLUMN account_id DROP NOT NULL,
+    ALTER COLUMN balance DROP NOT NULL,
+    ALTER COLUMN withdrawals DROP NOT NULL,
+    ALTER COLUMN created_at DROP NOT NULL;
+
+DROP INDEX IF EXISTS withdrawals_uid_idx;
+ALTER TABLE withdrawals
+    DROP CONSTRAINT IF EXISTS withdrawals_uid_fkey,
+    ALTER COLUMN uid DROP NOT NULL,
+    ALTER COLUMN order_id DROP NOT NULL,
+    ALTER COLUMN processed_at DROP NOT NULL,
+    ALTER COLUMN amount DROP NOT NULL;
+
+DROP INDEX IF EXISTS orders_uid_idx;
+ALTER TABLE orders
+    DROP CONSTRAINT IF EXISTS orders_uid_fkey,
+    DROP CONSTRAINT IF EXISTS orders_status_check,
+    ALTER COLUMN uid DROP NOT NULL,
+    ALTER COLUMN order_id DROP NOT NULL,
+    ALTER COLUMN uploaded_at DROP NOT NULL,
+    ALTER COLUMN status DROP NOT NULL,
+    ALTER COLUMN accrual DROP NOT NULL,
+    ALTER COLUMN retry_count DROP NOT NULL;
+
+DROP INDEX IF EXISTS sessions_uid_idx;
+ALTER TABLE sessions
+    DROP CONSTRAINT IF EXISTS sessions_uid_fkey,
+    ALTER COLUMN uid DROP NOT NULL,
+    ALTER COLUMN sid DROP NOT NULL,
+    ALTER COLUMN created_at DROP NOT NULL;
+
+DROP INDEX IF EXISTS users_uid_uniq_idx;
+ALTER TABLE users
+    ALTER COLUMN uid DROP NOT NULL,
+    ALTER COLUMN login DROP NOT NULL,
+    ALTER COLUMN password DROP NOT NULL,
+    ALTER COLUMN created_at DROP NOT NULL;
@@ -0,0 +1,17 @@
+-- +goose Up
+SET SEARCH_PATH TO gophermart;
+
+ALTER TABLE sessions
+    ADD COLUMN user_agent   varchar NOT NULL DEFAULT '',
+    ADD COLUMN ip           varchar NOT NULL DEFAULT '',
+    ADD COLUMN last_seen_at TIMESTAMP;
+UPDATE sessions SET last_seen_at = created_at;
+ALTER TABLE sessions ALTER COLUMN last_seen_at SET NOT NULL;
+
+-- +goose Down
+SET SEARCH_PATH TO gophermart;
+
+ALTER TABLE sessions
+    DROP COLUMN IF EXISTS user_agent,
+    DROP COLUMN IF EXISTS ip,
+    DROP COLUMN IF EXISTS last_seen_at;
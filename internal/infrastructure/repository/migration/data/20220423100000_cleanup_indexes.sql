@@ -0,0 +1,13 @@
+-- +goose Up
+SET SEARCH_PATH TO gophermart;
+
+CREATE INDEX IF NOT EXISTS sessions_created_at_idx ON sessions USING btree (created_at);
+CREATE INDEX IF NOT EXISTS idempotency_keys_created_at_idx ON idempotency_keys USING btree (created_at);
+CREATE INDEX IF NOT EXISTS rate_limits_updated_at_idx ON rate_limits USING btree (updated_at);
+
+-- +goose Down
+SET SEARCH_PATH TO gophermart;
+
+DROP INDEX IF EXISTS rate_limits_updated_at_idx;
+DROP INDEX IF EXISTS idempotency_keys_created_at_idx;
+DROP INDEX IF EXISTS sessions_created_at_idx;
@@ -0,0 +1,11 @@
+-- +goose Up
+SET SEARCH_PATH TO gophermart;
+
+CREATE INDEX IF NOT EXISTS events_uid_idx ON events USING btree (uid);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_event_uid_idx ON webhook_deliveries USING btree (event_uid);
+
+-- +goose Down
+SET SEARCH_PATH TO gophermart;
+
+DROP INDEX IF EXISTS webhook_deliveries_event_uid_idx;
+DROP INDEX IF EXISTS events_uid_idx;
@@ -0,0 +1,12 @@
+-- +goose Up
+SET SEARCH_PATH TO gophermart;
+
+CREATE INDEX IF NOT EXISTS events_created_at_idx ON events USING btree (created_at);
+-- первичный ключ (sink, seq) не помогает удалять отметки всех sinks по номеру события
+CREATE INDEX IF NOT EXISTS event_publications_seq_idx ON event_publications USING btree (seq);
+
+-- +goose Down
+SET SEARCH_PATH TO gophermart;
+
+DROP INDEX IF EXISTS event_publications_seq_idx;
+DROP INDEX IF EXISTS events_created_at_idx;
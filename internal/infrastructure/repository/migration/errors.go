@@ -0,0 +1,8 @@
+package migration
+
+import "errors"
+
+var (
+	ErrMigrationsPending    = errors.New("db migrations are not applied")
+	ErrConstraintViolations = errors.New("db rows violate schema constraints, fix them before migration")
+)
@@ -1,8 +1,12 @@
 package migration
 
 import (
+	"context"
 	"database/sql"
 	"embed"
+	"fmt"
+	"path/filepath"
+	"time"
 
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/github"
@@ -10,16 +14,188 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
-//go:embed data/*.sql
+//go:embed data/*.sql sqlite/*.sql
 var embedMigrations embed.FS
 
-func Migrate(db *sql.DB) error {
-	log.Info().Msg("start db migration")
+// Dialect диалект SQL и каталог его миграций
+type Dialect struct {
+	name  string
+	dir   string
+	table string
+	// schema префикс таблиц сервиса в запросах
+	schema string
+}
+
+var (
+	Postgres = Dialect{name: "postgres", dir: "data", table: "public.goose_db_version", schema: "gophermart."}
+	SQLite   = Dialect{name: "sqlite3", dir: "sqlite", table: "goose_db_version"}
+)
+
+func setup(dialect Dialect) error {
 	goose.SetBaseFS(embedMigrations)
-	goose.SetTableName("public.goose_db_version")
+	goose.SetTableName(dialect.table)
+	return goose.SetDialect(dialect.name)
+}
+
+func Migrate(db *sql.DB, dialect Dialect) error {
+	log.Info().Str("dialect", dialect.name).Msg("start db migration")
+	if err := setup(dialect); err != nil {
+		return err
+	}
+	if err := checkConstraints(db, dialect); err != nil {
+		return err
+	}
+
+	if err := goose.Up(db, dialect.dir); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Down откатывает последнюю примененную миграцию
+func Down(db *sql.DB, dialect Dialect) error {
+	log.Info().Str("dialect", dialect.name).Msg("rollback last db migration")
+	if err := setup(dialect); err != nil {
+		return err
+	}
+	return goose.Down(db, dialect.dir)
+}
+
+// Redo откатывает последнюю примененную миграцию и применяет ее заново
+func Redo(db *sql.DB, dialect Dialect) error {
+	log.Info().Str("dialect", dialect.name).Msg("redo last db migration")
+	if err := setup(dialect); err != nil {
+		return err
+	}
+	return goose.Redo(db, dialect.dir)
+}
+
+// Version версия последней примененной миграции, 0 - миграции не применялись
+func Version(db *sql.DB, dialect Dialect) (int64, error) {
+	if err := setup(dialect); err != nil {
+		return 0, err
+	}
+	return goose.GetDBVersion(db)
+}
+
+// Status состояние миграции
+type Status struct {
+	Version int64
+	Name    string
+	// AppliedAt когда применена миграция. Нулевое время - миграция не применена
+	AppliedAt time.Time
+}
+
+func (s Status) Applied() bool {
+	return !s.AppliedAt.IsZero()
+}
 
-	if err := goose.Up(db, "data"); err != nil {
+// Statuses состояние всех миграций в порядке версий
+func Statuses(db *sql.DB, dialect Dialect) ([]Status, error) {
+	if err := setup(dialect); err != nil {
+		return nil, err
+	}
+	migrations, err := goose.CollectMigrations(dialect.dir, 0, goose.MaxVersion)
+	if err != nil {
+		return nil, err
+	}
+	// на пустой БД таблица версий еще не создана
+	if _, err := goose.EnsureDBVersion(db); err != nil {
+		return nil, err
+	}
+
+	// при откате goose удаляет строку миграции, поэтому в таблице только примененные
+	rows, err := db.Query("select version_id, tstamp from " + dialect.table + " where is_applied and version_id > 0")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	applied := make(map[int64]time.Time, len(migrations))
+	for rows.Next() {
+		var version int64
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(migrations))
+	for _, m := range migrations {
+		statuses = append(statuses, Status{
+			Version:   m.Version,
+			Name:      filepath.Base(m.Source),
+			AppliedAt: applied[m.Version],
+		})
+	}
+	return statuses, nil
+}
+
+// Check проверяет, что к БД применены все миграции
+func Check(db *sql.DB, dialect Dialect) error {
+	last, err := latest(dialect)
+	if err != nil {
+		return err
+	}
+	current, err := goose.GetDBVersion(db)
+	if err != nil {
+		return err
+	}
+	if current < last {
+		return fmt.Errorf("%w: current %d, latest %d", ErrMigrationsPending, current, last)
+	}
+	return nil
+}
+
+// latest версия последней миграции диалекта
+func latest(dialect Dialect) (int64, error) {
+	if err := setup(dialect); err != nil {
+		return 0, err
+	}
+	migrations, err := goose.CollectMigrations(dialect.dir, 0, goose.MaxVersion)
+	if err != nil {
+		return 0, err
+	}
+	last, err := migrations.Last()
+	if err != nil {
+		return 0, err
+	}
+	return last.Version, nil
+}
+
+// Checker проверяет, что миграции применены, для проверки готовности. Настройки goose глобальные,
+// поэтому версия последней миграции вычисляется один раз при создании, а Check только читает таблицу версий
+// и безопасен для параллельных вызовов
+type Checker struct {
+	db     *sql.DB
+	query  string
+	latest int64
+}
+
+// NewChecker вызывается при запуске вместе с Migrate или Check, но не параллельно с ними
+func NewChecker(db *sql.DB, dialect Dialect) (*Checker, error) {
+	last, err := latest(dialect)
+	if err != nil {
+		return nil, err
+	}
+	return &Checker{
+		db: db,
+		// при откате goose удаляет строку миграции, поэтому в таблице только примененные
+		query:  "select coalesce(max(version_id), 0) from " + dialect.table + " where is_applied",
+		latest: last,
+	}, nil
+}
+
+func (c *Checker) Check(ctx context.Context) error {
+	var current int64
+	if err := c.db.QueryRowContext(ctx, c.query).Scan(&current); err != nil {
 		return err
 	}
+	if current < c.latest {
+		return fmt.Errorf("%w: current %d, latest %d", ErrMigrationsPending, current, c.latest)
+	}
 	return nil
 }
@@ -0,0 +1,130 @@
+package migration_test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/migration"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/repositorytest"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/sqlitedb"
+)
+
+// testRoundTrip откатывает все миграции по одной и применяет их заново
+func testRoundTrip(t *testing.T, db *sql.DB, dialect migration.Dialect) {
+	require.NoError(t, migration.Migrate(db, dialect))
+	statuses, err := migration.Statuses(db, dialect)
+	require.NoError(t, err)
+	require.NotEmpty(t, statuses)
+	last := statuses[len(statuses)-1].Version
+	for _, s := range statuses {
+		assert.True(t, s.Applied(), s.Name)
+	}
+	version, err := migration.Version(db, dialect)
+	require.NoError(t, err)
+	assert.Equal(t, last, version)
+
+	require.NoError(t, migration.Redo(db, dialect))
+	require.NoError(t, migration.Check(db, dialect))
+	checker, err := migration.NewChecker(db, dialect)
+	require.NoError(t, err)
+	require.NoError(t, checker.Check(context.Background()))
+
+	for i := len(statuses) - 1; i >= 0; i-- {
+		require.NoError(t, migration.Down(db, dialect), statuses[i].Name)
+		assert.ErrorIs(t, migration.Check(db, dialect), migration.ErrMigrationsPending)
+		assert.ErrorIs(t, checker.Check(context.Background()), migration.ErrMigrationsPending)
+	}
+	version, err = migration.Version(db, dialect)
+	require.NoError(t, err)
+	assert.Zero(t, version)
+	statuses, err = migration.Statuses(db, dialect)
+	require.NoError(t, err)
+	for _, s := range statuses {
+		assert.False(t, s.Applied(), s.Name)
+	}
+
+	require.NoError(t, migration.Migrate(db, dialect))
+	require.NoError(t, migration.Check(db, dialect))
+}
+
+// testPreflight откатывает миграцию ограничений и проверяет, что строки, которые ее нарушают,
+// попадают в отчет и не дают применить миграцию
+func testPreflight(t *testing.T, db *sql.DB, dialect migration.Dialect, schema string) {
+	require.NoError(t, migration.Migrate(db, dialect))
+	// откат до миграции ограничений
+	for {
+		version, err := migration.Version(db, dialect)
+		require.NoError(t, err)
+		if version < 20220409100000 {
+			break
+		}
+		require.NoError(t, migration.Down(db, dialect))
+	}
+
+	violations, err := migration.Preflight(db, dialect)
+	require.NoError(t, err)
+	assert.Empty(t, violations)
+
+	exec := func(query string) {
+		t.Helper()
+		_, err := db.Exec(strings.ReplaceAll(query, "{schema}", schema))
+		require.NoError(t, err)
+	}
+	exec("insert into {schema}users(id, uid, login, password) values(1, 'user', 'login', 'password')")
+	exec("insert into {schema}orders(id, uid, order_id, uploaded_at, status, accrual, retry_count) values(1, 'user', '1', current_timestamp, 'NEW', 0, 0)")
+	exec("insert into {schema}orders(id, uid, order_id, uploaded_at, status, accrual, retry_count) values(2, 'ghost', '2', current_timestamp, 'NEW', 0, 0)")
+	exec("insert into {schema}orders(id, uid, order_id, uploaded_at, status, accrual, retry_count) values(3, 'user', '3', current_timestamp, 'DONE', 0, 0)")
+	exec("insert into {schema}accounts(id, uid, account_id, balance, withdrawals, created_at) values(1, 'user', 'a1', -1, 0, current_timestamp)")
+	exec("insert into {schema}sessions(id, uid, sid, created_at) values(1, 'user', 's1', null)")
+
+	violations, err = migration.Preflight(db, dialect)
+	require.NoError(t, err)
+	assert.Equal(t, []migration.Violation{
+		{Check: "sessions: required column is null", Count: 1, Samples: []int64{1}},
+		{Check: "orders: unknown status", Count: 1, Samples: []int64{3}},
+		{Check: "orders: owner not found", Count: 1, Samples: []int64{2}},
+		{Check: "accounts: negative balance", Count: 1, Samples: []int64{1}},
+	}, violations)
+	assert.ErrorIs(t, migration.Migrate(db, dialect), migration.ErrConstraintViolations)
+	assert.ErrorIs(t, migration.Check(db, dialect), migration.ErrMigrationsPending)
+
+	exec("update {schema}sessions set created_at = current_timestamp")
+	exec("delete from {schema}orders where id in (2, 3)")
+	exec("update {schema}accounts set balance = 0")
+	require.NoError(t, migration.Migrate(db, dialect))
+	require.NoError(t, migration.Check(db, dialect))
+
+	// данные пережили пересоздание таблиц
+	var orders int
+	require.NoError(t, db.QueryRow(strings.ReplaceAll("select count(*) from {schema}orders where uid = 'user'", "{schema}", schema)).Scan(&orders))
+	assert.Equal(t, 1, orders)
+}
+
+func TestMigrations(t *testing.T) {
+	t.Run("sqlite", func(t *testing.T) {
+		db, err := sql.Open(sqlitedb.DriverName, sqlitedb.DSN(filepath.Join(t.TempDir(), "gophermart.db")))
+		require.NoError(t, err)
+		defer db.Close()
+		testRoundTrip(t, db, migration.SQLite)
+	})
+	t.Run("postgres", func(t *testing.T) {
+		testRoundTrip(t, repositorytest.PostgresDB(t), migration.Postgres)
+	})
+}
+
+func TestPreflight(t *testing.T) {
+	t.Run("sqlite", func(t *testing.T) {
+		db, err := sql.Open(sqlitedb.DriverName, sqlitedb.DSN(filepath.Join(t.TempDir(), "gophermart.db")))
+		require.NoError(t, err)
+		defer db.Close()
+		testPreflight(t, db, migration.SQLite, "")
+	})
+	t.Run("postgres", func(t *testing.T) {
+		testPreflight(t, repositorytest.PostgresDB(t), migration.Postgres, "gophermart.")
+	})
+}
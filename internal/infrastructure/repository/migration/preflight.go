@@ -0,0 +1,166 @@
+package migration
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/pressly/goose/v3"
+)
+
+// constraintsVersion миграция, которая добавляет NOT NULL, внешние ключи и CHECK-ограничения
+const constraintsVersion = 20220409100000
+
+// maxViolationSamples сколько id нарушающих строк попадает в отчет
+const maxViolationSamples = 10
+
+// Violation строки, которые не пройдут миграцию ограничений
+type Violation struct {
+	Check string
+	Count int
+	// Samples id первых нарушающих строк
+	Samples []int64
+}
+
+func (v Violation) String() string {
+	samples := make([]string, 0, len(v.Samples))
+	for _, id := range v.Samples {
+		samples = append(samples, fmt.Sprint(id))
+	}
+	return fmt.Sprintf("%s: %d rows (id %s)", v.Check, v.Count, strings.Join(samples, ", "))
+}
+
+type preflightCheck struct {
+	name string
+	// query выбирает id нарушающих строк, {schema} заменяется на схему диалекта
+	query string
+}
+
+var preflightChecks = []preflightCheck{
+	{
+		name:  "users: required column is null",
+		query: "select id from {schema}users where uid is null or login is null or password is null or created_at is null order by id",
+	},
+	{
+		name:  "users: duplicate uid",
+		query: "select id from {schema}users u where exists (select 1 from {schema}users d where d.uid = u.uid and d.id <> u.id) order by id",
+	},
+	{
+		name:  "sessions: required column is null",
+		query: "select id from {schema}sessions where uid is null or sid is null or created_at is null order by id",
+	},
+	{
+		name:  "sessions: owner not found",
+		query: "select id from {schema}sessions s where s.uid is not null and not exists (select 1 from {schema}users u where u.uid = s.uid) order by id",
+	},
+	{
+		name: "orders: required column is null",
+		query: "select id from {schema}orders where uid is null or order_id is null or uploaded_at is null " +
+			"or status is null or accrual is null or retry_count is null order by id",
+	},
+	{
+		name:  "orders: unknown status",
+		query: "select id from {schema}orders where status not in ('NEW', 'PROCESSING', 'INVALID', 'PROCESSED', 'TOO_MANY_RETRIES') order by id",
+	},
+	{
+		name:  "orders: owner not found",
+		query: "select id from {schema}orders o where o.uid is not null and not exists (select 1 from {schema}users u where u.uid = o.uid) order by id",
+	},
+	{
+		name:  "withdrawals: required column is null",
+		query: "select id from {schema}withdrawals where uid is null or order_id is null or processed_at is null or amount is null order by id",
+	},
+	{
+		name:  "withdrawals: owner not found",
+		query: "select id from {schema}withdrawals w where w.uid is not null and not exists (select 1 from {schema}users u where u.uid = w.uid) order by id",
+	},
+	{
+		name: "accounts: required column is null",
+		query: "select id from {schema}accounts where uid is null or account_id is null or balance is null " +
+			"or withdrawals is null or created_at is null order by id",
+	},
+	{
+		name:  "accounts: duplicate uid",
+		query: "select id from {schema}accounts a where exists (select 1 from {schema}accounts d where d.uid = a.uid and d.id <> a.id) order by id",
+	},
+	{
+		name:  "accounts: negative balance",
+		query: "select id from {schema}accounts where balance < 0 order by id",
+	},
+	{
+		name:  "accounts: owner not found",
+		query: "select id from {schema}accounts a where a.uid is not null and not exists (select 1 from {schema}users u where u.uid = a.uid) order by id",
+	},
+}
+
+// Preflight ищет строки, из-за которых миграция ограничений не применится.
+// Проверка имеет смысл, пока миграция ограничений не применена: после нее нарушений быть не может.
+// На пустой БД нарушений нет
+func Preflight(db *sql.DB, dialect Dialect) ([]Violation, error) {
+	if err := setup(dialect); err != nil {
+		return nil, err
+	}
+	current, err := goose.GetDBVersion(db)
+	if err != nil {
+		return nil, err
+	}
+	if current == 0 {
+		return nil, nil
+	}
+
+	var violations []Violation
+	for _, check := range preflightChecks {
+		v, err := runPreflightCheck(db, dialect, check)
+		if err != nil {
+			return nil, fmt.Errorf("preflight %q: %w", check.name, err)
+		}
+		if v.Count > 0 {
+			violations = append(violations, v)
+		}
+	}
+	return violations, nil
+}
+
+func runPreflightCheck(db *sql.DB, dialect Dialect, check preflightCheck) (Violation, error) {
+	rows, err := db.Query(strings.ReplaceAll(check.query, "{schema}", dialect.schema))
+	if err != nil {
+		return Violation{}, err
+	}
+	defer rows.Close()
+
+	v := Violation{Check: check.name}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return Violation{}, err
+		}
+		v.Count++
+		if len(v.Samples) < maxViolationSamples {
+			v.Samples = append(v.Samples, id)
+		}
+	}
+	return v, rows.Err()
+}
+
+// checkConstraints запускает Preflight, если следующий goose.Up применит миграцию ограничений
+func checkConstraints(db *sql.DB, dialect Dialect) error {
+	current, err := goose.GetDBVersion(db)
+	if err != nil {
+		return err
+	}
+	if current >= constraintsVersion {
+		return nil
+	}
+	violations, err := Preflight(db, dialect)
+	if err != nil {
+		return err
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	report := make([]string, 0, len(violations))
+	for _, v := range violations {
+		report = append(report, v.String())
+	}
+	return fmt.Errorf("%w: %s", ErrConstraintViolations, strings.Join(report, "; "))
+}
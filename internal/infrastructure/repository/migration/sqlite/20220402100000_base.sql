@@ -0,0 +1,137 @@
+-- +goose Up
+-- схема соответствует миграциям Postgres из data на момент появления SQLite
+
+CREATE TABLE IF NOT EXISTS users
+(
+    id           integer primary key autoincrement,
+    uid          text,
+    login        text,
+    password     text,
+    created_at   TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX users_login_uniq_idx ON users (login);
+
+CREATE TABLE IF NOT EXISTS sessions
+(
+    id           integer primary key autoincrement,
+    uid          text,
+    sid          text,
+    created_at   TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX sessions_sid_uniq_idx ON sessions (sid);
+
+CREATE TABLE IF NOT EXISTS orders
+(
+    id           integer primary key autoincrement,
+    uid          text,
+    order_id     text,
+    uploaded_at  TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    status       text,
+    accrual      real,
+    retry_count  integer
+);
+CREATE UNIQUE INDEX order_uniq_idx ON orders (order_id);
+
+CREATE TABLE IF NOT EXISTS withdrawals
+(
+    id            integer primary key autoincrement,
+    uid           text,
+    order_id      text,
+    processed_at  TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    amount        real
+);
+CREATE UNIQUE INDEX withdrawals_order_id_uniq_idx ON withdrawals (order_id);
+
+CREATE TABLE IF NOT EXISTS accounts
+(
+    id             integer primary key autoincrement,
+    uid            text,
+    account_id     text,
+    balance        real,
+    withdrawals    real,
+    created_at     TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX accounts_account_id_uniq_idx ON accounts (account_id);
+
+CREATE TABLE IF NOT EXISTS events
+(
+    seq          integer primary key autoincrement,
+    event_id     text,
+    type         text,
+    uid          text,
+    payload      text,
+    created_at   TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX events_event_id_uniq_idx ON events (event_id);
+
+CREATE TABLE IF NOT EXISTS event_publications
+(
+    sink          text,
+    seq           integer,
+    published_at  TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    primary key (sink, seq)
+);
+
+CREATE TABLE IF NOT EXISTS webhook_subscribers
+(
+    id             integer primary key autoincrement,
+    subscriber_id  text,
+    url            text,
+    event_types    text,
+    secret         text,
+    created_at     TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX webhook_subscribers_subscriber_id_uniq_idx ON webhook_subscribers (subscriber_id);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries
+(
+    id                integer primary key autoincrement,
+    delivery_id       text,
+    subscriber_id     text,
+    event_id          text,
+    event_type        text,
+    event_uid         text,
+    payload           text,
+    event_created_at  TIMESTAMP,
+    status            text,
+    attempts          integer,
+    next_attempt_at   TIMESTAMP,
+    last_error        text,
+    created_at        TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX webhook_deliveries_delivery_id_uniq_idx ON webhook_deliveries (delivery_id);
+CREATE UNIQUE INDEX webhook_deliveries_subscriber_event_uniq_idx ON webhook_deliveries (subscriber_id, event_id);
+CREATE INDEX webhook_deliveries_due_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'PENDING';
+
+CREATE TABLE IF NOT EXISTS idempotency_keys
+(
+    id               integer primary key autoincrement,
+    uid              text,
+    idempotency_key  text,
+    fingerprint      text,
+    status_code      integer,
+    content_type     text,
+    body             blob,
+    created_at       TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX idempotency_keys_uid_key_uniq_idx ON idempotency_keys (uid, idempotency_key);
+
+CREATE TABLE IF NOT EXISTS rate_limits
+(
+    key         text primary key,
+    tokens      real not null,
+    updated_at  TIMESTAMP
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS rate_limits;
+DROP TABLE IF EXISTS idempotency_keys;
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhook_subscribers;
+DROP TABLE IF EXISTS event_publications;
+DROP TABLE IF EXISTS events;
+DROP TABLE IF EXISTS accounts;
+DROP TABLE IF EXISTS withdrawals;
+DROP TABLE IF EXISTS orders;
+DROP TABLE IF EXISTS sessions;
+DROP TABLE IF EXISTS users;
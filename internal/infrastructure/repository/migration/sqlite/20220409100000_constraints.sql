@@ -0,0 +1,156 @@
+-- +goose Up
+-- SQLite не умеет добавлять ограничения через ALTER TABLE, поэтому таблицы пересоздаются:
+-- сначала users, на которую ссылаются внешние ключи, затем зависимые таблицы.
+-- перед миграцией строки, которые нарушают ограничения, выводит migration.Preflight (gophermart migrate preflight)
+
+CREATE TABLE users_new
+(
+    id           integer primary key autoincrement,
+    uid          text NOT NULL,
+    login        text NOT NULL,
+    password     text NOT NULL,
+    created_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+INSERT INTO users_new(id, uid, login, password, created_at)
+SELECT id, uid, login, password, created_at FROM users;
+DROP TABLE users;
+ALTER TABLE users_new RENAME TO users;
+CREATE UNIQUE INDEX users_login_uniq_idx ON users (login);
+CREATE UNIQUE INDEX users_uid_uniq_idx ON users (uid);
+
+CREATE TABLE sessions_new
+(
+    id           integer primary key autoincrement,
+    uid          text NOT NULL REFERENCES users (uid),
+    sid          text NOT NULL,
+    created_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+INSERT INTO sessions_new(id, uid, sid, created_at)
+SELECT id, uid, sid, created_at FROM sessions;
+DROP TABLE sessions;
+ALTER TABLE sessions_new RENAME TO sessions;
+CREATE UNIQUE INDEX sessions_sid_uniq_idx ON sessions (sid);
+CREATE INDEX sessions_uid_idx ON sessions (uid);
+
+CREATE TABLE orders_new
+(
+    id           integer primary key autoincrement,
+    uid          text NOT NULL REFERENCES users (uid),
+    order_id     text NOT NULL,
+    uploaded_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    status       text NOT NULL CHECK (status IN ('NEW', 'PROCESSING', 'INVALID', 'PROCESSED', 'TOO_MANY_RETRIES')),
+    accrual      real NOT NULL,
+    retry_count  integer NOT NULL
+);
+INSERT INTO orders_new(id, uid, order_id, uploaded_at, status, accrual, retry_count)
+SELECT id, uid, order_id, uploaded_at, status, accrual, retry_count FROM orders;
+DROP TABLE orders;
+ALTER TABLE orders_new RENAME TO orders;
+CREATE UNIQUE INDEX order_uniq_idx ON orders (order_id);
+CREATE INDEX orders_uid_idx ON orders (uid);
+
+CREATE TABLE withdrawals_new
+(
+    id            integer primary key autoincrement,
+    uid           text NOT NULL REFERENCES users (uid),
+    order_id      text NOT NULL,
+    processed_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    amount        real NOT NULL
+);
+INSERT INTO withdrawals_new(id, uid, order_id, processed_at, amount)
+SELECT id, uid, order_id, processed_at, amount FROM withdrawals;
+DROP TABLE withdrawals;
+ALTER TABLE withdrawals_new RENAME TO withdrawals;
+CREATE UNIQUE INDEX withdrawals_order_id_uniq_idx ON withdrawals (order_id);
+CREATE INDEX withdrawals_uid_idx ON withdrawals (uid);
+
+CREATE TABLE accounts_new
+(
+    id             integer primary key autoincrement,
+    uid            text NOT NULL REFERENCES users (uid),
+    account_id     text NOT NULL,
+    balance        real NOT NULL CHECK (balance >= 0),
+    withdrawals    real NOT NULL,
+    created_at     TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+INSERT INTO accounts_new(id, uid, account_id, balance, withdrawals, created_at)
+SELECT id, uid, account_id, balance, withdrawals, created_at FROM accounts;
+DROP TABLE accounts;
+ALTER TABLE accounts_new RENAME TO accounts;
+CREATE UNIQUE INDEX accounts_account_id_uniq_idx ON accounts (account_id);
+CREATE UNIQUE INDEX accounts_uid_uniq_idx ON accounts (uid);
+
+-- +goose Down
+-- зависимые таблицы пересоздаются раньше users, чтобы на неё не осталось ссылок
+
+CREATE TABLE accounts_old
+(
+    id             integer primary key autoincrement,
+    uid            text,
+    account_id     text,
+    balance        real,
+    withdrawals    real,
+    created_at     TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+INSERT INTO accounts_old(id, uid, account_id, balance, withdrawals, created_at)
+SELECT id, uid, account_id, balance, withdrawals, created_at FROM accounts;
+DROP TABLE accounts;
+ALTER TABLE accounts_old RENAME TO accounts;
+CREATE UNIQUE INDEX accounts_account_id_uniq_idx ON accounts (account_id);
+
+CREATE TABLE withdrawals_old
+(
+    id            integer primary key autoincrement,
+    uid           text,
+    order_id      text,
+    processed_at  TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    amount        real
+);
+INSERT INTO withdrawals_old(id, uid, order_id, processed_at, amount)
+SELECT id, uid, order_id, processed_at, amount FROM withdrawals;
+DROP TABLE withdrawals;
+ALTER TABLE withdrawals_old RENAME TO withdrawals;
+CREATE UNIQUE INDEX withdrawals_order_id_uniq_idx ON withdrawals (order_id);
+
+CREATE TABLE orders_old
+(
+    id           integer primary key autoincrement,
+    uid          text,
+    order_id     text,
+    uploaded_at  TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    status       text,
+    accrual      real,
+    retry_count  integer
+);
+INSERT INTO orders_old(id, uid, order_id, uploaded_at, status, accrual, retry_count)
+SELECT id, uid, order_id, uploaded_at, status, accrual, retry_count FROM orders;
+DROP TABLE orders;
+ALTER TABLE orders_old RENAME TO orders;
+CREATE UNIQUE INDEX order_uniq_idx ON orders (order_id);
+
+CREATE TABLE sessions_old
+(
+    id           integer primary key autoincrement,
+    uid          text,
+    sid          text,
+    created_at   TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+INSERT INTO sessions_old(id, uid, sid, created_at)
+SELECT id, uid, sid, created_at FROM sessions;
+DROP TABLE sessions;
+ALTER TABLE sessions_old RENAME TO sessions;
+CREATE UNIQUE INDEX sessions_sid_uniq_idx ON sessions (sid);
+
+CREATE TABLE users_old
+(
+    id           integer primary key autoincrement,
+    uid          text,
+    login        text,
+    password     text,
+    created_at   TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+INSERT INTO users_old(id, uid, login, password, created_at)
+SELECT id, uid, login, password, created_at FROM users;
+DROP TABLE users;
+ALTER TABLE users_old RENAME TO users;
+CREATE UNIQUE INDEX users_login_uniq_idx ON users (login);
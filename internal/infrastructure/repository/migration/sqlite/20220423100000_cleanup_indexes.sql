@@ -0,0 +1,9 @@
+-- +goose Up
+CREATE INDEX IF NOT EXISTS sessions_created_at_idx ON sessions (created_at);
+CREATE INDEX IF NOT EXISTS idempotency_keys_created_at_idx ON idempotency_keys (created_at);
+CREATE INDEX IF NOT EXISTS rate_limits_updated_at_idx ON rate_limits (updated_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS rate_limits_updated_at_idx;
+DROP INDEX IF EXISTS idempotency_keys_created_at_idx;
+DROP INDEX IF EXISTS sessions_created_at_idx;
@@ -0,0 +1,7 @@
+-- +goose Up
+CREATE INDEX IF NOT EXISTS events_uid_idx ON events (uid);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_event_uid_idx ON webhook_deliveries (event_uid);
+
+-- +goose Down
+DROP INDEX IF EXISTS webhook_deliveries_event_uid_idx;
+DROP INDEX IF EXISTS events_uid_idx;
@@ -0,0 +1,7 @@
+-- +goose Up
+CREATE INDEX IF NOT EXISTS events_created_at_idx ON events (created_at);
+CREATE INDEX IF NOT EXISTS event_publications_seq_idx ON event_publications (seq);
+
+-- +goose Down
+DROP INDEX IF EXISTS event_publications_seq_idx;
+DROP INDEX IF EXISTS events_created_at_idx;
@@ -4,3 +4,7 @@ import "errors"
 
 var ErrOrderExists = errors.New("order already exists")
 var ErrOrderNotFound = errors.New("order not found")
+var ErrInvalidOrderStatus = errors.New("invalid order status")
+
+// ErrUserNotFound заказ нельзя сохранить без пользователя, которому он принадлежит
+var ErrUserNotFound = errors.New("order owner not found")
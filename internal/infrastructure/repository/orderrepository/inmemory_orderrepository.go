@@ -2,61 +2,94 @@ package orderrepository
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"sync"
 	"time"
 
 	"github.com/zaz600/go-musthave-diploma/internal/entity"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/memorystore"
 )
 
+// opPutOrder в журнал пишется заказ после изменения
+const opPutOrder = "put"
+
 type InmemoryOrderRepository struct {
-	mu sync.RWMutex
-	db map[string]entity.Order
+	mu      sync.RWMutex
+	db      map[string]entity.Order
+	journal *memorystore.Journal
 }
 
-func (r *InmemoryOrderRepository) AddOrder(_ context.Context, order entity.Order) error {
+func (r *InmemoryOrderRepository) AddOrder(ctx context.Context, order entity.Order) error {
+	defer r.journal.Lock(ctx)()
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	if !entity.IsKnownOrderStatus(order.Status) {
+		return ErrInvalidOrderStatus
+	}
 	if _, ok := r.db[order.OrderID]; ok {
 		return ErrOrderExists
 	}
-	r.db[order.OrderID] = order
-	return nil
+	return r.put(ctx, order)
 }
 
-func (r *InmemoryOrderRepository) UpdateOrder(_ context.Context, order entity.Order) error {
+func (r *InmemoryOrderRepository) UpdateOrder(ctx context.Context, order entity.Order) error {
+	defer r.journal.Lock(ctx)()
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	r.db[order.OrderID] = order
-	return nil
+	return r.put(ctx, order)
 }
 
-func (r *InmemoryOrderRepository) SetOrderStatusAndAccrual(_ context.Context, orderID string, status entity.OrderStatus, accrual float32) error {
+func (r *InmemoryOrderRepository) SetOrderStatusAndAccrual(ctx context.Context, orderID string, status entity.OrderStatus, accrual float32) error {
+	if !entity.IsKnownOrderStatus(status) {
+		return ErrInvalidOrderStatus
+	}
+
+	defer r.journal.Lock(ctx)()
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	if order, ok := r.db[orderID]; ok {
 		order.Status = status
 		order.Accrual = accrual
-		r.db[orderID] = order
-		return nil
+		return r.put(ctx, order)
 	}
 	return ErrOrderNotFound
 }
 
-func (r *InmemoryOrderRepository) SetOrderNextRetryAt(_ context.Context, orderID string, _ time.Time) error {
+func (r *InmemoryOrderRepository) SetOrderNextRetryAt(ctx context.Context, orderID string, _ time.Time) error {
+	defer r.journal.Lock(ctx)()
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	if order, ok := r.db[orderID]; ok {
 		order.RetryCount++
-		r.db[orderID] = order
-		return nil
+		return r.put(ctx, order)
 	}
 	return ErrOrderNotFound
 }
 
+// put записывает заказ в журнал и сохраняет его. При откате транзакции возвращается прежний заказ
+func (r *InmemoryOrderRepository) put(ctx context.Context, order entity.Order) error {
+	if err := r.journal.Append(ctx, opPutOrder, order); err != nil {
+		return err
+	}
+	prev, existed := r.db[order.OrderID]
+	r.db[order.OrderID] = order
+	memorystore.OnRollback(ctx, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if existed {
+			r.db[order.OrderID] = prev
+			return
+		}
+		delete(r.db, order.OrderID)
+	})
+	return nil
+}
+
 func (r *InmemoryOrderRepository) GetUserOrders(_ context.Context, userID string) ([]entity.Order, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -81,10 +114,82 @@ func (r *InmemoryOrderRepository) GetOrder(_ context.Context, orderID string) (e
 	return order, nil
 }
 
+func (r *InmemoryOrderRepository) GetOrdersByStatus(_ context.Context, statuses ...entity.OrderStatus) ([]entity.Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var orders []entity.Order
+	for _, order := range r.db {
+		for _, status := range statuses {
+			if order.Status == status {
+				orders = append(orders, order)
+				break
+			}
+		}
+	}
+	return orders, nil
+}
+
+func (r *InmemoryOrderRepository) CountOrdersByStatus(_ context.Context) (map[entity.OrderStatus]int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counts := make(map[entity.OrderStatus]int)
+	for _, order := range r.db {
+		counts[order.Status]++
+	}
+	return counts, nil
+}
+
 func (r *InmemoryOrderRepository) Close() error {
 	return nil
 }
 
+func (r *InmemoryOrderRepository) SetJournal(j *memorystore.Journal) {
+	r.journal = j
+}
+
+func (r *InmemoryOrderRepository) Snapshot() (interface{}, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	orders := make([]entity.Order, 0, len(r.db))
+	for _, order := range r.db {
+		orders = append(orders, order)
+	}
+	return orders, nil
+}
+
+func (r *InmemoryOrderRepository) Restore(state json.RawMessage) error {
+	var orders []entity.Order
+	if err := json.Unmarshal(state, &orders); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.db = make(map[string]entity.Order, len(orders))
+	for _, order := range orders {
+		r.db[order.OrderID] = order
+	}
+	return nil
+}
+
+func (r *InmemoryOrderRepository) Apply(op string, data json.RawMessage) error {
+	if op != opPutOrder {
+		return fmt.Errorf("%w: %s", memorystore.ErrUnknownOp, op)
+	}
+	var order entity.Order
+	if err := json.Unmarshal(data, &order); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.db[order.OrderID] = order
+	return nil
+}
+
 func NewInmemoryOrderRepository() *InmemoryOrderRepository {
 	return &InmemoryOrderRepository{
 		mu: sync.RWMutex{},
@@ -14,5 +14,9 @@ type OrderRepository interface {
 	SetOrderNextRetryAt(ctx context.Context, orderID string, nextRetryAt time.Time) error
 	GetUserOrders(ctx context.Context, userID string) ([]entity.Order, error)
 	GetOrder(ctx context.Context, orderID string) (entity.Order, error)
+	// GetOrdersByStatus возвращает заказы всех пользователей в статусах statuses
+	GetOrdersByStatus(ctx context.Context, statuses ...entity.OrderStatus) ([]entity.Order, error)
+	// CountOrdersByStatus возвращает количество заказов в каждом статусе
+	CountOrdersByStatus(ctx context.Context) (map[entity.OrderStatus]int, error)
 	io.Closer
 }
@@ -0,0 +1,42 @@
+package orderrepository_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/orderrepository"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/repositorytest"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/userrepository"
+)
+
+func TestOrderRepository(t *testing.T) {
+	t.Run("inmemory", func(t *testing.T) {
+		repositorytest.OrderRepository(t, func(t *testing.T) (orderrepository.OrderRepository, userrepository.UserRepository) {
+			return orderrepository.NewInmemoryOrderRepository(), userrepository.NewInmemoryUserRepository()
+		})
+	})
+	t.Run("journaled", func(t *testing.T) {
+		repositorytest.OrderRepository(t, func(t *testing.T) (orderrepository.OrderRepository, userrepository.UserRepository) {
+			repo := orderrepository.NewInmemoryOrderRepository()
+			repositorytest.Journaled(t, repo)
+			return repo, userrepository.NewInmemoryUserRepository()
+		})
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		repositorytest.OrderRepository(t, func(t *testing.T) (orderrepository.OrderRepository, userrepository.UserRepository) {
+			db := repositorytest.SQLite(t)
+			repo, err := orderrepository.NewSQLiteOrderRepository(db)
+			require.NoError(t, err)
+			users, err := userrepository.NewSQLiteUserRepository(db)
+			require.NoError(t, err)
+			return repo, users
+		})
+	})
+	t.Run("postgres", func(t *testing.T) {
+		db := repositorytest.Postgres(t)
+		repositorytest.OrderRepository(t, func(t *testing.T) (orderrepository.OrderRepository, userrepository.UserRepository) {
+			repositorytest.Truncate(t, db)
+			return orderrepository.NewPgOrderRepository(db), userrepository.NewPgUserRepository(db)
+		})
+	})
+}
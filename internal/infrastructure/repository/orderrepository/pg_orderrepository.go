@@ -2,19 +2,18 @@ package orderrepository
 
 import (
 	"context"
-	"database/sql"
 	"errors"
-	"fmt"
 	"time"
 
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgerrcode"
 	"github.com/zaz600/go-musthave-diploma/internal/entity"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/pgdb"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/transaction"
 )
 
 type PgOrderRepository struct {
-	db         *sql.DB
-	statements map[queryType]*sql.Stmt
+	db *pgdb.Pool
 }
 
 type queryType string
@@ -25,73 +24,90 @@ const (
 	querySetOrderNextRetryAt queryType = "setOrderNextRetryAt"
 	queryGetUserOrders       queryType = "GetUserOrders"
 	queryGetOrder            queryType = "GetOrder"
+	queryGetOrdersByStatus   queryType = "GetOrdersByStatus"
+	queryCountOrdersByStatus queryType = "CountOrdersByStatus"
 )
 
 var queries = map[queryType]string{
-	queryAddOrder:            "insert into gophermart.orders(uid, order_id, status, accrual, retry_count) values($1, $2, $3, $4, $5)",
+	queryAddOrder:            "insert into gophermart.orders(uid, order_id, uploaded_at, status, accrual, retry_count) values($1, $2, $3, $4, $5, $6)",
 	querySetOrderStatus:      "update gophermart.orders set status=$1, accrual=$2 where order_id=$3",
 	querySetOrderNextRetryAt: "update gophermart.orders set retry_count=retry_count+1 where order_id=$1",
 	queryGetUserOrders:       "select uid, order_id, uploaded_at, status, accrual, retry_count from gophermart.orders where uid=$1",
 	queryGetOrder:            "select uid, order_id, uploaded_at, status, accrual, retry_count from gophermart.orders where order_id=$1",
+	queryGetOrdersByStatus:   "select uid, order_id, uploaded_at, status, accrual, retry_count from gophermart.orders where status = any($1)",
+	queryCountOrdersByStatus: "select status, count(*) from gophermart.orders group by status",
 }
 
 func (p PgOrderRepository) AddOrder(ctx context.Context, order entity.Order) error {
-	tx, err := p.db.Begin()
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback() //nolint:errcheck
-	stmt := tx.Stmt(p.statements[queryAddOrder])
-	_, err = stmt.ExecContext(ctx, order.UID, order.OrderID, order.Status, order.Accrual, order.RetryCount)
+	_, err := transaction.PgQuerier(ctx, p.db).Exec(ctx, queries[queryAddOrder],
+		order.UID, order.OrderID, order.UploadedAt, order.Status, order.Accrual, order.RetryCount)
 	if err != nil {
 		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
-			return ErrOrderExists
+		if errors.As(err, &pgErr) {
+			switch pgErr.Code {
+			case pgerrcode.UniqueViolation:
+				return ErrOrderExists
+			case pgerrcode.ForeignKeyViolation:
+				return ErrUserNotFound
+			case pgerrcode.CheckViolation:
+				return ErrInvalidOrderStatus
+			}
 		}
 		return err
 	}
-	if err = tx.Commit(); err != nil {
-		return err
-	}
 	return nil
 }
 
 func (p PgOrderRepository) SetOrderStatusAndAccrual(ctx context.Context, orderID string, status entity.OrderStatus, accrual float32) error {
-	tx, err := p.db.Begin()
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback() //nolint:errcheck
-	stmt := tx.Stmt(p.statements[querySetOrderStatus])
-	_, err = stmt.ExecContext(ctx, status, accrual, orderID)
+	result, err := transaction.PgQuerier(ctx, p.db).Exec(ctx, queries[querySetOrderStatus], status, accrual, orderID)
 	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.CheckViolation {
+			return ErrInvalidOrderStatus
+		}
 		return err
 	}
-	if err = tx.Commit(); err != nil {
-		return err
+	if result.RowsAffected() == 0 {
+		return ErrOrderNotFound
 	}
 	return nil
 }
 
 func (p PgOrderRepository) SetOrderNextRetryAt(ctx context.Context, orderID string, nextRetryAt time.Time) error {
-	tx, err := p.db.Begin()
+	result, err := transaction.PgQuerier(ctx, p.db).Exec(ctx, queries[querySetOrderNextRetryAt], orderID)
 	if err != nil {
 		return err
 	}
-	defer tx.Rollback() //nolint:errcheck
-	stmt := tx.Stmt(p.statements[querySetOrderNextRetryAt])
-	_, err = stmt.ExecContext(ctx, orderID)
-	if err != nil {
-		return err
-	}
-	if err = tx.Commit(); err != nil {
-		return err
+	if result.RowsAffected() == 0 {
+		return ErrOrderNotFound
 	}
 	return nil
 }
 
 func (p PgOrderRepository) GetUserOrders(ctx context.Context, userID string) ([]entity.Order, error) {
-	rows, err := p.statements[queryGetUserOrders].QueryContext(ctx, userID)
+	return p.queryOrders(ctx, queries[queryGetUserOrders], userID)
+}
+
+func (p PgOrderRepository) GetOrder(ctx context.Context, orderID string) (entity.Order, error) {
+	var order entity.Order
+	err := transaction.PgQuerier(ctx, p.db).QueryRow(ctx, queries[queryGetOrder], orderID).
+		Scan(&order.UID, &order.OrderID, &order.UploadedAt, &order.Status, &order.Accrual, &order.RetryCount)
+	if err != nil {
+		return order, ErrOrderNotFound
+	}
+	return order, nil
+}
+
+func (p PgOrderRepository) GetOrdersByStatus(ctx context.Context, statuses ...entity.OrderStatus) ([]entity.Order, error) {
+	values := make([]string, 0, len(statuses))
+	for _, status := range statuses {
+		values = append(values, string(status))
+	}
+	return p.queryOrders(ctx, queries[queryGetOrdersByStatus], values)
+}
+
+func (p PgOrderRepository) queryOrders(ctx context.Context, query string, args ...interface{}) ([]entity.Order, error) {
+	rows, err := transaction.PgQuerier(ctx, p.db).Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -100,7 +116,7 @@ func (p PgOrderRepository) GetUserOrders(ctx context.Context, userID string) ([]
 	for rows.Next() {
 		var order entity.Order
 		if err := rows.Scan(&order.UID, &order.OrderID, &order.UploadedAt, &order.Status, &order.Accrual, &order.RetryCount); err != nil {
-			return orders, err
+			return nil, err
 		}
 		orders = append(orders, order)
 	}
@@ -110,34 +126,32 @@ func (p PgOrderRepository) GetUserOrders(ctx context.Context, userID string) ([]
 	return orders, nil
 }
 
-func (p PgOrderRepository) GetOrder(ctx context.Context, orderID string) (entity.Order, error) {
-	var order entity.Order
-	err := p.statements[queryGetOrder].QueryRowContext(ctx, orderID).Scan(&order.UID, &order.OrderID, &order.UploadedAt, &order.Status, &order.Accrual, &order.RetryCount)
+func (p PgOrderRepository) CountOrdersByStatus(ctx context.Context) (map[entity.OrderStatus]int, error) {
+	rows, err := transaction.PgQuerier(ctx, p.db).Query(ctx, queries[queryCountOrdersByStatus])
 	if err != nil {
-		return order, ErrOrderNotFound
+		return nil, err
 	}
-	return order, nil
+	defer rows.Close()
+	counts := make(map[entity.OrderStatus]int)
+	for rows.Next() {
+		var status entity.OrderStatus
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+		counts[status] = count
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return counts, nil
 }
 
+// Close ничего не делает: пул соединений общий для всех репозиториев
 func (p PgOrderRepository) Close() error {
-	for name, stmt := range p.statements {
-		err := stmt.Close()
-		if err != nil {
-			return fmt.Errorf("error close stmt %s: %w", name, err)
-		}
-	}
 	return nil
 }
 
-func NewPgOrderRepository(db *sql.DB) (*PgOrderRepository, error) {
-	statements := make(map[queryType]*sql.Stmt, len(queries))
-	for name, query := range queries {
-		stmt, err := db.Prepare(query)
-		if err != nil {
-			return nil, fmt.Errorf("error prepare statement for %s: %w", name, err)
-		}
-		statements[name] = stmt
-	}
-
-	return &PgOrderRepository{db: db, statements: statements}, nil
+func NewPgOrderRepository(db *pgdb.Pool) *PgOrderRepository {
+	return &PgOrderRepository{db: db}
 }
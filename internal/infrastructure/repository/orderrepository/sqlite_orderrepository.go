@@ -0,0 +1,169 @@
+package orderrepository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/zaz600/go-musthave-diploma/internal/entity"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/sqlitedb"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/transaction"
+)
+
+type SQLiteOrderRepository struct {
+	db         *sql.DB
+	statements map[queryType]*sql.Stmt
+}
+
+// sqliteQueries в SQLite нет массивов, списки передаются JSON-массивом и разворачиваются json_each
+var sqliteQueries = map[queryType]string{
+	queryAddOrder:            "insert into orders(uid, order_id, uploaded_at, status, accrual, retry_count) values($1, $2, $3, $4, $5, $6)",
+	querySetOrderStatus:      "update orders set status=$1, accrual=$2 where order_id=$3",
+	querySetOrderNextRetryAt: "update orders set retry_count=retry_count+1 where order_id=$1",
+	queryGetUserOrders:       "select uid, order_id, uploaded_at, status, accrual, retry_count from orders where uid=$1",
+	queryGetOrder:            "select uid, order_id, uploaded_at, status, accrual, retry_count from orders where order_id=$1",
+	queryGetOrdersByStatus:   "select uid, order_id, uploaded_at, status, accrual, retry_count from orders where status in (select value from json_each($1))",
+	queryCountOrdersByStatus: "select status, count(*) from orders group by status",
+}
+
+func (p SQLiteOrderRepository) AddOrder(ctx context.Context, order entity.Order) error {
+	tx, err := transaction.Begin(ctx, p.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+	stmt := tx.Stmt(p.statements[queryAddOrder])
+	_, err = stmt.ExecContext(ctx, order.UID, order.OrderID, order.UploadedAt, order.Status, order.Accrual, order.RetryCount)
+	if err != nil {
+		switch {
+		case sqlitedb.IsUniqueViolation(err):
+			return ErrOrderExists
+		case sqlitedb.IsForeignKeyViolation(err):
+			return ErrUserNotFound
+		case sqlitedb.IsCheckViolation(err):
+			return ErrInvalidOrderStatus
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+func (p SQLiteOrderRepository) SetOrderStatusAndAccrual(ctx context.Context, orderID string, status entity.OrderStatus, accrual float32) error {
+	err := p.exec(ctx, querySetOrderStatus, status, accrual, orderID)
+	if sqlitedb.IsCheckViolation(err) {
+		return ErrInvalidOrderStatus
+	}
+	return err
+}
+
+func (p SQLiteOrderRepository) SetOrderNextRetryAt(ctx context.Context, orderID string, _ time.Time) error {
+	return p.exec(ctx, querySetOrderNextRetryAt, orderID)
+}
+
+// exec выполняет запрос, изменяющий один заказ
+func (p SQLiteOrderRepository) exec(ctx context.Context, query queryType, args ...interface{}) error {
+	tx, err := transaction.Begin(ctx, p.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+	result, err := tx.Stmt(p.statements[query]).ExecContext(ctx, args...)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrOrderNotFound
+	}
+	return tx.Commit()
+}
+
+func (p SQLiteOrderRepository) GetUserOrders(ctx context.Context, userID string) ([]entity.Order, error) {
+	return p.queryOrders(ctx, queryGetUserOrders, userID)
+}
+
+func (p SQLiteOrderRepository) GetOrder(ctx context.Context, orderID string) (entity.Order, error) {
+	var order entity.Order
+	err := p.statements[queryGetOrder].QueryRowContext(ctx, orderID).Scan(&order.UID, &order.OrderID, &order.UploadedAt, &order.Status, &order.Accrual, &order.RetryCount)
+	if err != nil {
+		return order, ErrOrderNotFound
+	}
+	return order, nil
+}
+
+func (p SQLiteOrderRepository) GetOrdersByStatus(ctx context.Context, statuses ...entity.OrderStatus) ([]entity.Order, error) {
+	values, err := json.Marshal(statuses)
+	if err != nil {
+		return nil, err
+	}
+	return p.queryOrders(ctx, queryGetOrdersByStatus, string(values))
+}
+
+func (p SQLiteOrderRepository) queryOrders(ctx context.Context, query queryType, args ...interface{}) ([]entity.Order, error) {
+	rows, err := p.statements[query].QueryContext(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var orders []entity.Order
+	for rows.Next() {
+		var order entity.Order
+		if err := rows.Scan(&order.UID, &order.OrderID, &order.UploadedAt, &order.Status, &order.Accrual, &order.RetryCount); err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return orders, nil
+}
+
+func (p SQLiteOrderRepository) CountOrdersByStatus(ctx context.Context) (map[entity.OrderStatus]int, error) {
+	rows, err := p.statements[queryCountOrdersByStatus].QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	counts := make(map[entity.OrderStatus]int)
+	for rows.Next() {
+		var status entity.OrderStatus
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+		counts[status] = count
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return counts, nil
+}
+
+func (p SQLiteOrderRepository) Close() error {
+	for name, stmt := range p.statements {
+		err := stmt.Close()
+		if err != nil {
+			return fmt.Errorf("error close stmt %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func NewSQLiteOrderRepository(db *sql.DB) (*SQLiteOrderRepository, error) {
+	statements := make(map[queryType]*sql.Stmt, len(sqliteQueries))
+	for name, query := range sqliteQueries {
+		stmt, err := db.Prepare(query)
+		if err != nil {
+			return nil, fmt.Errorf("error prepare statement for %s: %w", name, err)
+		}
+		statements[name] = stmt
+	}
+
+	return &SQLiteOrderRepository{db: db, statements: statements}, nil
+}
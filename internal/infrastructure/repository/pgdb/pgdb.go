@@ -0,0 +1,153 @@
+// Package pgdb пул соединений с Postgres на pgx без database/sql.
+// Подготовленные запросы кеширует драйвер на каждом соединении, поэтому репозитории передают текст запроса
+package pgdb
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/jackc/pgx/v4/stdlib"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/metrics"
+)
+
+// Querier запросы, которые выполняются и на пуле, и в транзакции
+type Querier interface {
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+	// SendBatch отправляет запросы одним обменом с сервером. Вне транзакции они выполняются в неявной транзакции
+	SendBatch(ctx context.Context, batch *pgx.Batch) pgx.BatchResults
+}
+
+// Pool пул соединений, запросы которого попадают в трассировку
+type Pool struct {
+	*pgxpool.Pool
+}
+
+var _ Querier = (*Pool)(nil)
+
+type Option func(cfg *pgxpool.Config)
+
+// WithMaxConns максимальное число соединений в пуле
+func WithMaxConns(n int) Option {
+	return func(cfg *pgxpool.Config) {
+		cfg.MaxConns = int32(n)
+	}
+}
+
+// WithMinConns сколько соединений пул держит открытыми без нагрузки
+func WithMinConns(n int) Option {
+	return func(cfg *pgxpool.Config) {
+		cfg.MinConns = int32(n)
+	}
+}
+
+// WithMaxConnLifetime через сколько соединение закрывается и открывается заново
+func WithMaxConnLifetime(d time.Duration) Option {
+	return func(cfg *pgxpool.Config) {
+		cfg.MaxConnLifetime = d
+	}
+}
+
+// WithMaxConnIdleTime через сколько закрывается неиспользуемое соединение
+func WithMaxConnIdleTime(d time.Duration) Option {
+	return func(cfg *pgxpool.Config) {
+		cfg.MaxConnIdleTime = d
+	}
+}
+
+// WithConnectTimeout сколько ждать установки соединения
+func WithConnectTimeout(d time.Duration) Option {
+	return func(cfg *pgxpool.Config) {
+		cfg.ConnConfig.ConnectTimeout = d
+	}
+}
+
+// Open создает пул и проверяет, что к серверу можно подключиться.
+// Параметры драйвера, например statement_cache_capacity, задаются в dsn
+func Open(ctx context.Context, dsn string, opts ...Option) (*Pool, error) {
+	cfg, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, err
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	pool, err := pgxpool.ConnectConfig(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Pool{Pool: pool}, nil
+}
+
+// OpenDB подключение database/sql с настройками соединений пула для кода, которому нужен *sql.DB,
+// например для миграций. Соединения открываются отдельно от пула, закрывает их вызывающий
+func (p *Pool) OpenDB() *sql.DB {
+	return stdlib.OpenDB(*p.Config().ConnConfig)
+}
+
+// Stats состояние пула для метрик
+func (p *Pool) Stats() metrics.DBPoolStats {
+	s := p.Stat()
+	return metrics.DBPoolStats{
+		MaxConns:        int(s.MaxConns()),
+		TotalConns:      int(s.TotalConns()),
+		AcquiredConns:   int(s.AcquiredConns()),
+		IdleConns:       int(s.IdleConns()),
+		Acquires:        s.AcquireCount(),
+		WaitedAcquires:  s.EmptyAcquireCount(),
+		AcquireDuration: s.AcquireDuration(),
+	}
+}
+
+func (p *Pool) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	return traceExec(ctx, p.Pool, sql, args)
+}
+
+func (p *Pool) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	return traceQuery(ctx, p.Pool, sql, args)
+}
+
+func (p *Pool) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	return traceQueryRow(ctx, p.Pool, sql, args)
+}
+
+func (p *Pool) SendBatch(ctx context.Context, batch *pgx.Batch) pgx.BatchResults {
+	return traceBatch(ctx, p.Pool, batch)
+}
+
+// Begin открывает транзакцию, запросы которой попадают в трассировку
+func (p *Pool) Begin(ctx context.Context) (pgx.Tx, error) {
+	tx, err := p.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &Tx{Tx: tx}, nil
+}
+
+// Tx транзакция, запросы которой попадают в трассировку
+type Tx struct {
+	pgx.Tx
+}
+
+var _ Querier = (*Tx)(nil)
+
+func (t *Tx) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	return traceExec(ctx, t.Tx, sql, args)
+}
+
+func (t *Tx) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	return traceQuery(ctx, t.Tx, sql, args)
+}
+
+func (t *Tx) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	return traceQueryRow(ctx, t.Tx, sql, args)
+}
+
+func (t *Tx) SendBatch(ctx context.Context, batch *pgx.Batch) pgx.BatchResults {
+	return traceBatch(ctx, t.Tx, batch)
+}
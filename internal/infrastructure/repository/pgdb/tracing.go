@@ -0,0 +1,100 @@
+package pgdb
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/pgdb"
+
+var tracer = otel.Tracer(instrumentationName)
+
+var attrBatchSize = attribute.Key("db.batch.size")
+
+// startSpan открывает спан запроса к БД
+func startSpan(ctx context.Context, operation string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "pgx."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemPostgreSQL),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// endSpan завершает спан, отмечая в нем ошибку, если она есть. Отсутствие строк ошибкой не считается
+func endSpan(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func traceExec(ctx context.Context, q Querier, sql string, args []interface{}) (pgconn.CommandTag, error) {
+	ctx, span := startSpan(ctx, "Exec", semconv.DBStatementKey.String(sql))
+	tag, err := q.Exec(ctx, sql, args...)
+	endSpan(span, err)
+	return tag, err
+}
+
+func traceQuery(ctx context.Context, q Querier, sql string, args []interface{}) (pgx.Rows, error) {
+	ctx, span := startSpan(ctx, "Query", semconv.DBStatementKey.String(sql))
+	rows, err := q.Query(ctx, sql, args...)
+	if err != nil {
+		endSpan(span, err)
+		return nil, err
+	}
+	return &tracedRows{Rows: rows, span: span}, nil
+}
+
+func traceQueryRow(ctx context.Context, q Querier, sql string, args []interface{}) pgx.Row {
+	ctx, span := startSpan(ctx, "QueryRow", semconv.DBStatementKey.String(sql))
+	return &tracedRow{row: q.QueryRow(ctx, sql, args...), span: span}
+}
+
+func traceBatch(ctx context.Context, q Querier, batch *pgx.Batch) pgx.BatchResults {
+	ctx, span := startSpan(ctx, "SendBatch", attrBatchSize.Int(batch.Len()))
+	return &tracedBatchResults{BatchResults: q.SendBatch(ctx, batch), span: span}
+}
+
+// tracedRows завершает спан запроса, когда строки прочитаны
+type tracedRows struct {
+	pgx.Rows
+	span trace.Span
+}
+
+func (r *tracedRows) Close() {
+	r.Rows.Close()
+	endSpan(r.span, r.Rows.Err())
+}
+
+// tracedRow завершает спан запроса после чтения строки
+type tracedRow struct {
+	row  pgx.Row
+	span trace.Span
+}
+
+func (r *tracedRow) Scan(dest ...interface{}) error {
+	err := r.row.Scan(dest...)
+	endSpan(r.span, err)
+	return err
+}
+
+// tracedBatchResults завершает спан пакета запросов, когда прочитаны результаты всех запросов
+type tracedBatchResults struct {
+	pgx.BatchResults
+	span trace.Span
+}
+
+func (b *tracedBatchResults) Close() error {
+	err := b.BatchResults.Close()
+	endSpan(b.span, err)
+	return err
+}
@@ -0,0 +1,51 @@
+package ratelimitrepository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/ratelimit"
+)
+
+type InmemoryRateLimitRepository struct {
+	mu sync.Mutex
+	db map[string]ratelimit.Bucket
+}
+
+func (r *InmemoryRateLimitRepository) Take(_ context.Context, key string, limit ratelimit.Limit, now time.Time) (ratelimit.Result, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	bucket, result := ratelimit.Take(r.db[key], limit, now)
+	r.db[key] = bucket
+	return result, nil
+}
+
+func (r *InmemoryRateLimitRepository) DelBucketsUpdatedBefore(_ context.Context, before time.Time, limit int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deleted := 0
+	for key, bucket := range r.db {
+		if deleted == limit {
+			break
+		}
+		if bucket.UpdatedAt.Before(before) {
+			delete(r.db, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (r *InmemoryRateLimitRepository) Close() error {
+	return nil
+}
+
+func NewInmemoryRateLimitRepository() *InmemoryRateLimitRepository {
+	return &InmemoryRateLimitRepository{
+		mu: sync.Mutex{},
+		db: make(map[string]ratelimit.Bucket, 100),
+	}
+}
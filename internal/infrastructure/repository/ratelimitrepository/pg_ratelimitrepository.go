@@ -0,0 +1,95 @@
+package ratelimitrepository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/pgdb"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/transaction"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/ratelimit"
+)
+
+type PgRateLimitRepository struct {
+	db *pgdb.Pool
+}
+
+type queryType string
+
+const (
+	queryAddBucket    queryType = "addBucket"
+	queryGetBucket    queryType = "getBucket"
+	queryUpdateBucket queryType = "updateBucket"
+	queryPurgeBuckets queryType = "purgeBuckets"
+)
+
+var queries = map[queryType]string{
+	queryAddBucket: "insert into gophermart.rate_limits(key, tokens, updated_at) values($1, 0, null) " +
+		"on conflict (key) do nothing",
+	queryGetBucket:    "select tokens, updated_at from gophermart.rate_limits where key=$1 for update",
+	queryUpdateBucket: "update gophermart.rate_limits set tokens=$1, updated_at=$2 where key=$3",
+	queryPurgeBuckets: "delete from gophermart.rate_limits where key in " +
+		"(select key from gophermart.rate_limits where updated_at < $1 order by updated_at limit $2)",
+}
+
+// Take блокирует строку корзины до конца транзакции, поэтому экземпляры сервиса не списывают токены параллельно.
+// Создание и чтение корзины отправляются одним пакетом
+func (p PgRateLimitRepository) Take(ctx context.Context, key string, limit ratelimit.Limit, now time.Time) (ratelimit.Result, error) {
+	tx, err := transaction.BeginPg(ctx, p.db)
+	if err != nil {
+		return ratelimit.Result{}, err
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	bucket, err := p.lockBucket(ctx, tx, key)
+	if err != nil {
+		return ratelimit.Result{}, err
+	}
+
+	bucket, result := ratelimit.Take(bucket, limit, now)
+	// timestamp без зоны: время хранится в UTC, чтобы экземпляры в разных зонах считали одинаково
+	_, err = tx.Exec(ctx, queries[queryUpdateBucket], bucket.Tokens, bucket.UpdatedAt.UTC(), key)
+	if err != nil {
+		return ratelimit.Result{}, err
+	}
+	return result, tx.Commit(ctx)
+}
+
+// lockBucket создает корзину, если ее нет, и блокирует ее строку
+func (p PgRateLimitRepository) lockBucket(ctx context.Context, tx *transaction.PgTx, key string) (ratelimit.Bucket, error) {
+	batch := &pgx.Batch{}
+	batch.Queue(queries[queryAddBucket], key)
+	batch.Queue(queries[queryGetBucket], key)
+	results := tx.SendBatch(ctx, batch)
+	defer results.Close()
+
+	var bucket ratelimit.Bucket
+	if _, err := results.Exec(); err != nil {
+		return bucket, err
+	}
+	var updatedAt sql.NullTime
+	if err := results.QueryRow().Scan(&bucket.Tokens, &updatedAt); err != nil {
+		return bucket, err
+	}
+	// у новой корзины updated_at не задан - она считается полной
+	bucket.UpdatedAt = updatedAt.Time
+	return bucket, results.Close()
+}
+
+func (p PgRateLimitRepository) DelBucketsUpdatedBefore(ctx context.Context, before time.Time, limit int) (int, error) {
+	result, err := transaction.PgQuerier(ctx, p.db).Exec(ctx, queries[queryPurgeBuckets], before.UTC(), limit)
+	if err != nil {
+		return 0, err
+	}
+	return int(result.RowsAffected()), nil
+}
+
+// Close ничего не делает: пул соединений общий для всех репозиториев
+func (p PgRateLimitRepository) Close() error {
+	return nil
+}
+
+func NewPgRateLimitRepository(db *pgdb.Pool) *PgRateLimitRepository {
+	return &PgRateLimitRepository{db: db}
+}
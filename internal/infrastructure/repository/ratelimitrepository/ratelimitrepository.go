@@ -0,0 +1,19 @@
+package ratelimitrepository
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/ratelimit"
+)
+
+// RateLimitRepository хранит корзины токенов клиентов
+type RateLimitRepository interface {
+	// Take атомарно списывает токен из корзины key. Корзина создается полной при первом обращении
+	Take(ctx context.Context, key string, limit ratelimit.Limit, now time.Time) (ratelimit.Result, error)
+	// DelBucketsUpdatedBefore удаляет не больше limit корзин, из которых не списывали токены с before,
+	// и возвращает, сколько удалено. Удаленная корзина при следующем обращении создается полной
+	DelBucketsUpdatedBefore(ctx context.Context, before time.Time, limit int) (int, error)
+	io.Closer
+}
@@ -0,0 +1,31 @@
+package ratelimitrepository_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/ratelimitrepository"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/repositorytest"
+)
+
+func TestRateLimitRepository(t *testing.T) {
+	t.Run("inmemory", func(t *testing.T) {
+		repositorytest.RateLimitRepository(t, func(t *testing.T) ratelimitrepository.RateLimitRepository {
+			return ratelimitrepository.NewInmemoryRateLimitRepository()
+		})
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		repositorytest.RateLimitRepository(t, func(t *testing.T) ratelimitrepository.RateLimitRepository {
+			repo, err := ratelimitrepository.NewSQLiteRateLimitRepository(repositorytest.SQLite(t))
+			require.NoError(t, err)
+			return repo
+		})
+	})
+	t.Run("postgres", func(t *testing.T) {
+		db := repositorytest.Postgres(t)
+		repositorytest.RateLimitRepository(t, func(t *testing.T) ratelimitrepository.RateLimitRepository {
+			repositorytest.Truncate(t, db)
+			return ratelimitrepository.NewPgRateLimitRepository(db)
+		})
+	})
+}
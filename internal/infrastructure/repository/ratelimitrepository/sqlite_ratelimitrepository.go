@@ -0,0 +1,86 @@
+package ratelimitrepository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/transaction"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/ratelimit"
+)
+
+type SQLiteRateLimitRepository struct {
+	db         *sql.DB
+	statements map[queryType]*sql.Stmt
+}
+
+var sqliteQueries = map[queryType]string{
+	queryAddBucket: "insert into rate_limits(key, tokens, updated_at) values($1, 0, null) " +
+		"on conflict (key) do nothing",
+	queryGetBucket:    "select tokens, updated_at from rate_limits where key=$1",
+	queryUpdateBucket: "update rate_limits set tokens=$1, updated_at=$2 where key=$3",
+	queryPurgeBuckets: "delete from rate_limits where key in " +
+		"(select key from rate_limits where updated_at < $1 order by updated_at limit $2)",
+}
+
+// Take транзакции SQLite выполняются по одной, поэтому токены не списываются параллельно
+func (p SQLiteRateLimitRepository) Take(ctx context.Context, key string, limit ratelimit.Limit, now time.Time) (ratelimit.Result, error) {
+	tx, err := transaction.Begin(ctx, p.db)
+	if err != nil {
+		return ratelimit.Result{}, err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	if _, err := tx.Stmt(p.statements[queryAddBucket]).ExecContext(ctx, key); err != nil {
+		return ratelimit.Result{}, err
+	}
+
+	var bucket ratelimit.Bucket
+	var updatedAt sql.NullTime
+	err = tx.Stmt(p.statements[queryGetBucket]).QueryRowContext(ctx, key).Scan(&bucket.Tokens, &updatedAt)
+	if err != nil {
+		return ratelimit.Result{}, err
+	}
+	// у новой корзины updated_at не задан - она считается полной
+	bucket.UpdatedAt = updatedAt.Time
+
+	bucket, result := ratelimit.Take(bucket, limit, now)
+	_, err = tx.Stmt(p.statements[queryUpdateBucket]).ExecContext(ctx, bucket.Tokens, bucket.UpdatedAt.UTC(), key)
+	if err != nil {
+		return ratelimit.Result{}, err
+	}
+	return result, tx.Commit()
+}
+
+func (p SQLiteRateLimitRepository) DelBucketsUpdatedBefore(ctx context.Context, before time.Time, limit int) (int, error) {
+	result, err := p.statements[queryPurgeBuckets].ExecContext(ctx, before.UTC(), limit)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	return int(affected), err
+}
+
+func (p SQLiteRateLimitRepository) Close() error {
+	for name, stmt := range p.statements {
+		err := stmt.Close()
+		if err != nil {
+			return fmt.Errorf("error close stmt %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func NewSQLiteRateLimitRepository(db *sql.DB) (*SQLiteRateLimitRepository, error) {
+	statements := make(map[queryType]*sql.Stmt, len(sqliteQueries))
+	for name, query := range sqliteQueries {
+		stmt, err := db.Prepare(query)
+		if err != nil {
+			return nil, fmt.Errorf("error prepare statement for %s: %w", name, err)
+		}
+		statements[name] = stmt
+	}
+
+	return &SQLiteRateLimitRepository{db: db, statements: statements}, nil
+}
@@ -1,19 +1,35 @@
 package repository
 
 import (
+	"io"
+
+	"github.com/rs/zerolog/log"
 	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/accountrepository"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/eventrepository"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/idempotencyrepository"
 	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/orderrepository"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/ratelimitrepository"
 	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/sessionrepository"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/transaction"
 	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/userrepository"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/webhookrepository"
 	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/withdrawalrepository"
 )
 
 type RepoRegistry struct {
-	UserRepo       userrepository.UserRepository
-	SessionRepo    sessionrepository.SessionRepository
-	OrderRepo      orderrepository.OrderRepository
-	WithdrawalRepo withdrawalrepository.WithdrawalRepository
-	AccountRepo    accountrepository.AccountRepository
+	UserRepo        userrepository.UserRepository
+	SessionRepo     sessionrepository.SessionRepository
+	OrderRepo       orderrepository.OrderRepository
+	WithdrawalRepo  withdrawalrepository.WithdrawalRepository
+	AccountRepo     accountrepository.AccountRepository
+	EventRepo       eventrepository.EventRepository
+	WebhookRepo     webhookrepository.WebhookRepository
+	IdempotencyRepo idempotencyrepository.IdempotencyRepository
+	RateLimitRepo   ratelimitrepository.RateLimitRepository
+
+	Transactor transaction.Transactor
+	// Store хранилище, в которое in-memory репозитории записывают изменения. Закрывается после репозиториев
+	Store io.Closer
 }
 
 func (r *RepoRegistry) Close() {
@@ -22,4 +38,13 @@ func (r *RepoRegistry) Close() {
 	_ = r.SessionRepo.Close()
 	_ = r.UserRepo.Close()
 	_ = r.WithdrawalRepo.Close()
+	_ = r.EventRepo.Close()
+	_ = r.WebhookRepo.Close()
+	_ = r.IdempotencyRepo.Close()
+	_ = r.RateLimitRepo.Close()
+	if r.Store != nil {
+		if err := r.Store.Close(); err != nil {
+			log.Err(err).Msg("close store")
+		}
+	}
 }
@@ -0,0 +1,138 @@
+package repositorytest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zaz600/go-musthave-diploma/internal/entity"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/accountrepository"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/userrepository"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/random"
+)
+
+// AccountRepository проверяет реализацию accountrepository.AccountRepository. newRepo вызывается
+// в каждом тесте и возвращает пустой репозиторий и репозиторий пользователей в том же хранилище
+func AccountRepository(t *testing.T, newRepo func(t *testing.T) (accountrepository.AccountRepository, userrepository.UserRepository)) {
+	ctx := context.Background()
+	open := func(t *testing.T) (accountrepository.AccountRepository, func(t *testing.T) string) {
+		repo, users := newRepo(t)
+		t.Cleanup(func() {
+			_ = repo.Close()
+			_ = users.Close()
+		})
+		return repo, newUser(users)
+	}
+	addAccount := func(t *testing.T, repo accountrepository.AccountRepository, uid string, opts ...entity.AccountOption) entity.Account {
+		account := entity.NewAccount(uid, opts...)
+		require.NoError(t, repo.AddAccount(ctx, account))
+		return account
+	}
+
+	t.Run("add and get", func(t *testing.T) {
+		repo, user := open(t)
+		account := addAccount(t, repo, user(t), entity.WithBalance(100.5), entity.WithWithdrawals(20.25))
+
+		got, err := repo.GetAccount(ctx, account.UID)
+		require.NoError(t, err)
+		assert.Equal(t, account, got)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		repo, _ := open(t)
+		_, err := repo.GetAccount(ctx, random.UserID())
+		assert.ErrorIs(t, err, accountrepository.ErrUserAccountNotFound)
+		assert.ErrorIs(t, repo.RefillAmount(ctx, random.UserID(), 10), accountrepository.ErrUserAccountNotFound)
+		assert.ErrorIs(t, repo.WithdrawalAmount(ctx, random.UserID(), 10), accountrepository.ErrUserAccountNotFound)
+	})
+
+	t.Run("account id is unique", func(t *testing.T) {
+		repo, user := open(t)
+		account := addAccount(t, repo, user(t))
+		err := repo.AddAccount(ctx, entity.NewAccount(user(t), entity.WithAccountID(account.AccountID)))
+		assert.ErrorIs(t, err, accountrepository.ErrAccountExists)
+	})
+
+	t.Run("one account per user", func(t *testing.T) {
+		repo, user := open(t)
+		account := addAccount(t, repo, user(t), entity.WithBalance(10))
+		err := repo.AddAccount(ctx, entity.NewAccount(account.UID))
+		assert.ErrorIs(t, err, accountrepository.ErrAccountExists)
+
+		got, err := repo.GetAccount(ctx, account.UID)
+		require.NoError(t, err)
+		assert.Equal(t, account, got)
+	})
+
+	t.Run("refill and withdrawal", func(t *testing.T) {
+		repo, user := open(t)
+		account := addAccount(t, repo, user(t))
+		require.NoError(t, repo.RefillAmount(ctx, account.UID, 100))
+		require.NoError(t, repo.WithdrawalAmount(ctx, account.UID, 30.5))
+
+		got, err := repo.GetAccount(ctx, account.UID)
+		require.NoError(t, err)
+		assert.Equal(t, float32(69.5), got.Balance)
+		assert.Equal(t, float32(30.5), got.Withdrawals)
+	})
+
+	t.Run("amount must be positive", func(t *testing.T) {
+		repo, user := open(t)
+		account := addAccount(t, repo, user(t), entity.WithBalance(10))
+		for _, amount := range []float32{0, -10} {
+			assert.ErrorIs(t, repo.RefillAmount(ctx, account.UID, amount), accountrepository.ErrInvalidAmount)
+			assert.ErrorIs(t, repo.WithdrawalAmount(ctx, account.UID, amount), accountrepository.ErrInvalidAmount)
+		}
+
+		got, err := repo.GetAccount(ctx, account.UID)
+		require.NoError(t, err)
+		assert.Equal(t, float32(10), got.Balance)
+		assert.Zero(t, got.Withdrawals)
+	})
+
+	t.Run("balance must not become negative", func(t *testing.T) {
+		repo, user := open(t)
+		account := addAccount(t, repo, user(t), entity.WithBalance(10))
+		assert.ErrorIs(t, repo.WithdrawalAmount(ctx, account.UID, 10.5), accountrepository.ErrInsufficientBalance)
+		require.NoError(t, repo.WithdrawalAmount(ctx, account.UID, 10))
+
+		got, err := repo.GetAccount(ctx, account.UID)
+		require.NoError(t, err)
+		assert.Zero(t, got.Balance)
+		assert.Equal(t, float32(10), got.Withdrawals)
+	})
+
+	t.Run("concurrent refill and withdrawal", func(t *testing.T) {
+		repo, user := open(t)
+		account := addAccount(t, repo, user(t), entity.WithBalance(concurrency))
+		errs := parallel(2*concurrency, func(i int) error {
+			if i%2 == 0 {
+				return repo.RefillAmount(ctx, account.UID, 3)
+			}
+			return repo.WithdrawalAmount(ctx, account.UID, 1)
+		})
+		ok, _ := countErrors(t, errs, nil)
+		require.Equal(t, 2*concurrency, ok)
+
+		got, err := repo.GetAccount(ctx, account.UID)
+		require.NoError(t, err)
+		assert.Equal(t, float32(3*concurrency), got.Balance)
+		assert.Equal(t, float32(concurrency), got.Withdrawals)
+	})
+
+	t.Run("concurrent withdrawal", func(t *testing.T) {
+		repo, user := open(t)
+		account := addAccount(t, repo, user(t), entity.WithBalance(concurrency/2))
+		errs := parallel(concurrency, func(int) error {
+			return repo.WithdrawalAmount(ctx, account.UID, 1)
+		})
+		ok, insufficient := countErrors(t, errs, accountrepository.ErrInsufficientBalance)
+		assert.Equal(t, concurrency/2, ok)
+		assert.Equal(t, concurrency/2, insufficient)
+
+		got, err := repo.GetAccount(ctx, account.UID)
+		require.NoError(t, err)
+		assert.Zero(t, got.Balance)
+	})
+}
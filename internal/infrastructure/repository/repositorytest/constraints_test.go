@@ -0,0 +1,68 @@
+package repositorytest_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zaz600/go-musthave-diploma/internal/entity"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/accountrepository"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/orderrepository"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/repositorytest"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/sessionrepository"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/withdrawalrepository"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/random"
+)
+
+type constraintRepos struct {
+	sessions    sessionrepository.SessionRepository
+	orders      orderrepository.OrderRepository
+	withdrawals withdrawalrepository.WithdrawalRepository
+	accounts    accountrepository.AccountRepository
+}
+
+// testOwnerConstraints внешние ключи на users.uid есть только в SQL-хранилищах,
+// поэтому проверка не входит в общие наборы тестов
+func testOwnerConstraints(t *testing.T, repos constraintRepos) {
+	ctx := context.Background()
+	uid := "unknown-" + random.String(8)
+
+	err := repos.sessions.AddSession(ctx, entity.NewRandomSession(uid))
+	assert.ErrorIs(t, err, sessionrepository.ErrUserNotFound)
+
+	err = repos.orders.AddOrder(ctx, entity.NewOrder(uid, random.OrderID()))
+	assert.ErrorIs(t, err, orderrepository.ErrUserNotFound)
+
+	err = repos.withdrawals.AddWithdrawal(ctx, entity.NewWithdrawal(uid, random.OrderID(), 10))
+	assert.ErrorIs(t, err, withdrawalrepository.ErrUserNotFound)
+
+	err = repos.accounts.AddAccount(ctx, entity.NewAccount(uid))
+	assert.ErrorIs(t, err, accountrepository.ErrUserNotFound)
+}
+
+func TestOwnerConstraints(t *testing.T) {
+	t.Run("sqlite", func(t *testing.T) {
+		db := repositorytest.SQLite(t)
+		var repos constraintRepos
+		var err error
+		repos.sessions, err = sessionrepository.NewSQLiteSessionRepository(db)
+		require.NoError(t, err)
+		repos.orders, err = orderrepository.NewSQLiteOrderRepository(db)
+		require.NoError(t, err)
+		repos.withdrawals, err = withdrawalrepository.NewSQLiteWithdrawalRepository(db)
+		require.NoError(t, err)
+		repos.accounts, err = accountrepository.NewSQLiteAccountRepository(db)
+		require.NoError(t, err)
+		testOwnerConstraints(t, repos)
+	})
+	t.Run("postgres", func(t *testing.T) {
+		db := repositorytest.Postgres(t)
+		testOwnerConstraints(t, constraintRepos{
+			sessions:    sessionrepository.NewPgSessionRepository(db),
+			orders:      orderrepository.NewPgOrderRepository(db),
+			withdrawals: withdrawalrepository.NewPgWithdrawalRepository(db),
+			accounts:    accountrepository.NewPgAccountRepository(db),
+		})
+	})
+}
@@ -0,0 +1,228 @@
+package repositorytest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zaz600/go-musthave-diploma/internal/entity"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/eventrepository"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/random"
+)
+
+// EventRepository проверяет реализацию eventrepository.EventRepository. newRepo вызывается
+// в каждом тесте и возвращает пустой репозиторий
+func EventRepository(t *testing.T, newRepo func(t *testing.T) eventrepository.EventRepository) {
+	ctx := context.Background()
+	open := func(t *testing.T) eventrepository.EventRepository {
+		repo := newRepo(t)
+		t.Cleanup(func() {
+			_ = repo.Close()
+		})
+		return repo
+	}
+	addEvent := func(t *testing.T, repo eventrepository.EventRepository) entity.Event {
+		event, err := entity.NewEvent(entity.EventOrderUploaded, random.UserID(), entity.OrderEventPayload{
+			OrderID: random.OrderID(),
+			Status:  entity.OrderStatusNew,
+		})
+		require.NoError(t, err)
+		require.NoError(t, repo.AddEvent(ctx, event))
+		return event
+	}
+	eventIDs := func(events []entity.Event) []string {
+		ids := make([]string, 0, len(events))
+		for _, event := range events {
+			ids = append(ids, event.EventID)
+		}
+		return ids
+	}
+	seqs := func(events []entity.Event) []int64 {
+		seqs := make([]int64, 0, len(events))
+		for _, event := range events {
+			seqs = append(seqs, event.Seq)
+		}
+		return seqs
+	}
+
+	t.Run("add and get in order", func(t *testing.T) {
+		repo := open(t)
+		first := addEvent(t, repo)
+		second := addEvent(t, repo)
+
+		events, err := repo.GetUnpublishedEvents(ctx, "test", 10)
+		require.NoError(t, err)
+		require.Equal(t, []string{first.EventID, second.EventID}, eventIDs(events))
+		assert.Less(t, events[0].Seq, events[1].Seq)
+
+		got := events[0]
+		assert.Equal(t, first.Type, got.Type)
+		assert.Equal(t, first.UID, got.UID)
+		assert.JSONEq(t, string(first.Payload), string(got.Payload))
+		assert.WithinDuration(t, first.CreatedAt, got.CreatedAt, time.Millisecond)
+	})
+
+	t.Run("event id is unique", func(t *testing.T) {
+		repo := open(t)
+		event := addEvent(t, repo)
+		assert.ErrorIs(t, repo.AddEvent(ctx, event), eventrepository.ErrEventExists)
+
+		events, err := repo.GetUnpublishedEvents(ctx, "test", 10)
+		require.NoError(t, err)
+		assert.Len(t, events, 1)
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		repo := open(t)
+		first := addEvent(t, repo)
+		second := addEvent(t, repo)
+		addEvent(t, repo)
+
+		events, err := repo.GetUnpublishedEvents(ctx, "test", 2)
+		require.NoError(t, err)
+		assert.Equal(t, []string{first.EventID, second.EventID}, eventIDs(events))
+	})
+
+	t.Run("published per sink", func(t *testing.T) {
+		repo := open(t)
+		first := addEvent(t, repo)
+		second := addEvent(t, repo)
+
+		events, err := repo.GetUnpublishedEvents(ctx, "webhooks", 10)
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+		require.NoError(t, repo.MarkPublished(ctx, "webhooks", seqs(events[:1])))
+		// повторная отметка ничего не меняет
+		require.NoError(t, repo.MarkPublished(ctx, "webhooks", seqs(events[:1])))
+
+		events, err = repo.GetUnpublishedEvents(ctx, "webhooks", 10)
+		require.NoError(t, err)
+		assert.Equal(t, []string{second.EventID}, eventIDs(events))
+
+		events, err = repo.GetUnpublishedEvents(ctx, "log", 10)
+		require.NoError(t, err)
+		assert.Equal(t, []string{first.EventID, second.EventID}, eventIDs(events))
+
+		require.NoError(t, repo.MarkPublished(ctx, "webhooks", nil))
+	})
+
+	t.Run("delete published events", func(t *testing.T) {
+		repo := open(t)
+		now := time.Now()
+		add := func(createdAt time.Time, sinks ...string) entity.Event {
+			event, err := entity.NewEvent(entity.EventOrderUploaded, random.UserID(), entity.OrderEventPayload{OrderID: random.OrderID()})
+			require.NoError(t, err)
+			event.CreatedAt = createdAt
+			require.NoError(t, repo.AddEvent(ctx, event))
+			events, err := repo.GetUnpublishedEvents(ctx, "other", 100)
+			require.NoError(t, err)
+			seq := events[len(events)-1].Seq
+			for _, sink := range sinks {
+				require.NoError(t, repo.MarkPublished(ctx, sink, []int64{seq}))
+			}
+			event.Seq = seq
+			return event
+		}
+		add(now.Add(-3*time.Hour), "webhooks", "log")
+		add(now.Add(-2*time.Hour), "webhooks", "log")
+		partial := add(now.Add(-2*time.Hour), "webhooks")
+		fresh := add(now, "webhooks", "log")
+
+		n, err := repo.DelPublishedEventsCreatedBefore(ctx, []string{"webhooks", "log"}, now.Add(-time.Hour), 1)
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+		n, err = repo.DelPublishedEventsCreatedBefore(ctx, []string{"webhooks", "log"}, now.Add(-time.Hour), 10)
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+		n, err = repo.DelPublishedEventsCreatedBefore(ctx, []string{"webhooks", "log"}, now.Add(-time.Hour), 10)
+		require.NoError(t, err)
+		assert.Equal(t, 0, n)
+
+		// событие, не опубликованное хотя бы в один sink, и свежие события остаются
+		events, err := repo.GetUnpublishedEvents(ctx, "other", 10)
+		require.NoError(t, err)
+		assert.Equal(t, []string{partial.EventID, fresh.EventID}, eventIDs(events))
+		events, err = repo.GetUnpublishedEvents(ctx, "log", 10)
+		require.NoError(t, err)
+		assert.Equal(t, []string{partial.EventID}, eventIDs(events))
+
+		// номера удаленных событий не используются повторно
+		next := addEvent(t, repo)
+		events, err = repo.GetUnpublishedEvents(ctx, "log", 10)
+		require.NoError(t, err)
+		require.Equal(t, []string{partial.EventID, next.EventID}, eventIDs(events))
+		assert.Greater(t, events[1].Seq, fresh.Seq)
+	})
+
+	t.Run("user events", func(t *testing.T) {
+		repo := open(t)
+		uid := random.UserID()
+		add := func(eventType entity.EventType, uid string, payload interface{}) entity.Event {
+			event, err := entity.NewEvent(eventType, uid, payload)
+			require.NoError(t, err)
+			require.NoError(t, repo.AddEvent(ctx, event))
+			return event
+		}
+		registered := add(entity.EventUserRegistered, uid, entity.UserEventPayload{Login: "user"})
+		add(entity.EventOrderUploaded, uid, entity.OrderEventPayload{OrderID: random.OrderID()})
+		credited := add(entity.EventAccrualCredited, uid, entity.AccrualEventPayload{OrderID: random.OrderID(), Amount: 10})
+		add(entity.EventAccrualCredited, random.UserID(), entity.AccrualEventPayload{OrderID: random.OrderID(), Amount: 10})
+
+		events, err := repo.GetUserEvents(ctx, uid, []entity.EventType{entity.EventAccrualCredited, entity.EventUserRegistered})
+		require.NoError(t, err)
+		require.Equal(t, []string{registered.EventID, credited.EventID}, eventIDs(events))
+		assert.Equal(t, uid, events[1].UID)
+		assert.JSONEq(t, string(credited.Payload), string(events[1].Payload))
+
+		events, err = repo.GetUserEvents(ctx, random.UserID(), entity.EventTypes)
+		require.NoError(t, err)
+		assert.Empty(t, events)
+	})
+
+	t.Run("redact user events", func(t *testing.T) {
+		repo := open(t)
+		uid := random.UserID()
+		add := func(uid string) entity.Event {
+			event, err := entity.NewEvent(entity.EventUserRegistered, uid, entity.UserEventPayload{Login: random.String(8)})
+			require.NoError(t, err)
+			require.NoError(t, repo.AddEvent(ctx, event))
+			return event
+		}
+		add(uid)
+		other := add(random.UserID())
+
+		redacted := []byte(`{"login":"deleted"}`)
+		require.NoError(t, repo.RedactUserEvents(ctx, uid, entity.EventUserRegistered, redacted))
+
+		events, err := repo.GetUserEvents(ctx, uid, []entity.EventType{entity.EventUserRegistered})
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.JSONEq(t, string(redacted), string(events[0].Payload))
+		events, err = repo.GetUserEvents(ctx, other.UID, []entity.EventType{entity.EventUserRegistered})
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.JSONEq(t, string(other.Payload), string(events[0].Payload))
+	})
+
+	t.Run("concurrent add", func(t *testing.T) {
+		repo := open(t)
+		errs := parallel(concurrency, func(int) error {
+			event, err := entity.NewEvent(entity.EventUserRegistered, random.UserID(), entity.UserEventPayload{Login: random.String(8)})
+			if err != nil {
+				return err
+			}
+			return repo.AddEvent(ctx, event)
+		})
+		ok, _ := countErrors(t, errs, nil)
+		require.Equal(t, concurrency, ok)
+
+		events, err := repo.GetUnpublishedEvents(ctx, "test", 2*concurrency)
+		require.NoError(t, err)
+		require.Len(t, events, concurrency)
+		for i := 1; i < len(events); i++ {
+			assert.Less(t, events[i-1].Seq, events[i].Seq)
+		}
+	})
+}
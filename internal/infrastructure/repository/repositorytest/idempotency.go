@@ -0,0 +1,147 @@
+package repositorytest
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zaz600/go-musthave-diploma/internal/entity"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/idempotencyrepository"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/random"
+)
+
+// IdempotencyRepository проверяет реализацию idempotencyrepository.IdempotencyRepository. newRepo вызывается
+// в каждом тесте и возвращает пустой репозиторий
+func IdempotencyRepository(t *testing.T, newRepo func(t *testing.T) idempotencyrepository.IdempotencyRepository) {
+	ctx := context.Background()
+	open := func(t *testing.T) idempotencyrepository.IdempotencyRepository {
+		repo := newRepo(t)
+		t.Cleanup(func() {
+			_ = repo.Close()
+		})
+		return repo
+	}
+
+	t.Run("add, update and get", func(t *testing.T) {
+		repo := open(t)
+		record := entity.NewIdempotencyRecord(random.UserID(), random.String(16), random.String(32))
+		require.NoError(t, repo.AddRecord(ctx, record))
+
+		got, err := repo.GetRecord(ctx, record.UID, record.Key)
+		require.NoError(t, err)
+		assert.Equal(t, record.Fingerprint, got.Fingerprint)
+		assert.False(t, got.Completed())
+		assert.WithinDuration(t, record.CreatedAt, got.CreatedAt, time.Millisecond)
+
+		record.StatusCode = http.StatusAccepted
+		record.ContentType = "application/json"
+		record.Body = []byte(`{"status":"NEW"}`)
+		require.NoError(t, repo.UpdateRecord(ctx, record))
+
+		got, err = repo.GetRecord(ctx, record.UID, record.Key)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusAccepted, got.StatusCode)
+		assert.Equal(t, record.ContentType, got.ContentType)
+		assert.Equal(t, record.Body, got.Body)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		repo := open(t)
+		record := entity.NewIdempotencyRecord(random.UserID(), random.String(16), random.String(32))
+		_, err := repo.GetRecord(ctx, record.UID, record.Key)
+		assert.ErrorIs(t, err, idempotencyrepository.ErrRecordNotFound)
+		record.StatusCode = http.StatusOK
+		assert.ErrorIs(t, repo.UpdateRecord(ctx, record), idempotencyrepository.ErrRecordNotFound)
+		assert.ErrorIs(t, repo.DelRecord(ctx, record.UID, record.Key), idempotencyrepository.ErrRecordNotFound)
+	})
+
+	t.Run("key is unique per user", func(t *testing.T) {
+		repo := open(t)
+		record := entity.NewIdempotencyRecord(random.UserID(), random.String(16), random.String(32))
+		require.NoError(t, repo.AddRecord(ctx, record))
+		err := repo.AddRecord(ctx, entity.NewIdempotencyRecord(record.UID, record.Key, random.String(32)))
+		assert.ErrorIs(t, err, idempotencyrepository.ErrRecordExists)
+		// тот же ключ у другого пользователя
+		require.NoError(t, repo.AddRecord(ctx, entity.NewIdempotencyRecord(random.UserID(), record.Key, random.String(32))))
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		repo := open(t)
+		record := entity.NewIdempotencyRecord(random.UserID(), random.String(16), random.String(32))
+		require.NoError(t, repo.AddRecord(ctx, record))
+		require.NoError(t, repo.DelRecord(ctx, record.UID, record.Key))
+		_, err := repo.GetRecord(ctx, record.UID, record.Key)
+		assert.ErrorIs(t, err, idempotencyrepository.ErrRecordNotFound)
+		// после удаления ключ можно использовать снова
+		require.NoError(t, repo.AddRecord(ctx, record))
+	})
+
+	t.Run("delete created before", func(t *testing.T) {
+		repo := open(t)
+		before := time.Now().Add(-time.Hour)
+		expired := make([]entity.IdempotencyRecord, 3)
+		for i := range expired {
+			expired[i] = entity.NewIdempotencyRecord(random.UserID(), random.String(16), random.String(32))
+			expired[i].CreatedAt = before.Add(-time.Duration(i+1) * time.Minute)
+			require.NoError(t, repo.AddRecord(ctx, expired[i]))
+		}
+		kept := entity.NewIdempotencyRecord(random.UserID(), random.String(16), random.String(32))
+		require.NoError(t, repo.AddRecord(ctx, kept))
+
+		deleted, err := repo.DelRecordsCreatedBefore(ctx, before, 2)
+		require.NoError(t, err)
+		assert.Equal(t, 2, deleted)
+		deleted, err = repo.DelRecordsCreatedBefore(ctx, before, 2)
+		require.NoError(t, err)
+		assert.Equal(t, 1, deleted)
+
+		for _, record := range expired {
+			_, err := repo.GetRecord(ctx, record.UID, record.Key)
+			assert.ErrorIs(t, err, idempotencyrepository.ErrRecordNotFound)
+		}
+		_, err = repo.GetRecord(ctx, kept.UID, kept.Key)
+		require.NoError(t, err)
+	})
+
+	t.Run("delete user records", func(t *testing.T) {
+		repo := open(t)
+		uid := random.UserID()
+		records := []entity.IdempotencyRecord{
+			entity.NewIdempotencyRecord(uid, random.String(16), random.String(32)),
+			entity.NewIdempotencyRecord(uid, random.String(16), random.String(32)),
+		}
+		for _, record := range records {
+			require.NoError(t, repo.AddRecord(ctx, record))
+		}
+		kept := entity.NewIdempotencyRecord(random.UserID(), records[0].Key, random.String(32))
+		require.NoError(t, repo.AddRecord(ctx, kept))
+
+		deleted, err := repo.DelUserRecords(ctx, uid)
+		require.NoError(t, err)
+		assert.Equal(t, len(records), deleted)
+		for _, record := range records {
+			_, err := repo.GetRecord(ctx, record.UID, record.Key)
+			assert.ErrorIs(t, err, idempotencyrepository.ErrRecordNotFound)
+		}
+		_, err = repo.GetRecord(ctx, kept.UID, kept.Key)
+		require.NoError(t, err)
+
+		deleted, err = repo.DelUserRecords(ctx, uid)
+		require.NoError(t, err)
+		assert.Zero(t, deleted)
+	})
+
+	t.Run("concurrent add with same key", func(t *testing.T) {
+		repo := open(t)
+		uid, key := random.UserID(), random.String(16)
+		errs := parallel(concurrency, func(int) error {
+			return repo.AddRecord(ctx, entity.NewIdempotencyRecord(uid, key, random.String(32)))
+		})
+		ok, exists := countErrors(t, errs, idempotencyrepository.ErrRecordExists)
+		assert.Equal(t, 1, ok)
+		assert.Equal(t, concurrency-1, exists)
+	})
+}
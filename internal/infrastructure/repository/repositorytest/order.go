@@ -0,0 +1,172 @@
+package repositorytest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zaz600/go-musthave-diploma/internal/entity"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/orderrepository"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/userrepository"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/random"
+)
+
+// OrderRepository проверяет реализацию orderrepository.OrderRepository. newRepo вызывается
+// в каждом тесте и возвращает пустой репозиторий и репозиторий пользователей в том же хранилище
+func OrderRepository(t *testing.T, newRepo func(t *testing.T) (orderrepository.OrderRepository, userrepository.UserRepository)) {
+	ctx := context.Background()
+	open := func(t *testing.T) (orderrepository.OrderRepository, func(t *testing.T) string) {
+		repo, users := newRepo(t)
+		t.Cleanup(func() {
+			_ = repo.Close()
+			_ = users.Close()
+		})
+		return repo, newUser(users)
+	}
+	addOrder := func(t *testing.T, repo orderrepository.OrderRepository, uid string, opts ...entity.OrderOption) entity.Order {
+		order := entity.NewOrder(uid, random.OrderID(), opts...)
+		require.NoError(t, repo.AddOrder(ctx, order))
+		return order
+	}
+	orderIDs := func(orders []entity.Order) []string {
+		ids := make([]string, 0, len(orders))
+		for _, order := range orders {
+			ids = append(ids, order.OrderID)
+		}
+		return ids
+	}
+
+	t.Run("add and get", func(t *testing.T) {
+		repo, user := open(t)
+		order := addOrder(t, repo, user(t), entity.WithUploadedAt(time.Now().Add(-time.Hour)))
+
+		got, err := repo.GetOrder(ctx, order.OrderID)
+		require.NoError(t, err)
+		assert.Equal(t, order.UID, got.UID)
+		assert.Equal(t, order.OrderID, got.OrderID)
+		assert.Equal(t, entity.OrderStatusNew, got.Status)
+		assert.Zero(t, got.Accrual)
+		assert.Zero(t, got.RetryCount)
+		assert.WithinDuration(t, order.UploadedAt, got.UploadedAt, time.Millisecond)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		repo, _ := open(t)
+		orderID := random.OrderID()
+		_, err := repo.GetOrder(ctx, orderID)
+		assert.ErrorIs(t, err, orderrepository.ErrOrderNotFound)
+		err = repo.SetOrderStatusAndAccrual(ctx, orderID, entity.OrderStatusProcessed, 10)
+		assert.ErrorIs(t, err, orderrepository.ErrOrderNotFound)
+		err = repo.SetOrderNextRetryAt(ctx, orderID, time.Now())
+		assert.ErrorIs(t, err, orderrepository.ErrOrderNotFound)
+	})
+
+	t.Run("order id is unique across users", func(t *testing.T) {
+		repo, user := open(t)
+		order := addOrder(t, repo, user(t))
+		err := repo.AddOrder(ctx, entity.NewOrder(user(t), order.OrderID))
+		assert.ErrorIs(t, err, orderrepository.ErrOrderExists)
+
+		got, err := repo.GetOrder(ctx, order.OrderID)
+		require.NoError(t, err)
+		assert.Equal(t, order.UID, got.UID)
+	})
+
+	t.Run("set status, accrual and retry", func(t *testing.T) {
+		repo, user := open(t)
+		order := addOrder(t, repo, user(t))
+		require.NoError(t, repo.SetOrderNextRetryAt(ctx, order.OrderID, time.Now().Add(time.Minute)))
+		require.NoError(t, repo.SetOrderNextRetryAt(ctx, order.OrderID, time.Now().Add(time.Minute)))
+		require.NoError(t, repo.SetOrderStatusAndAccrual(ctx, order.OrderID, entity.OrderStatusProcessed, 100.5))
+
+		got, err := repo.GetOrder(ctx, order.OrderID)
+		require.NoError(t, err)
+		assert.Equal(t, entity.OrderStatusProcessed, got.Status)
+		assert.Equal(t, float32(100.5), got.Accrual)
+		assert.Equal(t, 2, got.RetryCount)
+	})
+
+	t.Run("status must be known", func(t *testing.T) {
+		repo, user := open(t)
+		err := repo.AddOrder(ctx, entity.NewOrder(user(t), random.OrderID(), entity.WithStatus("DONE")))
+		assert.ErrorIs(t, err, orderrepository.ErrInvalidOrderStatus)
+
+		order := addOrder(t, repo, user(t))
+		err = repo.SetOrderStatusAndAccrual(ctx, order.OrderID, "DONE", 10)
+		assert.ErrorIs(t, err, orderrepository.ErrInvalidOrderStatus)
+		got, err := repo.GetOrder(ctx, order.OrderID)
+		require.NoError(t, err)
+		assert.Equal(t, entity.OrderStatusNew, got.Status)
+	})
+
+	t.Run("user orders", func(t *testing.T) {
+		repo, user := open(t)
+		uid := user(t)
+		first := addOrder(t, repo, uid)
+		second := addOrder(t, repo, uid)
+		addOrder(t, repo, user(t))
+
+		orders, err := repo.GetUserOrders(ctx, uid)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{first.OrderID, second.OrderID}, orderIDs(orders))
+
+		orders, err = repo.GetUserOrders(ctx, random.UserID())
+		require.NoError(t, err)
+		assert.Empty(t, orders)
+	})
+
+	t.Run("orders by status", func(t *testing.T) {
+		repo, user := open(t)
+		newOrder := addOrder(t, repo, user(t))
+		processing := addOrder(t, repo, user(t), entity.WithStatus(entity.OrderStatusProcessing))
+		addOrder(t, repo, user(t), entity.WithStatus(entity.OrderStatusProcessed))
+		addOrder(t, repo, user(t), entity.WithStatus(entity.OrderStatusProcessed))
+
+		orders, err := repo.GetOrdersByStatus(ctx, entity.OrderStatusNew, entity.OrderStatusProcessing)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{newOrder.OrderID, processing.OrderID}, orderIDs(orders))
+
+		orders, err = repo.GetOrdersByStatus(ctx, entity.OrderStatusInvalid)
+		require.NoError(t, err)
+		assert.Empty(t, orders)
+
+		counts, err := repo.CountOrdersByStatus(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, map[entity.OrderStatus]int{
+			entity.OrderStatusNew:        1,
+			entity.OrderStatusProcessing: 1,
+			entity.OrderStatusProcessed:  2,
+		}, counts)
+	})
+
+	t.Run("concurrent add with same order id", func(t *testing.T) {
+		repo, user := open(t)
+		orderID := random.OrderID()
+		uids := make([]string, concurrency)
+		for i := range uids {
+			uids[i] = user(t)
+		}
+		errs := parallel(concurrency, func(i int) error {
+			return repo.AddOrder(ctx, entity.NewOrder(uids[i], orderID))
+		})
+		ok, exists := countErrors(t, errs, orderrepository.ErrOrderExists)
+		assert.Equal(t, 1, ok)
+		assert.Equal(t, concurrency-1, exists)
+	})
+
+	t.Run("concurrent retries", func(t *testing.T) {
+		repo, user := open(t)
+		order := addOrder(t, repo, user(t))
+		errs := parallel(concurrency, func(int) error {
+			return repo.SetOrderNextRetryAt(ctx, order.OrderID, time.Now())
+		})
+		ok, _ := countErrors(t, errs, nil)
+		require.Equal(t, concurrency, ok)
+
+		got, err := repo.GetOrder(ctx, order.OrderID)
+		require.NoError(t, err)
+		assert.Equal(t, concurrency, got.RetryCount)
+	})
+}
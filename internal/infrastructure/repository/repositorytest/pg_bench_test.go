@@ -0,0 +1,158 @@
+package repositorytest_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/zaz600/go-musthave-diploma/internal/entity"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/pgdb"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/repositorytest"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/userrepository"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/withdrawalrepository"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/random"
+)
+
+// Бенчмарки сравнивают репозитории на пуле pgx с прежней реализацией на database/sql:
+// запросы, подготовленные при создании репозитория, и отдельная транзакция на каждую запись.
+// Прежняя реализация воспроизведена здесь запросами из тех же репозиториев.
+//
+//	TEST_PG=1 go test -run '^$' -bench Pg ./internal/infrastructure/repository/repositorytest/
+
+const (
+	benchAddUser       = "insert into gophermart.users(uid, login, password) values($1, $2, $3)"
+	benchGetUser       = "select uid, login, password from gophermart.users where login=$1"
+	benchAddWithdrawal = "insert into gophermart.withdrawals(uid, order_id, processed_at, amount) values($1, $2, $3, $4) " +
+		"on conflict (order_id) do nothing"
+	benchGetWithdrawalOwner = "select uid from gophermart.withdrawals where order_id=$1"
+)
+
+// sqlBench прежняя реализация на database/sql
+type sqlBench struct {
+	db    *sql.DB
+	stmts map[string]*sql.Stmt
+}
+
+func newSQLBench(b *testing.B, pool *pgdb.Pool) *sqlBench {
+	b.Helper()
+	db := pool.OpenDB()
+	b.Cleanup(func() {
+		_ = db.Close()
+	})
+	s := &sqlBench{db: db, stmts: map[string]*sql.Stmt{}}
+	for _, query := range []string{benchAddUser, benchGetUser, benchAddWithdrawal, benchGetWithdrawalOwner} {
+		stmt, err := db.Prepare(query)
+		require.NoError(b, err)
+		s.stmts[query] = stmt
+	}
+	return s
+}
+
+func (s *sqlBench) addUser(ctx context.Context, user entity.UserEntity) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+	if _, err := tx.Stmt(s.stmts[benchAddUser]).ExecContext(ctx, user.UID, user.Login, user.Password); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *sqlBench) getUser(ctx context.Context, login string) error {
+	var user entity.UserEntity
+	return s.stmts[benchGetUser].QueryRowContext(ctx, login).Scan(&user.UID, &user.Login, &user.Password)
+}
+
+// addWithdrawal вставка и запрос владельца заказа выполняются по очереди в одной транзакции
+func (s *sqlBench) addWithdrawal(ctx context.Context, withdrawal entity.Withdrawal) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+	_, err = tx.Stmt(s.stmts[benchAddWithdrawal]).ExecContext(ctx, withdrawal.UID, withdrawal.OrderID, withdrawal.ProcessedAt, withdrawal.Sum)
+	if err != nil {
+		return err
+	}
+	var owner string
+	if err := tx.Stmt(s.stmts[benchGetWithdrawalOwner]).QueryRowContext(ctx, withdrawal.OrderID).Scan(&owner); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func BenchmarkPgAddUser(b *testing.B) {
+	ctx := context.Background()
+	pool := repositorytest.Postgres(b)
+
+	b.Run("database_sql", func(b *testing.B) {
+		s := newSQLBench(b, pool)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			require.NoError(b, s.addUser(ctx, entity.NewUserEntity(random.String(16), "password")))
+		}
+	})
+	b.Run("pgxpool", func(b *testing.B) {
+		repo := userrepository.NewPgUserRepository(pool)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			require.NoError(b, repo.AddUser(ctx, entity.NewUserEntity(random.String(16), "password")))
+		}
+	})
+}
+
+func BenchmarkPgGetUser(b *testing.B) {
+	ctx := context.Background()
+	pool := repositorytest.Postgres(b)
+	repo := userrepository.NewPgUserRepository(pool)
+	user := entity.NewUserEntity(random.String(16), "password")
+	require.NoError(b, repo.AddUser(ctx, user))
+
+	b.Run("database_sql", func(b *testing.B) {
+		s := newSQLBench(b, pool)
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				if err := s.getUser(ctx, user.Login); err != nil {
+					b.Error(err)
+				}
+			}
+		})
+	})
+	b.Run("pgxpool", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				if _, err := repo.GetUser(ctx, user.Login); err != nil {
+					b.Error(err)
+				}
+			}
+		})
+	})
+}
+
+// BenchmarkPgAddWithdrawal прежняя реализация отправляет запросы по очереди, новая - одним пакетом
+func BenchmarkPgAddWithdrawal(b *testing.B) {
+	ctx := context.Background()
+	pool := repositorytest.Postgres(b)
+	users := userrepository.NewPgUserRepository(pool)
+	user := entity.NewUserEntity(random.String(16), "password")
+	require.NoError(b, users.AddUser(ctx, user))
+
+	b.Run("database_sql", func(b *testing.B) {
+		s := newSQLBench(b, pool)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			require.NoError(b, s.addWithdrawal(ctx, entity.NewWithdrawal(user.UID, random.OrderID(), 10)))
+		}
+	})
+	b.Run("pgxpool_batch", func(b *testing.B) {
+		repo := withdrawalrepository.NewPgWithdrawalRepository(pool)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			require.NoError(b, repo.AddWithdrawal(ctx, entity.NewWithdrawal(user.UID, random.OrderID(), 10)))
+		}
+	})
+}
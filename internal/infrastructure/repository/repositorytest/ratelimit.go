@@ -0,0 +1,120 @@
+package repositorytest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/ratelimitrepository"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/random"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/ratelimit"
+)
+
+// RateLimitRepository проверяет реализацию ratelimitrepository.RateLimitRepository. newRepo вызывается
+// в каждом тесте и возвращает пустой репозиторий
+func RateLimitRepository(t *testing.T, newRepo func(t *testing.T) ratelimitrepository.RateLimitRepository) {
+	ctx := context.Background()
+	open := func(t *testing.T) ratelimitrepository.RateLimitRepository {
+		repo := newRepo(t)
+		t.Cleanup(func() {
+			_ = repo.Close()
+		})
+		return repo
+	}
+	limit := ratelimit.Limit{Requests: 2, Period: time.Minute}
+	// время в тестах с точностью до микросекунд, как в Postgres
+	now := time.Now().Truncate(time.Microsecond)
+
+	t.Run("take until empty and refill", func(t *testing.T) {
+		repo := open(t)
+		key := random.String(16)
+
+		result, err := repo.Take(ctx, key, limit, now)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 2, result.Limit)
+		assert.Equal(t, 1, result.Remaining)
+
+		result, err = repo.Take(ctx, key, limit, now)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 0, result.Remaining)
+
+		result, err = repo.Take(ctx, key, limit, now.Add(time.Second))
+		require.NoError(t, err)
+		assert.False(t, result.Allowed)
+		assert.Positive(t, result.RetryAfter)
+
+		// за 30 секунд добавляется один токен
+		result, err = repo.Take(ctx, key, limit, now.Add(30*time.Second))
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+	})
+
+	t.Run("keys are independent", func(t *testing.T) {
+		repo := open(t)
+		key := random.String(16)
+		for i := 0; i < limit.Requests; i++ {
+			_, err := repo.Take(ctx, key, limit, now)
+			require.NoError(t, err)
+		}
+		result, err := repo.Take(ctx, key, limit, now)
+		require.NoError(t, err)
+		assert.False(t, result.Allowed)
+
+		result, err = repo.Take(ctx, random.String(16), limit, now)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+	})
+
+	t.Run("delete updated before", func(t *testing.T) {
+		repo := open(t)
+		idle := make([]string, 3)
+		for i := range idle {
+			idle[i] = random.String(16)
+			_, err := repo.Take(ctx, idle[i], limit, now.Add(-time.Duration(i+1)*time.Hour))
+			require.NoError(t, err)
+		}
+		active := random.String(16)
+		for i := 0; i < limit.Requests; i++ {
+			_, err := repo.Take(ctx, active, limit, now)
+			require.NoError(t, err)
+		}
+
+		deleted, err := repo.DelBucketsUpdatedBefore(ctx, now.Add(-time.Minute), 2)
+		require.NoError(t, err)
+		assert.Equal(t, 2, deleted)
+		deleted, err = repo.DelBucketsUpdatedBefore(ctx, now.Add(-time.Minute), 2)
+		require.NoError(t, err)
+		assert.Equal(t, 1, deleted)
+
+		// корзина активного клиента осталась пустой
+		result, err := repo.Take(ctx, active, limit, now)
+		require.NoError(t, err)
+		assert.False(t, result.Allowed)
+	})
+
+	t.Run("concurrent take", func(t *testing.T) {
+		repo := open(t)
+		key := random.String(16)
+		limit := ratelimit.Limit{Requests: concurrency / 2, Period: time.Hour}
+		allowed := make([]bool, concurrency)
+		errs := parallel(concurrency, func(i int) error {
+			result, err := repo.Take(ctx, key, limit, now)
+			allowed[i] = result.Allowed
+			return err
+		})
+		ok, _ := countErrors(t, errs, nil)
+		require.Equal(t, concurrency, ok)
+
+		count := 0
+		for _, a := range allowed {
+			if a {
+				count++
+			}
+		}
+		assert.Equal(t, limit.Requests, count)
+	})
+}
@@ -0,0 +1,209 @@
+package repositorytest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zaz600/go-musthave-diploma/internal/entity"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/sessionrepository"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/userrepository"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/random"
+)
+
+// SessionRepository проверяет реализацию sessionrepository.SessionRepository. newRepo вызывается
+// в каждом тесте и возвращает пустой репозиторий и репозиторий пользователей в том же хранилище
+func SessionRepository(t *testing.T, newRepo func(t *testing.T) (sessionrepository.SessionRepository, userrepository.UserRepository)) {
+	ctx := context.Background()
+	open := func(t *testing.T) (sessionrepository.SessionRepository, func(t *testing.T) string) {
+		repo, users := newRepo(t)
+		t.Cleanup(func() {
+			_ = repo.Close()
+			_ = users.Close()
+		})
+		return repo, newUser(users)
+	}
+
+	t.Run("add and get", func(t *testing.T) {
+		repo, user := open(t)
+		session := entity.NewRandomSession(user(t))
+		require.NoError(t, repo.AddSession(ctx, session))
+
+		got, err := repo.GetSession(ctx, session.SessionID)
+		require.NoError(t, err)
+		assert.Equal(t, session.SessionID, got.SessionID)
+		assert.Equal(t, session.UID, got.UID)
+		assert.WithinDuration(t, session.CreatedAt, got.CreatedAt, time.Millisecond)
+	})
+
+	t.Run("device", func(t *testing.T) {
+		repo, user := open(t)
+		session := entity.New(user(t), entity.WithDevice("Mozilla/5.0", "192.0.2.1"))
+		require.NoError(t, repo.AddSession(ctx, session))
+
+		got, err := repo.GetSession(ctx, session.SessionID)
+		require.NoError(t, err)
+		assert.Equal(t, "Mozilla/5.0", got.UserAgent)
+		assert.Equal(t, "192.0.2.1", got.IP)
+		assert.WithinDuration(t, session.CreatedAt, got.LastSeenAt, time.Millisecond)
+	})
+
+	t.Run("user sessions", func(t *testing.T) {
+		repo, user := open(t)
+		uid, other := user(t), user(t)
+		now := time.Now()
+		first := entity.New(uid, entity.WithCreatedAt(now.Add(-time.Minute)))
+		second := entity.New(uid, entity.WithCreatedAt(now))
+		require.NoError(t, repo.AddSession(ctx, second))
+		require.NoError(t, repo.AddSession(ctx, first))
+		require.NoError(t, repo.AddSession(ctx, entity.New(other)))
+
+		sessions, err := repo.GetUserSessions(ctx, uid)
+		require.NoError(t, err)
+		require.Len(t, sessions, 2)
+		assert.Equal(t, first.SessionID, sessions[0].SessionID)
+		assert.Equal(t, second.SessionID, sessions[1].SessionID)
+
+		sessions, err = repo.GetUserSessions(ctx, "unknown-"+random.String(8))
+		require.NoError(t, err)
+		assert.Empty(t, sessions)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		repo, user := open(t)
+		uid := user(t)
+		session, kept := entity.New(uid), entity.New(uid)
+		require.NoError(t, repo.AddSession(ctx, session))
+		require.NoError(t, repo.AddSession(ctx, kept))
+
+		require.NoError(t, repo.DelSession(ctx, session.SessionID))
+		_, err := repo.GetSession(ctx, session.SessionID)
+		assert.ErrorIs(t, err, sessionrepository.ErrSessionNotFound)
+		assert.ErrorIs(t, repo.DelSession(ctx, session.SessionID), sessionrepository.ErrSessionNotFound)
+
+		sessions, err := repo.GetUserSessions(ctx, uid)
+		require.NoError(t, err)
+		require.Len(t, sessions, 1)
+		assert.Equal(t, kept.SessionID, sessions[0].SessionID)
+	})
+
+	t.Run("touch", func(t *testing.T) {
+		repo, user := open(t)
+		uid := user(t)
+		session, stale := entity.New(uid), entity.New(uid)
+		require.NoError(t, repo.AddSession(ctx, session))
+		require.NoError(t, repo.AddSession(ctx, stale))
+
+		seen := session.CreatedAt.Add(time.Minute)
+		require.NoError(t, repo.TouchSessions(ctx, map[string]time.Time{
+			session.SessionID:  seen,
+			stale.SessionID:    stale.CreatedAt.Add(-time.Minute),
+			random.SessionID(): seen,
+		}))
+
+		got, err := repo.GetSession(ctx, session.SessionID)
+		require.NoError(t, err)
+		assert.WithinDuration(t, seen, got.LastSeenAt, time.Millisecond)
+		got, err = repo.GetSession(ctx, stale.SessionID)
+		require.NoError(t, err)
+		assert.WithinDuration(t, stale.CreatedAt, got.LastSeenAt, time.Millisecond, "last seen does not move back")
+		require.NoError(t, repo.TouchSessions(ctx, nil))
+	})
+
+	t.Run("delete created before", func(t *testing.T) {
+		repo, user := open(t)
+		uid := user(t)
+		// старше времени жизни токена: хранилища с TTL такие сессии не сохраняют
+		before := time.Now().Add(-2 * time.Hour)
+		expired := make([]*entity.Session, 3)
+		for i := range expired {
+			expired[i] = entity.New(uid, entity.WithCreatedAt(before.Add(-time.Duration(i+1)*time.Minute)))
+			require.NoError(t, repo.AddSession(ctx, expired[i]))
+		}
+		kept := entity.New(uid)
+		require.NoError(t, repo.AddSession(ctx, kept))
+
+		for {
+			deleted, err := repo.DelSessionsCreatedBefore(ctx, before, 2)
+			require.NoError(t, err)
+			require.LessOrEqual(t, deleted, 2)
+			if deleted < 2 {
+				break
+			}
+		}
+		for _, session := range expired {
+			_, err := repo.GetSession(ctx, session.SessionID)
+			assert.ErrorIs(t, err, sessionrepository.ErrSessionNotFound)
+		}
+		_, err := repo.GetSession(ctx, kept.SessionID)
+		require.NoError(t, err)
+
+		deleted, err := repo.DelSessionsCreatedBefore(ctx, before, 2)
+		require.NoError(t, err)
+		assert.Zero(t, deleted)
+	})
+
+	t.Run("delete user sessions", func(t *testing.T) {
+		repo, user := open(t)
+		uid, other := user(t), user(t)
+		sessions := []*entity.Session{entity.New(uid), entity.New(uid)}
+		for _, session := range sessions {
+			require.NoError(t, repo.AddSession(ctx, session))
+		}
+		kept := entity.New(other)
+		require.NoError(t, repo.AddSession(ctx, kept))
+
+		deleted, err := repo.DelUserSessions(ctx, uid)
+		require.NoError(t, err)
+		assert.Equal(t, len(sessions), deleted)
+		for _, session := range sessions {
+			_, err := repo.GetSession(ctx, session.SessionID)
+			assert.ErrorIs(t, err, sessionrepository.ErrSessionNotFound)
+		}
+		got, err := repo.GetUserSessions(ctx, uid)
+		require.NoError(t, err)
+		assert.Empty(t, got)
+		_, err = repo.GetSession(ctx, kept.SessionID)
+		require.NoError(t, err)
+
+		deleted, err = repo.DelUserSessions(ctx, uid)
+		require.NoError(t, err)
+		assert.Zero(t, deleted)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		repo, _ := open(t)
+		_, err := repo.GetSession(ctx, random.SessionID())
+		assert.ErrorIs(t, err, sessionrepository.ErrSessionNotFound)
+	})
+
+	t.Run("session id is unique", func(t *testing.T) {
+		repo, user := open(t)
+		session := entity.NewRandomSession(user(t))
+		require.NoError(t, repo.AddSession(ctx, session))
+		err := repo.AddSession(ctx, entity.New(user(t), entity.WithSessionID(session.SessionID)))
+		assert.ErrorIs(t, err, sessionrepository.ErrSessionExists)
+	})
+
+	t.Run("concurrent add", func(t *testing.T) {
+		repo, user := open(t)
+		uid := user(t)
+		sessions := make([]*entity.Session, concurrency)
+		for i := range sessions {
+			sessions[i] = entity.NewRandomSession(uid)
+		}
+		errs := parallel(concurrency, func(i int) error {
+			return repo.AddSession(ctx, sessions[i])
+		})
+		ok, _ := countErrors(t, errs, nil)
+		require.Equal(t, concurrency, ok)
+
+		for _, session := range sessions {
+			got, err := repo.GetSession(ctx, session.SessionID)
+			require.NoError(t, err)
+			assert.Equal(t, uid, got.UID)
+		}
+	})
+}
@@ -0,0 +1,102 @@
+package repositorytest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zaz600/go-musthave-diploma/internal/entity"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/userrepository"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/random"
+)
+
+// UserRepository проверяет реализацию userrepository.UserRepository. newRepo вызывается
+// в каждом тесте и возвращает пустой репозиторий
+func UserRepository(t *testing.T, newRepo func(t *testing.T) userrepository.UserRepository) {
+	ctx := context.Background()
+	open := func(t *testing.T) userrepository.UserRepository {
+		repo := newRepo(t)
+		t.Cleanup(func() {
+			_ = repo.Close()
+		})
+		return repo
+	}
+
+	t.Run("add and get", func(t *testing.T) {
+		repo := open(t)
+		user := entity.NewUserEntity(random.String(8), "hash")
+		require.NoError(t, repo.AddUser(ctx, user))
+
+		got, err := repo.GetUser(ctx, user.Login)
+		require.NoError(t, err)
+		assert.Equal(t, user, got)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		repo := open(t)
+		_, err := repo.GetUser(ctx, random.String(8))
+		assert.ErrorIs(t, err, userrepository.ErrUserNotFound)
+	})
+
+	t.Run("get by id", func(t *testing.T) {
+		repo := open(t)
+		user := entity.NewUserEntity(random.String(8), "hash")
+		require.NoError(t, repo.AddUser(ctx, user))
+
+		got, err := repo.GetUserByID(ctx, user.UID)
+		require.NoError(t, err)
+		assert.Equal(t, user, got)
+
+		_, err = repo.GetUserByID(ctx, "unknown-"+random.String(8))
+		assert.ErrorIs(t, err, userrepository.ErrUserNotFound)
+	})
+
+	t.Run("anonymize", func(t *testing.T) {
+		repo := open(t)
+		user := entity.NewUserEntity(random.String(8), "hash")
+		require.NoError(t, repo.AddUser(ctx, user))
+		anonymous := "deleted-" + user.UID
+		require.NoError(t, repo.AnonymizeUser(ctx, user.UID, anonymous))
+
+		_, err := repo.GetUser(ctx, user.Login)
+		assert.ErrorIs(t, err, userrepository.ErrUserNotFound)
+		got, err := repo.GetUserByID(ctx, user.UID)
+		require.NoError(t, err)
+		assert.Equal(t, anonymous, got.Login)
+		assert.Empty(t, got.Password)
+
+		// освободившийся логин можно зарегистрировать заново
+		require.NoError(t, repo.AddUser(ctx, entity.NewUserEntity(user.Login, "hash")))
+
+		err = repo.AnonymizeUser(ctx, "unknown-"+random.String(8), "deleted-"+random.String(8))
+		assert.ErrorIs(t, err, userrepository.ErrUserNotFound)
+		other := entity.NewUserEntity(random.String(8), "hash")
+		require.NoError(t, repo.AddUser(ctx, other))
+		err = repo.AnonymizeUser(ctx, other.UID, anonymous)
+		assert.ErrorIs(t, err, userrepository.ErrUserExists)
+	})
+
+	t.Run("login is unique", func(t *testing.T) {
+		repo := open(t)
+		login := random.String(8)
+		require.NoError(t, repo.AddUser(ctx, entity.NewUserEntity(login, "hash")))
+		err := repo.AddUser(ctx, entity.NewUserEntity(login, "other"))
+		assert.ErrorIs(t, err, userrepository.ErrUserExists)
+
+		got, err := repo.GetUser(ctx, login)
+		require.NoError(t, err)
+		assert.Equal(t, "hash", got.Password)
+	})
+
+	t.Run("concurrent add with same login", func(t *testing.T) {
+		repo := open(t)
+		login := random.String(8)
+		errs := parallel(concurrency, func(int) error {
+			return repo.AddUser(ctx, entity.NewUserEntity(login, "hash"))
+		})
+		ok, exists := countErrors(t, errs, userrepository.ErrUserExists)
+		assert.Equal(t, 1, ok)
+		assert.Equal(t, concurrency-1, exists)
+	})
+}
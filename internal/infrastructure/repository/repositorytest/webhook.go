@@ -0,0 +1,242 @@
+package repositorytest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zaz600/go-musthave-diploma/internal/entity"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/webhookrepository"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/random"
+)
+
+// WebhookRepository проверяет реализацию webhookrepository.WebhookRepository. newRepo вызывается
+// в каждом тесте и возвращает пустой репозиторий
+func WebhookRepository(t *testing.T, newRepo func(t *testing.T) webhookrepository.WebhookRepository) {
+	ctx := context.Background()
+	open := func(t *testing.T) webhookrepository.WebhookRepository {
+		repo := newRepo(t)
+		t.Cleanup(func() {
+			_ = repo.Close()
+		})
+		return repo
+	}
+	// время в тестах с точностью до микросекунд, как в Postgres
+	now := time.Now().Truncate(time.Microsecond)
+	newDelivery := func(t *testing.T, nextAttemptAt time.Time) entity.WebhookDelivery {
+		event, err := entity.NewEvent(entity.EventWithdrawalCreated, random.UserID(), entity.WithdrawalEventPayload{
+			OrderID: random.OrderID(),
+			Sum:     10,
+		})
+		require.NoError(t, err)
+		delivery := entity.NewWebhookDelivery(random.String(16), event)
+		delivery.NextAttemptAt = nextAttemptAt
+		return delivery
+	}
+	addDelivery := func(t *testing.T, repo webhookrepository.WebhookRepository, nextAttemptAt time.Time) entity.WebhookDelivery {
+		delivery := newDelivery(t, nextAttemptAt)
+		require.NoError(t, repo.AddDelivery(ctx, delivery))
+		return delivery
+	}
+	deliveryIDs := func(deliveries []entity.WebhookDelivery) []string {
+		ids := make([]string, 0, len(deliveries))
+		for _, delivery := range deliveries {
+			ids = append(ids, delivery.ID)
+		}
+		return ids
+	}
+
+	t.Run("subscribers", func(t *testing.T) {
+		repo := open(t)
+		first := entity.NewWebhookSubscriber("http://localhost/first", []entity.EventType{entity.EventOrderProcessed}, "secret")
+		second := entity.NewWebhookSubscriber("http://localhost/second", entity.EventTypes, "")
+		second.CreatedAt = first.CreatedAt.Add(time.Second)
+		require.NoError(t, repo.AddSubscriber(ctx, first))
+		require.NoError(t, repo.AddSubscriber(ctx, second))
+		assert.ErrorIs(t, repo.AddSubscriber(ctx, first), webhookrepository.ErrSubscriberExists)
+
+		got, err := repo.GetSubscriber(ctx, first.ID)
+		require.NoError(t, err)
+		assert.Equal(t, first.URL, got.URL)
+		assert.Equal(t, first.EventTypes, got.EventTypes)
+		assert.Equal(t, first.Secret, got.Secret)
+		assert.WithinDuration(t, first.CreatedAt, got.CreatedAt, time.Millisecond)
+
+		subscribers, err := repo.GetSubscribers(ctx)
+		require.NoError(t, err)
+		require.Len(t, subscribers, 2)
+		assert.Equal(t, first.ID, subscribers[0].ID)
+		assert.Equal(t, second.ID, subscribers[1].ID)
+		assert.Equal(t, entity.EventTypes, subscribers[1].EventTypes)
+
+		require.NoError(t, repo.DelSubscriber(ctx, first.ID))
+		_, err = repo.GetSubscriber(ctx, first.ID)
+		assert.ErrorIs(t, err, webhookrepository.ErrSubscriberNotFound)
+		assert.ErrorIs(t, repo.DelSubscriber(ctx, first.ID), webhookrepository.ErrSubscriberNotFound)
+	})
+
+	t.Run("add, get and update delivery", func(t *testing.T) {
+		repo := open(t)
+		delivery := addDelivery(t, repo, now)
+
+		got, err := repo.GetDelivery(ctx, delivery.ID)
+		require.NoError(t, err)
+		assert.Equal(t, delivery.SubscriberID, got.SubscriberID)
+		assert.Equal(t, delivery.Event.EventID, got.Event.EventID)
+		assert.Equal(t, delivery.Event.Type, got.Event.Type)
+		assert.Equal(t, delivery.Event.UID, got.Event.UID)
+		assert.JSONEq(t, string(delivery.Event.Payload), string(got.Event.Payload))
+		assert.Equal(t, entity.DeliveryStatusPending, got.Status)
+		assert.WithinDuration(t, delivery.NextAttemptAt, got.NextAttemptAt, time.Millisecond)
+
+		got.Status = entity.DeliveryStatusDead
+		got.Attempts = 3
+		got.LastError = "connection refused"
+		require.NoError(t, repo.UpdateDelivery(ctx, got))
+		updated, err := repo.GetDelivery(ctx, delivery.ID)
+		require.NoError(t, err)
+		assert.Equal(t, entity.DeliveryStatusDead, updated.Status)
+		assert.Equal(t, 3, updated.Attempts)
+		assert.Equal(t, "connection refused", updated.LastError)
+	})
+
+	t.Run("redact user deliveries", func(t *testing.T) {
+		repo := open(t)
+		newUserDelivery := func(uid string) entity.WebhookDelivery {
+			event, err := entity.NewEvent(entity.EventUserRegistered, uid, entity.UserEventPayload{Login: random.String(8)})
+			require.NoError(t, err)
+			delivery := entity.NewWebhookDelivery(random.String(16), event)
+			require.NoError(t, repo.AddDelivery(ctx, delivery))
+			return delivery
+		}
+		uid := random.UserID()
+		redactedDelivery, other := newUserDelivery(uid), newUserDelivery(random.UserID())
+		withdrawal := addDelivery(t, repo, now)
+
+		redacted := []byte(`{"login":"deleted"}`)
+		require.NoError(t, repo.RedactUserDeliveries(ctx, uid, entity.EventUserRegistered, redacted))
+		require.NoError(t, repo.RedactUserDeliveries(ctx, withdrawal.Event.UID, entity.EventUserRegistered, redacted))
+
+		got, err := repo.GetDelivery(ctx, redactedDelivery.ID)
+		require.NoError(t, err)
+		assert.JSONEq(t, string(redacted), string(got.Event.Payload))
+		assert.Equal(t, redactedDelivery.Event.EventID, got.Event.EventID)
+		for _, kept := range []entity.WebhookDelivery{other, withdrawal} {
+			got, err := repo.GetDelivery(ctx, kept.ID)
+			require.NoError(t, err)
+			assert.JSONEq(t, string(kept.Event.Payload), string(got.Event.Payload))
+		}
+	})
+
+	t.Run("delivery not found", func(t *testing.T) {
+		repo := open(t)
+		delivery := newDelivery(t, now)
+		_, err := repo.GetDelivery(ctx, delivery.ID)
+		assert.ErrorIs(t, err, webhookrepository.ErrDeliveryNotFound)
+		assert.ErrorIs(t, repo.UpdateDelivery(ctx, delivery), webhookrepository.ErrDeliveryNotFound)
+	})
+
+	t.Run("delivery is unique", func(t *testing.T) {
+		repo := open(t)
+		delivery := addDelivery(t, repo, now)
+		assert.ErrorIs(t, repo.AddDelivery(ctx, delivery), webhookrepository.ErrDeliveryExists)
+
+		// то же событие тому же подписчику
+		again := entity.NewWebhookDelivery(delivery.SubscriberID, delivery.Event)
+		assert.ErrorIs(t, repo.AddDelivery(ctx, again), webhookrepository.ErrDeliveryExists)
+
+		// то же событие другому подписчику
+		require.NoError(t, repo.AddDelivery(ctx, entity.NewWebhookDelivery(random.String(16), delivery.Event)))
+	})
+
+	t.Run("deliveries by status", func(t *testing.T) {
+		repo := open(t)
+		first := newDelivery(t, now)
+		second := newDelivery(t, now)
+		second.CreatedAt = first.CreatedAt.Add(time.Second)
+		third := newDelivery(t, now)
+		third.CreatedAt = first.CreatedAt.Add(2 * time.Second)
+		delivered := newDelivery(t, now)
+		delivered.Status = entity.DeliveryStatusDelivered
+		for _, delivery := range []entity.WebhookDelivery{third, first, delivered, second} {
+			require.NoError(t, repo.AddDelivery(ctx, delivery))
+		}
+
+		deliveries, err := repo.GetDeliveries(ctx, entity.DeliveryStatusPending, 2)
+		require.NoError(t, err)
+		assert.Equal(t, []string{first.ID, second.ID}, deliveryIDs(deliveries))
+
+		deliveries, err = repo.GetDeliveries(ctx, entity.DeliveryStatusDelivered, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []string{delivered.ID}, deliveryIDs(deliveries))
+
+		deliveries, err = repo.GetDeliveries(ctx, entity.DeliveryStatusDead, 10)
+		require.NoError(t, err)
+		assert.Empty(t, deliveries)
+	})
+
+	t.Run("claim due deliveries", func(t *testing.T) {
+		repo := open(t)
+		later := addDelivery(t, repo, now.Add(-time.Second))
+		earlier := addDelivery(t, repo, now.Add(-time.Minute))
+		earliest := addDelivery(t, repo, now.Add(-time.Hour))
+		addDelivery(t, repo, now.Add(time.Minute))
+		dead := newDelivery(t, now.Add(-time.Minute))
+		dead.Status = entity.DeliveryStatusDead
+		require.NoError(t, repo.AddDelivery(ctx, dead))
+
+		// сначала доставки, которые ждут дольше
+		claimed, err := repo.ClaimDueDeliveries(ctx, now, time.Minute, 2)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{earliest.ID, earlier.ID}, deliveryIDs(claimed))
+		for _, delivery := range claimed {
+			assert.WithinDuration(t, now.Add(time.Minute), delivery.NextAttemptAt, time.Millisecond)
+		}
+
+		claimed, err = repo.ClaimDueDeliveries(ctx, now, time.Minute, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []string{later.ID}, deliveryIDs(claimed))
+
+		// пока аренда не истекла, доставки не выдаются повторно
+		claimed, err = repo.ClaimDueDeliveries(ctx, now, time.Minute, 10)
+		require.NoError(t, err)
+		assert.Empty(t, claimed)
+
+		got, err := repo.GetDelivery(ctx, later.ID)
+		require.NoError(t, err)
+		assert.WithinDuration(t, now.Add(time.Minute), got.NextAttemptAt, time.Millisecond)
+	})
+
+	t.Run("concurrent claim", func(t *testing.T) {
+		repo := open(t)
+		for i := 0; i < concurrency; i++ {
+			addDelivery(t, repo, now.Add(-time.Minute))
+		}
+		claimed := make([][]entity.WebhookDelivery, concurrency)
+		errs := parallel(concurrency, func(i int) error {
+			var err error
+			claimed[i], err = repo.ClaimDueDeliveries(ctx, now, time.Minute, 3)
+			return err
+		})
+		ok, _ := countErrors(t, errs, nil)
+		require.Equal(t, concurrency, ok)
+
+		// доставки, которые пропустили конкурирующие обработчики, берутся следующим вызовом
+		rest, err := repo.ClaimDueDeliveries(ctx, now, time.Minute, concurrency)
+		require.NoError(t, err)
+		claimed = append(claimed, rest)
+
+		seen := make(map[string]int, concurrency)
+		for _, deliveries := range claimed {
+			for _, delivery := range deliveries {
+				seen[delivery.ID]++
+			}
+		}
+		assert.Len(t, seen, concurrency)
+		for id, count := range seen {
+			assert.Equal(t, 1, count, id)
+		}
+	})
+}
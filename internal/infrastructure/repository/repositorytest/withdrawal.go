@@ -0,0 +1,91 @@
+package repositorytest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zaz600/go-musthave-diploma/internal/entity"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/userrepository"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/withdrawalrepository"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/random"
+)
+
+// WithdrawalRepository проверяет реализацию withdrawalrepository.WithdrawalRepository. newRepo вызывается
+// в каждом тесте и возвращает пустой репозиторий и репозиторий пользователей в том же хранилище
+func WithdrawalRepository(t *testing.T, newRepo func(t *testing.T) (withdrawalrepository.WithdrawalRepository, userrepository.UserRepository)) {
+	ctx := context.Background()
+	open := func(t *testing.T) (withdrawalrepository.WithdrawalRepository, func(t *testing.T) string) {
+		repo, users := newRepo(t)
+		t.Cleanup(func() {
+			_ = repo.Close()
+			_ = users.Close()
+		})
+		return repo, newUser(users)
+	}
+
+	t.Run("add and get", func(t *testing.T) {
+		repo, user := open(t)
+		uid := user(t)
+		first := entity.NewWithdrawal(uid, random.OrderID(), 100.5)
+		second := entity.NewWithdrawal(uid, random.OrderID(), 20)
+		require.NoError(t, repo.AddWithdrawal(ctx, first))
+		require.NoError(t, repo.AddWithdrawal(ctx, second))
+		require.NoError(t, repo.AddWithdrawal(ctx, entity.NewWithdrawal(user(t), random.OrderID(), 10)))
+
+		withdrawals, err := repo.GetUserWithdrawals(ctx, uid)
+		require.NoError(t, err)
+		require.Len(t, withdrawals, 2)
+		byOrder := make(map[string]entity.Withdrawal, len(withdrawals))
+		for _, withdrawal := range withdrawals {
+			byOrder[withdrawal.OrderID] = withdrawal
+		}
+		for _, want := range []entity.Withdrawal{first, second} {
+			got, ok := byOrder[want.OrderID]
+			require.True(t, ok, want.OrderID)
+			assert.Equal(t, want.UID, got.UID)
+			assert.Equal(t, want.Sum, got.Sum)
+			assert.WithinDuration(t, want.ProcessedAt, got.ProcessedAt, time.Millisecond)
+		}
+	})
+
+	t.Run("no withdrawals", func(t *testing.T) {
+		repo, _ := open(t)
+		withdrawals, err := repo.GetUserWithdrawals(ctx, random.UserID())
+		require.NoError(t, err)
+		assert.Empty(t, withdrawals)
+	})
+
+	t.Run("order id is unique", func(t *testing.T) {
+		repo, user := open(t)
+		withdrawal := entity.NewWithdrawal(user(t), random.OrderID(), 10)
+		require.NoError(t, repo.AddWithdrawal(ctx, withdrawal))
+
+		err := repo.AddWithdrawal(ctx, entity.NewWithdrawal(withdrawal.UID, withdrawal.OrderID, 20))
+		assert.ErrorIs(t, err, withdrawalrepository.ErrWithdrawalExists)
+		err = repo.AddWithdrawal(ctx, entity.NewWithdrawal(user(t), withdrawal.OrderID, 20))
+		assert.ErrorIs(t, err, withdrawalrepository.ErrWithdrawalOwnedByAnotherUser)
+
+		withdrawals, err := repo.GetUserWithdrawals(ctx, withdrawal.UID)
+		require.NoError(t, err)
+		require.Len(t, withdrawals, 1)
+		assert.Equal(t, float32(10), withdrawals[0].Sum)
+	})
+
+	t.Run("concurrent add with same order id", func(t *testing.T) {
+		repo, user := open(t)
+		orderID := random.OrderID()
+		uids := make([]string, concurrency)
+		for i := range uids {
+			uids[i] = user(t)
+		}
+		errs := parallel(concurrency, func(i int) error {
+			return repo.AddWithdrawal(ctx, entity.NewWithdrawal(uids[i], orderID, 10))
+		})
+		ok, anotherUser := countErrors(t, errs, withdrawalrepository.ErrWithdrawalOwnedByAnotherUser)
+		assert.Equal(t, 1, ok)
+		assert.Equal(t, concurrency-1, anotherUser)
+	})
+}
@@ -4,3 +4,6 @@ import "errors"
 
 var ErrSessionExists = errors.New("session already exists")
 var ErrSessionNotFound = errors.New("session not found")
+
+// ErrUserNotFound сессию нельзя сохранить без пользователя, которому она принадлежит
+var ErrUserNotFound = errors.New("session owner not found")
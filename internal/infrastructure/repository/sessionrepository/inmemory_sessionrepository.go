@@ -2,46 +2,270 @@ package sessionrepository
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/zaz600/go-musthave-diploma/internal/entity"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/memorystore"
+)
+
+const (
+	opAddSession    = "add"
+	opDelSession    = "del"
+	opTouchSessions = "touch"
+	opPurgeSessions = "purge"
 )
 
 type InmemorySessionRepository struct {
-	mu sync.RWMutex
-	db map[string]*entity.Session
+	mu      sync.RWMutex
+	db      map[string]*entity.Session
+	journal *memorystore.Journal
 }
 
 func (r *InmemorySessionRepository) AddSession(ctx context.Context, session *entity.Session) error {
+	defer r.journal.Lock(ctx)()
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	if _, ok := r.db[session.SessionID]; ok {
 		return ErrSessionExists
 	}
-	r.db[session.SessionID] = session
+	if err := r.journal.Append(ctx, opAddSession, session); err != nil {
+		return err
+	}
+	stored := *session
+	r.db[session.SessionID] = &stored
+	memorystore.OnRollback(ctx, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.db, session.SessionID)
+	})
 	return nil
 }
 
-func (r *InmemorySessionRepository) DelSession(ctx context.Context, sessionID string) error {
-	// TODO implement me
-	panic("implement me")
-}
-
 func (r *InmemorySessionRepository) GetSession(ctx context.Context, sessionID string) (*entity.Session, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	if sessionEntity, ok := r.db[sessionID]; ok {
-		return sessionEntity, nil
+		session := *sessionEntity
+		return &session, nil
 	}
 	return nil, ErrSessionNotFound
 }
 
+func (r *InmemorySessionRepository) GetUserSessions(ctx context.Context, uid string) ([]*entity.Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var sessions []*entity.Session
+	for _, sessionEntity := range r.db {
+		if sessionEntity.UID == uid {
+			session := *sessionEntity
+			sessions = append(sessions, &session)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].CreatedAt.Before(sessions[j].CreatedAt)
+	})
+	return sessions, nil
+}
+
+func (r *InmemorySessionRepository) DelSession(ctx context.Context, sessionID string) error {
+	defer r.journal.Lock(ctx)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.db[sessionID]; !ok {
+		return ErrSessionNotFound
+	}
+	if err := r.journal.Append(ctx, opDelSession, sessionID); err != nil {
+		return err
+	}
+	r.del(ctx, []string{sessionID})
+	return nil
+}
+
+func (r *InmemorySessionRepository) TouchSessions(ctx context.Context, lastSeen map[string]time.Time) error {
+	defer r.journal.Lock(ctx)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	changed := make(map[string]time.Time, len(lastSeen))
+	for sessionID, seen := range lastSeen {
+		if session, ok := r.db[sessionID]; ok && seen.After(session.LastSeenAt) {
+			changed[sessionID] = seen
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	if err := r.journal.Append(ctx, opTouchSessions, changed); err != nil {
+		return err
+	}
+	prev := make(map[string]*entity.Session, len(changed))
+	for sessionID := range changed {
+		prev[sessionID] = r.db[sessionID]
+	}
+	r.touch(changed)
+	memorystore.OnRollback(ctx, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		for sessionID, session := range prev {
+			if _, ok := r.db[sessionID]; ok {
+				r.db[sessionID] = session
+			}
+		}
+	})
+	return nil
+}
+
+func (r *InmemorySessionRepository) DelSessionsCreatedBefore(ctx context.Context, before time.Time, limit int) (int, error) {
+	defer r.journal.Lock(ctx)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var expired []string
+	for sessionID, session := range r.db {
+		if len(expired) == limit {
+			break
+		}
+		if session.CreatedAt.Before(before) {
+			expired = append(expired, sessionID)
+		}
+	}
+	if len(expired) == 0 {
+		return 0, nil
+	}
+	if err := r.journal.Append(ctx, opPurgeSessions, expired); err != nil {
+		return 0, err
+	}
+	r.del(ctx, expired)
+	return len(expired), nil
+}
+
+func (r *InmemorySessionRepository) DelUserSessions(ctx context.Context, uid string) (int, error) {
+	defer r.journal.Lock(ctx)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var sessionIDs []string
+	for sessionID, session := range r.db {
+		if session.UID == uid {
+			sessionIDs = append(sessionIDs, sessionID)
+		}
+	}
+	if len(sessionIDs) == 0 {
+		return 0, nil
+	}
+	if err := r.journal.Append(ctx, opPurgeSessions, sessionIDs); err != nil {
+		return 0, err
+	}
+	r.del(ctx, sessionIDs)
+	return len(sessionIDs), nil
+}
+
+// del удаляет сессии. При откате транзакции они возвращаются
+func (r *InmemorySessionRepository) del(ctx context.Context, sessionIDs []string) {
+	removed := make(map[string]*entity.Session, len(sessionIDs))
+	for _, sessionID := range sessionIDs {
+		removed[sessionID] = r.db[sessionID]
+		delete(r.db, sessionID)
+	}
+	memorystore.OnRollback(ctx, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		for sessionID, session := range removed {
+			r.db[sessionID] = session
+		}
+	})
+}
+
+// touch меняет время последнего запроса сессий, которые есть в репозитории
+func (r *InmemorySessionRepository) touch(lastSeen map[string]time.Time) {
+	for sessionID, seen := range lastSeen {
+		if session, ok := r.db[sessionID]; ok {
+			updated := *session
+			updated.LastSeenAt = seen
+			r.db[sessionID] = &updated
+		}
+	}
+}
+
 func (r *InmemorySessionRepository) Close() error {
 	return nil
 }
 
+func (r *InmemorySessionRepository) SetJournal(j *memorystore.Journal) {
+	r.journal = j
+}
+
+func (r *InmemorySessionRepository) Snapshot() (interface{}, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	sessions := make([]*entity.Session, 0, len(r.db))
+	for _, session := range r.db {
+		sessions = append(sessions, session)
+	}
+	return sessions, nil
+}
+
+func (r *InmemorySessionRepository) Restore(state json.RawMessage) error {
+	var sessions []*entity.Session
+	if err := json.Unmarshal(state, &sessions); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.db = make(map[string]*entity.Session, len(sessions))
+	for _, session := range sessions {
+		r.db[session.SessionID] = session
+	}
+	return nil
+}
+
+func (r *InmemorySessionRepository) Apply(op string, data json.RawMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch op {
+	case opAddSession:
+		session := &entity.Session{}
+		if err := json.Unmarshal(data, session); err != nil {
+			return err
+		}
+		r.db[session.SessionID] = session
+	case opDelSession:
+		var sessionID string
+		if err := json.Unmarshal(data, &sessionID); err != nil {
+			return err
+		}
+		delete(r.db, sessionID)
+	case opTouchSessions:
+		var lastSeen map[string]time.Time
+		if err := json.Unmarshal(data, &lastSeen); err != nil {
+			return err
+		}
+		r.touch(lastSeen)
+	case opPurgeSessions:
+		var expired []string
+		if err := json.Unmarshal(data, &expired); err != nil {
+			return err
+		}
+		for _, sessionID := range expired {
+			delete(r.db, sessionID)
+		}
+	default:
+		return fmt.Errorf("%w: %s", memorystore.ErrUnknownOp, op)
+	}
+	return nil
+}
+
 func NewInmemorySessionRepository() *InmemorySessionRepository {
 	return &InmemorySessionRepository{
 		mu: sync.RWMutex{},
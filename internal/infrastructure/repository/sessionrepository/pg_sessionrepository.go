@@ -2,76 +2,137 @@ package sessionrepository
 
 import (
 	"context"
-	"database/sql"
-	"fmt"
+	"errors"
+	"time"
 
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v4"
 	"github.com/zaz600/go-musthave-diploma/internal/entity"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/pgdb"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/transaction"
 )
 
 type PgSessionRepository struct {
-	db         *sql.DB
-	statements map[queryType]*sql.Stmt
+	db *pgdb.Pool
 }
 
 type queryType string
 
 const (
-	queryAddSession queryType = "addSession"
-	queryGetSession queryType = "getSession"
+	queryAddSession      queryType = "addSession"
+	queryGetSession      queryType = "getSession"
+	queryGetUserSessions queryType = "getUserSessions"
+	queryDelSession      queryType = "delSession"
+	queryTouchSession    queryType = "touchSession"
+	queryPurgeSessions   queryType = "purgeSessions"
+	queryDelUserSessions queryType = "delUserSessions"
 )
 
 var queries = map[queryType]string{
-	queryAddSession: "insert into gophermart.sessions(sid, uid, created_at) values($1, $2, $3)",
-	queryGetSession: "select sid, uid, created_at from gophermart.sessions where sid=$1",
+	queryAddSession: "insert into gophermart.sessions(sid, uid, created_at, last_seen_at, user_agent, ip) values($1, $2, $3, $4, $5, $6)",
+	queryGetSession: "select sid, uid, created_at, last_seen_at, user_agent, ip from gophermart.sessions where sid=$1",
+	queryGetUserSessions: "select sid, uid, created_at, last_seen_at, user_agent, ip from gophermart.sessions " +
+		"where uid=$1 order by created_at, id",
+	queryDelSession:   "delete from gophermart.sessions where sid=$1",
+	queryTouchSession: "update gophermart.sessions set last_seen_at=$2 where sid=$1 and last_seen_at < $2",
+	queryPurgeSessions: "delete from gophermart.sessions where id in " +
+		"(select id from gophermart.sessions where created_at < $1 order by created_at limit $2)",
+	queryDelUserSessions: "delete from gophermart.sessions where uid=$1",
 }
 
 func (p PgSessionRepository) AddSession(ctx context.Context, session *entity.Session) error {
-	tx, err := p.db.Begin()
+	_, err := transaction.PgQuerier(ctx, p.db).Exec(ctx, queries[queryAddSession],
+		session.SessionID, session.UID, session.CreatedAt, session.LastSeenAt, session.UserAgent, session.IP)
 	if err != nil {
-		return err
-	}
-	defer tx.Rollback() //nolint:errcheck
-	stmt := tx.Stmt(p.statements[queryAddSession])
-	_, err = stmt.ExecContext(ctx, session.SessionID, session.UID, session.CreatedAt)
-	if err != nil {
-		return err
-	}
-	if err = tx.Commit(); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			switch pgErr.Code {
+			case pgerrcode.UniqueViolation:
+				return ErrSessionExists
+			case pgerrcode.ForeignKeyViolation:
+				return ErrUserNotFound
+			}
+		}
 		return err
 	}
 	return nil
 }
 
 func (p PgSessionRepository) GetSession(ctx context.Context, sessionID string) (*entity.Session, error) {
-	query := "select sid, uid, created_at from gophermart.sessions where sid=$1"
-
 	var session entity.Session
-	err := p.statements[queryGetSession].QueryRowContext(ctx, query, sessionID).Scan(&session.SessionID, &session.UID, &session.CreatedAt)
+	err := transaction.PgQuerier(ctx, p.db).QueryRow(ctx, queries[queryGetSession], sessionID).
+		Scan(&session.SessionID, &session.UID, &session.CreatedAt, &session.LastSeenAt, &session.UserAgent, &session.IP)
 	if err != nil {
-		return nil, ErrSessionNotFound
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, err
 	}
 	return &session, nil
 }
 
-func (p PgSessionRepository) Close() error {
-	for name, stmt := range p.statements {
-		err := stmt.Close()
-		if err != nil {
-			return fmt.Errorf("error close stmt %s: %w", name, err)
+func (p PgSessionRepository) GetUserSessions(ctx context.Context, uid string) ([]*entity.Session, error) {
+	rows, err := transaction.PgQuerier(ctx, p.db).Query(ctx, queries[queryGetUserSessions], uid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var sessions []*entity.Session
+	for rows.Next() {
+		var session entity.Session
+		if err := rows.Scan(&session.SessionID, &session.UID, &session.CreatedAt, &session.LastSeenAt, &session.UserAgent, &session.IP); err != nil {
+			return nil, err
 		}
+		sessions = append(sessions, &session)
+	}
+	return sessions, rows.Err()
+}
+
+func (p PgSessionRepository) DelSession(ctx context.Context, sessionID string) error {
+	tag, err := transaction.PgQuerier(ctx, p.db).Exec(ctx, queries[queryDelSession], sessionID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrSessionNotFound
 	}
 	return nil
 }
 
-func NewPgSessionRepository(db *sql.DB) (*PgSessionRepository, error) {
-	statements := make(map[queryType]*sql.Stmt, len(queries))
-	for name, query := range queries {
-		stmt, err := db.Prepare(query)
-		if err != nil {
-			return nil, fmt.Errorf("error prepare statement for %s: %w", name, err)
-		}
-		statements[name] = stmt
+// TouchSessions отправляет обновления всех сессий одним пакетом
+func (p PgSessionRepository) TouchSessions(ctx context.Context, lastSeen map[string]time.Time) error {
+	if len(lastSeen) == 0 {
+		return nil
+	}
+	batch := &pgx.Batch{}
+	for sessionID, seen := range lastSeen {
+		batch.Queue(queries[queryTouchSession], sessionID, seen)
+	}
+	return transaction.PgQuerier(ctx, p.db).SendBatch(ctx, batch).Close()
+}
+
+func (p PgSessionRepository) DelSessionsCreatedBefore(ctx context.Context, before time.Time, limit int) (int, error) {
+	result, err := transaction.PgQuerier(ctx, p.db).Exec(ctx, queries[queryPurgeSessions], before, limit)
+	if err != nil {
+		return 0, err
+	}
+	return int(result.RowsAffected()), nil
+}
+
+func (p PgSessionRepository) DelUserSessions(ctx context.Context, uid string) (int, error) {
+	result, err := transaction.PgQuerier(ctx, p.db).Exec(ctx, queries[queryDelUserSessions], uid)
+	if err != nil {
+		return 0, err
 	}
+	return int(result.RowsAffected()), nil
+}
+
+// Close ничего не делает: пул соединений общий для всех репозиториев
+func (p PgSessionRepository) Close() error {
+	return nil
+}
 
-	return &PgSessionRepository{db: db, statements: statements}, nil
+func NewPgSessionRepository(db *pgdb.Pool) *PgSessionRepository {
+	return &PgSessionRepository{db: db}
 }
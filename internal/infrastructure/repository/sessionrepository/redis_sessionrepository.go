@@ -0,0 +1,208 @@
+package sessionrepository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/zaz600/go-musthave-diploma/internal/entity"
+)
+
+const (
+	redisSessionPrefix     = "gophermart:session:"
+	redisUserSessionPrefix = "gophermart:user_sessions:"
+)
+
+// RedisSessionRepository хранит сессии в Redis отдельно от остальных данных.
+// Сессия хранится, пока действует выпущенный для нее JWT, после этого Redis удаляет ее сам.
+// Для списка сессий пользователя рядом хранится множество их id, из которого истекшие сессии
+// удаляются при чтении. Внешнего ключа на пользователя нет, как и у in-memory репозитория.
+// Нужен Redis 6.0 и новее: время последнего запроса обновляется с SET KEEPTTL
+type RedisSessionRepository struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+func (r RedisSessionRepository) AddSession(ctx context.Context, session *entity.Session) error {
+	expiration := time.Until(session.CreatedAt.Add(r.ttl))
+	if expiration <= 0 {
+		// JWT для такой сессии уже истек, GetSession ее не найдет
+		return nil
+	}
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	added, err := r.client.SetNX(ctx, redisSessionPrefix+session.SessionID, data, expiration).Result()
+	if err != nil {
+		return err
+	}
+	if !added {
+		return ErrSessionExists
+	}
+	// множество живет, пока не истечет последняя созданная сессия пользователя
+	userKey := redisUserSessionPrefix + session.UID
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.SAdd(ctx, userKey, session.SessionID)
+		pipe.Expire(ctx, userKey, expiration)
+		return nil
+	})
+	return err
+}
+
+func (r RedisSessionRepository) GetSession(ctx context.Context, sessionID string) (*entity.Session, error) {
+	data, err := r.client.Get(ctx, redisSessionPrefix+sessionID).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, err
+	}
+	session := &entity.Session{}
+	if err := json.Unmarshal(data, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func (r RedisSessionRepository) GetUserSessions(ctx context.Context, uid string) ([]*entity.Session, error) {
+	userKey := redisUserSessionPrefix + uid
+	ids, err := r.client.SMembers(ctx, userKey).Result()
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+	sessions, expired, err := r.getSessions(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	if len(expired) > 0 {
+		if err := r.client.SRem(ctx, userKey, expired...).Err(); err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].CreatedAt.Before(sessions[j].CreatedAt)
+	})
+	return sessions, nil
+}
+
+// getSessions читает сессии одним запросом и возвращает id тех, что уже истекли
+func (r RedisSessionRepository) getSessions(ctx context.Context, ids []string) ([]*entity.Session, []interface{}, error) {
+	keys := make([]string, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, redisSessionPrefix+id)
+	}
+	values, err := r.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, nil, err
+	}
+	var sessions []*entity.Session
+	var expired []interface{}
+	for i, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			expired = append(expired, ids[i])
+			continue
+		}
+		session := &entity.Session{}
+		if err := json.Unmarshal([]byte(data), session); err != nil {
+			return nil, nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, expired, nil
+}
+
+func (r RedisSessionRepository) DelSession(ctx context.Context, sessionID string) error {
+	session, err := r.GetSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	var deleted *redis.IntCmd
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		deleted = pipe.Del(ctx, redisSessionPrefix+sessionID)
+		pipe.SRem(ctx, redisUserSessionPrefix+session.UID, sessionID)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if deleted.Val() == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// TouchSessions читает сессии одним запросом и записывает изменившиеся одним пакетом, не меняя срок их жизни
+func (r RedisSessionRepository) TouchSessions(ctx context.Context, lastSeen map[string]time.Time) error {
+	if len(lastSeen) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(lastSeen))
+	for id := range lastSeen {
+		ids = append(ids, id)
+	}
+	sessions, _, err := r.getSessions(ctx, ids)
+	if err != nil {
+		return err
+	}
+	_, err = r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, session := range sessions {
+			seen := lastSeen[session.SessionID]
+			if !seen.After(session.LastSeenAt) {
+				continue
+			}
+			session.LastSeenAt = seen
+			data, err := json.Marshal(session)
+			if err != nil {
+				return err
+			}
+			pipe.SetXX(ctx, redisSessionPrefix+session.SessionID, data, redis.KeepTTL)
+		}
+		return nil
+	})
+	return err
+}
+
+// DelSessionsCreatedBefore ничего не удаляет: Redis сам удаляет сессии, когда истекает их JWT
+func (r RedisSessionRepository) DelSessionsCreatedBefore(context.Context, time.Time, int) (int, error) {
+	return 0, nil
+}
+
+// DelUserSessions удаляет сессии из множества сессий пользователя вместе с самим множеством.
+// Уже истекшие сессии в результат не попадают
+func (r RedisSessionRepository) DelUserSessions(ctx context.Context, uid string) (int, error) {
+	ids, err := r.client.SMembers(ctx, redisUserSessionPrefix+uid).Result()
+	if err != nil {
+		return 0, err
+	}
+	var deleted *redis.IntCmd
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		if len(ids) > 0 {
+			keys := make([]string, 0, len(ids))
+			for _, id := range ids {
+				keys = append(keys, redisSessionPrefix+id)
+			}
+			deleted = pipe.Del(ctx, keys...)
+		}
+		pipe.Del(ctx, redisUserSessionPrefix+uid)
+		return nil
+	})
+	if err != nil || deleted == nil {
+		return 0, err
+	}
+	return int(deleted.Val()), nil
+}
+
+// Close ничего не делает: клиентом Redis владеет тот, кто его создал
+func (r RedisSessionRepository) Close() error {
+	return nil
+}
+
+// NewRedisSessionRepository ttl - время жизни JWT, с которым выпускаются токены сессий
+func NewRedisSessionRepository(client *redis.Client, ttl time.Duration) *RedisSessionRepository {
+	return &RedisSessionRepository{client: client, ttl: ttl}
+}
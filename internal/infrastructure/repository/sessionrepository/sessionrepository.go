@@ -3,6 +3,7 @@ package sessionrepository
 import (
 	"context"
 	"io"
+	"time"
 
 	"github.com/zaz600/go-musthave-diploma/internal/entity"
 )
@@ -10,5 +11,16 @@ import (
 type SessionRepository interface {
 	AddSession(ctx context.Context, session *entity.Session) error
 	GetSession(ctx context.Context, sessionID string) (*entity.Session, error)
+	// GetUserSessions сессии пользователя в порядке создания
+	GetUserSessions(ctx context.Context, uid string) ([]*entity.Session, error)
+	// DelSession удаляет сессию. Если сессии нет - ErrSessionNotFound
+	DelSession(ctx context.Context, sessionID string) error
+	// TouchSessions обновляет время последнего запроса сессий. Время не сдвигается назад,
+	// сессии, которых уже нет, пропускаются
+	TouchSessions(ctx context.Context, lastSeen map[string]time.Time) error
+	// DelSessionsCreatedBefore удаляет не больше limit сессий, созданных раньше before, и возвращает, сколько удалено
+	DelSessionsCreatedBefore(ctx context.Context, before time.Time, limit int) (int, error)
+	// DelUserSessions удаляет все сессии пользователя и возвращает, сколько удалено
+	DelUserSessions(ctx context.Context, uid string) (int, error)
 	io.Closer
 }
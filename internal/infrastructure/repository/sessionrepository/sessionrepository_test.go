@@ -0,0 +1,85 @@
+package sessionrepository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zaz600/go-musthave-diploma/internal/entity"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/repositorytest"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/sessionrepository"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/userrepository"
+)
+
+func TestSessionRepository(t *testing.T) {
+	t.Run("inmemory", func(t *testing.T) {
+		repositorytest.SessionRepository(t, func(t *testing.T) (sessionrepository.SessionRepository, userrepository.UserRepository) {
+			return sessionrepository.NewInmemorySessionRepository(), userrepository.NewInmemoryUserRepository()
+		})
+	})
+	t.Run("journaled", func(t *testing.T) {
+		repositorytest.SessionRepository(t, func(t *testing.T) (sessionrepository.SessionRepository, userrepository.UserRepository) {
+			repo := sessionrepository.NewInmemorySessionRepository()
+			repositorytest.Journaled(t, repo)
+			return repo, userrepository.NewInmemoryUserRepository()
+		})
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		repositorytest.SessionRepository(t, func(t *testing.T) (sessionrepository.SessionRepository, userrepository.UserRepository) {
+			db := repositorytest.SQLite(t)
+			repo, err := sessionrepository.NewSQLiteSessionRepository(db)
+			require.NoError(t, err)
+			users, err := userrepository.NewSQLiteUserRepository(db)
+			require.NoError(t, err)
+			return repo, users
+		})
+	})
+	t.Run("redis", func(t *testing.T) {
+		repositorytest.SessionRepository(t, func(t *testing.T) (sessionrepository.SessionRepository, userrepository.UserRepository) {
+			return sessionrepository.NewRedisSessionRepository(newRedis(t), time.Hour), userrepository.NewInmemoryUserRepository()
+		})
+	})
+	t.Run("postgres", func(t *testing.T) {
+		db := repositorytest.Postgres(t)
+		repositorytest.SessionRepository(t, func(t *testing.T) (sessionrepository.SessionRepository, userrepository.UserRepository) {
+			repositorytest.Truncate(t, db)
+			return sessionrepository.NewPgSessionRepository(db), userrepository.NewPgUserRepository(db)
+		})
+	})
+}
+
+// newRedis клиент встроенного в тест сервера, совместимого с Redis
+func newRedis(t *testing.T) *redis.Client {
+	t.Helper()
+	client := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	t.Cleanup(func() {
+		_ = client.Close()
+	})
+	return client
+}
+
+func TestRedisSessionRepository_TTL(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+	repo := sessionrepository.NewRedisSessionRepository(client, time.Hour)
+
+	session := entity.NewRandomSession("uid")
+	require.NoError(t, repo.AddSession(ctx, session))
+	assert.InDelta(t, time.Hour, server.TTL("gophermart:session:"+session.SessionID), float64(time.Second))
+
+	server.FastForward(time.Hour)
+	_, err := repo.GetSession(ctx, session.SessionID)
+	assert.ErrorIs(t, err, sessionrepository.ErrSessionNotFound)
+
+	// JWT сессии, созданной раньше времени жизни токена, уже истек
+	expired := entity.New("uid", entity.WithCreatedAt(time.Now().Add(-2*time.Hour)))
+	require.NoError(t, repo.AddSession(ctx, expired))
+	_, err = repo.GetSession(ctx, expired.SessionID)
+	assert.ErrorIs(t, err, sessionrepository.ErrSessionNotFound)
+}
@@ -0,0 +1,166 @@
+package sessionrepository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/zaz600/go-musthave-diploma/internal/entity"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/sqlitedb"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/transaction"
+)
+
+type SQLiteSessionRepository struct {
+	db         *sql.DB
+	statements map[queryType]*sql.Stmt
+}
+
+var sqliteQueries = map[queryType]string{
+	queryAddSession:      "insert into sessions(sid, uid, created_at, last_seen_at, user_agent, ip) values($1, $2, $3, $4, $5, $6)",
+	queryGetSession:      "select sid, uid, created_at, last_seen_at, user_agent, ip from sessions where sid=$1",
+	queryGetUserSessions: "select sid, uid, created_at, last_seen_at, user_agent, ip from sessions where uid=$1 order by created_at, id",
+	queryDelSession:      "delete from sessions where sid=$1",
+	queryTouchSession:    "update sessions set last_seen_at=$2 where sid=$1 and last_seen_at < $2",
+	queryPurgeSessions:   "delete from sessions where id in (select id from sessions where created_at < $1 order by created_at limit $2)",
+	queryDelUserSessions: "delete from sessions where uid=$1",
+}
+
+func (p SQLiteSessionRepository) AddSession(ctx context.Context, session *entity.Session) error {
+	tx, err := transaction.Begin(ctx, p.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+	stmt := tx.Stmt(p.statements[queryAddSession])
+	_, err = stmt.ExecContext(ctx, session.SessionID, session.UID, session.CreatedAt.UTC(), session.LastSeenAt.UTC(), session.UserAgent, session.IP)
+	if err != nil {
+		switch {
+		case sqlitedb.IsUniqueViolation(err):
+			return ErrSessionExists
+		case sqlitedb.IsForeignKeyViolation(err):
+			return ErrUserNotFound
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+func (p SQLiteSessionRepository) GetSession(ctx context.Context, sessionID string) (*entity.Session, error) {
+	var session entity.Session
+	err := p.statements[queryGetSession].QueryRowContext(ctx, sessionID).
+		Scan(&session.SessionID, &session.UID, &session.CreatedAt, &session.LastSeenAt, &session.UserAgent, &session.IP)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (p SQLiteSessionRepository) GetUserSessions(ctx context.Context, uid string) ([]*entity.Session, error) {
+	rows, err := p.statements[queryGetUserSessions].QueryContext(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var sessions []*entity.Session
+	for rows.Next() {
+		var session entity.Session
+		if err := rows.Scan(&session.SessionID, &session.UID, &session.CreatedAt, &session.LastSeenAt, &session.UserAgent, &session.IP); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, &session)
+	}
+	return sessions, rows.Err()
+}
+
+func (p SQLiteSessionRepository) DelSession(ctx context.Context, sessionID string) error {
+	tx, err := transaction.Begin(ctx, p.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+	result, err := tx.Stmt(p.statements[queryDelSession]).ExecContext(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrSessionNotFound
+	}
+	return tx.Commit()
+}
+
+// TouchSessions обновляет все сессии в одной транзакции
+func (p SQLiteSessionRepository) TouchSessions(ctx context.Context, lastSeen map[string]time.Time) error {
+	if len(lastSeen) == 0 {
+		return nil
+	}
+	tx, err := transaction.Begin(ctx, p.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+	stmt := tx.Stmt(p.statements[queryTouchSession])
+	for sessionID, seen := range lastSeen {
+		if _, err := stmt.ExecContext(ctx, sessionID, seen.UTC()); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (p SQLiteSessionRepository) DelSessionsCreatedBefore(ctx context.Context, before time.Time, limit int) (int, error) {
+	result, err := p.statements[queryPurgeSessions].ExecContext(ctx, before.UTC(), limit)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	return int(affected), err
+}
+
+func (p SQLiteSessionRepository) DelUserSessions(ctx context.Context, uid string) (int, error) {
+	tx, err := transaction.Begin(ctx, p.db)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback() //nolint:errcheck
+	result, err := tx.Stmt(p.statements[queryDelUserSessions]).ExecContext(ctx, uid)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(affected), tx.Commit()
+}
+
+func (p SQLiteSessionRepository) Close() error {
+	for name, stmt := range p.statements {
+		err := stmt.Close()
+		if err != nil {
+			return fmt.Errorf("error close stmt %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func NewSQLiteSessionRepository(db *sql.DB) (*SQLiteSessionRepository, error) {
+	statements := make(map[queryType]*sql.Stmt, len(sqliteQueries))
+	for name, query := range sqliteQueries {
+		stmt, err := db.Prepare(query)
+		if err != nil {
+			return nil, fmt.Errorf("error prepare statement for %s: %w", name, err)
+		}
+		statements[name] = stmt
+	}
+
+	return &SQLiteSessionRepository{db: db, statements: statements}, nil
+}
@@ -0,0 +1,55 @@
+// Package sqlitedb подключение к SQLite для инсталляций на одном узле
+package sqlitedb
+
+import (
+	"errors"
+	"net/url"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// DriverName имя драйвера database/sql
+const DriverName = "sqlite"
+
+// DSN строка подключения к файлу БД.
+// Транзакции сразу берут блокировку на запись, поэтому параллельные транзакции ждут друг друга до busy_timeout,
+// а не получают SQLITE_BUSY при попытке записи. WAL позволяет читать во время записи.
+// Время хранится в текстовом формате SQLite, значения в UTC можно сравнивать в запросах
+func DSN(path string) string {
+	params := url.Values{}
+	params.Add("_pragma", "busy_timeout(5000)")
+	params.Add("_pragma", "journal_mode(WAL)")
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Set("_txlock", "immediate")
+	params.Set("_time_format", "sqlite")
+	return "file:" + (&url.URL{Path: path}).EscapedPath() + "?" + params.Encode()
+}
+
+// IsUniqueViolation нарушено ли ограничение уникальности
+func IsUniqueViolation(err error) bool {
+	return hasCode(err, sqlite3.SQLITE_CONSTRAINT_UNIQUE, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY)
+}
+
+// IsForeignKeyViolation нарушен ли внешний ключ. Внешние ключи проверяются, только если они включены в DSN
+func IsForeignKeyViolation(err error) bool {
+	return hasCode(err, sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY)
+}
+
+// IsCheckViolation нарушено ли ограничение CHECK
+func IsCheckViolation(err error) bool {
+	return hasCode(err, sqlite3.SQLITE_CONSTRAINT_CHECK)
+}
+
+func hasCode(err error, codes ...int) bool {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	for _, code := range codes {
+		if sqliteErr.Code() == code {
+			return true
+		}
+	}
+	return false
+}
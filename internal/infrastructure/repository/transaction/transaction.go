@@ -0,0 +1,177 @@
+package transaction
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/memorystore"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/pgdb"
+)
+
+type txKey struct{}
+
+// Transactor выполняет несколько операций с репозиториями в одной транзакции
+type Transactor interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// PgTransactor открывает транзакцию в пуле Postgres и передает ее репозиториям через контекст
+type PgTransactor struct {
+	db *pgdb.Pool
+}
+
+func (t PgTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := t.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func NewPgTransactor(db *pgdb.Pool) *PgTransactor {
+	return &PgTransactor{db: db}
+}
+
+// PgQuerier транзакция из контекста, либо пул, если запрос выполняется вне транзакции.
+// Отдельный запрос вне транзакции выполняется без BEGIN и COMMIT
+func PgQuerier(ctx context.Context, db *pgdb.Pool) pgdb.Querier {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+	return db
+}
+
+// PgTx транзакция Postgres, в которой выполняется несколько запросов репозитория.
+// Если транзакция пришла из контекста, то Commit и Rollback выполняет тот, кто ее открыл
+type PgTx struct {
+	pgx.Tx
+	outer bool
+}
+
+func (t *PgTx) Commit(ctx context.Context) error {
+	if t.outer {
+		return nil
+	}
+	return t.Tx.Commit(ctx)
+}
+
+func (t *PgTx) Rollback(ctx context.Context) error {
+	if t.outer {
+		return nil
+	}
+	return t.Tx.Rollback(ctx)
+}
+
+// BeginPg возвращает транзакцию из контекста, либо открывает новую
+func BeginPg(ctx context.Context, db *pgdb.Pool) (*PgTx, error) {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return &PgTx{Tx: tx, outer: true}, nil
+	}
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &PgTx{Tx: tx}, nil
+}
+
+// SQLiteTransactor открывает транзакцию в SQLite и передает ее репозиториям через контекст.
+// БД открывается с блокировкой на запись в начале транзакции, поэтому транзакции выполняются по одной
+type SQLiteTransactor struct {
+	db *sql.DB
+}
+
+func (t SQLiteTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func NewSQLiteTransactor(db *sql.DB) *SQLiteTransactor {
+	return &SQLiteTransactor{db: db}
+}
+
+// Tx транзакция SQLite, в которой выполняется запрос репозитория.
+// Если транзакция пришла из контекста, то Commit и Rollback выполняет тот, кто ее открыл
+type Tx struct {
+	*sql.Tx
+	outer bool
+}
+
+func (t *Tx) Commit() error {
+	if t.outer {
+		return nil
+	}
+	return t.Tx.Commit()
+}
+
+func (t *Tx) Rollback() error {
+	if t.outer {
+		return nil
+	}
+	return t.Tx.Rollback()
+}
+
+// Begin возвращает транзакцию из контекста, либо открывает новую
+func Begin(ctx context.Context, db *sql.DB) (*Tx, error) {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return &Tx{Tx: tx, outer: true}, nil
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Tx{Tx: tx}, nil
+}
+
+// InmemoryTransactor сериализует транзакции для in-memory репозиториев. Репозитории регистрируют
+// отмену своих изменений в транзакции, и при ошибке изменения откатываются. Если данные сохраняются в store,
+// изменения транзакции попадают в журнал одной записью при фиксации.
+// Изоляции нет: запросы вне транзакции видят ее изменения до фиксации
+type InmemoryTransactor struct {
+	mu    *sync.Mutex
+	store *memorystore.Store
+}
+
+func (t InmemoryTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := memorystore.TxFromContext(ctx); ok {
+		return fn(ctx)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tx := t.store.Begin()
+	defer tx.Rollback()
+
+	if err := fn(memorystore.WithTx(ctx, tx)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// NewInmemoryTransactor store - хранилище, в котором зарегистрированы репозитории, либо nil
+func NewInmemoryTransactor(store *memorystore.Store) *InmemoryTransactor {
+	return &InmemoryTransactor{mu: &sync.Mutex{}, store: store}
+}
@@ -2,14 +2,29 @@ package userrepository
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"sync"
 
 	"github.com/zaz600/go-musthave-diploma/internal/entity"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/memorystore"
 )
 
+const (
+	opAddUser       = "add"
+	opAnonymizeUser = "anonymize"
+)
+
+// anonymization данные записи журнала об обезличивании пользователя
+type anonymization struct {
+	UID   string
+	Login string
+}
+
 type InmemoryUserRepository struct {
-	mu sync.RWMutex
-	db map[string]entity.UserEntity
+	mu      sync.RWMutex
+	db      map[string]entity.UserEntity
+	journal *memorystore.Journal
 }
 
 func NewInmemoryUserRepository() *InmemoryUserRepository {
@@ -29,17 +44,135 @@ func (r *InmemoryUserRepository) GetUser(_ context.Context, login string) (entit
 	return entity.UserEntity{}, ErrUserNotFound
 }
 
-func (r *InmemoryUserRepository) AddUser(_ context.Context, userEntity entity.UserEntity) error {
+func (r *InmemoryUserRepository) GetUserByID(_ context.Context, uid string) (entity.UserEntity, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if userEntity, ok := r.find(uid); ok {
+		return userEntity, nil
+	}
+	return entity.UserEntity{}, ErrUserNotFound
+}
+
+func (r *InmemoryUserRepository) AddUser(ctx context.Context, userEntity entity.UserEntity) error {
+	defer r.journal.Lock(ctx)()
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	if _, ok := r.db[userEntity.Login]; ok {
 		return ErrUserExists
 	}
+	if err := r.journal.Append(ctx, opAddUser, userEntity); err != nil {
+		return err
+	}
 	r.db[userEntity.Login] = userEntity
+	memorystore.OnRollback(ctx, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.db, userEntity.Login)
+	})
 	return nil
 }
 
+func (r *InmemoryUserRepository) AnonymizeUser(ctx context.Context, uid string, login string) error {
+	defer r.journal.Lock(ctx)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.db[login]; ok {
+		return ErrUserExists
+	}
+	prev, ok := r.find(uid)
+	if !ok {
+		return ErrUserNotFound
+	}
+	if err := r.journal.Append(ctx, opAnonymizeUser, anonymization{UID: uid, Login: login}); err != nil {
+		return err
+	}
+	r.anonymize(uid, login)
+	memorystore.OnRollback(ctx, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.db, login)
+		r.db[prev.Login] = prev
+	})
+	return nil
+}
+
+func (r *InmemoryUserRepository) find(uid string) (entity.UserEntity, bool) {
+	for _, userEntity := range r.db {
+		if userEntity.UID == uid {
+			return userEntity, true
+		}
+	}
+	return entity.UserEntity{}, false
+}
+
+// anonymize заменяет логин и удаляет хэш пароля. Пользователь хранится по логину, поэтому переносится на новый ключ
+func (r *InmemoryUserRepository) anonymize(uid string, login string) {
+	userEntity, ok := r.find(uid)
+	if !ok {
+		return
+	}
+	delete(r.db, userEntity.Login)
+	userEntity.Login = login
+	userEntity.Password = ""
+	r.db[login] = userEntity
+}
+
 func (r *InmemoryUserRepository) Close() error {
 	return nil
 }
+
+func (r *InmemoryUserRepository) SetJournal(j *memorystore.Journal) {
+	r.journal = j
+}
+
+func (r *InmemoryUserRepository) Snapshot() (interface{}, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	users := make([]entity.UserEntity, 0, len(r.db))
+	for _, userEntity := range r.db {
+		users = append(users, userEntity)
+	}
+	return users, nil
+}
+
+func (r *InmemoryUserRepository) Restore(state json.RawMessage) error {
+	var users []entity.UserEntity
+	if err := json.Unmarshal(state, &users); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.db = make(map[string]entity.UserEntity, len(users))
+	for _, userEntity := range users {
+		r.db[userEntity.Login] = userEntity
+	}
+	return nil
+}
+
+func (r *InmemoryUserRepository) Apply(op string, data json.RawMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch op {
+	case opAddUser:
+		var userEntity entity.UserEntity
+		if err := json.Unmarshal(data, &userEntity); err != nil {
+			return err
+		}
+		r.db[userEntity.Login] = userEntity
+	case opAnonymizeUser:
+		var a anonymization
+		if err := json.Unmarshal(data, &a); err != nil {
+			return err
+		}
+		r.anonymize(a.UID, a.Login)
+	default:
+		return fmt.Errorf("%w: %s", memorystore.ErrUnknownOp, op)
+	}
+	return nil
+}
@@ -2,37 +2,47 @@ package userrepository
 
 import (
 	"context"
-	"database/sql"
 	"errors"
-	"fmt"
 
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgerrcode"
-
-	_ "github.com/jackc/pgx/v4/stdlib"
 	"github.com/zaz600/go-musthave-diploma/internal/entity"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/pgdb"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/transaction"
 )
 
 type PgUserRepository struct {
-	db         *sql.DB
-	statements map[queryType]*sql.Stmt
+	db *pgdb.Pool
 }
 
 type queryType string
 
 const (
-	queryGetUser queryType = "getUser"
-	queryAddUser queryType = "addUser"
+	queryGetUser       queryType = "getUser"
+	queryGetUserByID   queryType = "getUserByID"
+	queryAddUser       queryType = "addUser"
+	queryAnonymizeUser queryType = "anonymizeUser"
 )
 
 var queries = map[queryType]string{
-	queryGetUser: "select uid, login, password from gophermart.users where login=$1",
-	queryAddUser: "insert into gophermart.users(uid, login, password) values($1, $2, $3)",
+	queryGetUser:       "select uid, login, password from gophermart.users where login=$1",
+	queryGetUserByID:   "select uid, login, password from gophermart.users where uid=$1",
+	queryAddUser:       "insert into gophermart.users(uid, login, password) values($1, $2, $3)",
+	queryAnonymizeUser: "update gophermart.users set login=$2, password='' where uid=$1",
 }
 
 func (p PgUserRepository) GetUser(ctx context.Context, login string) (entity.UserEntity, error) {
 	var user entity.UserEntity
-	err := p.statements[queryGetUser].QueryRowContext(ctx, login).Scan(&user.UID, &user.Login, &user.Password)
+	err := transaction.PgQuerier(ctx, p.db).QueryRow(ctx, queries[queryGetUser], login).Scan(&user.UID, &user.Login, &user.Password)
+	if err != nil {
+		return user, ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (p PgUserRepository) GetUserByID(ctx context.Context, uid string) (entity.UserEntity, error) {
+	var user entity.UserEntity
+	err := transaction.PgQuerier(ctx, p.db).QueryRow(ctx, queries[queryGetUserByID], uid).Scan(&user.UID, &user.Login, &user.Password)
 	if err != nil {
 		return user, ErrUserNotFound
 	}
@@ -40,13 +50,19 @@ func (p PgUserRepository) GetUser(ctx context.Context, login string) (entity.Use
 }
 
 func (p PgUserRepository) AddUser(ctx context.Context, userEntity entity.UserEntity) error {
-	tx, err := p.db.Begin()
+	_, err := transaction.PgQuerier(ctx, p.db).Exec(ctx, queries[queryAddUser], userEntity.UID, userEntity.Login, userEntity.Password)
 	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return ErrUserExists
+		}
 		return err
 	}
-	defer tx.Rollback() //nolint:errcheck
-	stmt := tx.Stmt(p.statements[queryAddUser])
-	_, err = stmt.ExecContext(ctx, userEntity.UID, userEntity.Login, userEntity.Password)
+	return nil
+}
+
+func (p PgUserRepository) AnonymizeUser(ctx context.Context, uid string, login string) error {
+	result, err := transaction.PgQuerier(ctx, p.db).Exec(ctx, queries[queryAnonymizeUser], uid, login)
 	if err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
@@ -54,31 +70,17 @@ func (p PgUserRepository) AddUser(ctx context.Context, userEntity entity.UserEnt
 		}
 		return err
 	}
-	if err = tx.Commit(); err != nil {
-		return err
+	if result.RowsAffected() == 0 {
+		return ErrUserNotFound
 	}
 	return nil
 }
 
+// Close ничего не делает: пул соединений общий для всех репозиториев
 func (p PgUserRepository) Close() error {
-	for name, stmt := range p.statements {
-		err := stmt.Close()
-		if err != nil {
-			return fmt.Errorf("error close stmt %s: %w", name, err)
-		}
-	}
 	return nil
 }
 
-func NewPgUserRepository(db *sql.DB) (*PgUserRepository, error) {
-	statements := make(map[queryType]*sql.Stmt, len(queries))
-	for name, query := range queries {
-		stmt, err := db.Prepare(query)
-		if err != nil {
-			return nil, fmt.Errorf("error prepare statement for %s: %w", name, err)
-		}
-		statements[name] = stmt
-	}
-
-	return &PgUserRepository{db: db, statements: statements}, nil
+func NewPgUserRepository(db *pgdb.Pool) *PgUserRepository {
+	return &PgUserRepository{db: db}
 }
@@ -0,0 +1,105 @@
+package userrepository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/zaz600/go-musthave-diploma/internal/entity"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/sqlitedb"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/transaction"
+)
+
+type SQLiteUserRepository struct {
+	db         *sql.DB
+	statements map[queryType]*sql.Stmt
+}
+
+var sqliteQueries = map[queryType]string{
+	queryGetUser:       "select uid, login, password from users where login=$1",
+	queryGetUserByID:   "select uid, login, password from users where uid=$1",
+	queryAddUser:       "insert into users(uid, login, password) values($1, $2, $3)",
+	queryAnonymizeUser: "update users set login=$2, password='' where uid=$1",
+}
+
+func (p SQLiteUserRepository) GetUser(ctx context.Context, login string) (entity.UserEntity, error) {
+	var user entity.UserEntity
+	err := p.statements[queryGetUser].QueryRowContext(ctx, login).Scan(&user.UID, &user.Login, &user.Password)
+	if err != nil {
+		return user, ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (p SQLiteUserRepository) GetUserByID(ctx context.Context, uid string) (entity.UserEntity, error) {
+	var user entity.UserEntity
+	err := p.statements[queryGetUserByID].QueryRowContext(ctx, uid).Scan(&user.UID, &user.Login, &user.Password)
+	if err != nil {
+		return user, ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (p SQLiteUserRepository) AddUser(ctx context.Context, userEntity entity.UserEntity) error {
+	tx, err := transaction.Begin(ctx, p.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+	stmt := tx.Stmt(p.statements[queryAddUser])
+	_, err = stmt.ExecContext(ctx, userEntity.UID, userEntity.Login, userEntity.Password)
+	if err != nil {
+		if sqlitedb.IsUniqueViolation(err) {
+			return ErrUserExists
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+func (p SQLiteUserRepository) AnonymizeUser(ctx context.Context, uid string, login string) error {
+	tx, err := transaction.Begin(ctx, p.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+	stmt := tx.Stmt(p.statements[queryAnonymizeUser])
+	result, err := stmt.ExecContext(ctx, uid, login)
+	if err != nil {
+		if sqlitedb.IsUniqueViolation(err) {
+			return ErrUserExists
+		}
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrUserNotFound
+	}
+	return tx.Commit()
+}
+
+func (p SQLiteUserRepository) Close() error {
+	for name, stmt := range p.statements {
+		err := stmt.Close()
+		if err != nil {
+			return fmt.Errorf("error close stmt %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func NewSQLiteUserRepository(db *sql.DB) (*SQLiteUserRepository, error) {
+	statements := make(map[queryType]*sql.Stmt, len(sqliteQueries))
+	for name, query := range sqliteQueries {
+		stmt, err := db.Prepare(query)
+		if err != nil {
+			return nil, fmt.Errorf("error prepare statement for %s: %w", name, err)
+		}
+		statements[name] = stmt
+	}
+
+	return &SQLiteUserRepository{db: db, statements: statements}, nil
+}
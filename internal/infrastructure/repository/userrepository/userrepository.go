@@ -9,6 +9,10 @@ import (
 
 type UserRepository interface {
 	GetUser(ctx context.Context, login string) (entity.UserEntity, error)
+	GetUserByID(ctx context.Context, uid string) (entity.UserEntity, error)
 	AddUser(ctx context.Context, entity entity.UserEntity) error
+	// AnonymizeUser заменяет логин пользователя uid на login и удаляет хэш пароля,
+	// после чего войти под пользователем нельзя. uid сохраняется, на него ссылаются заказы и списания
+	AnonymizeUser(ctx context.Context, uid string, login string) error
 	io.Closer
 }
@@ -0,0 +1,38 @@
+package userrepository_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/repositorytest"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/userrepository"
+)
+
+func TestUserRepository(t *testing.T) {
+	t.Run("inmemory", func(t *testing.T) {
+		repositorytest.UserRepository(t, func(t *testing.T) userrepository.UserRepository {
+			return userrepository.NewInmemoryUserRepository()
+		})
+	})
+	t.Run("journaled", func(t *testing.T) {
+		repositorytest.UserRepository(t, func(t *testing.T) userrepository.UserRepository {
+			repo := userrepository.NewInmemoryUserRepository()
+			repositorytest.Journaled(t, repo)
+			return repo
+		})
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		repositorytest.UserRepository(t, func(t *testing.T) userrepository.UserRepository {
+			repo, err := userrepository.NewSQLiteUserRepository(repositorytest.SQLite(t))
+			require.NoError(t, err)
+			return repo
+		})
+	})
+	t.Run("postgres", func(t *testing.T) {
+		db := repositorytest.Postgres(t)
+		repositorytest.UserRepository(t, func(t *testing.T) userrepository.UserRepository {
+			repositorytest.Truncate(t, db)
+			return userrepository.NewPgUserRepository(db)
+		})
+	})
+}
@@ -0,0 +1,10 @@
+package webhookrepository
+
+import "errors"
+
+var (
+	ErrSubscriberExists   = errors.New("webhook subscriber already exists")
+	ErrSubscriberNotFound = errors.New("webhook subscriber not found")
+	ErrDeliveryExists     = errors.New("webhook delivery already exists")
+	ErrDeliveryNotFound   = errors.New("webhook delivery not found")
+)
@@ -0,0 +1,320 @@
+package webhookrepository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/zaz600/go-musthave-diploma/internal/entity"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/memorystore"
+)
+
+const (
+	opAddSubscriber = "add_subscriber"
+	opDelSubscriber = "del_subscriber"
+	// opPutDeliveries в журнал пишутся доставки после изменения
+	opPutDeliveries = "put_deliveries"
+)
+
+// webhookState снимок репозитория
+type webhookState struct {
+	Subscribers []entity.WebhookSubscriber
+	Deliveries  []entity.WebhookDelivery
+}
+
+type InmemoryWebhookRepository struct {
+	mu          sync.RWMutex
+	subscribers map[string]entity.WebhookSubscriber
+	deliveries  map[string]entity.WebhookDelivery
+	// eventDeliveries ключ - subscriberID + eventID, для проверки уникальности доставки
+	eventDeliveries map[string]struct{}
+	journal         *memorystore.Journal
+}
+
+func deliveryKey(delivery entity.WebhookDelivery) string {
+	return delivery.SubscriberID + "/" + delivery.Event.EventID
+}
+
+func (r *InmemoryWebhookRepository) AddSubscriber(ctx context.Context, subscriber entity.WebhookSubscriber) error {
+	defer r.journal.Lock(ctx)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.subscribers[subscriber.ID]; ok {
+		return ErrSubscriberExists
+	}
+	if err := r.journal.Append(ctx, opAddSubscriber, subscriber); err != nil {
+		return err
+	}
+	r.subscribers[subscriber.ID] = subscriber
+	memorystore.OnRollback(ctx, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.subscribers, subscriber.ID)
+	})
+	return nil
+}
+
+func (r *InmemoryWebhookRepository) GetSubscriber(_ context.Context, subscriberID string) (entity.WebhookSubscriber, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if subscriber, ok := r.subscribers[subscriberID]; ok {
+		return subscriber, nil
+	}
+	return entity.WebhookSubscriber{}, ErrSubscriberNotFound
+}
+
+func (r *InmemoryWebhookRepository) GetSubscribers(_ context.Context) ([]entity.WebhookSubscriber, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	subscribers := make([]entity.WebhookSubscriber, 0, len(r.subscribers))
+	for _, subscriber := range r.subscribers {
+		subscribers = append(subscribers, subscriber)
+	}
+	sort.Slice(subscribers, func(i, j int) bool {
+		return subscribers[i].CreatedAt.Before(subscribers[j].CreatedAt)
+	})
+	return subscribers, nil
+}
+
+func (r *InmemoryWebhookRepository) DelSubscriber(ctx context.Context, subscriberID string) error {
+	defer r.journal.Lock(ctx)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.subscribers[subscriberID]; !ok {
+		return ErrSubscriberNotFound
+	}
+	if err := r.journal.Append(ctx, opDelSubscriber, subscriberID); err != nil {
+		return err
+	}
+	subscriber := r.subscribers[subscriberID]
+	delete(r.subscribers, subscriberID)
+	memorystore.OnRollback(ctx, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.subscribers[subscriberID] = subscriber
+	})
+	return nil
+}
+
+func (r *InmemoryWebhookRepository) AddDelivery(ctx context.Context, delivery entity.WebhookDelivery) error {
+	defer r.journal.Lock(ctx)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.eventDeliveries[deliveryKey(delivery)]; ok {
+		return ErrDeliveryExists
+	}
+	if _, ok := r.deliveries[delivery.ID]; ok {
+		return ErrDeliveryExists
+	}
+	return r.put(ctx, delivery)
+}
+
+// put записывает доставки в журнал и сохраняет их. При откате транзакции возвращаются прежние доставки
+func (r *InmemoryWebhookRepository) put(ctx context.Context, deliveries ...entity.WebhookDelivery) error {
+	if err := r.journal.Append(ctx, opPutDeliveries, deliveries); err != nil {
+		return err
+	}
+	prev := make(map[string]entity.WebhookDelivery, len(deliveries))
+	for _, delivery := range deliveries {
+		if p, ok := r.deliveries[delivery.ID]; ok {
+			prev[delivery.ID] = p
+		}
+	}
+	r.setDeliveries(deliveries)
+	memorystore.OnRollback(ctx, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		for _, delivery := range deliveries {
+			if p, ok := prev[delivery.ID]; ok {
+				r.deliveries[delivery.ID] = p
+				continue
+			}
+			delete(r.deliveries, delivery.ID)
+			delete(r.eventDeliveries, deliveryKey(delivery))
+		}
+	})
+	return nil
+}
+
+func (r *InmemoryWebhookRepository) setDeliveries(deliveries []entity.WebhookDelivery) {
+	for _, delivery := range deliveries {
+		r.deliveries[delivery.ID] = delivery
+		r.eventDeliveries[deliveryKey(delivery)] = struct{}{}
+	}
+}
+
+func (r *InmemoryWebhookRepository) ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]entity.WebhookDelivery, error) {
+	defer r.journal.Lock(ctx)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deliveries []entity.WebhookDelivery
+	for _, delivery := range r.deliveries {
+		if delivery.Status == entity.DeliveryStatusPending && !delivery.NextAttemptAt.After(now) {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i].NextAttemptAt.Before(deliveries[j].NextAttemptAt)
+	})
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+	if len(deliveries) == 0 {
+		return deliveries, nil
+	}
+	for i := range deliveries {
+		deliveries[i].NextAttemptAt = now.Add(lease)
+	}
+	if err := r.put(ctx, deliveries...); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (r *InmemoryWebhookRepository) GetDeliveries(_ context.Context, status entity.DeliveryStatus, limit int) ([]entity.WebhookDelivery, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var deliveries []entity.WebhookDelivery
+	for _, delivery := range r.deliveries {
+		if delivery.Status == status {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i].CreatedAt.Before(deliveries[j].CreatedAt)
+	})
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+	return deliveries, nil
+}
+
+func (r *InmemoryWebhookRepository) GetDelivery(_ context.Context, deliveryID string) (entity.WebhookDelivery, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if delivery, ok := r.deliveries[deliveryID]; ok {
+		return delivery, nil
+	}
+	return entity.WebhookDelivery{}, ErrDeliveryNotFound
+}
+
+func (r *InmemoryWebhookRepository) UpdateDelivery(ctx context.Context, delivery entity.WebhookDelivery) error {
+	defer r.journal.Lock(ctx)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.deliveries[delivery.ID]; !ok {
+		return ErrDeliveryNotFound
+	}
+	return r.put(ctx, delivery)
+}
+
+func (r *InmemoryWebhookRepository) RedactUserDeliveries(ctx context.Context, uid string, eventType entity.EventType, payload json.RawMessage) error {
+	defer r.journal.Lock(ctx)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var redacted []entity.WebhookDelivery
+	for _, delivery := range r.deliveries {
+		if delivery.Event.UID == uid && delivery.Event.Type == eventType {
+			delivery.Event.Payload = payload
+			redacted = append(redacted, delivery)
+		}
+	}
+	if len(redacted) == 0 {
+		return nil
+	}
+	return r.put(ctx, redacted...)
+}
+
+func (r *InmemoryWebhookRepository) Close() error {
+	return nil
+}
+
+func (r *InmemoryWebhookRepository) SetJournal(j *memorystore.Journal) {
+	r.journal = j
+}
+
+func (r *InmemoryWebhookRepository) Snapshot() (interface{}, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	state := webhookState{
+		Subscribers: make([]entity.WebhookSubscriber, 0, len(r.subscribers)),
+		Deliveries:  make([]entity.WebhookDelivery, 0, len(r.deliveries)),
+	}
+	for _, subscriber := range r.subscribers {
+		state.Subscribers = append(state.Subscribers, subscriber)
+	}
+	for _, delivery := range r.deliveries {
+		state.Deliveries = append(state.Deliveries, delivery)
+	}
+	return state, nil
+}
+
+func (r *InmemoryWebhookRepository) Restore(data json.RawMessage) error {
+	var state webhookState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subscribers = make(map[string]entity.WebhookSubscriber, len(state.Subscribers))
+	r.deliveries = make(map[string]entity.WebhookDelivery, len(state.Deliveries))
+	r.eventDeliveries = make(map[string]struct{}, len(state.Deliveries))
+	for _, subscriber := range state.Subscribers {
+		r.subscribers[subscriber.ID] = subscriber
+	}
+	r.setDeliveries(state.Deliveries)
+	return nil
+}
+
+func (r *InmemoryWebhookRepository) Apply(op string, data json.RawMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch op {
+	case opAddSubscriber:
+		var subscriber entity.WebhookSubscriber
+		if err := json.Unmarshal(data, &subscriber); err != nil {
+			return err
+		}
+		r.subscribers[subscriber.ID] = subscriber
+	case opDelSubscriber:
+		var subscriberID string
+		if err := json.Unmarshal(data, &subscriberID); err != nil {
+			return err
+		}
+		delete(r.subscribers, subscriberID)
+	case opPutDeliveries:
+		var deliveries []entity.WebhookDelivery
+		if err := json.Unmarshal(data, &deliveries); err != nil {
+			return err
+		}
+		r.setDeliveries(deliveries)
+	default:
+		return fmt.Errorf("%w: %s", memorystore.ErrUnknownOp, op)
+	}
+	return nil
+}
+
+func NewInmemoryWebhookRepository() *InmemoryWebhookRepository {
+	return &InmemoryWebhookRepository{
+		mu:              sync.RWMutex{},
+		subscribers:     make(map[string]entity.WebhookSubscriber, 10),
+		deliveries:      make(map[string]entity.WebhookDelivery, 100),
+		eventDeliveries: make(map[string]struct{}, 100),
+	}
+}
@@ -0,0 +1,221 @@
+package webhookrepository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v4"
+	"github.com/zaz600/go-musthave-diploma/internal/entity"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/pgdb"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/transaction"
+)
+
+type PgWebhookRepository struct {
+	db *pgdb.Pool
+}
+
+type queryType string
+
+const (
+	queryAddSubscriber      queryType = "addSubscriber"
+	queryGetSubscriber      queryType = "getSubscriber"
+	queryGetSubscribers     queryType = "getSubscribers"
+	queryDelSubscriber      queryType = "delSubscriber"
+	queryAddDelivery        queryType = "addDelivery"
+	queryClaimDueDeliveries queryType = "claimDueDeliveries"
+	queryGetDeliveries      queryType = "getDeliveries"
+	queryGetDelivery        queryType = "getDelivery"
+	queryUpdateDelivery     queryType = "updateDelivery"
+	queryRedactDeliveries   queryType = "redactDeliveries"
+)
+
+const deliveryColumns = "delivery_id, subscriber_id, event_id, event_type, event_uid, payload, event_created_at, status, attempts, next_attempt_at, last_error, created_at"
+
+var queries = map[queryType]string{
+	queryAddSubscriber:  "insert into gophermart.webhook_subscribers(subscriber_id, url, event_types, secret, created_at) values($1, $2, $3, $4, $5)",
+	queryGetSubscriber:  "select subscriber_id, url, event_types, secret, created_at from gophermart.webhook_subscribers where subscriber_id=$1",
+	queryGetSubscribers: "select subscriber_id, url, event_types, secret, created_at from gophermart.webhook_subscribers order by created_at",
+	queryDelSubscriber:  "delete from gophermart.webhook_subscribers where subscriber_id=$1",
+	queryAddDelivery: "insert into gophermart.webhook_deliveries(" + deliveryColumns + ") " +
+		"values($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)",
+	queryClaimDueDeliveries: "update gophermart.webhook_deliveries set next_attempt_at=$2 where delivery_id in (" +
+		"select delivery_id from gophermart.webhook_deliveries where status='PENDING' and next_attempt_at<=$1 " +
+		"order by next_attempt_at limit $3 for update skip locked) returning " + deliveryColumns,
+	queryGetDeliveries: "select " + deliveryColumns + " from gophermart.webhook_deliveries where status=$1 order by created_at limit $2",
+	queryGetDelivery:   "select " + deliveryColumns + " from gophermart.webhook_deliveries where delivery_id=$1",
+	queryUpdateDelivery: "update gophermart.webhook_deliveries set status=$1, attempts=$2, next_attempt_at=$3, last_error=$4 " +
+		"where delivery_id=$5",
+	queryRedactDeliveries: "update gophermart.webhook_deliveries set payload=$3 where event_uid=$1 and event_type=$2",
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanSubscriber(row scanner) (entity.WebhookSubscriber, error) {
+	var subscriber entity.WebhookSubscriber
+	var eventTypes string
+	err := row.Scan(&subscriber.ID, &subscriber.URL, &eventTypes, &subscriber.Secret, &subscriber.CreatedAt)
+	if err != nil {
+		return subscriber, err
+	}
+	for _, eventType := range strings.Split(eventTypes, ",") {
+		subscriber.EventTypes = append(subscriber.EventTypes, entity.EventType(eventType))
+	}
+	return subscriber, nil
+}
+
+func scanDelivery(row scanner) (entity.WebhookDelivery, error) {
+	var delivery entity.WebhookDelivery
+	var payload []byte
+	err := row.Scan(&delivery.ID, &delivery.SubscriberID, &delivery.Event.EventID, &delivery.Event.Type, &delivery.Event.UID, &payload,
+		&delivery.Event.CreatedAt, &delivery.Status, &delivery.Attempts, &delivery.NextAttemptAt, &delivery.LastError,
+		&delivery.CreatedAt)
+	delivery.Event.Payload = payload
+	return delivery, err
+}
+
+func (p PgWebhookRepository) AddSubscriber(ctx context.Context, subscriber entity.WebhookSubscriber) error {
+	eventTypes := make([]string, 0, len(subscriber.EventTypes))
+	for _, eventType := range subscriber.EventTypes {
+		eventTypes = append(eventTypes, string(eventType))
+	}
+
+	_, err := transaction.PgQuerier(ctx, p.db).Exec(ctx, queries[queryAddSubscriber],
+		subscriber.ID, subscriber.URL, strings.Join(eventTypes, ","), subscriber.Secret, subscriber.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return ErrSubscriberExists
+		}
+		return err
+	}
+	return nil
+}
+
+func (p PgWebhookRepository) GetSubscriber(ctx context.Context, subscriberID string) (entity.WebhookSubscriber, error) {
+	subscriber, err := scanSubscriber(transaction.PgQuerier(ctx, p.db).QueryRow(ctx, queries[queryGetSubscriber], subscriberID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return subscriber, ErrSubscriberNotFound
+		}
+		return subscriber, err
+	}
+	return subscriber, nil
+}
+
+func (p PgWebhookRepository) GetSubscribers(ctx context.Context) ([]entity.WebhookSubscriber, error) {
+	rows, err := transaction.PgQuerier(ctx, p.db).Query(ctx, queries[queryGetSubscribers])
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var subscribers []entity.WebhookSubscriber
+	for rows.Next() {
+		subscriber, err := scanSubscriber(rows)
+		if err != nil {
+			return nil, err
+		}
+		subscribers = append(subscribers, subscriber)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return subscribers, nil
+}
+
+func (p PgWebhookRepository) DelSubscriber(ctx context.Context, subscriberID string) error {
+	result, err := transaction.PgQuerier(ctx, p.db).Exec(ctx, queries[queryDelSubscriber], subscriberID)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrSubscriberNotFound
+	}
+	return nil
+}
+
+func (p PgWebhookRepository) AddDelivery(ctx context.Context, delivery entity.WebhookDelivery) error {
+	_, err := transaction.PgQuerier(ctx, p.db).Exec(ctx, queries[queryAddDelivery], delivery.ID, delivery.SubscriberID,
+		delivery.Event.EventID, delivery.Event.Type, delivery.Event.UID, []byte(delivery.Event.Payload), delivery.Event.CreatedAt,
+		delivery.Status, delivery.Attempts, delivery.NextAttemptAt, delivery.LastError, delivery.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return ErrDeliveryExists
+		}
+		return err
+	}
+	return nil
+}
+
+// ClaimDueDeliveries выбирает и продлевает доставки одним запросом, поэтому отдельная транзакция не нужна
+func (p PgWebhookRepository) ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]entity.WebhookDelivery, error) {
+	return p.queryDeliveries(ctx, queries[queryClaimDueDeliveries], now, now.Add(lease), limit)
+}
+
+func (p PgWebhookRepository) GetDeliveries(ctx context.Context, status entity.DeliveryStatus, limit int) ([]entity.WebhookDelivery, error) {
+	return p.queryDeliveries(ctx, queries[queryGetDeliveries], status, limit)
+}
+
+func (p PgWebhookRepository) queryDeliveries(ctx context.Context, query string, args ...interface{}) ([]entity.WebhookDelivery, error) {
+	rows, err := transaction.PgQuerier(ctx, p.db).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var deliveries []entity.WebhookDelivery
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return deliveries, nil
+}
+
+func (p PgWebhookRepository) GetDelivery(ctx context.Context, deliveryID string) (entity.WebhookDelivery, error) {
+	delivery, err := scanDelivery(transaction.PgQuerier(ctx, p.db).QueryRow(ctx, queries[queryGetDelivery], deliveryID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return delivery, ErrDeliveryNotFound
+		}
+		return delivery, err
+	}
+	return delivery, nil
+}
+
+func (p PgWebhookRepository) UpdateDelivery(ctx context.Context, delivery entity.WebhookDelivery) error {
+	result, err := transaction.PgQuerier(ctx, p.db).Exec(ctx, queries[queryUpdateDelivery],
+		delivery.Status, delivery.Attempts, delivery.NextAttemptAt, delivery.LastError, delivery.ID)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrDeliveryNotFound
+	}
+	return nil
+}
+
+func (p PgWebhookRepository) RedactUserDeliveries(ctx context.Context, uid string, eventType entity.EventType, payload json.RawMessage) error {
+	_, err := transaction.PgQuerier(ctx, p.db).Exec(ctx, queries[queryRedactDeliveries], uid, eventType, []byte(payload))
+	return err
+}
+
+// Close ничего не делает: пул соединений общий для всех репозиториев
+func (p PgWebhookRepository) Close() error {
+	return nil
+}
+
+func NewPgWebhookRepository(db *pgdb.Pool) *PgWebhookRepository {
+	return &PgWebhookRepository{db: db}
+}
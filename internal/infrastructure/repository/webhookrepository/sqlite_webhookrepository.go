@@ -0,0 +1,248 @@
+package webhookrepository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/zaz600/go-musthave-diploma/internal/entity"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/sqlitedb"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/transaction"
+)
+
+// SQLiteWebhookRepository хранит время в UTC: SQLite сравнивает и сортирует его как строки
+type SQLiteWebhookRepository struct {
+	db         *sql.DB
+	statements map[queryType]*sql.Stmt
+}
+
+// sqliteQueries выборка доставок не блокирует строки: транзакции SQLite и так выполняются по одной
+var sqliteQueries = map[queryType]string{
+	queryAddSubscriber:  "insert into webhook_subscribers(subscriber_id, url, event_types, secret, created_at) values($1, $2, $3, $4, $5)",
+	queryGetSubscriber:  "select subscriber_id, url, event_types, secret, created_at from webhook_subscribers where subscriber_id=$1",
+	queryGetSubscribers: "select subscriber_id, url, event_types, secret, created_at from webhook_subscribers order by created_at",
+	queryDelSubscriber:  "delete from webhook_subscribers where subscriber_id=$1",
+	queryAddDelivery: "insert into webhook_deliveries(" + deliveryColumns + ") " +
+		"values($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)",
+	queryClaimDueDeliveries: "update webhook_deliveries set next_attempt_at=$2 where delivery_id in (" +
+		"select delivery_id from webhook_deliveries where status='PENDING' and next_attempt_at<=$1 " +
+		"order by next_attempt_at limit $3) returning " + deliveryColumns,
+	queryGetDeliveries: "select " + deliveryColumns + " from webhook_deliveries where status=$1 order by created_at limit $2",
+	queryGetDelivery:   "select " + deliveryColumns + " from webhook_deliveries where delivery_id=$1",
+	queryUpdateDelivery: "update webhook_deliveries set status=$1, attempts=$2, next_attempt_at=$3, last_error=$4 " +
+		"where delivery_id=$5",
+	queryRedactDeliveries: "update webhook_deliveries set payload=$3 where event_uid=$1 and event_type=$2",
+}
+
+func (p SQLiteWebhookRepository) AddSubscriber(ctx context.Context, subscriber entity.WebhookSubscriber) error {
+	eventTypes := make([]string, 0, len(subscriber.EventTypes))
+	for _, eventType := range subscriber.EventTypes {
+		eventTypes = append(eventTypes, string(eventType))
+	}
+
+	tx, err := transaction.Begin(ctx, p.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+	stmt := tx.Stmt(p.statements[queryAddSubscriber])
+	_, err = stmt.ExecContext(ctx, subscriber.ID, subscriber.URL, strings.Join(eventTypes, ","), subscriber.Secret, subscriber.CreatedAt.UTC())
+	if err != nil {
+		if sqlitedb.IsUniqueViolation(err) {
+			return ErrSubscriberExists
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+func (p SQLiteWebhookRepository) GetSubscriber(ctx context.Context, subscriberID string) (entity.WebhookSubscriber, error) {
+	subscriber, err := scanSubscriber(p.statements[queryGetSubscriber].QueryRowContext(ctx, subscriberID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return subscriber, ErrSubscriberNotFound
+		}
+		return subscriber, err
+	}
+	return subscriber, nil
+}
+
+func (p SQLiteWebhookRepository) GetSubscribers(ctx context.Context) ([]entity.WebhookSubscriber, error) {
+	rows, err := p.statements[queryGetSubscribers].QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var subscribers []entity.WebhookSubscriber
+	for rows.Next() {
+		subscriber, err := scanSubscriber(rows)
+		if err != nil {
+			return nil, err
+		}
+		subscribers = append(subscribers, subscriber)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return subscribers, nil
+}
+
+func (p SQLiteWebhookRepository) DelSubscriber(ctx context.Context, subscriberID string) error {
+	tx, err := transaction.Begin(ctx, p.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+	result, err := tx.Stmt(p.statements[queryDelSubscriber]).ExecContext(ctx, subscriberID)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrSubscriberNotFound
+	}
+	return tx.Commit()
+}
+
+func (p SQLiteWebhookRepository) AddDelivery(ctx context.Context, delivery entity.WebhookDelivery) error {
+	tx, err := transaction.Begin(ctx, p.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+	stmt := tx.Stmt(p.statements[queryAddDelivery])
+	_, err = stmt.ExecContext(ctx, delivery.ID, delivery.SubscriberID, delivery.Event.EventID, delivery.Event.Type,
+		delivery.Event.UID, string(delivery.Event.Payload), delivery.Event.CreatedAt, delivery.Status, delivery.Attempts,
+		delivery.NextAttemptAt.UTC(), delivery.LastError, delivery.CreatedAt.UTC())
+	if err != nil {
+		if sqlitedb.IsUniqueViolation(err) {
+			return ErrDeliveryExists
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+func (p SQLiteWebhookRepository) ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]entity.WebhookDelivery, error) {
+	tx, err := transaction.Begin(ctx, p.db)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	deliveries, err := p.queryDeliveries(ctx, tx.Stmt(p.statements[queryClaimDueDeliveries]), now.UTC(), now.Add(lease).UTC(), limit)
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, tx.Commit()
+}
+
+func (p SQLiteWebhookRepository) GetDeliveries(ctx context.Context, status entity.DeliveryStatus, limit int) ([]entity.WebhookDelivery, error) {
+	return p.queryDeliveries(ctx, p.statements[queryGetDeliveries], status, limit)
+}
+
+func (p SQLiteWebhookRepository) queryDeliveries(ctx context.Context, stmt *sql.Stmt, args ...interface{}) ([]entity.WebhookDelivery, error) {
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var deliveries []entity.WebhookDelivery
+	for rows.Next() {
+		delivery, err := scanSQLiteDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return deliveries, nil
+}
+
+func (p SQLiteWebhookRepository) GetDelivery(ctx context.Context, deliveryID string) (entity.WebhookDelivery, error) {
+	delivery, err := scanSQLiteDelivery(p.statements[queryGetDelivery].QueryRowContext(ctx, deliveryID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return delivery, ErrDeliveryNotFound
+		}
+		return delivery, err
+	}
+	return delivery, nil
+}
+
+func (p SQLiteWebhookRepository) UpdateDelivery(ctx context.Context, delivery entity.WebhookDelivery) error {
+	tx, err := transaction.Begin(ctx, p.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+	stmt := tx.Stmt(p.statements[queryUpdateDelivery])
+	result, err := stmt.ExecContext(ctx, delivery.Status, delivery.Attempts, delivery.NextAttemptAt.UTC(), delivery.LastError, delivery.ID)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrDeliveryNotFound
+	}
+	return tx.Commit()
+}
+
+func (p SQLiteWebhookRepository) RedactUserDeliveries(ctx context.Context, uid string, eventType entity.EventType, payload json.RawMessage) error {
+	tx, err := transaction.Begin(ctx, p.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+	stmt := tx.Stmt(p.statements[queryRedactDeliveries])
+	if _, err = stmt.ExecContext(ctx, uid, eventType, string(payload)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// scanSQLiteDelivery payload хранится текстом, а не jsonb
+func scanSQLiteDelivery(row scanner) (entity.WebhookDelivery, error) {
+	var delivery entity.WebhookDelivery
+	var payload string
+	err := row.Scan(&delivery.ID, &delivery.SubscriberID, &delivery.Event.EventID, &delivery.Event.Type, &delivery.Event.UID, &payload,
+		&delivery.Event.CreatedAt, &delivery.Status, &delivery.Attempts, &delivery.NextAttemptAt, &delivery.LastError,
+		&delivery.CreatedAt)
+	delivery.Event.Payload = json.RawMessage(payload)
+	return delivery, err
+}
+
+func (p SQLiteWebhookRepository) Close() error {
+	for name, stmt := range p.statements {
+		err := stmt.Close()
+		if err != nil {
+			return fmt.Errorf("error close stmt %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func NewSQLiteWebhookRepository(db *sql.DB) (*SQLiteWebhookRepository, error) {
+	statements := make(map[queryType]*sql.Stmt, len(sqliteQueries))
+	for name, query := range sqliteQueries {
+		stmt, err := db.Prepare(query)
+		if err != nil {
+			return nil, fmt.Errorf("error prepare statement for %s: %w", name, err)
+		}
+		statements[name] = stmt
+	}
+
+	return &SQLiteWebhookRepository{db: db, statements: statements}, nil
+}
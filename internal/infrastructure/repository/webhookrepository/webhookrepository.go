@@ -0,0 +1,28 @@
+package webhookrepository
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/zaz600/go-musthave-diploma/internal/entity"
+)
+
+type WebhookRepository interface {
+	AddSubscriber(ctx context.Context, subscriber entity.WebhookSubscriber) error
+	GetSubscriber(ctx context.Context, subscriberID string) (entity.WebhookSubscriber, error)
+	GetSubscribers(ctx context.Context) ([]entity.WebhookSubscriber, error)
+	DelSubscriber(ctx context.Context, subscriberID string) error
+
+	AddDelivery(ctx context.Context, delivery entity.WebhookDelivery) error
+	// ClaimDueDeliveries возвращает доставки, время попытки которых наступило, и откладывает
+	// их следующую попытку на lease, чтобы их не взял другой обработчик
+	ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]entity.WebhookDelivery, error)
+	GetDeliveries(ctx context.Context, status entity.DeliveryStatus, limit int) ([]entity.WebhookDelivery, error)
+	GetDelivery(ctx context.Context, deliveryID string) (entity.WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, delivery entity.WebhookDelivery) error
+	// RedactUserDeliveries заменяет данные события во всех доставках событий пользователя типа eventType
+	RedactUserDeliveries(ctx context.Context, uid string, eventType entity.EventType, payload json.RawMessage) error
+	io.Closer
+}
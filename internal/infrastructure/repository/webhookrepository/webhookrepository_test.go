@@ -0,0 +1,38 @@
+package webhookrepository_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/repositorytest"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/webhookrepository"
+)
+
+func TestWebhookRepository(t *testing.T) {
+	t.Run("inmemory", func(t *testing.T) {
+		repositorytest.WebhookRepository(t, func(t *testing.T) webhookrepository.WebhookRepository {
+			return webhookrepository.NewInmemoryWebhookRepository()
+		})
+	})
+	t.Run("journaled", func(t *testing.T) {
+		repositorytest.WebhookRepository(t, func(t *testing.T) webhookrepository.WebhookRepository {
+			repo := webhookrepository.NewInmemoryWebhookRepository()
+			repositorytest.Journaled(t, repo)
+			return repo
+		})
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		repositorytest.WebhookRepository(t, func(t *testing.T) webhookrepository.WebhookRepository {
+			repo, err := webhookrepository.NewSQLiteWebhookRepository(repositorytest.SQLite(t))
+			require.NoError(t, err)
+			return repo
+		})
+	})
+	t.Run("postgres", func(t *testing.T) {
+		db := repositorytest.Postgres(t)
+		repositorytest.WebhookRepository(t, func(t *testing.T) webhookrepository.WebhookRepository {
+			repositorytest.Truncate(t, db)
+			return webhookrepository.NewPgWebhookRepository(db)
+		})
+	})
+}
@@ -4,3 +4,6 @@ import "errors"
 
 var ErrWithdrawalExists = errors.New("withdrawal already exists")
 var ErrWithdrawalOwnedByAnotherUser = errors.New("withdrawal uploaded by another user")
+
+// ErrUserNotFound списание нельзя сохранить без пользователя, которому оно принадлежит
+var ErrUserNotFound = errors.New("withdrawal owner not found")
@@ -2,18 +2,26 @@ package withdrawalrepository
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
 	"sync"
 
 	"github.com/zaz600/go-musthave-diploma/internal/entity"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/memorystore"
 )
 
+const opAddWithdrawal = "add"
+
 type InmemoryWithdrawalRepository struct {
 	mu              sync.RWMutex
 	db              map[string]entity.Withdrawal
 	userWithdrawals map[string][]entity.Withdrawal
+	journal         *memorystore.Journal
 }
 
-func (r *InmemoryWithdrawalRepository) AddWithdrawal(_ context.Context, withdrawal entity.Withdrawal) error {
+func (r *InmemoryWithdrawalRepository) AddWithdrawal(ctx context.Context, withdrawal entity.Withdrawal) error {
+	defer r.journal.Lock(ctx)()
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
@@ -23,9 +31,38 @@ func (r *InmemoryWithdrawalRepository) AddWithdrawal(_ context.Context, withdraw
 		}
 		return ErrWithdrawalExists
 	}
+	if err := r.journal.Append(ctx, opAddWithdrawal, withdrawal); err != nil {
+		return err
+	}
+	r.add(withdrawal)
+	memorystore.OnRollback(ctx, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.remove(withdrawal)
+	})
+	return nil
+}
+
+func (r *InmemoryWithdrawalRepository) add(withdrawal entity.Withdrawal) {
 	r.db[withdrawal.OrderID] = withdrawal
 	r.userWithdrawals[withdrawal.UID] = append(r.userWithdrawals[withdrawal.UID], withdrawal)
-	return nil
+}
+
+// remove удаляет списание при откате транзакции. Срез списаний пользователя копируется,
+// потому что GetUserWithdrawals отдает его без копирования
+func (r *InmemoryWithdrawalRepository) remove(withdrawal entity.Withdrawal) {
+	delete(r.db, withdrawal.OrderID)
+	withdrawals := make([]entity.Withdrawal, 0, len(r.userWithdrawals[withdrawal.UID]))
+	for _, w := range r.userWithdrawals[withdrawal.UID] {
+		if w.OrderID != withdrawal.OrderID {
+			withdrawals = append(withdrawals, w)
+		}
+	}
+	if len(withdrawals) == 0 {
+		delete(r.userWithdrawals, withdrawal.UID)
+		return
+	}
+	r.userWithdrawals[withdrawal.UID] = withdrawals
 }
 
 func (r *InmemoryWithdrawalRepository) GetUserWithdrawals(_ context.Context, userID string) ([]entity.Withdrawal, error) {
@@ -43,6 +80,58 @@ func (r *InmemoryWithdrawalRepository) Close() error {
 	return nil
 }
 
+func (r *InmemoryWithdrawalRepository) SetJournal(j *memorystore.Journal) {
+	r.journal = j
+}
+
+// Snapshot списания в порядке добавления, чтобы после восстановления списки пользователей не изменились
+func (r *InmemoryWithdrawalRepository) Snapshot() (interface{}, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	uids := make([]string, 0, len(r.userWithdrawals))
+	for uid := range r.userWithdrawals {
+		uids = append(uids, uid)
+	}
+	sort.Strings(uids)
+	withdrawals := make([]entity.Withdrawal, 0, len(r.db))
+	for _, uid := range uids {
+		withdrawals = append(withdrawals, r.userWithdrawals[uid]...)
+	}
+	return withdrawals, nil
+}
+
+func (r *InmemoryWithdrawalRepository) Restore(state json.RawMessage) error {
+	var withdrawals []entity.Withdrawal
+	if err := json.Unmarshal(state, &withdrawals); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.db = make(map[string]entity.Withdrawal, len(withdrawals))
+	r.userWithdrawals = make(map[string][]entity.Withdrawal, len(withdrawals))
+	for _, withdrawal := range withdrawals {
+		r.add(withdrawal)
+	}
+	return nil
+}
+
+func (r *InmemoryWithdrawalRepository) Apply(op string, data json.RawMessage) error {
+	if op != opAddWithdrawal {
+		return fmt.Errorf("%w: %s", memorystore.ErrUnknownOp, op)
+	}
+	var withdrawal entity.Withdrawal
+	if err := json.Unmarshal(data, &withdrawal); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.add(withdrawal)
+	return nil
+}
+
 func NewInmemoryWithdrawalRepository() *InmemoryWithdrawalRepository {
 	return &InmemoryWithdrawalRepository{
 		mu:              sync.RWMutex{},
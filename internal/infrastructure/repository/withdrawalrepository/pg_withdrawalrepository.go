@@ -2,55 +2,67 @@ package withdrawalrepository
 
 import (
 	"context"
-	"database/sql"
 	"errors"
-	"fmt"
 
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v4"
 	"github.com/zaz600/go-musthave-diploma/internal/entity"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/pgdb"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/transaction"
 )
 
 type PgWithdrawalRepository struct {
-	db         *sql.DB
-	statements map[queryType]*sql.Stmt
+	db *pgdb.Pool
 }
 
 type queryType string
 
 const (
 	queryAddWithdrawal      queryType = "AddWithdrawal"
+	queryGetWithdrawalOwner queryType = "GetWithdrawalOwner"
 	queryGetUserWithdrawals queryType = "GetUserWithdrawals"
 )
 
+// queryAddWithdrawal не завершается ошибкой при повторе номера заказа, чтобы следующим запросом пакета
+// можно было узнать, кто загрузил заказ раньше
 var queries = map[queryType]string{
-	queryAddWithdrawal:      "insert into gophermart.withdrawals(uid, order_id, processed_at, amount) values($1, $2, $3, $4)",
+	queryAddWithdrawal:      "insert into gophermart.withdrawals(uid, order_id, processed_at, amount) values($1, $2, $3, $4) on conflict (order_id) do nothing",
+	queryGetWithdrawalOwner: "select uid from gophermart.withdrawals where order_id=$1",
 	queryGetUserWithdrawals: "select uid, order_id, processed_at, amount from gophermart.withdrawals where uid=$1",
 }
 
+// AddWithdrawal отправляет вставку и запрос владельца заказа одним пакетом
 func (p PgWithdrawalRepository) AddWithdrawal(ctx context.Context, withdrawal entity.Withdrawal) error {
-	tx, err := p.db.Begin()
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback() //nolint:errcheck
-	stmt := tx.Stmt(p.statements[queryAddWithdrawal])
-	_, err = stmt.ExecContext(ctx, withdrawal.UID, withdrawal.OrderID, withdrawal.ProcessedAt, withdrawal.Sum)
+	batch := &pgx.Batch{}
+	batch.Queue(queries[queryAddWithdrawal], withdrawal.UID, withdrawal.OrderID, withdrawal.ProcessedAt, withdrawal.Sum)
+	batch.Queue(queries[queryGetWithdrawalOwner], withdrawal.OrderID)
+	results := transaction.PgQuerier(ctx, p.db).SendBatch(ctx, batch)
+	defer results.Close()
+
+	result, err := results.Exec()
 	if err != nil {
 		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
-			return ErrWithdrawalExists
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.ForeignKeyViolation {
+			return ErrUserNotFound
 		}
 		return err
 	}
-	if err = tx.Commit(); err != nil {
+	var owner string
+	if err := results.QueryRow().Scan(&owner); err != nil {
 		return err
 	}
-	return nil
+	if result.RowsAffected() == 0 {
+		if owner != withdrawal.UID {
+			return ErrWithdrawalOwnedByAnotherUser
+		}
+		return ErrWithdrawalExists
+	}
+	return results.Close()
 }
 
 func (p PgWithdrawalRepository) GetUserWithdrawals(ctx context.Context, userID string) ([]entity.Withdrawal, error) {
-	rows, err := p.statements[queryGetUserWithdrawals].QueryContext(ctx, userID)
+	rows, err := transaction.PgQuerier(ctx, p.db).Query(ctx, queries[queryGetUserWithdrawals], userID)
 	if err != nil {
 		return nil, err
 	}
@@ -69,25 +81,11 @@ func (p PgWithdrawalRepository) GetUserWithdrawals(ctx context.Context, userID s
 	return withdrawals, nil
 }
 
+// Close ничего не делает: пул соединений общий для всех репозиториев
 func (p PgWithdrawalRepository) Close() error {
-	for name, stmt := range p.statements {
-		err := stmt.Close()
-		if err != nil {
-			return fmt.Errorf("error close stmt %s: %w", name, err)
-		}
-	}
 	return nil
 }
 
-func NewPgUserRepository(db *sql.DB) (*PgWithdrawalRepository, error) {
-	statements := make(map[queryType]*sql.Stmt, len(queries))
-	for name, query := range queries {
-		stmt, err := db.Prepare(query)
-		if err != nil {
-			return nil, fmt.Errorf("error prepare statement for %s: %w", name, err)
-		}
-		statements[name] = stmt
-	}
-
-	return &PgWithdrawalRepository{db: db, statements: statements}, nil
+func NewPgWithdrawalRepository(db *pgdb.Pool) *PgWithdrawalRepository {
+	return &PgWithdrawalRepository{db: db}
 }
@@ -0,0 +1,96 @@
+package withdrawalrepository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/zaz600/go-musthave-diploma/internal/entity"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/sqlitedb"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/transaction"
+)
+
+type SQLiteWithdrawalRepository struct {
+	db         *sql.DB
+	statements map[queryType]*sql.Stmt
+}
+
+var sqliteQueries = map[queryType]string{
+	queryAddWithdrawal:      "insert into withdrawals(uid, order_id, processed_at, amount) values($1, $2, $3, $4) on conflict (order_id) do nothing",
+	queryGetWithdrawalOwner: "select uid from withdrawals where order_id=$1",
+	queryGetUserWithdrawals: "select uid, order_id, processed_at, amount from withdrawals where uid=$1",
+}
+
+func (p SQLiteWithdrawalRepository) AddWithdrawal(ctx context.Context, withdrawal entity.Withdrawal) error {
+	tx, err := transaction.Begin(ctx, p.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+	stmt := tx.Stmt(p.statements[queryAddWithdrawal])
+	result, err := stmt.ExecContext(ctx, withdrawal.UID, withdrawal.OrderID, withdrawal.ProcessedAt, withdrawal.Sum)
+	if err != nil {
+		if sqlitedb.IsForeignKeyViolation(err) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		var owner string
+		if err := tx.Stmt(p.statements[queryGetWithdrawalOwner]).QueryRowContext(ctx, withdrawal.OrderID).Scan(&owner); err != nil {
+			return err
+		}
+		if owner != withdrawal.UID {
+			return ErrWithdrawalOwnedByAnotherUser
+		}
+		return ErrWithdrawalExists
+	}
+	return tx.Commit()
+}
+
+func (p SQLiteWithdrawalRepository) GetUserWithdrawals(ctx context.Context, userID string) ([]entity.Withdrawal, error) {
+	rows, err := p.statements[queryGetUserWithdrawals].QueryContext(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var withdrawals []entity.Withdrawal
+	for rows.Next() {
+		var withdrawal entity.Withdrawal
+		if err := rows.Scan(&withdrawal.UID, &withdrawal.OrderID, &withdrawal.ProcessedAt, &withdrawal.Sum); err != nil {
+			return nil, err
+		}
+		withdrawals = append(withdrawals, withdrawal)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return withdrawals, nil
+}
+
+func (p SQLiteWithdrawalRepository) Close() error {
+	for name, stmt := range p.statements {
+		err := stmt.Close()
+		if err != nil {
+			return fmt.Errorf("error close stmt %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func NewSQLiteWithdrawalRepository(db *sql.DB) (*SQLiteWithdrawalRepository, error) {
+	statements := make(map[queryType]*sql.Stmt, len(sqliteQueries))
+	for name, query := range sqliteQueries {
+		stmt, err := db.Prepare(query)
+		if err != nil {
+			return nil, fmt.Errorf("error prepare statement for %s: %w", name, err)
+		}
+		statements[name] = stmt
+	}
+
+	return &SQLiteWithdrawalRepository{db: db, statements: statements}, nil
+}
@@ -0,0 +1,42 @@
+package withdrawalrepository_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/repositorytest"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/userrepository"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/withdrawalrepository"
+)
+
+func TestWithdrawalRepository(t *testing.T) {
+	t.Run("inmemory", func(t *testing.T) {
+		repositorytest.WithdrawalRepository(t, func(t *testing.T) (withdrawalrepository.WithdrawalRepository, userrepository.UserRepository) {
+			return withdrawalrepository.NewInmemoryWithdrawalRepository(), userrepository.NewInmemoryUserRepository()
+		})
+	})
+	t.Run("journaled", func(t *testing.T) {
+		repositorytest.WithdrawalRepository(t, func(t *testing.T) (withdrawalrepository.WithdrawalRepository, userrepository.UserRepository) {
+			repo := withdrawalrepository.NewInmemoryWithdrawalRepository()
+			repositorytest.Journaled(t, repo)
+			return repo, userrepository.NewInmemoryUserRepository()
+		})
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		repositorytest.WithdrawalRepository(t, func(t *testing.T) (withdrawalrepository.WithdrawalRepository, userrepository.UserRepository) {
+			db := repositorytest.SQLite(t)
+			repo, err := withdrawalrepository.NewSQLiteWithdrawalRepository(db)
+			require.NoError(t, err)
+			users, err := userrepository.NewSQLiteUserRepository(db)
+			require.NoError(t, err)
+			return repo, users
+		})
+	})
+	t.Run("postgres", func(t *testing.T) {
+		db := repositorytest.Postgres(t)
+		repositorytest.WithdrawalRepository(t, func(t *testing.T) (withdrawalrepository.WithdrawalRepository, userrepository.UserRepository) {
+			repositorytest.Truncate(t, db)
+			return withdrawalrepository.NewPgWithdrawalRepository(db), userrepository.NewPgUserRepository(db)
+		})
+	})
+}
@@ -10,7 +10,38 @@ import (
 	"github.com/zaz600/go-musthave-diploma/internal/entity"
 )
 
-const key = "secureSecretText" // TODO взять из волта
+const (
+	defaultKey      = "secureSecretText"
+	defaultTokenTTL = 24 * time.Hour
+)
+
+var (
+	key      = defaultKey
+	tokenTTL = defaultTokenTTL
+)
+
+type Option func()
+
+// WithKey задает ключ подписи JWT
+func WithKey(k string) Option {
+	return func() {
+		key = k
+	}
+}
+
+// WithTokenTTL задает время жизни JWT
+func WithTokenTTL(ttl time.Duration) Option {
+	return func() {
+		tokenTTL = ttl
+	}
+}
+
+// Configure настраивает выпуск и проверку JWT. Вызывается один раз при запуске приложения
+func Configure(opts ...Option) {
+	for _, opt := range opts {
+		opt()
+	}
+}
 
 type UserClaims struct {
 	UserID    string `json:"user_id"`
@@ -23,7 +54,7 @@ func createToken(userID string, sessionID string) (string, error) {
 		UserID:    userID,
 		SessionID: sessionID,
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(time.Hour * 24).Unix(),
+			ExpiresAt: time.Now().Add(tokenTTL).Unix(),
 			Issuer:    "Gophermart",
 		},
 	}
@@ -56,8 +87,13 @@ func getClaims(jwtToken string) (*UserClaims, error) {
 	return claims, nil
 }
 
+// NewToken выпускает JWT для сессии пользователя
+func NewToken(session *entity.Session) (string, error) {
+	return createToken(session.UID, session.SessionID)
+}
+
 func SetJWT(w http.ResponseWriter, session *entity.Session) error {
-	jwtToken, err := createToken(session.UID, session.SessionID)
+	jwtToken, err := NewToken(session)
 	if err != nil {
 		return err
 	}
@@ -66,7 +102,12 @@ func SetJWT(w http.ResponseWriter, session *entity.Session) error {
 }
 
 func GetClaims(r *http.Request) (*UserClaims, error) {
-	jwtToken := strings.Replace(r.Header.Get("Authorization"), "Bearer ", "", 1)
+	return ParseAuthorization(r.Header.Get("Authorization"))
+}
+
+// ParseAuthorization проверяет значение заголовка (метаданных) authorization вида "Bearer <jwt>"
+func ParseAuthorization(value string) (*UserClaims, error) {
+	jwtToken := strings.Replace(value, "Bearer ", "", 1)
 	if jwtToken == "" {
 		return nil, ErrTokenNotFound
 	}
@@ -0,0 +1,5 @@
+package feature
+
+import "errors"
+
+var ErrUnknownFeature = errors.New("unknown feature")
@@ -0,0 +1,58 @@
+// Package feature флаги функциональности, которые можно выключить без перезапуска
+package feature
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Feature функция сервиса, которую можно выключить
+type Feature string
+
+const (
+	// Registration регистрация новых пользователей
+	Registration Feature = "registration"
+	// Withdrawals списание баллов
+	Withdrawals Feature = "withdrawals"
+)
+
+var known = map[Feature]struct{}{
+	Registration: {},
+	Withdrawals:  {},
+}
+
+// Disabled выключенные функции. Пустое значение - все функции включены
+type Disabled map[Feature]struct{}
+
+// ParseDisabled разбирает список выключенных функций через запятую: "registration,withdrawals"
+func ParseDisabled(s string) (Disabled, error) {
+	disabled := Disabled{}
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if _, ok := known[Feature(item)]; !ok {
+			return nil, fmt.Errorf("%w: %q, known features: %s", ErrUnknownFeature, item, strings.Join(Known(), ","))
+		}
+		disabled[Feature(item)] = struct{}{}
+	}
+	return disabled, nil
+}
+
+// Enabled включена ли функция
+func (d Disabled) Enabled(f Feature) bool {
+	_, ok := d[f]
+	return !ok
+}
+
+// Known имена всех функций по алфавиту
+func Known() []string {
+	names := make([]string, 0, len(known))
+	for f := range known {
+		names = append(names, string(f))
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,26 @@
+package feature
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDisabled(t *testing.T) {
+	disabled, err := ParseDisabled("")
+	require.NoError(t, err)
+	assert.True(t, disabled.Enabled(Registration))
+	assert.True(t, disabled.Enabled(Withdrawals))
+
+	disabled, err = ParseDisabled(" registration , ")
+	require.NoError(t, err)
+	assert.False(t, disabled.Enabled(Registration))
+	assert.True(t, disabled.Enabled(Withdrawals))
+
+	_, err = ParseDisabled("registration,export")
+	assert.ErrorIs(t, err, ErrUnknownFeature)
+
+	// nil - все функции включены
+	assert.True(t, Disabled(nil).Enabled(Withdrawals))
+}
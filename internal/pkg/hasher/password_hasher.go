@@ -2,10 +2,13 @@ package hasher
 
 import "golang.org/x/crypto/bcrypt"
 
-func HashPassword(password string) (string, error) {
+// DefaultCost сложность bcrypt по умолчанию
+const DefaultCost = 8
+
+func HashPassword(password string, cost int) (string, error) {
 	// TODO соль нужна и замена на scrypt
 	// https://www.sohamkamani.com/golang/password-authentication-and-storage/
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 8)
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), cost)
 	return string(bytes), err
 }
 
@@ -11,19 +11,19 @@ import (
 
 func TestHashPassword(t *testing.T) {
 	password := random.String(10)
-	hash, err := HashPassword(password)
+	hash, err := HashPassword(password, DefaultCost)
 	require.NoError(t, err)
 	assert.NotEqual(t, password, hash)
 	assert.NotEmpty(t, hash)
 
-	hash2, err := HashPassword(strings.ToUpper(password))
+	hash2, err := HashPassword(strings.ToUpper(password), DefaultCost)
 	require.NoError(t, err)
 	assert.NotEqual(t, hash, hash2)
 }
 
 func TestCheckPasswordHash(t *testing.T) {
 	password := random.String(10)
-	hash, err := HashPassword(password)
+	hash, err := HashPassword(password, DefaultCost)
 	require.NoError(t, err)
 
 	assert.True(t, CheckPasswordHash(password, hash))
@@ -0,0 +1,5 @@
+package health
+
+import "errors"
+
+var ErrShuttingDown = errors.New("shutting down")
@@ -0,0 +1,140 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+
+	defaultCheckTimeout = 2 * time.Second
+)
+
+// CheckFunc проверка готовности зависимости. Ошибка означает, что сервис не готов принимать запросы
+type CheckFunc func(ctx context.Context) error
+
+type check struct {
+	name string
+	fn   CheckFunc
+}
+
+// CheckResult результат одной проверки
+type CheckResult struct {
+	Status   string  `json:"status"`
+	Error    string  `json:"error,omitempty"`
+	Duration float64 `json:"duration_ms"`
+}
+
+// Response ответ /healthz и /readyz
+type Response struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// Health отвечает на запросы оркестратора о состоянии процесса.
+// /healthz - процесс жив, /readyz - все проверки зависимостей прошли и процесс не завершается
+type Health struct {
+	mu           sync.RWMutex
+	checks       []check
+	shuttingDown int32
+	timeout      time.Duration
+}
+
+func New() *Health {
+	return &Health{timeout: defaultCheckTimeout}
+}
+
+// AddCheck добавляет проверку готовности
+func (h *Health) AddCheck(name string, fn CheckFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks = append(h.checks, check{name: name, fn: fn})
+}
+
+// SetShuttingDown переводит /readyz в состояние fail, чтобы оркестратор перестал направлять запросы
+func (h *Health) SetShuttingDown() {
+	atomic.StoreInt32(&h.shuttingDown, 1)
+}
+
+// Liveness обработчик /healthz
+func (h *Health) Liveness(w http.ResponseWriter, _ *http.Request) {
+	writeResponse(w, Response{Status: StatusOK})
+}
+
+// Readiness обработчик /readyz. Проверки выполняются параллельно
+func (h *Health) Readiness(w http.ResponseWriter, r *http.Request) {
+	writeResponse(w, h.Check(r.Context()))
+}
+
+// ReadinessStatus обработчик /readyz без результатов отдельных проверок. В ошибках проверок бывают адреса
+// и сообщения зависимостей, поэтому на публичном listener отдается только общий статус
+func (h *Health) ReadinessStatus(w http.ResponseWriter, r *http.Request) {
+	resp := h.Check(r.Context())
+	writeResponse(w, Response{Status: resp.Status})
+}
+
+// Check выполняет все проверки готовности
+func (h *Health) Check(ctx context.Context) Response {
+	h.mu.RLock()
+	checks := h.checks
+	h.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	results := make([]CheckResult, len(checks))
+	wg := sync.WaitGroup{}
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c check) {
+			defer wg.Done()
+			start := time.Now()
+			err := c.fn(ctx)
+			results[i] = newCheckResult(err, time.Since(start))
+		}(i, c)
+	}
+	wg.Wait()
+
+	resp := Response{Status: StatusOK, Checks: make(map[string]CheckResult, len(checks)+1)}
+	for i, c := range checks {
+		resp.Checks[c.name] = results[i]
+	}
+	var shutdownErr error
+	if atomic.LoadInt32(&h.shuttingDown) == 1 {
+		shutdownErr = ErrShuttingDown
+	}
+	resp.Checks["shutdown"] = newCheckResult(shutdownErr, 0)
+
+	for _, result := range resp.Checks {
+		if result.Status != StatusOK {
+			resp.Status = StatusFail
+		}
+	}
+	return resp
+}
+
+func newCheckResult(err error, duration time.Duration) CheckResult {
+	result := CheckResult{Status: StatusOK, Duration: float64(duration.Microseconds()) / 1000}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+	return result
+}
+
+func writeResponse(w http.ResponseWriter, resp Response) {
+	status := http.StatusOK
+	if resp.Status != StatusOK {
+		status = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(resp)
+}
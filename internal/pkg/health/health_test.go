@@ -0,0 +1,73 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readiness(t *testing.T, h *Health) (int, Response) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.Readiness(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	var resp Response
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	return rec.Code, resp
+}
+
+func TestHealth_Liveness(t *testing.T) {
+	h := New()
+	h.AddCheck("db", func(context.Context) error { return errors.New("down") })
+
+	rec := httptest.NewRecorder()
+	h.Liveness(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"status": "ok"}`, rec.Body.String())
+}
+
+func TestHealth_Readiness(t *testing.T) {
+	h := New()
+	h.AddCheck("db", func(context.Context) error { return nil })
+
+	code, resp := readiness(t, h)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, StatusOK, resp.Status)
+	assert.Equal(t, StatusOK, resp.Checks["db"].Status)
+	assert.Equal(t, StatusOK, resp.Checks["shutdown"].Status)
+
+	h.AddCheck("accrual", func(context.Context) error { return errors.New("circuit open") })
+	code, resp = readiness(t, h)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, StatusFail, resp.Status)
+	assert.Equal(t, StatusOK, resp.Checks["db"].Status)
+	assert.Equal(t, CheckResult{Status: StatusFail, Error: "circuit open"}, CheckResult{
+		Status: resp.Checks["accrual"].Status,
+		Error:  resp.Checks["accrual"].Error,
+	})
+}
+
+func TestHealth_ShuttingDown(t *testing.T) {
+	h := New()
+	h.SetShuttingDown()
+
+	code, resp := readiness(t, h)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, StatusFail, resp.Checks["shutdown"].Status)
+	assert.Equal(t, ErrShuttingDown.Error(), resp.Checks["shutdown"].Error)
+}
+
+func TestHealth_ReadinessStatus(t *testing.T) {
+	h := New()
+	h.AddCheck("db", func(context.Context) error { return errors.New("dial tcp 10.0.0.1:5432: connection refused") })
+
+	rec := httptest.NewRecorder()
+	h.ReadinessStatus(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.JSONEq(t, `{"status": "fail"}`, rec.Body.String())
+}
@@ -0,0 +1,8 @@
+package logger
+
+import "errors"
+
+var (
+	ErrUnknownLevel  = errors.New("unknown log level")
+	ErrUnknownFormat = errors.New("unknown log format")
+)
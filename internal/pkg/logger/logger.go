@@ -1,13 +1,93 @@
 package logger
 
 import (
+	"fmt"
+	"io"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
 )
 
-func New() *zerolog.Logger {
-	zerolog.TimeFieldFormat = zerolog.TimeFormatUnixMicro
-	logger := zerolog.New(os.Stdout).With().Timestamp().Logger()
-	return &logger
+const (
+	FormatJSON    = "json"
+	FormatConsole = "console"
+)
+
+type Option func(*options)
+
+type options struct {
+	level  string
+	format string
+	out    io.Writer
+}
+
+// WithLevel задает минимальный уровень логирования: trace, debug, info, warn, error
+func WithLevel(level string) Option {
+	return func(o *options) {
+		o.level = level
+	}
+}
+
+// WithFormat задает формат логов: json или console (человекочитаемый, для локальной разработки)
+func WithFormat(format string) Option {
+	return func(o *options) {
+		o.format = format
+	}
+}
+
+func WithOutput(out io.Writer) Option {
+	return func(o *options) {
+		o.out = out
+	}
+}
+
+// New создает логгер и делает его глобальным: он используется в log.* и в log.Ctx,
+// если в контексте нет логгера запроса
+func New(opts ...Option) (*zerolog.Logger, error) {
+	o := &options{level: zerolog.InfoLevel.String(), format: FormatJSON, out: os.Stdout}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if err := SetLevel(o.level); err != nil {
+		return nil, err
+	}
+
+	out := o.out
+	switch o.format {
+	case FormatJSON:
+		zerolog.TimeFieldFormat = zerolog.TimeFormatUnixMicro
+	case FormatConsole:
+		zerolog.TimeFieldFormat = time.RFC3339Nano
+		out = zerolog.ConsoleWriter{Out: o.out, TimeFormat: "15:04:05.000"}
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, o.format)
+	}
+
+	logger := zerolog.New(out).With().Timestamp().Logger()
+	log.Logger = logger
+	zerolog.DefaultContextLogger = &log.Logger
+	return &logger, nil
+}
+
+// SetLevel меняет минимальный уровень логирования для всех логгеров, в том числе логгеров запросов
+func SetLevel(level string) error {
+	l, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	zerolog.SetGlobalLevel(l)
+	return nil
+}
+
+// ParseLevel проверяет уровень логирования, чтобы применить его позже вместе с другими настройками
+func ParseLevel(level string) (zerolog.Level, error) {
+	l, err := zerolog.ParseLevel(strings.ToLower(level))
+	if err != nil || l == zerolog.NoLevel {
+		return zerolog.NoLevel, fmt.Errorf("%w: %q", ErrUnknownLevel, level)
+	}
+	return l, nil
 }
@@ -0,0 +1,64 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	defer func() {
+		_, _ = New()
+	}()
+
+	buf := &bytes.Buffer{}
+	_, err := New(WithLevel("WARN"), WithOutput(buf))
+	require.NoError(t, err)
+	assert.Equal(t, zerolog.WarnLevel, zerolog.GlobalLevel())
+
+	log.Info().Msg("skipped")
+	log.Ctx(context.Background()).Warn().Msg("written")
+	assert.NotContains(t, buf.String(), "skipped")
+	assert.Contains(t, buf.String(), `"message":"written"`)
+
+	buf.Reset()
+	_, err = New(WithFormat(FormatConsole), WithOutput(buf))
+	require.NoError(t, err)
+	log.Info().Str("orderID", "1").Msg("console")
+	assert.Contains(t, buf.String(), "console")
+	assert.NotContains(t, buf.String(), `"message"`)
+}
+
+func TestNew_Errors(t *testing.T) {
+	_, err := New(WithLevel("verbose"))
+	assert.ErrorIs(t, err, ErrUnknownLevel)
+	_, err = New(WithFormat("xml"))
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+}
+
+func TestSetLevel(t *testing.T) {
+	defer func() {
+		_, _ = New()
+	}()
+
+	buf := &bytes.Buffer{}
+	_, err := New(WithOutput(buf))
+	require.NoError(t, err)
+	requestLogger := log.With().Str("request_id", "1").Logger()
+
+	require.NoError(t, SetLevel("debug"))
+	requestLogger.Debug().Msg("debug enabled")
+	assert.Contains(t, buf.String(), "debug enabled")
+
+	require.NoError(t, SetLevel("error"))
+	requestLogger.Warn().Msg("warn disabled")
+	assert.NotContains(t, buf.String(), "warn disabled")
+
+	assert.ErrorIs(t, SetLevel("verbose"), ErrUnknownLevel)
+	assert.Equal(t, zerolog.ErrorLevel, zerolog.GlobalLevel())
+}
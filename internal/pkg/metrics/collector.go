@@ -0,0 +1,99 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog/log"
+)
+
+const collectTimeout = 5 * time.Second
+
+// pendingJobsCollector запрашивает число ожидающих заказов в момент сбора метрик
+type pendingJobsCollector struct {
+	count func(ctx context.Context) (map[string]int, error)
+	desc  *prometheus.Desc
+}
+
+func (c *pendingJobsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *pendingJobsCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+
+	counts, err := c.count(ctx)
+	if err != nil {
+		log.Err(err).Msg("collect pending accrual jobs error")
+		ch <- prometheus.NewInvalidMetric(c.desc, err)
+		return
+	}
+	for status, n := range counts {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(n), status)
+	}
+}
+
+// DBPoolStats состояние пула соединений с БД
+type DBPoolStats struct {
+	MaxConns      int
+	TotalConns    int
+	AcquiredConns int
+	IdleConns     int
+	// Acquires сколько раз соединение бралось из пула, WaitedAcquires - из них пришлось ждать свободного соединения
+	Acquires       int64
+	WaitedAcquires int64
+	// AcquireDuration суммарное время получения соединений
+	AcquireDuration time.Duration
+}
+
+// dbPoolCollector читает состояние пула в момент сбора метрик
+type dbPoolCollector struct {
+	stats func() DBPoolStats
+
+	maxConns        *prometheus.Desc
+	totalConns      *prometheus.Desc
+	acquiredConns   *prometheus.Desc
+	idleConns       *prometheus.Desc
+	acquires        *prometheus.Desc
+	waitedAcquires  *prometheus.Desc
+	acquireDuration *prometheus.Desc
+}
+
+func newDBPoolCollector(stats func() DBPoolStats, name string) *dbPoolCollector {
+	desc := func(metric, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", metric), help, nil, prometheus.Labels{"db_name": name})
+	}
+	return &dbPoolCollector{
+		stats:           stats,
+		maxConns:        desc("max_conns", "Maximum number of connections in the pool."),
+		totalConns:      desc("conns", "Number of open connections."),
+		acquiredConns:   desc("acquired_conns", "Number of connections in use."),
+		idleConns:       desc("idle_conns", "Number of idle connections."),
+		acquires:        desc("acquires_total", "Number of connection acquires."),
+		waitedAcquires:  desc("waited_acquires_total", "Number of acquires that waited for a free connection."),
+		acquireDuration: desc("acquire_duration_seconds_total", "Total time spent acquiring connections."),
+	}
+}
+
+func (c *dbPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.maxConns
+	ch <- c.totalConns
+	ch <- c.acquiredConns
+	ch <- c.idleConns
+	ch <- c.acquires
+	ch <- c.waitedAcquires
+	ch <- c.acquireDuration
+}
+
+func (c *dbPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.stats()
+	ch <- prometheus.MustNewConstMetric(c.maxConns, prometheus.GaugeValue, float64(s.MaxConns))
+	ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(s.TotalConns))
+	ch <- prometheus.MustNewConstMetric(c.acquiredConns, prometheus.GaugeValue, float64(s.AcquiredConns))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(s.IdleConns))
+	ch <- prometheus.MustNewConstMetric(c.acquires, prometheus.CounterValue, float64(s.Acquires))
+	ch <- prometheus.MustNewConstMetric(c.waitedAcquires, prometheus.CounterValue, float64(s.WaitedAcquires))
+	ch <- prometheus.MustNewConstMetric(c.acquireDuration, prometheus.CounterValue, s.AcquireDuration.Seconds())
+}
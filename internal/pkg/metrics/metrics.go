@@ -0,0 +1,243 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "gophermart"
+
+// Исходы запроса к системе расчета начислений
+const (
+	AccrualOutcomeError = "error"
+	AccrualOutcome5xx   = "5xx"
+	AccrualOutcomeOther = "other"
+)
+
+// Данные, которые удаляет janitor
+const (
+	JanitorSessions        = "sessions"
+	JanitorIdempotencyKeys = "idempotency_keys"
+	JanitorRateLimits      = "rate_limits"
+	JanitorEvents          = "events"
+)
+
+// Metrics метрики приложения в собственном реестре Prometheus.
+// Методы безопасно вызывать на nil, тогда метрики не собираются
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+
+	accrualRequests      *prometheus.CounterVec
+	accrualDuration      *prometheus.HistogramVec
+	accrualRateLimitWait prometheus.Histogram
+	accrualRetries       prometheus.Counter
+	accrualRetryLimit    prometheus.Counter
+
+	janitorRemoved  *prometheus.CounterVec
+	janitorErrors   *prometheus.CounterVec
+	janitorDuration prometheus.Histogram
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "Number of HTTP requests by route and status code.",
+		}, []string{"method", "route", "code"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "HTTP request latency by route.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		accrualRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "accrual",
+			Name:      "requests_total",
+			Help:      "Number of accrual system requests by outcome: 200, 204, 429, 5xx, other, error.",
+		}, []string{"outcome"}),
+		accrualDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "accrual",
+			Name:      "request_duration_seconds",
+			Help:      "Accrual system request latency by outcome.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"outcome"}),
+		accrualRateLimitWait: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "accrual",
+			Name:      "rate_limiter_wait_seconds",
+			Help:      "Time spent waiting for the accrual client rate limiter.",
+			Buckets:   []float64{.0001, .001, .01, .05, .1, .5, 1, 5, 10},
+		}),
+		accrualRetries: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "accrual",
+			Name:      "retries_total",
+			Help:      "Number of rescheduled accrual requests.",
+		}),
+		accrualRetryLimit: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "accrual",
+			Name:      "retry_limit_exceeded_total",
+			Help:      "Number of orders moved to TOO_MANY_RETRIES.",
+		}),
+		janitorRemoved: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "janitor",
+			Name:      "removed_total",
+			Help:      "Number of removed stale records by kind: sessions, idempotency_keys, rate_limits.",
+		}, []string{"kind"}),
+		janitorErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "janitor",
+			Name:      "errors_total",
+			Help:      "Number of failed cleanups by kind.",
+		}, []string{"kind"}),
+		janitorDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "janitor",
+			Name:      "run_duration_seconds",
+			Help:      "Duration of a cleanup run over all kinds.",
+			Buckets:   prometheus.DefBuckets,
+		}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.accrualRequests,
+		m.accrualDuration,
+		m.accrualRateLimitWait,
+		m.accrualRetries,
+		m.accrualRetryLimit,
+		m.janitorRemoved,
+		m.janitorErrors,
+		m.janitorDuration,
+	)
+	return m
+}
+
+// Handler отдает метрики в формате Prometheus
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// RegisterDB добавляет статистику пула соединений из sql.DB.Stats()
+func (m *Metrics) RegisterDB(db *sql.DB, name string) error {
+	if m == nil {
+		return nil
+	}
+	return m.registry.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// RegisterDBPool добавляет статистику пула соединений, который работает без database/sql.
+// stats вызывается при каждом сборе метрик
+func (m *Metrics) RegisterDBPool(stats func() DBPoolStats, name string) error {
+	if m == nil {
+		return nil
+	}
+	return m.registry.Register(newDBPoolCollector(stats, name))
+}
+
+// RegisterPendingAccrualJobs добавляет число заказов, ожидающих начисления, по статусам.
+// count вызывается при каждом сборе метрик
+func (m *Metrics) RegisterPendingAccrualJobs(count func(ctx context.Context) (map[string]int, error)) error {
+	if m == nil {
+		return nil
+	}
+	return m.registry.Register(&pendingJobsCollector{
+		count: count,
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "accrual", "pending_jobs"),
+			"Number of orders waiting for accrual by status.",
+			[]string{"status"}, nil,
+		),
+	})
+}
+
+func (m *Metrics) ObserveHTTPRequest(method, route string, code int, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	m.httpRequests.WithLabelValues(method, route, strconv.Itoa(code)).Inc()
+	m.httpDuration.WithLabelValues(method, route).Observe(duration.Seconds())
+}
+
+// ObserveAccrualRequest учитывает запрос к системе расчета начислений.
+// statusCode 0 означает, что ответ не получен
+func (m *Metrics) ObserveAccrualRequest(statusCode int, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	outcome := accrualOutcome(statusCode)
+	m.accrualRequests.WithLabelValues(outcome).Inc()
+	m.accrualDuration.WithLabelValues(outcome).Observe(duration.Seconds())
+}
+
+func (m *Metrics) ObserveAccrualRateLimitWait(duration time.Duration) {
+	if m == nil {
+		return
+	}
+	m.accrualRateLimitWait.Observe(duration.Seconds())
+}
+
+func (m *Metrics) IncAccrualRetries() {
+	if m == nil {
+		return
+	}
+	m.accrualRetries.Inc()
+}
+
+func (m *Metrics) IncAccrualRetryLimitExceeded() {
+	if m == nil {
+		return
+	}
+	m.accrualRetryLimit.Inc()
+}
+
+// ObserveJanitorCleanup учитывает удаленные записи одного вида. Если err не nil, очистка прервана ошибкой
+func (m *Metrics) ObserveJanitorCleanup(kind string, removed int, err error) {
+	if m == nil {
+		return
+	}
+	m.janitorRemoved.WithLabelValues(kind).Add(float64(removed))
+	if err != nil {
+		m.janitorErrors.WithLabelValues(kind).Inc()
+	}
+}
+
+func (m *Metrics) ObserveJanitorRun(duration time.Duration) {
+	if m == nil {
+		return
+	}
+	m.janitorDuration.Observe(duration.Seconds())
+}
+
+func accrualOutcome(statusCode int) string {
+	switch {
+	case statusCode == 0:
+		return AccrualOutcomeError
+	case statusCode == http.StatusOK, statusCode == http.StatusNoContent, statusCode == http.StatusTooManyRequests:
+		return strconv.Itoa(statusCode)
+	case statusCode >= http.StatusInternalServerError:
+		return AccrualOutcome5xx
+	default:
+		return AccrualOutcomeOther
+	}
+}
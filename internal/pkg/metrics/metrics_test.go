@@ -0,0 +1,110 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccrualOutcome(t *testing.T) {
+	tests := []struct {
+		statusCode int
+		want       string
+	}{
+		{0, "error"},
+		{200, "200"},
+		{204, "204"},
+		{429, "429"},
+		{500, "5xx"},
+		{503, "5xx"},
+		{404, "other"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, accrualOutcome(tt.statusCode), tt.statusCode)
+	}
+}
+
+func TestMetrics_Observe(t *testing.T) {
+	m := New()
+	m.ObserveHTTPRequest(http.MethodGet, "/api/user/orders", http.StatusOK, time.Millisecond)
+	m.ObserveHTTPRequest(http.MethodGet, "/api/user/orders", http.StatusOK, time.Millisecond)
+	m.ObserveAccrualRequest(http.StatusTooManyRequests, time.Millisecond)
+	m.IncAccrualRetries()
+	m.ObserveJanitorCleanup(JanitorSessions, 3, nil)
+	m.ObserveJanitorCleanup(JanitorSessions, 2, errors.New("boom"))
+
+	assert.Equal(t, 5.0, testutil.ToFloat64(m.janitorRemoved.WithLabelValues(JanitorSessions)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.janitorErrors.WithLabelValues(JanitorSessions)))
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.httpRequests.WithLabelValues(http.MethodGet, "/api/user/orders", "200")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.accrualRequests.WithLabelValues("429")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.accrualRetries))
+}
+
+func TestMetrics_Nil(t *testing.T) {
+	var m *Metrics
+	assert.NotPanics(t, func() {
+		m.ObserveHTTPRequest(http.MethodGet, "/", http.StatusOK, time.Millisecond)
+		m.ObserveAccrualRequest(http.StatusOK, time.Millisecond)
+		m.ObserveAccrualRateLimitWait(time.Millisecond)
+		m.IncAccrualRetries()
+		m.IncAccrualRetryLimitExceeded()
+		m.ObserveJanitorCleanup(JanitorSessions, 1, nil)
+		m.ObserveJanitorRun(time.Millisecond)
+		assert.NoError(t, m.RegisterPendingAccrualJobs(nil))
+		assert.NoError(t, m.RegisterDBPool(nil, "db"))
+	})
+}
+
+func TestMetrics_Handler(t *testing.T) {
+	m := New()
+	err := m.RegisterPendingAccrualJobs(func(context.Context) (map[string]int, error) {
+		return map[string]int{"NEW": 3, "PROCESSING": 1}, nil
+	})
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+	assert.Contains(t, body, `gophermart_accrual_pending_jobs{status="NEW"} 3`)
+	assert.Contains(t, body, `gophermart_accrual_pending_jobs{status="PROCESSING"} 1`)
+	assert.True(t, strings.Contains(body, "go_goroutines"))
+}
+
+func TestMetrics_DBPool(t *testing.T) {
+	m := New()
+	err := m.RegisterDBPool(func() DBPoolStats {
+		return DBPoolStats{MaxConns: 10, TotalConns: 4, AcquiredConns: 3, IdleConns: 1, Acquires: 7, WaitedAcquires: 2,
+			AcquireDuration: 1500 * time.Millisecond}
+	}, "gophermart")
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+	assert.Contains(t, body, `gophermart_db_pool_max_conns{db_name="gophermart"} 10`)
+	assert.Contains(t, body, `gophermart_db_pool_acquired_conns{db_name="gophermart"} 3`)
+	assert.Contains(t, body, `gophermart_db_pool_waited_acquires_total{db_name="gophermart"} 2`)
+	assert.Contains(t, body, `gophermart_db_pool_acquire_duration_seconds_total{db_name="gophermart"} 1.5`)
+}
+
+func TestMetrics_PendingJobsError(t *testing.T) {
+	m := New()
+	err := m.RegisterPendingAccrualJobs(func(context.Context) (map[string]int, error) {
+		return nil, errors.New("boom")
+	})
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+}
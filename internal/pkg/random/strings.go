@@ -2,18 +2,25 @@ package random
 
 import (
 	"math/rand"
+	"sync"
 	"time"
 )
 
 const charSet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
 
-var seededRand = rand.New(rand.NewSource(time.Now().UnixNano())) //nolint:gosec
+var (
+	seededRand = rand.New(rand.NewSource(time.Now().UnixNano())) //nolint:gosec
+	// randMu rand.Rand не безопасен для конкурентного использования
+	randMu sync.Mutex
+)
 
 func String(length int) string {
 	if length < 0 {
 		return ""
 	}
 	b := make([]byte, length)
+	randMu.Lock()
+	defer randMu.Unlock()
 	for i := range b {
 		b[i] = charSet[seededRand.Intn(len(charSet))]
 	}
@@ -0,0 +1,5 @@
+package ratelimit
+
+import "errors"
+
+var ErrInvalidRule = errors.New("invalid rate limit rule")
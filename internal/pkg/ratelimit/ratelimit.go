@@ -0,0 +1,91 @@
+// Package ratelimit ограничение частоты запросов к API алгоритмом token bucket
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit Requests запросов за Period. Корзина вмещает Requests токенов и пополняется равномерно
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// ParseLimit разбирает лимит вида "60/1m"
+func ParseLimit(s string) (Limit, error) {
+	parts := strings.Split(strings.TrimSpace(s), "/")
+	if len(parts) != 2 {
+		return Limit{}, fmt.Errorf("%w: limit %q, expected <requests>/<period>", ErrInvalidRule, s)
+	}
+	requests, err := strconv.Atoi(parts[0])
+	if err != nil || requests <= 0 {
+		return Limit{}, fmt.Errorf("%w: requests in %q must be a positive integer", ErrInvalidRule, s)
+	}
+	period, err := time.ParseDuration(parts[1])
+	if err != nil || period <= 0 {
+		return Limit{}, fmt.Errorf("%w: period in %q must be a positive duration", ErrInvalidRule, s)
+	}
+	return Limit{Requests: requests, Period: period}, nil
+}
+
+func (l Limit) String() string {
+	return strconv.Itoa(l.Requests) + "/" + l.Period.String()
+}
+
+// rate токенов в секунду
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+// Bucket состояние корзины клиента
+type Bucket struct {
+	Tokens    float64
+	UpdatedAt time.Time
+}
+
+// Result результат попытки выполнить запрос
+type Result struct {
+	Allowed bool
+	// Limit размер корзины
+	Limit int
+	// Remaining сколько запросов можно выполнить сразу
+	Remaining int
+	// Reset через сколько корзина заполнится полностью
+	Reset time.Duration
+	// RetryAfter через сколько появится токен для следующего запроса, если запрос отклонен
+	RetryAfter time.Duration
+}
+
+// Take пополняет корзину на время, прошедшее с прошлого запроса, и списывает токен, если он есть.
+// Для нового клиента передается нулевая корзина, она считается полной
+func Take(bucket Bucket, limit Limit, now time.Time) (Bucket, Result) {
+	capacity := float64(limit.Requests)
+	tokens := capacity
+	if !bucket.UpdatedAt.IsZero() {
+		elapsed := now.Sub(bucket.UpdatedAt).Seconds()
+		if elapsed < 0 {
+			// часы экземпляров могут немного расходиться
+			elapsed = 0
+		}
+		tokens = math.Min(capacity, bucket.Tokens+elapsed*limit.rate())
+	}
+
+	result := Result{Limit: limit.Requests}
+	if tokens >= 1 {
+		tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - tokens) / limit.rate())
+	}
+	result.Remaining = int(math.Floor(tokens))
+	result.Reset = seconds((capacity - tokens) / limit.rate())
+	return Bucket{Tokens: tokens, UpdatedAt: now}, result
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,81 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTake(t *testing.T) {
+	limit := Limit{Requests: 2, Period: time.Minute}
+	now := time.Now()
+
+	bucket, result := Take(Bucket{}, limit, now)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 1, result.Remaining)
+	assert.Equal(t, 30*time.Second, result.Reset)
+
+	bucket, result = Take(bucket, limit, now)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+
+	bucket, result = Take(bucket, limit, now.Add(15*time.Second))
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+	assert.Equal(t, 15*time.Second, result.RetryAfter)
+	assert.Equal(t, 45*time.Second, result.Reset)
+
+	// за 30 секунд добавляется один токен
+	_, result = Take(bucket, limit, now.Add(30*time.Second))
+	assert.True(t, result.Allowed)
+
+	// корзина не переполняется
+	_, result = Take(bucket, limit, now.Add(time.Hour))
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 1, result.Remaining)
+}
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules("POST /api/user/orders=10/1m, get /api/user/*=100/1s,* *=600/1m")
+	require.NoError(t, err)
+	assert.Equal(t, []Rule{
+		{Method: "POST", Route: "/api/user/orders", Limit: Limit{Requests: 10, Period: time.Minute}},
+		{Method: "GET", Route: "/api/user/*", Limit: Limit{Requests: 100, Period: time.Second}},
+		{Method: Any, Route: Any, Limit: Limit{Requests: 600, Period: time.Minute}},
+	}, rules)
+	assert.Equal(t, "POST /api/user/orders=10/1m0s", rules[0].String())
+
+	rules, err = ParseRules("")
+	require.NoError(t, err)
+	assert.Empty(t, rules)
+
+	for _, invalid := range []string{
+		"POST /api/user/orders",
+		"/api/user/orders=10/1m",
+		"POST api/user/orders=10/1m",
+		"POST /api/user/orders=0/1m",
+		"POST /api/user/orders=10/soon",
+		"POST /api/user/orders=10",
+	} {
+		_, err := ParseRules(invalid)
+		assert.ErrorIs(t, err, ErrInvalidRule, invalid)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	rules, err := ParseRules("POST /api/user/orders=10/1m,GET /api/user/*=100/1m")
+	require.NoError(t, err)
+
+	rule, ok := Match(rules, "POST", "/api/user/orders")
+	assert.True(t, ok)
+	assert.Equal(t, 10, rule.Limit.Requests)
+
+	rule, ok = Match(rules, "GET", "/api/user/orders")
+	assert.True(t, ok)
+	assert.Equal(t, 100, rule.Limit.Requests)
+
+	_, ok = Match(rules, "POST", "/api/user/login")
+	assert.False(t, ok)
+}
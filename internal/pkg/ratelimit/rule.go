@@ -0,0 +1,88 @@
+package ratelimit
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Any подходит к любому методу или маршруту
+const Any = "*"
+
+// Rule лимит для маршрута. Маршрут - путь запроса ("/api/user/orders"), префикс с * на конце ("/api/user/*") или *.
+// У каждого правила свои корзины клиентов
+type Rule struct {
+	Method string
+	Route  string
+	Limit  Limit
+}
+
+// ParseRules разбирает правила вида "POST /api/user/orders=10/1m", разделенные запятыми.
+// Правила проверяются по порядку, применяется первое подходящее
+func ParseRules(s string) ([]Rule, error) {
+	var rules []Rule
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		rule, err := parseRule(item)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func parseRule(s string) (Rule, error) {
+	i := strings.LastIndex(s, "=")
+	if i < 0 {
+		return Rule{}, fmt.Errorf("%w: %q, expected <METHOD> <route>=<requests>/<period>", ErrInvalidRule, s)
+	}
+	target := strings.Fields(s[:i])
+	if len(target) != 2 {
+		return Rule{}, fmt.Errorf("%w: %q, expected <METHOD> <route>=<requests>/<period>", ErrInvalidRule, s)
+	}
+	if target[1] != Any && !strings.HasPrefix(target[1], "/") {
+		return Rule{}, fmt.Errorf("%w: route %q must start with / or be *", ErrInvalidRule, target[1])
+	}
+	limit, err := ParseLimit(s[i+1:])
+	if err != nil {
+		return Rule{}, err
+	}
+	return Rule{Method: strings.ToUpper(target[0]), Route: target[1], Limit: limit}, nil
+}
+
+func (r Rule) String() string {
+	return r.Method + " " + r.Route + "=" + r.Limit.String()
+}
+
+// Name идентификатор правила в ключе корзины
+func (r Rule) Name() string {
+	return r.Method + " " + r.Route
+}
+
+// Matches подходит ли правило к запросу
+func (r Rule) Matches(method string, path string) bool {
+	if r.Method != Any && r.Method != method {
+		return false
+	}
+	switch {
+	case r.Route == Any:
+		return true
+	case strings.HasSuffix(r.Route, "*"):
+		return strings.HasPrefix(path, strings.TrimSuffix(r.Route, "*"))
+	default:
+		return r.Route == path
+	}
+}
+
+// Match первое правило, подходящее к запросу
+func Match(rules []Rule, method string, path string) (Rule, bool) {
+	for _, rule := range rules {
+		if rule.Matches(method, path) {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
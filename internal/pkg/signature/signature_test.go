@@ -0,0 +1,28 @@
+package signature
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"type": "order.processed"}`)
+	sig := Sign("secret", 1645000000, body)
+
+	assert.Equal(t, "sha256=", sig[:7])
+	assert.Len(t, sig, 7+64)
+	assert.Equal(t, sig, Sign("secret", 1645000000, body))
+	assert.NotEqual(t, sig, Sign("secret2", 1645000000, body))
+	assert.NotEqual(t, sig, Sign("secret", 1645000001, body))
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"type": "order.processed"}`)
+	sig := Sign("secret", 1645000000, body)
+
+	assert.True(t, Verify("secret", 1645000000, body, sig))
+	assert.False(t, Verify("secret", 1645000000, []byte(`{}`), sig))
+	assert.False(t, Verify("wrong", 1645000000, body, sig))
+	assert.False(t, Verify("secret", 1645000000, body, ""))
+}
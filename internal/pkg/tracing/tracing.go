@@ -0,0 +1,87 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+)
+
+// ShutdownFunc отправляет накопленные спаны и закрывает экспортер
+type ShutdownFunc func(ctx context.Context) error
+
+// Setup настраивает глобальный TracerProvider и W3C propagator (traceparent, baggage).
+// exporter задается строкой вида:
+//
+//	"" - трассировка выключена, контекст трассировки только пробрасывается дальше
+//	otlp - спаны отправляются по OTLP/gRPC, адрес и TLS берутся из OTEL_EXPORTER_OTLP_* переменных окружения
+//	stdout - спаны пишутся в stdout в формате JSON
+//	file:/path/to/traces.json - спаны дописываются в файл в формате JSON
+func Setup(ctx context.Context, exporter string, serviceName string) (ShutdownFunc, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if exporter == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	spanExporter, closer, err := newExporter(ctx, exporter)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceNameKey.String(serviceName),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(tp)
+
+	return func(ctx context.Context) error {
+		err := tp.Shutdown(ctx)
+		if closer != nil {
+			if closeErr := closer.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		return err
+	}, nil
+}
+
+func newExporter(ctx context.Context, exporter string) (sdktrace.SpanExporter, io.Closer, error) {
+	switch {
+	case exporter == "otlp":
+		spanExporter, err := otlptracegrpc.New(ctx)
+		return spanExporter, nil, err
+	case exporter == "stdout":
+		spanExporter, err := stdouttrace.New()
+		return spanExporter, nil, err
+	case strings.HasPrefix(exporter, "file:"):
+		f, err := os.OpenFile(strings.TrimPrefix(exporter, "file:"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, nil, err
+		}
+		spanExporter, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			_ = f.Close()
+			return nil, nil, err
+		}
+		return spanExporter, f, nil
+	default:
+		return nil, nil, fmt.Errorf("%w: %q", ErrUnknownExporter, exporter)
+	}
+}
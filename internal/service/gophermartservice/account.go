@@ -2,7 +2,10 @@ package gophermartservice
 
 import "context"
 
-func (s GophermartService) GetUserBalance(ctx context.Context, userID string) (float32, float32, error) {
+func (s GophermartService) GetUserBalance(ctx context.Context, userID string) (_ float32, _ float32, err error) {
+	ctx, span := startSpan(ctx, "GetUserBalance", attrUserID.String(userID))
+	defer func() { endSpan(span, err) }()
+
 	account, err := s.repo.AccountRepo.GetAccount(ctx, userID)
 	if err != nil {
 		return 0, 0, err
@@ -1,18 +1,25 @@
 package gophermartservice
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	Accrual "github.com/zaz600/go-musthave-diploma/api/accrual"
+	"github.com/zaz600/go-musthave-diploma/internal/entity"
 	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/providers/accrual"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/random"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func TestGophermartService_calcNext(t *testing.T) {
 	interval := 100 * time.Millisecond
-	s := GophermartService{accrualRetryInterval: interval}
+	s := GophermartService{accrualRetry: newRetryPolicy(interval, accrualDefaultMaxRetries)}
 
 	t.Run("no error", func(t *testing.T) {
 		resp := &accrual.GetAccrualResponse{
@@ -41,3 +48,39 @@ func TestGophermartService_calcNext(t *testing.T) {
 		assert.Equal(t, interval, next)
 	})
 }
+
+func TestGophermartService_accrualAttemptCircuitOpen(t *testing.T) {
+	ctx := context.Background()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	client, err := Accrual.NewClientWithResponses(server.URL)
+	require.NoError(t, err)
+	s, err := New(client, WithMemoryStorage(), WithAccrualCircuitBreaker(1, time.Hour))
+	require.NoError(t, err)
+	defer s.Shutdown()
+
+	session, err := s.RegisterUser(ctx, random.String(8), random.String(8))
+	require.NoError(t, err)
+	order := entity.NewOrder(session.UID, random.OrderID())
+	require.NoError(t, s.repo.OrderRepo.AddOrder(ctx, order))
+
+	// ошибка системы начислений расходует попытку и открывает circuit breaker
+	_, _, retry := s.accrualAttempt(ctx, order.OrderID, trace.SpanContext{})
+	require.True(t, retry)
+	got, err := s.repo.OrderRepo.GetOrder(ctx, order.OrderID)
+	require.NoError(t, err)
+	require.Equal(t, 1, got.RetryCount)
+
+	// пока circuit breaker открыт, запросы не отправляются и попытки не расходуются
+	for i := 0; i < accrualDefaultMaxRetries+2; i++ {
+		next, _, retry := s.accrualAttempt(ctx, order.OrderID, trace.SpanContext{})
+		require.True(t, retry)
+		assert.Greater(t, next, time.Minute)
+	}
+	got, err = s.repo.OrderRepo.GetOrder(ctx, order.OrderID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, got.RetryCount)
+	assert.Equal(t, entity.OrderStatusNew, got.Status)
+}
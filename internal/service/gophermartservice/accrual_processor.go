@@ -5,74 +5,191 @@ import (
 	"errors"
 	"time"
 
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	Accrual "github.com/zaz600/go-musthave-diploma/api/accrual"
 	"github.com/zaz600/go-musthave-diploma/internal/entity"
 	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/providers/accrual"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
-//nolint:funlen
-func (s GophermartService) GetAccruals(orderID string) {
-	ctx, cancel := context.WithCancel(context.Background())
+// GetAccruals запрашивает начисления по заказу в фоне, пока заказ не перейдет в конечный статус.
+// Из ctx используются только контекст трассировки и логгер: спаны попыток связываются ссылкой
+// со спаном загрузки заказа, а в логах обработки остаются request_id и user_id запроса.
+// Если обработка прервана остановкой сервиса, заказ остается в статусе NEW или PROCESSING
+// и подхватывается ResumeAccruals при следующем запуске
+func (s GophermartService) GetAccruals(ctx context.Context, orderID string) {
+	logger := zerolog.Ctx(ctx).With().Str("orderID", orderID).Logger()
+	if !s.accrualWorkers.start() {
+		logger.Info().Msg("GetAccruals service is stopping, order will be resumed")
+		return
+	}
+	s.getAccruals(orderID, trace.SpanContextFromContext(ctx), &logger)
+}
+
+// ResumeAccruals запускает обработку заказов, начисления по которым не были получены до остановки сервиса
+func (s GophermartService) ResumeAccruals(ctx context.Context) error {
+	orders, err := s.repo.OrderRepo.GetOrdersByStatus(ctx, pendingAccrualStatuses...)
+	if err != nil {
+		return err
+	}
+	for _, order := range orders {
+		go s.GetAccruals(ctx, order.OrderID)
+	}
+	log.Info().Int("orders", len(orders)).Msg("accruals resumed")
+	return nil
+}
+
+// StopAccruals прерывает ожидание повторов и дожидается завершения текущих попыток, но не дольше, чем до отмены ctx
+func (s GophermartService) StopAccruals(ctx context.Context) error {
+	return s.accrualWorkers.stop(ctx)
+}
+
+// getAccruals выполняет одну попытку и, если нужно, планирует следующую.
+// Спан каждой попытки ссылается на спан предыдущей, поэтому ожидание между попытками видно в трассировке
+func (s GophermartService) getAccruals(orderID string, prev trace.SpanContext, logger *zerolog.Logger) {
+	ctx, cancel := context.WithCancel(logger.WithContext(s.accrualWorkers.ctx))
 	defer cancel()
+
+	rescheduled := false
+	defer func() {
+		if !rescheduled {
+			s.accrualWorkers.done()
+		}
+	}()
+
+	next, attempt, retry := s.accrualAttempt(ctx, orderID, prev)
+	if !retry {
+		return
+	}
+
+	select {
+	case <-ctx.Done():
+		logger.Info().Msg("GetAccruals context done")
+		return
+	case <-time.After(next):
+		s.metrics.IncAccrualRetries()
+		rescheduled = true
+		go s.getAccruals(orderID, attempt, logger) // решедуллим
+		return
+	}
+}
+
+// accrualAttempt запрашивает начисления и обновляет заказ.
+// Возвращает, через сколько повторить запрос, контекст спана попытки и нужен ли повтор
+//
+//nolint:funlen
+func (s GophermartService) accrualAttempt(ctx context.Context, orderID string, prev trace.SpanContext) (time.Duration, trace.SpanContext, bool) {
+	opts := []trace.SpanStartOption{trace.WithAttributes(attrOrderID.String(orderID))}
+	if prev.IsValid() {
+		opts = append(opts, trace.WithLinks(trace.Link{SpanContext: prev}))
+	}
+	ctx, span := tracer.Start(ctx, "GophermartService.GetAccruals", opts...)
+	defer span.End()
+	attempt := span.SpanContext()
+	logger := zerolog.Ctx(ctx)
+
 	order, err := s.repo.OrderRepo.GetOrder(ctx, orderID)
 	if err != nil {
-		log.Err(err).Str("orderID", orderID).Msg("order not found")
-		return
+		logger.Err(err).Msg("order not found")
+		span.RecordError(err)
+		return 0, attempt, false
 	}
+	span.SetAttributes(attribute.Int("order.retry_count", order.RetryCount))
 
 	logError := func(err error) {
 		if err != nil {
-			log.Err(err).Str("orderID", orderID).Int("retryCount", order.RetryCount).Msg("error during getAccrual occurred")
+			logger.Err(err).Int("retryCount", order.RetryCount).Msg("error during getAccrual occurred")
+			span.RecordError(err)
 		}
 	}
 
-	if order.RetryCount > 5 {
-		log.Info().Str("orderID", orderID).Int("retryCount", order.RetryCount).Msg("GetAccruals retry limit")
+	if _, maxRetries := s.accrualRetry.get(); order.RetryCount > maxRetries {
+		logger.Info().Int("retryCount", order.RetryCount).Msg("GetAccruals retry limit")
+		s.metrics.IncAccrualRetryLimitExceeded()
 		err = s.repo.OrderRepo.SetOrderStatusAndAccrual(ctx, orderID, entity.OrderStatusTooManyRetries, 0)
 		logError(err)
-		return
+		return 0, attempt, false
 	}
 
 	if order.Status == entity.OrderStatusProcessed || order.Status == entity.OrderStatusInvalid {
-		log.Info().Str("orderID", orderID).Int("retryCount", order.RetryCount).Msg("GetAccruals finnish order status")
-		return
+		logger.Info().Int("retryCount", order.RetryCount).Msg("GetAccruals finnish order status")
+		return 0, attempt, false
 	}
 
 	var resp *accrual.GetAccrualResponse
 	resultCh := s.accrualProvider.GetAccrual(ctx, orderID)
 	select {
 	case <-ctx.Done():
-		return
+		return 0, attempt, false
 	case resp = <-resultCh:
 	}
+	if ctx.Err() != nil {
+		// сервис останавливается: статус заказа не меняем, обработка продолжится после перезапуска
+		logger.Info().Msg("GetAccruals interrupted")
+		return 0, attempt, false
+	}
 
 	next := s.calcNext(resp)
 	if err := resp.Err; err != nil {
-		log.Err(err).Str("orderID", orderID).Msg("error during GetAccrual")
+		logger.Err(err).Msg("error during GetAccrual")
 		if errors.Is(err, accrual.ErrFatalError) {
-			return
+			return 0, attempt, false
+		}
+		// запрос не отправлялся, поэтому попытка заказа не расходуется: иначе за время недоступности
+		// системы начислений все ожидающие заказы перешли бы в TOO_MANY_RETRIES
+		var errCircuitOpen *accrual.CircuitOpenError
+		if errors.As(err, &errCircuitOpen) {
+			span.AddEvent("retry scheduled", trace.WithAttributes(attribute.String("retry.after", next.String())))
+			return next, attempt, true
 		}
 	}
 
 	switch resp.Status {
 	case Accrual.ResponseStatusPROCESSED:
 		accrualAmount := *resp.Accrual
-		log.Info().Str("orderID", orderID).Int("retryCount", order.RetryCount).Str("status", string(resp.Status)).Float32("accrual", accrualAmount).Msg("GetAccruals completed")
-		err = s.repo.OrderRepo.SetOrderStatusAndAccrual(ctx, orderID, entity.OrderStatusProcessed, accrualAmount)
+		logger.Info().Int("retryCount", order.RetryCount).Str("status", string(resp.Status)).Float32("accrual", accrualAmount).Msg("GetAccruals completed")
+		err = s.repo.Transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+			err := s.repo.OrderRepo.SetOrderStatusAndAccrual(ctx, orderID, entity.OrderStatusProcessed, accrualAmount)
+			if err != nil {
+				return err
+			}
+			if accrualAmount > 0 {
+				if err := s.repo.AccountRepo.RefillAmount(ctx, order.UID, accrualAmount); err != nil {
+					return err
+				}
+				err := s.addEvent(ctx, entity.EventAccrualCredited, order.UID, entity.AccrualEventPayload{
+					OrderID: orderID,
+					Amount:  accrualAmount,
+				})
+				if err != nil {
+					return err
+				}
+			}
+			return s.addEvent(ctx, entity.EventOrderProcessed, order.UID, entity.OrderEventPayload{
+				OrderID: orderID,
+				Status:  entity.OrderStatusProcessed,
+				Accrual: accrualAmount,
+			})
+		})
 		logError(err)
-		if *resp.Accrual > 0 {
-			// TODO баланс изменять надо в одной транзакции с регистрацией зачисления
-			err = s.repo.AccountRepo.RefillAmount(ctx, order.UID, accrualAmount)
-			logError(err)
-		}
-		return
+		return 0, attempt, false
 
 	case Accrual.ResponseStatusINVALID:
-		log.Info().Str("orderID", orderID).Int("retryCount", order.RetryCount).Str("status", string(resp.Status)).Msg("GetAccruals completed")
-		err = s.repo.OrderRepo.SetOrderStatusAndAccrual(ctx, orderID, entity.OrderStatusInvalid, 0)
+		logger.Info().Int("retryCount", order.RetryCount).Str("status", string(resp.Status)).Msg("GetAccruals completed")
+		err = s.repo.Transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+			err := s.repo.OrderRepo.SetOrderStatusAndAccrual(ctx, orderID, entity.OrderStatusInvalid, 0)
+			if err != nil {
+				return err
+			}
+			return s.addEvent(ctx, entity.EventOrderInvalid, order.UID, entity.OrderEventPayload{
+				OrderID: orderID,
+				Status:  entity.OrderStatusInvalid,
+			})
+		})
 		logError(err)
-		return
+		return 0, attempt, false
 	case Accrual.ResponseStatusPROCESSING, Accrual.ResponseStatusREGISTERED:
 		if resp.Err == nil {
 			err = s.repo.OrderRepo.SetOrderStatusAndAccrual(ctx, orderID, entity.OrderStatusProcessing, 0)
@@ -80,30 +197,43 @@ func (s GophermartService) GetAccruals(orderID string) {
 		}
 
 	default:
-		log.Info().Str("orderID", orderID).Int("retryCount", order.RetryCount).Str("status", string(resp.Status)).Msg("unknown accrual status")
+		logger.Info().Int("retryCount", order.RetryCount).Str("status", string(resp.Status)).Msg("unknown accrual status")
 	}
 
 	err = s.repo.OrderRepo.SetOrderNextRetryAt(ctx, orderID, time.Now().Add(next))
 	logError(err)
-
-	select {
-	case <-ctx.Done():
-		log.Info().Str("orderID", orderID).Int("retryCount", order.RetryCount).Msg("GetAccruals context done")
-		return
-	case <-time.After(next):
-		go s.GetAccruals(orderID) // решедуллим
-		return
-	}
+	span.AddEvent("retry scheduled", trace.WithAttributes(attribute.String("retry.after", next.String())))
+	return next, attempt, true
 }
 
 // calcNext вычисляет через сколько надо повторить запрос
 func (s GophermartService) calcNext(resp *accrual.GetAccrualResponse) time.Duration {
-	next := s.accrualRetryInterval
+	next, _ := s.accrualRetry.get()
 	if err := resp.Err; err != nil {
 		var errTooManyRequests accrual.TooManyRequestsError
 		if errors.As(err, &errTooManyRequests) {
 			next = time.Duration(errTooManyRequests.RetryAfterSec) * time.Second
 		}
+		var errCircuitOpen *accrual.CircuitOpenError
+		if errors.As(err, &errCircuitOpen) {
+			next = errCircuitOpen.RetryAfter
+		}
 	}
 	return next
 }
+
+// pendingAccrualStatuses статусы заказов, по которым еще ожидаются начисления
+var pendingAccrualStatuses = []entity.OrderStatus{entity.OrderStatusNew, entity.OrderStatusProcessing}
+
+// countPendingAccrualJobs возвращает число заказов, ожидающих начисления, по статусам
+func (s GophermartService) countPendingAccrualJobs(ctx context.Context) (map[string]int, error) {
+	counts, err := s.repo.OrderRepo.CountOrdersByStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]int, len(pendingAccrualStatuses))
+	for _, status := range pendingAccrualStatuses {
+		result[string(status)] = counts[status]
+	}
+	return result, nil
+}
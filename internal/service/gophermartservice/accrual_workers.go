@@ -0,0 +1,67 @@
+package gophermartservice
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// accrualWorkers учитывает фоновую обработку начислений.
+// Все попытки выполняются в корневом контексте ctx, который отменяется при остановке сервиса
+type accrualWorkers struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	stopped bool
+	wg      sync.WaitGroup
+	// jobs число заказов, по которым сейчас запрашиваются начисления
+	jobs int64
+}
+
+func newAccrualWorkers() *accrualWorkers {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &accrualWorkers{ctx: ctx, cancel: cancel}
+}
+
+// start регистрирует обработку заказа. После остановки новые заказы не принимаются
+func (w *accrualWorkers) start() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stopped {
+		return false
+	}
+	w.wg.Add(1)
+	atomic.AddInt64(&w.jobs, 1)
+	return true
+}
+
+// done завершает обработку заказа, в том числе со всеми повторами
+func (w *accrualWorkers) done() {
+	atomic.AddInt64(&w.jobs, -1)
+	w.wg.Done()
+}
+
+func (w *accrualWorkers) count() int64 {
+	return atomic.LoadInt64(&w.jobs)
+}
+
+// stop отменяет корневой контекст и дожидается завершения текущих попыток, но не дольше, чем до отмены ctx
+func (w *accrualWorkers) stop(ctx context.Context) error {
+	w.mu.Lock()
+	w.stopped = true
+	w.mu.Unlock()
+	w.cancel()
+
+	stopped := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
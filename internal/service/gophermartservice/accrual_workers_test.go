@@ -0,0 +1,99 @@
+package gophermartservice
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	Accrual "github.com/zaz600/go-musthave-diploma/api/accrual"
+	"github.com/zaz600/go-musthave-diploma/internal/entity"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/providers/accrual"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/random"
+)
+
+type stubAccrualProvider struct {
+	status Accrual.ResponseStatus
+}
+
+func (p stubAccrualProvider) GetAccrual(_ context.Context, _ string) chan *accrual.GetAccrualResponse {
+	resultCh := make(chan *accrual.GetAccrualResponse, 1)
+	amount := float32(10)
+	resultCh <- &accrual.GetAccrualResponse{Status: p.status, Accrual: &amount}
+	close(resultCh)
+	return resultCh
+}
+
+func (p stubAccrualProvider) CircuitState() accrual.CircuitState {
+	return accrual.CircuitClosed
+}
+
+func (p stubAccrualProvider) SetRateLimit(int) {}
+
+func newAccrualTestService(t *testing.T, status Accrual.ResponseStatus) *GophermartService {
+	t.Helper()
+	s, err := New(nil, WithMemoryStorage(), WithAccrualRetryInterval(time.Hour))
+	require.NoError(t, err)
+	s.accrualProvider = stubAccrualProvider{status: status}
+	return s
+}
+
+func addTestOrder(t *testing.T, s *GophermartService) entity.Order {
+	t.Helper()
+	uid := random.String(8)
+	require.NoError(t, s.repo.AccountRepo.AddAccount(context.Background(), entity.NewAccount(uid)))
+	order := entity.NewOrder(uid, random.OrderID())
+	require.NoError(t, s.repo.OrderRepo.AddOrder(context.Background(), order))
+	return order
+}
+
+func TestGophermartService_StopAccruals(t *testing.T) {
+	ctx := context.Background()
+	s := newAccrualTestService(t, Accrual.ResponseStatusPROCESSING)
+	order := addTestOrder(t, s)
+
+	go s.GetAccruals(ctx, order.OrderID)
+	g := NewGomegaWithT(t)
+	g.Eventually(func() entity.OrderStatus {
+		order, _ := s.repo.OrderRepo.GetOrder(ctx, order.OrderID)
+		return order.Status
+	}, time.Second, 5*time.Millisecond).Should(Equal(entity.OrderStatusProcessing))
+	assert.Equal(t, int64(1), s.accrualWorkers.count())
+
+	// заказ ждет повтора через час, остановка прерывает ожидание
+	stopCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	require.NoError(t, s.StopAccruals(stopCtx))
+	assert.Equal(t, int64(0), s.accrualWorkers.count())
+
+	stored, err := s.repo.OrderRepo.GetOrder(ctx, order.OrderID)
+	require.NoError(t, err)
+	assert.Equal(t, entity.OrderStatusProcessing, stored.Status, "order is left for resumption")
+
+	// после остановки новые заказы не обрабатываются
+	s.GetAccruals(ctx, addTestOrder(t, s).OrderID)
+	assert.Equal(t, int64(0), s.accrualWorkers.count())
+}
+
+func TestGophermartService_ResumeAccruals(t *testing.T) {
+	ctx := context.Background()
+	s := newAccrualTestService(t, Accrual.ResponseStatusPROCESSED)
+	pending := addTestOrder(t, s)
+	processed := addTestOrder(t, s)
+	require.NoError(t, s.repo.OrderRepo.SetOrderStatusAndAccrual(ctx, processed.OrderID, entity.OrderStatusInvalid, 0))
+
+	require.NoError(t, s.ResumeAccruals(ctx))
+
+	g := NewGomegaWithT(t)
+	g.Eventually(func() entity.OrderStatus {
+		order, _ := s.repo.OrderRepo.GetOrder(ctx, pending.OrderID)
+		return order.Status
+	}, time.Second, 5*time.Millisecond).Should(Equal(entity.OrderStatusProcessed))
+	g.Eventually(s.accrualWorkers.count, time.Second, 5*time.Millisecond).Should(BeZero())
+
+	stored, err := s.repo.OrderRepo.GetOrder(ctx, processed.OrderID)
+	require.NoError(t, err)
+	assert.Equal(t, entity.OrderStatusInvalid, stored.Status)
+}
@@ -3,9 +3,21 @@ package gophermartservice
 import "errors"
 
 var (
-	ErrUserExists              = errors.New("user already exists")
-	ErrAuth                    = errors.New("invalid login or password")
-	ErrOrderExists             = errors.New("order already exists")
-	ErrOrderOwnedByAnotherUser = errors.New("order uploaded by another user")
-	ErrInvalidOrderFormat      = errors.New("order format error")
+	ErrUserExists               = errors.New("user already exists")
+	ErrReservedLogin            = errors.New("login is reserved")
+	ErrAuth                     = errors.New("invalid login or password")
+	ErrLoginThrottled           = errors.New("too many login attempts")
+	ErrFeatureDisabled          = errors.New("feature is disabled")
+	ErrSessionNotFound          = errors.New("session not found")
+	ErrOrderExists              = errors.New("order already exists")
+	ErrOrderOwnedByAnotherUser  = errors.New("order uploaded by another user")
+	ErrInvalidOrderFormat       = errors.New("order format error")
+	ErrInsufficientFunds        = errors.New("insufficient funds")
+	ErrInvalidWebhook           = errors.New("invalid webhook subscriber")
+	ErrWebhookNotFound          = errors.New("webhook subscriber not found")
+	ErrDeliveryNotFound         = errors.New("webhook delivery not found")
+	ErrIdempotencyKeyMismatch   = errors.New("idempotency key reused with different request")
+	ErrIdempotencyKeyInProgress = errors.New("request with idempotency key is in progress")
+	ErrAccrualUnavailable       = errors.New("accrual system is unavailable")
+	ErrAccrualJobsSaturated     = errors.New("too many accrual jobs in progress")
 )
@@ -0,0 +1,17 @@
+package gophermartservice
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/zaz600/go-musthave-diploma/internal/entity"
+)
+
+// addEvent сохраняет событие в outbox. Вызывается внутри транзакции вместе с изменением состояния
+func (s GophermartService) addEvent(ctx context.Context, eventType entity.EventType, userID string, payload interface{}) error {
+	event, err := entity.NewEvent(eventType, userID, payload)
+	if err != nil {
+		return fmt.Errorf("error creating event %s: %w", eventType, err)
+	}
+	return s.repo.EventRepo.AddEvent(ctx, event)
+}
@@ -0,0 +1,90 @@
+package gophermartservice
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/zaz600/go-musthave-diploma/internal/entity"
+)
+
+// UserExport данные пользователя для выгрузки по его запросу. Хэш пароля в выгрузку не попадает
+type UserExport struct {
+	UID         string
+	Login       string
+	Balance     float32
+	Withdrawals float32
+	Sessions    []*entity.Session
+	Orders      []entity.Order
+	Withdrawn   []entity.Withdrawal
+	// Ledger начисления и списания баллов в порядке их проведения
+	Ledger     []entity.LedgerEntry
+	ExportedAt time.Time
+}
+
+// ExportUserData собирает все данные пользователя, которые хранит сервис
+func (s GophermartService) ExportUserData(ctx context.Context, uid string) (_ *UserExport, err error) {
+	ctx, span := startSpan(ctx, "ExportUserData", attrUserID.String(uid))
+	defer func() { endSpan(span, err) }()
+
+	user, err := s.repo.UserRepo.GetUserByID(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	account, err := s.repo.AccountRepo.GetAccount(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := s.repo.SessionRepo.GetUserSessions(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	orders, err := s.GetUserOrders(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	withdrawals, err := s.repo.WithdrawalRepo.GetUserWithdrawals(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	return &UserExport{
+		UID:         user.UID,
+		Login:       user.Login,
+		Balance:     account.Balance,
+		Withdrawals: account.Withdrawals,
+		Sessions:    sessions,
+		Orders:      orders,
+		Withdrawn:   withdrawals,
+		Ledger:      userLedger(orders, withdrawals),
+		ExportedAt:  time.Now(),
+	}, nil
+}
+
+// userLedger собирает движение баллов из учетных записей: начислений по обработанным заказам и списаний.
+// Время заказа, когда он был обработан, не хранится, поэтому начисление датируется загрузкой заказа
+func userLedger(orders []entity.Order, withdrawals []entity.Withdrawal) []entity.LedgerEntry {
+	ledger := make([]entity.LedgerEntry, 0, len(orders)+len(withdrawals))
+	for _, order := range orders {
+		if order.Status != entity.OrderStatusProcessed || order.Accrual <= 0 {
+			continue
+		}
+		ledger = append(ledger, entity.LedgerEntry{
+			Type:      entity.LedgerEntryAccrual,
+			OrderID:   order.OrderID,
+			Amount:    order.Accrual,
+			CreatedAt: order.UploadedAt,
+		})
+	}
+	for _, withdrawal := range withdrawals {
+		ledger = append(ledger, entity.LedgerEntry{
+			Type:      entity.LedgerEntryWithdrawal,
+			OrderID:   withdrawal.OrderID,
+			Amount:    withdrawal.Sum,
+			CreatedAt: withdrawal.ProcessedAt,
+		})
+	}
+	sort.SliceStable(ledger, func(i, j int) bool {
+		return ledger[i].CreatedAt.Before(ledger[j].CreatedAt)
+	})
+	return ledger
+}
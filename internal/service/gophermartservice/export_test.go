@@ -0,0 +1,154 @@
+package gophermartservice
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zaz600/go-musthave-diploma/internal/entity"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/random"
+)
+
+func TestGophermartService_ExportUserData(t *testing.T) {
+	ctx := context.Background()
+	s, err := New(nil, WithMemoryStorage())
+	require.NoError(t, err)
+	defer s.Shutdown()
+
+	login := random.String(8)
+	session, err := s.RegisterUser(ctx, login, random.String(8), entity.WithDevice("agent", "10.0.0.1"))
+	require.NoError(t, err)
+	other, err := s.RegisterUser(ctx, random.String(8), random.String(8))
+	require.NoError(t, err)
+
+	order := random.OrderID()
+	require.NoError(t, s.UploadOrder(ctx, session.UID, order))
+	require.NoError(t, s.repo.OrderRepo.SetOrderStatusAndAccrual(ctx, order, entity.OrderStatusProcessed, 100))
+	require.NoError(t, s.repo.AccountRepo.RefillAmount(ctx, session.UID, 100))
+	// в историю попадают только обработанные заказы
+	require.NoError(t, s.UploadOrder(ctx, session.UID, random.OrderID()))
+	withdrawal := random.OrderID()
+	require.NoError(t, s.UploadWithdrawal(ctx, session.UID, withdrawal, 30))
+	otherOrder := random.OrderID()
+	require.NoError(t, s.UploadOrder(ctx, other.UID, otherOrder))
+	require.NoError(t, s.repo.OrderRepo.SetOrderStatusAndAccrual(ctx, otherOrder, entity.OrderStatusProcessed, 10))
+	require.NoError(t, s.repo.AccountRepo.RefillAmount(ctx, other.UID, 10))
+
+	export, err := s.ExportUserData(ctx, session.UID)
+	require.NoError(t, err)
+	assert.Equal(t, session.UID, export.UID)
+	assert.Equal(t, login, export.Login)
+	assert.Equal(t, float32(70), export.Balance)
+	assert.Equal(t, float32(30), export.Withdrawals)
+	require.Len(t, export.Sessions, 1)
+	assert.Equal(t, "agent", export.Sessions[0].UserAgent)
+	require.Len(t, export.Orders, 2)
+	uploaded := make(map[string]entity.Order, len(export.Orders))
+	for _, o := range export.Orders {
+		uploaded[o.OrderID] = o
+	}
+	require.Contains(t, uploaded, order)
+	require.Len(t, export.Withdrawn, 1)
+	assert.Equal(t, withdrawal, export.Withdrawn[0].OrderID)
+	require.Len(t, export.Ledger, 2)
+	assert.Equal(t, entity.LedgerEntry{
+		Type:      entity.LedgerEntryAccrual,
+		OrderID:   order,
+		Amount:    100,
+		CreatedAt: uploaded[order].UploadedAt,
+	}, export.Ledger[0])
+	assert.Equal(t, entity.LedgerEntryWithdrawal, export.Ledger[1].Type)
+	assert.Equal(t, withdrawal, export.Ledger[1].OrderID)
+	assert.Equal(t, float32(30), export.Ledger[1].Amount)
+}
+
+func TestGophermartService_DeleteUser(t *testing.T) {
+	storages := map[string]func(t *testing.T) *GophermartService{
+		"memory": func(t *testing.T) *GophermartService {
+			s, err := New(nil, WithMemoryStorage())
+			require.NoError(t, err)
+			return s
+		},
+		"sqlite": func(t *testing.T) *GophermartService {
+			s, db := newSQLiteTestService(t, filepath.Join(t.TempDir(), "gophermart.db"))
+			t.Cleanup(func() { _ = db.Close() })
+			return s
+		},
+	}
+	for name, newService := range storages {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newService(t)
+			defer s.Shutdown()
+
+			login, password := random.String(8), random.String(8)
+			session, err := s.RegisterUser(ctx, login, password)
+			require.NoError(t, err)
+			second, err := s.LoginUser(ctx, login, password)
+			require.NoError(t, err)
+			order := random.OrderID()
+			require.NoError(t, s.UploadOrder(ctx, session.UID, order))
+			require.NoError(t, s.repo.AccountRepo.RefillAmount(ctx, session.UID, 100))
+			require.NoError(t, s.UploadWithdrawal(ctx, session.UID, random.OrderID(), 30))
+			_, err = s.StartIdempotentRequest(ctx, session.UID, random.String(16), random.String(32))
+			require.NoError(t, err)
+
+			registered, err := s.repo.EventRepo.GetUserEvents(ctx, session.UID, []entity.EventType{entity.EventUserRegistered})
+			require.NoError(t, err)
+			require.Len(t, registered, 1)
+			delivery := entity.NewWebhookDelivery(random.String(16), registered[0])
+			require.NoError(t, s.repo.WebhookRepo.AddDelivery(ctx, delivery))
+
+			assert.ErrorIs(t, s.DeleteUser(ctx, session.UID, "wrong"+password), ErrAuth)
+			require.NoError(t, s.CheckSession(ctx, session.UID, session.SessionID))
+
+			require.NoError(t, s.DeleteUser(ctx, session.UID, password))
+
+			// войти и пользоваться выданными токенами больше нельзя
+			_, err = s.LoginUser(ctx, login, password)
+			assert.ErrorIs(t, err, ErrAuth)
+			assert.ErrorIs(t, s.CheckSession(ctx, session.UID, session.SessionID), ErrSessionNotFound)
+			assert.ErrorIs(t, s.CheckSession(ctx, second.UID, second.SessionID), ErrSessionNotFound)
+
+			user, err := s.repo.UserRepo.GetUserByID(ctx, session.UID)
+			require.NoError(t, err)
+			assert.Equal(t, deletedLoginPrefix+session.UID, user.Login)
+			assert.Empty(t, user.Password)
+
+			// логин больше нигде не хранится
+			events, err := s.repo.EventRepo.GetUserEvents(ctx, session.UID, entity.EventTypes)
+			require.NoError(t, err)
+			for _, event := range events {
+				assert.NotContains(t, string(event.Payload), login)
+			}
+			assert.Equal(t, entity.EventUserDeleted, events[len(events)-1].Type)
+			got, err := s.repo.WebhookRepo.GetDelivery(ctx, delivery.ID)
+			require.NoError(t, err)
+			var payload entity.UserEventPayload
+			require.NoError(t, json.Unmarshal(got.Event.Payload, &payload))
+			assert.Equal(t, user.Login, payload.Login)
+
+			// финансовые записи остаются
+			orders, err := s.GetUserOrders(ctx, session.UID)
+			require.NoError(t, err)
+			require.Len(t, orders, 1)
+			assert.Equal(t, order, orders[0].OrderID)
+			withdrawals, err := s.GetUserWithdrawals(ctx, session.UID)
+			require.NoError(t, err)
+			assert.Len(t, withdrawals, 1)
+			balance, withdrawn, err := s.GetUserBalance(ctx, session.UID)
+			require.NoError(t, err)
+			assert.Equal(t, float32(70), balance)
+			assert.Equal(t, float32(30), withdrawn)
+
+			// логин освободился, а логин удаленного пользователя занять нельзя
+			_, err = s.RegisterUser(ctx, login, password)
+			require.NoError(t, err)
+			_, err = s.RegisterUser(ctx, user.Login, password)
+			assert.ErrorIs(t, err, ErrReservedLogin)
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package gophermartservice
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/providers/accrual"
+)
+
+// CheckAccrual проверяет, что запросы к системе расчета начислений не заблокированы circuit breaker
+func (s GophermartService) CheckAccrual(_ context.Context) error {
+	if state := s.accrualProvider.CircuitState(); state == accrual.CircuitOpen {
+		return fmt.Errorf("%w: circuit %s", ErrAccrualUnavailable, state)
+	}
+	return nil
+}
+
+// CheckAccrualJobs проверяет, что число обрабатываемых заказов не превышает допустимое
+func (s GophermartService) CheckAccrualJobs(_ context.Context) error {
+	if jobs := s.accrualWorkers.count(); jobs >= s.accrualMaxJobs {
+		return fmt.Errorf("%w: %d of %d", ErrAccrualJobsSaturated, jobs, s.accrualMaxJobs)
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package gophermartservice
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/providers/accrual"
+)
+
+type circuitStateProvider struct {
+	accrual.Provider
+	state accrual.CircuitState
+}
+
+func (p circuitStateProvider) CircuitState() accrual.CircuitState {
+	return p.state
+}
+
+func TestGophermartService_CheckAccrual(t *testing.T) {
+	s := GophermartService{accrualProvider: circuitStateProvider{state: accrual.CircuitClosed}}
+	assert.NoError(t, s.CheckAccrual(context.Background()))
+
+	s.accrualProvider = circuitStateProvider{state: accrual.CircuitHalfOpen}
+	assert.NoError(t, s.CheckAccrual(context.Background()))
+
+	s.accrualProvider = circuitStateProvider{state: accrual.CircuitOpen}
+	assert.ErrorIs(t, s.CheckAccrual(context.Background()), ErrAccrualUnavailable)
+}
+
+func TestGophermartService_CheckAccrualJobs(t *testing.T) {
+	s := GophermartService{accrualWorkers: newAccrualWorkers(), accrualMaxJobs: 2}
+	assert.NoError(t, s.CheckAccrualJobs(context.Background()))
+
+	s.accrualWorkers.jobs = 2
+	assert.ErrorIs(t, s.CheckAccrualJobs(context.Background()), ErrAccrualJobsSaturated)
+}
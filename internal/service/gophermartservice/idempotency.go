@@ -0,0 +1,67 @@
+package gophermartservice
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/zaz600/go-musthave-diploma/internal/entity"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/idempotencyrepository"
+)
+
+// idempotencyKeyTTL время, в течение которого повтор запроса с тем же ключом возвращает сохраненный ответ
+const idempotencyKeyTTL = 24 * time.Hour
+
+// StartIdempotentRequest резервирует ключ идемпотентности за запросом.
+// Если ключ уже использовался для такого же запроса, возвращает запись с сохраненным ответом (record.Completed()).
+// Если ключ использовался для другого запроса - ErrIdempotencyKeyMismatch,
+// если запрос с этим ключом еще выполняется - ErrIdempotencyKeyInProgress
+func (s GophermartService) StartIdempotentRequest(ctx context.Context, userID string, key string, fingerprint string) (entity.IdempotencyRecord, error) {
+	record := entity.NewIdempotencyRecord(userID, key, fingerprint)
+	err := s.repo.IdempotencyRepo.AddRecord(ctx, record)
+	if err == nil {
+		return record, nil
+	}
+	if !errors.Is(err, idempotencyrepository.ErrRecordExists) {
+		return entity.IdempotencyRecord{}, err
+	}
+
+	existing, err := s.repo.IdempotencyRepo.GetRecord(ctx, userID, key)
+	if err != nil {
+		return entity.IdempotencyRecord{}, err
+	}
+	if time.Since(existing.CreatedAt) > idempotencyKeyTTL {
+		// ключ устарел, используем его заново
+		if err := s.repo.IdempotencyRepo.DelRecord(ctx, userID, key); err != nil && !errors.Is(err, idempotencyrepository.ErrRecordNotFound) {
+			return entity.IdempotencyRecord{}, err
+		}
+		if err := s.repo.IdempotencyRepo.AddRecord(ctx, record); err != nil {
+			if errors.Is(err, idempotencyrepository.ErrRecordExists) {
+				return entity.IdempotencyRecord{}, ErrIdempotencyKeyInProgress
+			}
+			return entity.IdempotencyRecord{}, err
+		}
+		return record, nil
+	}
+	if existing.Fingerprint != fingerprint {
+		return entity.IdempotencyRecord{}, ErrIdempotencyKeyMismatch
+	}
+	if !existing.Completed() {
+		return entity.IdempotencyRecord{}, ErrIdempotencyKeyInProgress
+	}
+	return existing, nil
+}
+
+// CompleteIdempotentRequest сохраняет ответ на запрос для последующих повторов
+func (s GophermartService) CompleteIdempotentRequest(ctx context.Context, record entity.IdempotencyRecord) error {
+	return s.repo.IdempotencyRepo.UpdateRecord(ctx, record)
+}
+
+// CancelIdempotentRequest освобождает ключ, если запрос не удалось выполнить и клиент может его повторить
+func (s GophermartService) CancelIdempotentRequest(ctx context.Context, userID string, key string) error {
+	err := s.repo.IdempotencyRepo.DelRecord(ctx, userID, key)
+	if errors.Is(err, idempotencyrepository.ErrRecordNotFound) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,84 @@
+package gophermartservice
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/metrics"
+)
+
+// RunJanitor удаляет устаревшие данные каждые janitorInterval, пока не будет отменен ctx:
+// сессии с истекшим JWT, ключи идемпотентности старше janitorIdempotencyRetention,
+// корзины лимитов, к которым не обращались дольше janitorRateLimitRetention,
+// и события outbox старше janitorEventRetention, уже опубликованные во все sinks
+func (s GophermartService) RunJanitor(ctx context.Context) {
+	ticker := time.NewTicker(s.janitorInterval)
+	defer ticker.Stop()
+	for {
+		s.cleanup(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// cleanup удаляет все данные, устаревшие к моменту now. Ошибка в одном виде данных не мешает остальным
+func (s GophermartService) cleanup(ctx context.Context, now time.Time) {
+	started := time.Now()
+	s.purge(ctx, metrics.JanitorSessions, func(limit int) (int, error) {
+		return s.repo.SessionRepo.DelSessionsCreatedBefore(ctx, now.Add(-s.sessionTTL), limit)
+	})
+	s.purge(ctx, metrics.JanitorIdempotencyKeys, func(limit int) (int, error) {
+		return s.repo.IdempotencyRepo.DelRecordsCreatedBefore(ctx, now.Add(-s.janitorIdempotencyRetention), limit)
+	})
+	s.purge(ctx, metrics.JanitorRateLimits, func(limit int) (int, error) {
+		return s.repo.RateLimitRepo.DelBucketsUpdatedBefore(ctx, now.Add(-s.rateLimitRetention()), limit)
+	})
+	sinks := s.eventSinkNames()
+	s.purge(ctx, metrics.JanitorEvents, func(limit int) (int, error) {
+		return s.repo.EventRepo.DelPublishedEventsCreatedBefore(ctx, sinks, now.Add(-s.janitorEventRetention), limit)
+	})
+	s.metrics.ObserveJanitorRun(time.Since(started))
+}
+
+// purge удаляет записи пачками по janitorBatchSize, чтобы не держать долгих блокировок,
+// пока очередная пачка не окажется неполной
+func (s GophermartService) purge(ctx context.Context, kind string, del func(limit int) (int, error)) {
+	removed := 0
+	var err error
+	for ctx.Err() == nil {
+		var n int
+		n, err = del(s.janitorBatchSize)
+		removed += n
+		if err != nil || n < s.janitorBatchSize {
+			break
+		}
+	}
+	s.metrics.ObserveJanitorCleanup(kind, removed, err)
+	if err != nil {
+		log.Err(err).Str("kind", kind).Int("removed", removed).Msg("janitor cleanup error")
+		return
+	}
+	if removed > 0 {
+		log.Info().Str("kind", kind).Int("removed", removed).Msg("janitor cleanup")
+	}
+}
+
+// rateLimitRetention корзина удаляется не раньше, чем гарантированно заполнится по самому длинному правилу
+// или лимиту попыток входа: иначе клиент получил бы полную корзину раньше времени
+func (s GophermartService) rateLimitRetention() time.Duration {
+	retention := s.janitorRateLimitRetention
+	for _, rule := range s.rateLimitRules.get() {
+		if rule.Limit.Period > retention {
+			retention = rule.Limit.Period
+		}
+	}
+	if period := s.loginLimit.get().Period; period > retention {
+		retention = period
+	}
+	return retention
+}
@@ -0,0 +1,101 @@
+package gophermartservice
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zaz600/go-musthave-diploma/internal/entity"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/idempotencyrepository"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/sessionrepository"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/metrics"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/random"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/ratelimit"
+)
+
+func TestGophermartService_Cleanup(t *testing.T) {
+	ctx := context.Background()
+	m := metrics.New()
+	rule := ratelimit.Rule{Method: "*", Route: "/api/user/*", Limit: ratelimit.Limit{Requests: 1, Period: 2 * time.Hour}}
+	s, err := New(nil, WithMemoryStorage(), WithMetrics(m), WithSessionTTL(time.Hour), WithJanitor(time.Hour, 2),
+		WithJanitorRetention(24*time.Hour, time.Hour, 48*time.Hour), WithRateLimitRules([]ratelimit.Rule{rule}))
+	require.NoError(t, err)
+	defer s.Shutdown()
+	now := time.Now()
+
+	session, err := s.RegisterUser(ctx, random.String(8), random.String(8))
+	require.NoError(t, err)
+	expired := make([]*entity.Session, 3)
+	for i := range expired {
+		expired[i] = entity.New(session.UID, entity.WithCreatedAt(now.Add(-2*time.Hour)))
+		require.NoError(t, s.repo.SessionRepo.AddSession(ctx, expired[i]))
+	}
+
+	used := entity.NewIdempotencyRecord(session.UID, random.String(16), random.String(32))
+	used.CreatedAt = now.Add(-25 * time.Hour)
+	require.NoError(t, s.repo.IdempotencyRepo.AddRecord(ctx, used))
+	fresh := entity.NewIdempotencyRecord(session.UID, random.String(16), random.String(32))
+	require.NoError(t, s.repo.IdempotencyRepo.AddRecord(ctx, fresh))
+
+	// корзина правила с периодом 2h хранится дольше janitor.rate_limit_retention
+	_, err = s.repo.RateLimitRepo.Take(ctx, "idle", rule.Limit, now.Add(-3*time.Hour))
+	require.NoError(t, err)
+	_, err = s.repo.RateLimitRepo.Take(ctx, "recent", rule.Limit, now.Add(-90*time.Minute))
+	require.NoError(t, err)
+
+	// старое событие удаляется, только когда опубликовано во все sinks
+	addOldEvent := func() entity.Event {
+		event, err := entity.NewEvent(entity.EventOrderUploaded, session.UID, entity.OrderEventPayload{OrderID: random.OrderID()})
+		require.NoError(t, err)
+		event.CreatedAt = now.Add(-49 * time.Hour)
+		require.NoError(t, s.repo.EventRepo.AddEvent(ctx, event))
+		return event
+	}
+	published := addOldEvent()
+	unpublished := addOldEvent()
+	events, err := s.repo.EventRepo.GetUnpublishedEvents(ctx, "webhooks", 100)
+	require.NoError(t, err)
+	for _, event := range events {
+		if event.EventID == published.EventID {
+			require.NoError(t, s.repo.EventRepo.MarkPublished(ctx, "webhooks", []int64{event.Seq}))
+		}
+	}
+
+	s.cleanup(ctx, now)
+
+	_, err = s.repo.SessionRepo.GetSession(ctx, session.SessionID)
+	require.NoError(t, err)
+	for _, session := range expired {
+		_, err = s.repo.SessionRepo.GetSession(ctx, session.SessionID)
+		assert.ErrorIs(t, err, sessionrepository.ErrSessionNotFound)
+	}
+	_, err = s.repo.IdempotencyRepo.GetRecord(ctx, used.UID, used.Key)
+	assert.ErrorIs(t, err, idempotencyrepository.ErrRecordNotFound)
+	_, err = s.repo.IdempotencyRepo.GetRecord(ctx, fresh.UID, fresh.Key)
+	require.NoError(t, err)
+	result, err := s.repo.RateLimitRepo.Take(ctx, "recent", rule.Limit, now)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed, "bucket is kept until refilled")
+
+	events, err = s.repo.EventRepo.GetUnpublishedEvents(ctx, "other", 100)
+	require.NoError(t, err)
+	eventIDs := make([]string, 0, len(events))
+	for _, event := range events {
+		eventIDs = append(eventIDs, event.EventID)
+	}
+	assert.NotContains(t, eventIDs, published.EventID)
+	assert.Contains(t, eventIDs, unpublished.EventID)
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+	assert.Contains(t, body, `gophermart_janitor_removed_total{kind="sessions"} 3`)
+	assert.Contains(t, body, `gophermart_janitor_removed_total{kind="idempotency_keys"} 1`)
+	assert.Contains(t, body, `gophermart_janitor_removed_total{kind="rate_limits"} 1`)
+	assert.Contains(t, body, `gophermart_janitor_removed_total{kind="events"} 1`)
+	assert.Contains(t, body, "gophermart_janitor_run_duration_seconds_count 1")
+}
@@ -0,0 +1,172 @@
+package gophermartservice
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zaz600/go-musthave-diploma/internal/entity"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/userrepository"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/random"
+)
+
+func newPersistentMemoryTestService(t *testing.T, path string) *GophermartService {
+	t.Helper()
+	s, err := New(nil, WithPersistentMemoryStorage(path, time.Hour))
+	require.NoError(t, err)
+	return s
+}
+
+func TestGophermartService_PersistentMemoryStorage(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "gophermart.log")
+	login, password := random.String(8), random.String(8)
+
+	s := newPersistentMemoryTestService(t, path)
+	session, err := s.RegisterUser(ctx, login, password)
+	require.NoError(t, err)
+	order := entity.NewOrder(session.UID, random.OrderID())
+	require.NoError(t, s.repo.OrderRepo.AddOrder(ctx, order))
+	require.NoError(t, s.repo.OrderRepo.SetOrderStatusAndAccrual(ctx, order.OrderID, entity.OrderStatusProcessed, 100))
+	require.NoError(t, s.repo.AccountRepo.RefillAmount(ctx, session.UID, 100))
+
+	// остановка с записью снимка
+	s.Shutdown()
+	s = newPersistentMemoryTestService(t, path)
+	require.NoError(t, s.UploadWithdrawal(ctx, session.UID, random.OrderID(), 30))
+	events, err := s.repo.EventRepo.GetUnpublishedEvents(ctx, "test", 10)
+	require.NoError(t, err)
+	require.NotEmpty(t, events)
+	require.NoError(t, s.repo.EventRepo.MarkPublished(ctx, "test", []int64{events[0].Seq}))
+
+	// аварийная остановка: снимок не записан, изменения восстанавливаются из журнала
+	s = newPersistentMemoryTestService(t, path)
+	defer s.Shutdown()
+
+	_, err = s.LoginUser(ctx, login, password)
+	require.NoError(t, err)
+	_, err = s.RegisterUser(ctx, login, password)
+	assert.ErrorIs(t, err, ErrUserExists)
+
+	saved, err := s.repo.OrderRepo.GetOrder(ctx, order.OrderID)
+	require.NoError(t, err)
+	assert.Equal(t, entity.OrderStatusProcessed, saved.Status)
+	assert.Equal(t, float32(100), saved.Accrual)
+
+	current, withdrawn, err := s.GetUserBalance(ctx, session.UID)
+	require.NoError(t, err)
+	assert.Equal(t, float32(70), current)
+	assert.Equal(t, float32(30), withdrawn)
+	withdrawals, err := s.GetUserWithdrawals(ctx, session.UID)
+	require.NoError(t, err)
+	assert.Len(t, withdrawals, 1)
+
+	unpublished, err := s.repo.EventRepo.GetUnpublishedEvents(ctx, "test", 10)
+	require.NoError(t, err)
+	assert.Len(t, unpublished, len(events)-1)
+
+	// номера событий продолжаются после восстановления
+	event, err := entity.NewEvent(entity.EventUserRegistered, session.UID, entity.UserEventPayload{Login: login})
+	require.NoError(t, err)
+	require.NoError(t, s.repo.EventRepo.AddEvent(ctx, event))
+	unpublished, err = s.repo.EventRepo.GetUnpublishedEvents(ctx, "test", 10)
+	require.NoError(t, err)
+	assert.Equal(t, events[len(events)-1].Seq+1, unpublished[len(unpublished)-1].Seq)
+}
+
+func TestGophermartService_PersistentMemorySessions(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "gophermart.log")
+	login, password := random.String(8), random.String(8)
+
+	s := newPersistentMemoryTestService(t, path)
+	registered, err := s.RegisterUser(ctx, login, password)
+	require.NoError(t, err)
+	loggedIn, err := s.LoginUser(ctx, login, password)
+	require.NoError(t, err)
+	lastSeen := time.Now().Add(time.Minute)
+	require.NoError(t, s.repo.SessionRepo.TouchSessions(ctx, map[string]time.Time{loggedIn.SessionID: lastSeen}))
+	require.NoError(t, s.DeleteUserSession(ctx, registered.UID, registered.SessionID))
+
+	// аварийная остановка: завершение сессии и время запроса восстанавливаются из журнала
+	s = newPersistentMemoryTestService(t, path)
+	defer s.Shutdown()
+
+	assert.ErrorIs(t, s.CheckSession(ctx, registered.UID, registered.SessionID), ErrSessionNotFound)
+	sessions, err := s.GetUserSessions(ctx, registered.UID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, loggedIn.SessionID, sessions[0].SessionID)
+	assert.True(t, lastSeen.Equal(sessions[0].LastSeenAt))
+}
+
+func TestGophermartService_PersistentMemoryDeleteUser(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "gophermart.log")
+	login, password := random.String(8), random.String(8)
+
+	s := newPersistentMemoryTestService(t, path)
+	session, err := s.RegisterUser(ctx, login, password)
+	require.NoError(t, err)
+	require.NoError(t, s.DeleteUser(ctx, session.UID, password))
+
+	// аварийная остановка: обезличивание пользователя восстанавливается из журнала
+	s = newPersistentMemoryTestService(t, path)
+	defer s.Shutdown()
+
+	_, err = s.LoginUser(ctx, login, password)
+	assert.ErrorIs(t, err, ErrAuth)
+	assert.ErrorIs(t, s.CheckSession(ctx, session.UID, session.SessionID), ErrSessionNotFound)
+	user, err := s.repo.UserRepo.GetUserByID(ctx, session.UID)
+	require.NoError(t, err)
+	assert.Equal(t, deletedLoginPrefix+session.UID, user.Login)
+	events, err := s.repo.EventRepo.GetUserEvents(ctx, session.UID, []entity.EventType{entity.EventUserRegistered})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.NotContains(t, string(events[0].Payload), login)
+}
+
+func TestGophermartService_PersistentMemoryRollback(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "gophermart.log")
+	errFailed := errors.New("failed")
+
+	s := newPersistentMemoryTestService(t, path)
+	session, err := s.RegisterUser(ctx, random.String(8), random.String(8))
+	require.NoError(t, err)
+	require.NoError(t, s.repo.AccountRepo.RefillAmount(ctx, session.UID, 100))
+
+	user := entity.NewUserEntity(random.String(8), random.String(8))
+	withdrawal := entity.NewWithdrawal(session.UID, random.OrderID(), 30)
+	err = s.repo.Transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		require.NoError(t, s.repo.UserRepo.AddUser(ctx, user))
+		require.NoError(t, s.repo.WithdrawalRepo.AddWithdrawal(ctx, withdrawal))
+		require.NoError(t, s.repo.AccountRepo.WithdrawalAmount(ctx, session.UID, 30))
+		require.NoError(t, s.repo.SessionRepo.DelSession(ctx, session.SessionID))
+		return errFailed
+	})
+	require.ErrorIs(t, err, errFailed)
+
+	check := func(s *GophermartService) {
+		_, err := s.repo.UserRepo.GetUser(ctx, user.Login)
+		assert.ErrorIs(t, err, userrepository.ErrUserNotFound)
+		withdrawals, err := s.GetUserWithdrawals(ctx, session.UID)
+		require.NoError(t, err)
+		assert.Empty(t, withdrawals)
+		current, withdrawn, err := s.GetUserBalance(ctx, session.UID)
+		require.NoError(t, err)
+		assert.Equal(t, float32(100), current)
+		assert.Zero(t, withdrawn)
+		assert.NoError(t, s.CheckSession(ctx, session.UID, session.SessionID))
+	}
+	check(s)
+
+	// аварийная остановка: откаченная транзакция не попала в журнал
+	s = newPersistentMemoryTestService(t, path)
+	defer s.Shutdown()
+	check(s)
+}
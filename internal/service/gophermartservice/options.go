@@ -4,71 +4,312 @@ import (
 	"database/sql"
 	"time"
 
+	"github.com/go-redis/redis/v8"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/eventsink"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/providers/accrual"
 	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository"
 	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/accountrepository"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/eventrepository"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/idempotencyrepository"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/memorystore"
 	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/orderrepository"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/pgdb"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/ratelimitrepository"
 	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/sessionrepository"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/transaction"
 	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/userrepository"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/webhookrepository"
 	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/withdrawalrepository"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/metrics"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/ratelimit"
 )
 
 type StorageType int
 
 type Option func(*GophermartService) error
 
+// WithMemoryStorage хранит данные в памяти до остановки сервиса.
+// При ошибке в транзакции репозитории отменяют уже примененные изменения
 func WithMemoryStorage() Option {
 	return func(s *GophermartService) error {
-		repo := repository.RepoRegistry{
-			UserRepo:       userrepository.NewInmemoryUserRepository(),
-			SessionRepo:    sessionrepository.NewInmemorySessionRepository(),
-			OrderRepo:      orderrepository.NewInmemoryOrderRepository(),
-			WithdrawalRepo: withdrawalrepository.NewInmemoryWithdrawalRepository(),
-			AccountRepo:    accountrepository.NewInmemoryAccountRepository(),
+		s.repo = newMemoryRegistry(nil)
+		return nil
+	}
+}
+
+// WithPersistentMemoryStorage хранит данные в памяти, а изменения записывает в журнал path.
+// Каждые snapshotInterval состояние записывается в снимок рядом с журналом. При запуске данные восстанавливаются.
+// Изменения транзакции записываются в журнал одной записью после ее успешного завершения
+func WithPersistentMemoryStorage(path string, snapshotInterval time.Duration) Option {
+	return func(s *GophermartService) error {
+		store := memorystore.New(path, memorystore.WithSnapshotInterval(snapshotInterval))
+		repo := newMemoryRegistry(store)
+		if err := store.Load(); err != nil {
+			return err
 		}
+		repo.Store = store
 		s.repo = repo
 		return nil
 	}
 }
 
-func WithPgStorage(db *sql.DB) Option {
+// newMemoryRegistry in-memory репозитории. Если store не nil, репозитории регистрируются в нем.
+// Корзины лимитов частоты запросов не сохраняются
+func newMemoryRegistry(store *memorystore.Store) repository.RepoRegistry {
+	userRepo := userrepository.NewInmemoryUserRepository()
+	sessionRepo := sessionrepository.NewInmemorySessionRepository()
+	orderRepo := orderrepository.NewInmemoryOrderRepository()
+	withdrawalRepo := withdrawalrepository.NewInmemoryWithdrawalRepository()
+	accountRepo := accountrepository.NewInmemoryAccountRepository()
+	eventRepo := eventrepository.NewInmemoryEventRepository()
+	webhookRepo := webhookrepository.NewInmemoryWebhookRepository()
+	idempotencyRepo := idempotencyrepository.NewInmemoryIdempotencyRepository()
+
+	if store != nil {
+		store.Register("users", userRepo)
+		store.Register("sessions", sessionRepo)
+		store.Register("orders", orderRepo)
+		store.Register("withdrawals", withdrawalRepo)
+		store.Register("accounts", accountRepo)
+		store.Register("events", eventRepo)
+		store.Register("webhooks", webhookRepo)
+		store.Register("idempotency_keys", idempotencyRepo)
+	}
+
+	return repository.RepoRegistry{
+		UserRepo:        userRepo,
+		SessionRepo:     sessionRepo,
+		OrderRepo:       orderRepo,
+		WithdrawalRepo:  withdrawalRepo,
+		AccountRepo:     accountRepo,
+		EventRepo:       eventRepo,
+		WebhookRepo:     webhookRepo,
+		IdempotencyRepo: idempotencyRepo,
+		RateLimitRepo:   ratelimitrepository.NewInmemoryRateLimitRepository(),
+		Transactor:      transaction.NewInmemoryTransactor(store),
+	}
+}
+
+// WithPgStorage хранит данные в Postgres, к которому уже применены миграции
+func WithPgStorage(db *pgdb.Pool) Option {
+	return func(s *GophermartService) error {
+		s.repo = repository.RepoRegistry{
+			UserRepo:        userrepository.NewPgUserRepository(db),
+			SessionRepo:     sessionrepository.NewPgSessionRepository(db),
+			OrderRepo:       orderrepository.NewPgOrderRepository(db),
+			WithdrawalRepo:  withdrawalrepository.NewPgWithdrawalRepository(db),
+			AccountRepo:     accountrepository.NewPgAccountRepository(db),
+			EventRepo:       eventrepository.NewPgEventRepository(db),
+			WebhookRepo:     webhookrepository.NewPgWebhookRepository(db),
+			IdempotencyRepo: idempotencyrepository.NewPgIdempotencyRepository(db),
+			RateLimitRepo:   ratelimitrepository.NewPgRateLimitRepository(db),
+			Transactor:      transaction.NewPgTransactor(db),
+		}
+		return nil
+	}
+}
+
+// WithSQLiteStorage хранит данные в файле SQLite, к которому уже применены миграции
+func WithSQLiteStorage(db *sql.DB) Option {
 	return func(s *GophermartService) error {
-		accrualRepo, err := accountrepository.NewPgAccountRepository(db)
+		accountRepo, err := accountrepository.NewSQLiteAccountRepository(db)
 		if err != nil {
 			return err
 		}
-		orderRepo, err := orderrepository.NewPgOrderRepository(db)
+		orderRepo, err := orderrepository.NewSQLiteOrderRepository(db)
 		if err != nil {
 			return err
 		}
-		sessionRepo, err := sessionrepository.NewPgSessionRepository(db)
+		sessionRepo, err := sessionrepository.NewSQLiteSessionRepository(db)
 		if err != nil {
 			return err
 		}
-		userRepo, err := userrepository.NewPgUserRepository(db)
+		userRepo, err := userrepository.NewSQLiteUserRepository(db)
 		if err != nil {
 			return err
 		}
-
-		withdrawalRepo, err := withdrawalrepository.NewPgUserRepository(db)
+		withdrawalRepo, err := withdrawalrepository.NewSQLiteWithdrawalRepository(db)
+		if err != nil {
+			return err
+		}
+		eventRepo, err := eventrepository.NewSQLiteEventRepository(db)
+		if err != nil {
+			return err
+		}
+		webhookRepo, err := webhookrepository.NewSQLiteWebhookRepository(db)
+		if err != nil {
+			return err
+		}
+		idempotencyRepo, err := idempotencyrepository.NewSQLiteIdempotencyRepository(db)
+		if err != nil {
+			return err
+		}
+		rateLimitRepo, err := ratelimitrepository.NewSQLiteRateLimitRepository(db)
 		if err != nil {
 			return err
 		}
 
-		repo := repository.RepoRegistry{
-			UserRepo:       userRepo,
-			SessionRepo:    sessionRepo,
-			OrderRepo:      orderRepo,
-			WithdrawalRepo: withdrawalRepo,
-			AccountRepo:    accrualRepo,
+		s.repo = repository.RepoRegistry{
+			UserRepo:        userRepo,
+			SessionRepo:     sessionRepo,
+			OrderRepo:       orderRepo,
+			WithdrawalRepo:  withdrawalRepo,
+			AccountRepo:     accountRepo,
+			EventRepo:       eventRepo,
+			WebhookRepo:     webhookRepo,
+			IdempotencyRepo: idempotencyRepo,
+			RateLimitRepo:   rateLimitRepo,
+			Transactor:      transaction.NewSQLiteTransactor(db),
 		}
-		s.repo = repo
+		return nil
+	}
+}
+
+// WithRedisSessions хранит сессии в Redis независимо от хранилища остальных данных.
+// ttl - время жизни JWT, после которого сессия удаляется
+func WithRedisSessions(client *redis.Client, ttl time.Duration) Option {
+	return func(s *GophermartService) error {
+		s.sessionRepo = sessionrepository.NewRedisSessionRepository(client, ttl)
+		s.sessionTTL = ttl
+		return nil
+	}
+}
+
+// WithSessionTTL задает время жизни JWT, после которого janitor удаляет сессию
+func WithSessionTTL(ttl time.Duration) Option {
+	return func(s *GophermartService) error {
+		s.sessionTTL = ttl
+		return nil
+	}
+}
+
+// WithSessionActivityInterval задает, как часто время последнего запроса сессий записывается в репозиторий
+func WithSessionActivityInterval(interval time.Duration) Option {
+	return func(s *GophermartService) error {
+		s.sessionActivityInterval = interval
 		return nil
 	}
 }
 
 func WithAccrualRetryInterval(interval time.Duration) Option {
 	return func(s *GophermartService) error {
-		s.accrualRetryInterval = interval
+		_, maxRetries := s.accrualRetry.get()
+		s.accrualRetry.set(interval, maxRetries)
+		return nil
+	}
+}
+
+// WithAccrualMaxRetries задает, после скольких повторных запросов заказ переводится в TOO_MANY_RETRIES
+func WithAccrualMaxRetries(retries int) Option {
+	return func(s *GophermartService) error {
+		interval, _ := s.accrualRetry.get()
+		s.accrualRetry.set(interval, retries)
+		return nil
+	}
+}
+
+// WithAccrualRateLimit задает, сколько запросов в минуту можно отправить в систему расчета начислений
+func WithAccrualRateLimit(perMinute int) Option {
+	return func(s *GophermartService) error {
+		s.accrualOptions = append(s.accrualOptions, accrual.WithRateLimit(perMinute))
+		return nil
+	}
+}
+
+// WithAccrualCircuitBreaker задает, после скольких ошибок подряд запросы в систему расчета начислений
+// перестают отправляться и через сколько выполняется пробный запрос
+func WithAccrualCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(s *GophermartService) error {
+		s.accrualOptions = append(s.accrualOptions, accrual.WithCircuitBreaker(threshold, cooldown))
+		return nil
+	}
+}
+
+// WithPasswordHashCost задает сложность bcrypt для паролей новых пользователей
+func WithPasswordHashCost(cost int) Option {
+	return func(s *GophermartService) error {
+		s.passwordHashCost = cost
+		return nil
+	}
+}
+
+// WithRateLimitRules задает лимиты частоты запросов к API. Без правил запросы не ограничиваются
+func WithRateLimitRules(rules []ratelimit.Rule) Option {
+	return func(s *GophermartService) error {
+		s.rateLimitRules.set(rules)
+		return nil
+	}
+}
+
+// WithAccrualMaxJobs задает число одновременно обрабатываемых заказов,
+// после которого сервис считается перегруженным и не готовым принимать запросы
+func WithAccrualMaxJobs(jobs int64) Option {
+	return func(s *GophermartService) error {
+		s.accrualMaxJobs = jobs
+		return nil
+	}
+}
+
+func WithWebhookPollInterval(interval time.Duration) Option {
+	return func(s *GophermartService) error {
+		s.webhookPollInterval = interval
+		return nil
+	}
+}
+
+func WithWebhookRetryInterval(interval time.Duration) Option {
+	return func(s *GophermartService) error {
+		s.webhookRetryInterval = interval
+		return nil
+	}
+}
+
+func WithWebhookMaxAttempts(attempts int) Option {
+	return func(s *GophermartService) error {
+		s.webhookMaxAttempts = attempts
+		return nil
+	}
+}
+
+// WithEventSinks добавляет получателей доменных событий из outbox
+func WithEventSinks(sinks ...eventsink.Sink) Option {
+	return func(s *GophermartService) error {
+		s.eventSinks = append(s.eventSinks, sinks...)
+		return nil
+	}
+}
+
+func WithEventRelayInterval(interval time.Duration) Option {
+	return func(s *GophermartService) error {
+		s.eventRelayInterval = interval
+		return nil
+	}
+}
+
+// WithJanitor задает, как часто удаляются устаревшие данные и сколько записей удаляется за один запрос
+func WithJanitor(interval time.Duration, batchSize int) Option {
+	return func(s *GophermartService) error {
+		s.janitorInterval = interval
+		s.janitorBatchSize = batchSize
+		return nil
+	}
+}
+
+// WithJanitorRetention задает, сколько хранятся ключи идемпотентности после создания,
+// корзины лимитов частоты запросов после последнего обращения и опубликованные во все sinks события outbox
+func WithJanitorRetention(idempotencyKeys time.Duration, rateLimits time.Duration, events time.Duration) Option {
+	return func(s *GophermartService) error {
+		s.janitorIdempotencyRetention = idempotencyKeys
+		s.janitorRateLimitRetention = rateLimits
+		s.janitorEventRetention = events
+		return nil
+	}
+}
+
+// WithMetrics включает сбор метрик обработки начислений
+func WithMetrics(m *metrics.Metrics) Option {
+	return func(s *GophermartService) error {
+		s.metrics = m
 		return nil
 	}
 }
@@ -11,13 +11,24 @@ import (
 	"github.com/zaz600/go-musthave-diploma/internal/pkg/luhn"
 )
 
-func (s GophermartService) UploadOrder(ctx context.Context, userID string, orderID string) error {
+func (s GophermartService) UploadOrder(ctx context.Context, userID string, orderID string) (err error) {
+	ctx, span := startSpan(ctx, "UploadOrder", attrUserID.String(userID), attrOrderID.String(orderID))
+	defer func() { endSpan(span, err) }()
+
 	if ok := luhn.CheckLuhn(orderID); !ok {
 		return ErrInvalidOrderFormat
 	}
 
 	order := entity.NewOrder(userID, orderID)
-	err := s.repo.OrderRepo.AddOrder(ctx, order)
+	err = s.repo.Transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.OrderRepo.AddOrder(ctx, order); err != nil {
+			return err
+		}
+		return s.addEvent(ctx, entity.EventOrderUploaded, userID, entity.OrderEventPayload{
+			OrderID: order.OrderID,
+			Status:  order.Status,
+		})
+	})
 	if err != nil {
 		if errors.Is(err, orderrepository.ErrOrderExists) {
 			order, err := s.repo.OrderRepo.GetOrder(ctx, orderID)
@@ -34,7 +45,10 @@ func (s GophermartService) UploadOrder(ctx context.Context, userID string, order
 	return nil
 }
 
-func (s GophermartService) GetUserOrders(ctx context.Context, userID string) ([]entity.Order, error) {
+func (s GophermartService) GetUserOrders(ctx context.Context, userID string) (_ []entity.Order, err error) {
+	ctx, span := startSpan(ctx, "GetUserOrders", attrUserID.String(userID))
+	defer func() { endSpan(span, err) }()
+
 	// TODO обработать ошибки и завернуть их
 	orders, err := s.repo.OrderRepo.GetUserOrders(ctx, userID)
 	if err != nil {
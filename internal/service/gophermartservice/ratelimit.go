@@ -0,0 +1,82 @@
+package gophermartservice
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/ratelimit"
+)
+
+// TakeRateLimit списывает токен из корзины клиента для правила, подходящего к запросу.
+// client - идентификатор пользователя или IP. Если ни одно правило не подходит, ok=false.
+// Ошибка хранилища не блокирует запрос: лимит пропускается, чтобы не отказывать всем клиентам сразу
+func (s GophermartService) TakeRateLimit(ctx context.Context, method string, path string, client string) (ratelimit.Result, bool) {
+	rule, ok := ratelimit.Match(s.rateLimitRules.get(), method, path)
+	if !ok {
+		return ratelimit.Result{}, false
+	}
+	result, err := s.repo.RateLimitRepo.Take(ctx, rule.Name()+"|"+client, rule.Limit, time.Now())
+	if err != nil {
+		log.Ctx(ctx).Warn().Err(err).Str("rule", rule.String()).Msg("rate limit check failed")
+		return ratelimit.Result{}, false
+	}
+	return result, true
+}
+
+// rateLimitRules правила ограничения частоты запросов, меняются при перезагрузке конфигурации
+type rateLimitRules struct {
+	mu    sync.RWMutex
+	rules []ratelimit.Rule
+}
+
+func (r *rateLimitRules) get() []ratelimit.Rule {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.rules
+}
+
+func (r *rateLimitRules) set(rules []ratelimit.Rule) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rules = rules
+}
+
+// loginLimit лимит попыток входа под одним логином, меняется при перезагрузке конфигурации
+type loginLimit struct {
+	mu    sync.RWMutex
+	limit ratelimit.Limit
+}
+
+func (l *loginLimit) get() ratelimit.Limit {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.limit
+}
+
+func (l *loginLimit) set(limit ratelimit.Limit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limit = limit
+}
+
+// takeLoginAttempt списывает попытку входа под логином. В отличие от правил ratelimit попытки считаются
+// по логину, поэтому подбор пароля с разных адресов тоже ограничивается.
+// Ошибка хранилища, как и в TakeRateLimit, не блокирует вход
+func (s GophermartService) takeLoginAttempt(ctx context.Context, login string) error {
+	limit := s.loginLimit.get()
+	if limit.Requests == 0 {
+		return nil
+	}
+	result, err := s.repo.RateLimitRepo.Take(ctx, "login|"+login, limit, time.Now())
+	if err != nil {
+		log.Ctx(ctx).Warn().Err(err).Str("limit", limit.String()).Msg("login limit check failed")
+		return nil
+	}
+	if !result.Allowed {
+		return fmt.Errorf("%w: retry after %s", ErrLoginThrottled, result.RetryAfter.Round(time.Second))
+	}
+	return nil
+}
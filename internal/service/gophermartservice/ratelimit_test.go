@@ -0,0 +1,66 @@
+package gophermartservice
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/ratelimit"
+)
+
+func TestGophermartService_TakeRateLimit(t *testing.T) {
+	ctx := context.Background()
+	rules, err := ratelimit.ParseRules("POST /api/user/orders=1/1h,* /api/user/*=2/1h")
+	require.NoError(t, err)
+	s, err := New(nil, WithMemoryStorage(), WithRateLimitRules(rules))
+	require.NoError(t, err)
+
+	result, ok := s.TakeRateLimit(ctx, "POST", "/api/user/orders", "user:1")
+	require.True(t, ok)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 1, result.Limit)
+
+	result, ok = s.TakeRateLimit(ctx, "POST", "/api/user/orders", "user:1")
+	require.True(t, ok)
+	assert.False(t, result.Allowed)
+
+	// у другого клиента и другого правила свои корзины
+	result, _ = s.TakeRateLimit(ctx, "POST", "/api/user/orders", "user:2")
+	assert.True(t, result.Allowed)
+	result, _ = s.TakeRateLimit(ctx, "GET", "/api/user/orders", "user:1")
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 2, result.Limit)
+
+	_, ok = s.TakeRateLimit(ctx, "GET", "/ping", "user:1")
+	assert.False(t, ok)
+}
+
+func TestGophermartService_LoginLimit(t *testing.T) {
+	ctx := context.Background()
+	s, err := New(nil, WithMemoryStorage())
+	require.NoError(t, err)
+	_, err = s.RegisterUser(ctx, "user1", "secret")
+	require.NoError(t, err)
+
+	settings := validSettings()
+	settings.LoginLimit = ratelimit.Limit{Requests: 2, Period: time.Hour}
+	s.ApplySettings(settings)
+
+	_, err = s.LoginUser(ctx, "user1", "wrong")
+	assert.ErrorIs(t, err, ErrAuth)
+	_, err = s.LoginUser(ctx, "user1", "secret")
+	assert.NoError(t, err)
+	_, err = s.LoginUser(ctx, "user1", "secret")
+	assert.ErrorIs(t, err, ErrLoginThrottled)
+
+	// попытки считаются по логину
+	_, err = s.LoginUser(ctx, "user2", "secret")
+	assert.ErrorIs(t, err, ErrAuth)
+
+	// нулевой лимит выключает ограничение
+	s.ApplySettings(validSettings())
+	_, err = s.LoginUser(ctx, "user1", "secret")
+	assert.NoError(t, err)
+}
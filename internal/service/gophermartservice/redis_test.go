@@ -0,0 +1,72 @@
+package gophermartservice
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/transaction"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/random"
+)
+
+func TestGophermartService_RedisSessions(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+
+	// порядок опций не важен: сессии хранятся в Redis при любом хранилище
+	s, err := New(nil, WithRedisSessions(client, time.Hour), WithMemoryStorage())
+	require.NoError(t, err)
+	defer s.Shutdown()
+
+	login, password := random.String(8), random.String(8)
+	registered, err := s.RegisterUser(ctx, login, password)
+	require.NoError(t, err)
+	loggedIn, err := s.LoginUser(ctx, login, password)
+	require.NoError(t, err)
+
+	for _, session := range []string{registered.SessionID, loggedIn.SessionID} {
+		assert.True(t, server.Exists("gophermart:session:"+session), session)
+		got, err := s.repo.SessionRepo.GetSession(ctx, session)
+		require.NoError(t, err)
+		assert.Equal(t, registered.UID, got.UID)
+	}
+}
+
+// failingCommitTransactor выполняет транзакцию, но не может ее зафиксировать
+type failingCommitTransactor struct {
+	transaction.Transactor
+}
+
+func (t failingCommitTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return t.Transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := fn(ctx); err != nil {
+			return err
+		}
+		return errCommitFailed
+	})
+}
+
+var errCommitFailed = errors.New("commit failed")
+
+func TestGophermartService_RedisSessionsRegisterCommitFailed(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+
+	s, err := New(nil, WithMemoryStorage(), WithRedisSessions(client, time.Hour))
+	require.NoError(t, err)
+	defer s.Shutdown()
+	s.repo.Transactor = failingCommitTransactor{Transactor: s.repo.Transactor}
+
+	_, err = s.RegisterUser(ctx, random.String(8), random.String(8))
+	require.ErrorIs(t, err, errCommitFailed)
+	assert.Empty(t, server.Keys())
+}
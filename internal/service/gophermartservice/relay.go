@@ -0,0 +1,111 @@
+package gophermartservice
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zaz600/go-musthave-diploma/internal/entity"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/eventsink"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/webhookrepository"
+)
+
+const eventRelayBatchSize = 100
+
+// RunEventRelay публикует события из outbox во все настроенные sinks, пока не будет отменен ctx.
+// Каждый sink обрабатывается независимо: событие помечается опубликованным для sink
+// только после успешного Publish, поэтому доставка выполняется как минимум один раз
+func (s GophermartService) RunEventRelay(ctx context.Context) {
+	wg := sync.WaitGroup{}
+	for _, sink := range s.eventSinks {
+		wg.Add(1)
+		go func(sink eventsink.Sink) {
+			defer wg.Done()
+			s.runSinkRelay(ctx, sink, s.eventRelayInterval)
+		}(sink)
+	}
+	wg.Wait()
+}
+
+func (s GophermartService) runSinkRelay(ctx context.Context, sink eventsink.Sink, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := s.relayEvents(ctx, sink); err != nil {
+			log.Err(err).Str("sink", sink.Name()).Msg("event relay error")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// eventSinkNames имена всех sinks, в которые публикуются события, включая раскладку по вебхукам
+func (s GophermartService) eventSinkNames() []string {
+	names := []string{webhookSink{}.Name()}
+	for _, sink := range s.eventSinks {
+		names = append(names, sink.Name())
+	}
+	return names
+}
+
+// relayEvents публикует в sink все накопившиеся события пачками
+func (s GophermartService) relayEvents(ctx context.Context, sink eventsink.Sink) error {
+	for {
+		events, err := s.repo.EventRepo.GetUnpublishedEvents(ctx, sink.Name(), eventRelayBatchSize)
+		if err != nil || len(events) == 0 {
+			return err
+		}
+		if err := sink.Publish(ctx, events); err != nil {
+			return err
+		}
+
+		seqs := make([]int64, 0, len(events))
+		for _, event := range events {
+			seqs = append(seqs, event.Seq)
+		}
+		if err := s.repo.EventRepo.MarkPublished(ctx, sink.Name(), seqs); err != nil {
+			return err
+		}
+		if len(events) < eventRelayBatchSize {
+			return nil
+		}
+	}
+}
+
+// webhookSink раскладывает события по доставкам для подписчиков вебхуков
+type webhookSink struct {
+	repo webhookrepository.WebhookRepository
+}
+
+func (w webhookSink) Name() string {
+	return "webhooks"
+}
+
+func (w webhookSink) Publish(ctx context.Context, events []entity.Event) error {
+	subscribers, err := w.repo.GetSubscribers(ctx)
+	if err != nil {
+		return err
+	}
+	for _, event := range events {
+		for _, subscriber := range subscribers {
+			if !subscriber.Subscribed(event.Type) {
+				continue
+			}
+			err := w.repo.AddDelivery(ctx, entity.NewWebhookDelivery(subscriber.ID, event))
+			if err != nil && !errors.Is(err, webhookrepository.ErrDeliveryExists) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (w webhookSink) Close() error {
+	return nil
+}
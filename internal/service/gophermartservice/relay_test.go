@@ -0,0 +1,84 @@
+package gophermartservice
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zaz600/go-musthave-diploma/internal/entity"
+)
+
+type stubSink struct {
+	name   string
+	fail   bool
+	events []entity.Event
+}
+
+func (s *stubSink) Name() string {
+	return s.name
+}
+
+func (s *stubSink) Publish(_ context.Context, events []entity.Event) error {
+	if s.fail {
+		return errors.New("sink unavailable")
+	}
+	s.events = append(s.events, events...)
+	return nil
+}
+
+func (s *stubSink) Close() error {
+	return nil
+}
+
+func TestGophermartService_relayEvents(t *testing.T) {
+	ctx := context.Background()
+	s := GophermartService{}
+	require.NoError(t, WithMemoryStorage()(&s))
+
+	for i := 0; i < eventRelayBatchSize+5; i++ {
+		require.NoError(t, s.addEvent(ctx, entity.EventOrderUploaded, "uid", entity.OrderEventPayload{OrderID: "1"}))
+	}
+
+	healthy := &stubSink{name: "healthy"}
+	broken := &stubSink{name: "broken", fail: true}
+
+	require.NoError(t, s.relayEvents(ctx, healthy))
+	assert.Error(t, s.relayEvents(ctx, broken))
+	assert.Len(t, healthy.events, eventRelayBatchSize+5)
+	assert.Equal(t, int64(1), healthy.events[0].Seq)
+
+	// повторный запуск не публикует события еще раз
+	require.NoError(t, s.relayEvents(ctx, healthy))
+	assert.Len(t, healthy.events, eventRelayBatchSize+5)
+
+	// после восстановления sink получает все пропущенные события
+	broken.fail = false
+	require.NoError(t, s.relayEvents(ctx, broken))
+	assert.Len(t, broken.events, eventRelayBatchSize+5)
+
+	events, err := s.repo.EventRepo.GetUnpublishedEvents(ctx, "new", 10)
+	require.NoError(t, err)
+	assert.Len(t, events, 10)
+}
+
+func TestGophermartService_EventsInTransaction(t *testing.T) {
+	ctx := context.Background()
+	s := GophermartService{features: &featureFlags{}}
+	require.NoError(t, WithMemoryStorage()(&s))
+
+	session, err := s.RegisterUser(ctx, "user", "password")
+	require.NoError(t, err)
+	_, err = s.RegisterUser(ctx, "user", "password")
+	require.ErrorIs(t, err, ErrUserExists)
+	require.NoError(t, s.UploadOrder(ctx, session.UID, "12345678903"))
+	require.ErrorIs(t, s.UploadOrder(ctx, session.UID, "12345678903"), ErrOrderExists)
+
+	sink := &stubSink{name: "test"}
+	require.NoError(t, s.relayEvents(ctx, sink))
+	require.Len(t, sink.events, 2)
+	assert.Equal(t, entity.EventUserRegistered, sink.events[0].Type)
+	assert.Equal(t, entity.EventOrderUploaded, sink.events[1].Type)
+	assert.Equal(t, session.UID, sink.events[1].UID)
+}
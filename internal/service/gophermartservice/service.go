@@ -1,35 +1,122 @@
 package gophermartservice
 
 import (
+	"net/http"
 	"time"
 
 	Accrual "github.com/zaz600/go-musthave-diploma/api/accrual"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/eventsink"
 	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/providers/accrual"
 	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/sessionrepository"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/hasher"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/metrics"
 )
 
-const accrualDefaultRetryInterval = 50 * time.Millisecond
+const (
+	accrualDefaultRetryInterval = 50 * time.Millisecond
+	accrualDefaultMaxJobs       = 10000
+	accrualDefaultMaxRetries    = 5
+
+	webhookDefaultPollInterval  = 1 * time.Second
+	webhookDefaultRetryInterval = 5 * time.Second
+	webhookDefaultMaxAttempts   = 10
+	webhookDefaultTimeout       = 5 * time.Second
+
+	eventRelayDefaultInterval = 1 * time.Second
+
+	sessionActivityDefaultInterval = 1 * time.Minute
+	sessionActivityFlushTimeout    = 5 * time.Second
+	sessionDefaultTTL              = 24 * time.Hour
+
+	janitorDefaultInterval             = 10 * time.Minute
+	janitorDefaultBatchSize            = 1000
+	janitorDefaultIdempotencyRetention = 24 * time.Hour
+	janitorDefaultRateLimitRetention   = 1 * time.Hour
+	janitorDefaultEventRetention       = 7 * 24 * time.Hour
+)
 
 type GophermartService struct {
 	repo repository.RepoRegistry
+	// sessionRepo заменяет репозиторий сессий хранилища, если сессии хранятся отдельно
+	sessionRepo sessionrepository.SessionRepository
+
+	accrualProvider accrual.Provider
+	accrualRetry    *retryPolicy
+	accrualWorkers  *accrualWorkers
+	accrualMaxJobs  int64
+	accrualOptions  []accrual.Option
+
+	passwordHashCost int
+
+	sessionActivity         *sessionActivity
+	sessionActivityInterval time.Duration
+	// sessionTTL время жизни JWT сессии, после которого она удаляется
+	sessionTTL time.Duration
 
-	accrualProvider      accrual.Provider
-	accrualRetryInterval time.Duration
+	rateLimitRules *rateLimitRules
+	loginLimit     *loginLimit
+	features       *featureFlags
+
+	webhookClient        *http.Client
+	webhookPollInterval  time.Duration
+	webhookRetryInterval time.Duration
+	webhookMaxAttempts   int
+
+	eventSinks         []eventsink.Sink
+	eventRelayInterval time.Duration
+
+	janitorInterval             time.Duration
+	janitorBatchSize            int
+	janitorIdempotencyRetention time.Duration
+	janitorRateLimitRetention   time.Duration
+	janitorEventRetention       time.Duration
+
+	metrics *metrics.Metrics
 }
 
 func (s GophermartService) Shutdown() {
+	for _, sink := range s.eventSinks {
+		_ = sink.Close()
+	}
 	s.repo.Close()
 }
 
 func New(accrualAPIClient Accrual.ClientWithResponsesInterface, opts ...Option) (*GophermartService, error) {
 	s := &GophermartService{
-		accrualProvider:      accrual.NewProvider(accrualAPIClient),
-		accrualRetryInterval: accrualDefaultRetryInterval,
+		accrualRetry:            newRetryPolicy(accrualDefaultRetryInterval, accrualDefaultMaxRetries),
+		accrualWorkers:          newAccrualWorkers(),
+		accrualMaxJobs:          accrualDefaultMaxJobs,
+		passwordHashCost:        hasher.DefaultCost,
+		sessionActivity:         newSessionActivity(),
+		sessionActivityInterval: sessionActivityDefaultInterval,
+		sessionTTL:              sessionDefaultTTL,
+		rateLimitRules:          &rateLimitRules{},
+		loginLimit:              &loginLimit{},
+		features:                &featureFlags{},
+		webhookClient:           &http.Client{Timeout: webhookDefaultTimeout},
+		webhookPollInterval:     webhookDefaultPollInterval,
+		webhookRetryInterval:    webhookDefaultRetryInterval,
+		webhookMaxAttempts:      webhookDefaultMaxAttempts,
+		eventRelayInterval:      eventRelayDefaultInterval,
+
+		janitorInterval:             janitorDefaultInterval,
+		janitorBatchSize:            janitorDefaultBatchSize,
+		janitorIdempotencyRetention: janitorDefaultIdempotencyRetention,
+		janitorRateLimitRetention:   janitorDefaultRateLimitRetention,
+		janitorEventRetention:       janitorDefaultEventRetention,
 	}
 	for _, opt := range opts {
 		if err := opt(s); err != nil {
 			return nil, err
 		}
 	}
+	if s.sessionRepo != nil {
+		s.repo.SessionRepo = s.sessionRepo
+	}
+	s.accrualProvider = accrual.NewProvider(accrualAPIClient, append(s.accrualOptions, accrual.WithMetrics(s.metrics))...)
+	if err := s.metrics.RegisterPendingAccrualJobs(s.countPendingAccrualJobs); err != nil {
+		return nil, err
+	}
 	return s, nil
 }
@@ -0,0 +1,115 @@
+package gophermartservice
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zaz600/go-musthave-diploma/internal/entity"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/sessionrepository"
+)
+
+// sessionActivity время последнего запроса сессий, которое еще не записано в репозиторий
+type sessionActivity struct {
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+func newSessionActivity() *sessionActivity {
+	return &sessionActivity{seen: make(map[string]time.Time)}
+}
+
+func (a *sessionActivity) touch(sessionID string, at time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.seen[sessionID] = at
+}
+
+// take возвращает накопленное время запросов и начинает копить заново
+func (a *sessionActivity) take() map[string]time.Time {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	seen := a.seen
+	a.seen = make(map[string]time.Time, len(seen))
+	return seen
+}
+
+// CheckSession проверяет, что сессия из токена не завершена, и запоминает время запроса.
+// Время последнего запроса записывается в репозиторий пакетами в RunSessionActivity
+func (s GophermartService) CheckSession(ctx context.Context, uid string, sessionID string) error {
+	session, err := s.repo.SessionRepo.GetSession(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, sessionrepository.ErrSessionNotFound) {
+			return ErrSessionNotFound
+		}
+		return err
+	}
+	if session.UID != uid {
+		return ErrSessionNotFound
+	}
+	s.sessionActivity.touch(sessionID, time.Now())
+	return nil
+}
+
+// GetUserSessions сессии пользователя в порядке создания
+func (s GophermartService) GetUserSessions(ctx context.Context, uid string) (_ []*entity.Session, err error) {
+	ctx, span := startSpan(ctx, "GetUserSessions")
+	defer func() { endSpan(span, err) }()
+
+	return s.repo.SessionRepo.GetUserSessions(ctx, uid)
+}
+
+// DeleteUserSession завершает сессию пользователя. Токен сессии перестает приниматься сразу
+func (s GophermartService) DeleteUserSession(ctx context.Context, uid string, sessionID string) (err error) {
+	ctx, span := startSpan(ctx, "DeleteUserSession")
+	defer func() { endSpan(span, err) }()
+
+	session, err := s.repo.SessionRepo.GetSession(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, sessionrepository.ErrSessionNotFound) {
+			return ErrSessionNotFound
+		}
+		return err
+	}
+	// чужая сессия для пользователя не отличается от несуществующей
+	if session.UID != uid {
+		return ErrSessionNotFound
+	}
+	if err := s.repo.SessionRepo.DelSession(ctx, sessionID); err != nil {
+		if errors.Is(err, sessionrepository.ErrSessionNotFound) {
+			return ErrSessionNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// RunSessionActivity каждые sessionActivityInterval записывает время последнего запроса сессий, пока не будет отменен ctx.
+// Накопленное к остановке время записывается перед выходом
+func (s GophermartService) RunSessionActivity(ctx context.Context) {
+	ticker := time.NewTicker(s.sessionActivityInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), sessionActivityFlushTimeout)
+			s.flushSessionActivity(flushCtx)
+			cancel()
+			return
+		case <-ticker.C:
+			s.flushSessionActivity(ctx)
+		}
+	}
+}
+
+func (s GophermartService) flushSessionActivity(ctx context.Context) {
+	seen := s.sessionActivity.take()
+	if len(seen) == 0 {
+		return
+	}
+	if err := s.repo.SessionRepo.TouchSessions(ctx, seen); err != nil {
+		log.Err(err).Int("sessions", len(seen)).Msg("session activity flush error")
+	}
+}
@@ -0,0 +1,77 @@
+package gophermartservice
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zaz600/go-musthave-diploma/internal/entity"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/random"
+)
+
+func TestGophermartService_CheckSession(t *testing.T) {
+	ctx := context.Background()
+	s, err := New(nil, WithMemoryStorage())
+	require.NoError(t, err)
+	defer s.Shutdown()
+
+	session, err := s.RegisterUser(ctx, random.String(8), random.String(8), entity.WithDevice("agent", "10.0.0.1"))
+	require.NoError(t, err)
+	other, err := s.RegisterUser(ctx, random.String(8), random.String(8))
+	require.NoError(t, err)
+
+	require.NoError(t, s.CheckSession(ctx, session.UID, session.SessionID))
+	assert.ErrorIs(t, s.CheckSession(ctx, other.UID, session.SessionID), ErrSessionNotFound)
+	assert.ErrorIs(t, s.CheckSession(ctx, session.UID, random.String(32)), ErrSessionNotFound)
+
+	sessions, err := s.GetUserSessions(ctx, session.UID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, "agent", sessions[0].UserAgent)
+	assert.Equal(t, "10.0.0.1", sessions[0].IP)
+
+	// чужую сессию завершить нельзя
+	assert.ErrorIs(t, s.DeleteUserSession(ctx, other.UID, session.SessionID), ErrSessionNotFound)
+	require.NoError(t, s.CheckSession(ctx, session.UID, session.SessionID))
+
+	require.NoError(t, s.DeleteUserSession(ctx, session.UID, session.SessionID))
+	assert.ErrorIs(t, s.CheckSession(ctx, session.UID, session.SessionID), ErrSessionNotFound)
+	assert.ErrorIs(t, s.DeleteUserSession(ctx, session.UID, session.SessionID), ErrSessionNotFound)
+}
+
+func TestGophermartService_RunSessionActivity(t *testing.T) {
+	ctx := context.Background()
+	s, err := New(nil, WithMemoryStorage(), WithSessionActivityInterval(time.Hour))
+	require.NoError(t, err)
+	defer s.Shutdown()
+
+	session, err := s.RegisterUser(ctx, random.String(8), random.String(8))
+	require.NoError(t, err)
+	created, err := s.repo.SessionRepo.GetSession(ctx, session.SessionID)
+	require.NoError(t, err)
+
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(t, s.CheckSession(ctx, session.UID, session.SessionID))
+
+	// время запроса копится в памяти до следующей записи
+	saved, err := s.repo.SessionRepo.GetSession(ctx, session.SessionID)
+	require.NoError(t, err)
+	assert.Equal(t, created.LastSeenAt, saved.LastSeenAt)
+
+	// при остановке накопленное время записывается
+	workCtx, stop := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.RunSessionActivity(workCtx)
+	}()
+	stop()
+	<-done
+
+	saved, err = s.repo.SessionRepo.GetSession(ctx, session.SessionID)
+	require.NoError(t, err)
+	assert.True(t, saved.LastSeenAt.After(created.LastSeenAt))
+	assert.Empty(t, s.sessionActivity.take())
+}
@@ -0,0 +1,85 @@
+package gophermartservice
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/feature"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/ratelimit"
+)
+
+// RuntimeSettings настройки сервиса, которые меняются без перезапуска
+type RuntimeSettings struct {
+	// AccrualRateLimit запросов в минуту к системе расчета начислений
+	AccrualRateLimit     int
+	AccrualRetryInterval time.Duration
+	AccrualMaxRetries    int
+	// RateLimitRules лимиты частоты запросов к API, у новых правил корзины клиентов начинаются заново
+	RateLimitRules []ratelimit.Rule
+	// LoginLimit лимит попыток входа под одним логином. Нулевой лимит выключает ограничение
+	LoginLimit ratelimit.Limit
+	// DisabledFeatures выключенные функции
+	DisabledFeatures feature.Disabled
+}
+
+// ApplySettings применяет настройки к работающему сервису.
+// Заказы, ожидающие повторного запроса, используют новую политику со следующей попытки
+func (s GophermartService) ApplySettings(settings RuntimeSettings) {
+	s.accrualRetry.set(settings.AccrualRetryInterval, settings.AccrualMaxRetries)
+	s.accrualProvider.SetRateLimit(settings.AccrualRateLimit)
+	s.rateLimitRules.set(settings.RateLimitRules)
+	s.loginLimit.set(settings.LoginLimit)
+	s.features.set(settings.DisabledFeatures)
+}
+
+// retryPolicy политика повторных запросов в систему расчета начислений.
+// Общая для всех копий сервиса, поэтому хранится по указателю
+type retryPolicy struct {
+	mu         sync.RWMutex
+	interval   time.Duration
+	maxRetries int
+}
+
+func newRetryPolicy(interval time.Duration, maxRetries int) *retryPolicy {
+	return &retryPolicy{interval: interval, maxRetries: maxRetries}
+}
+
+func (p *retryPolicy) get() (time.Duration, int) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.interval, p.maxRetries
+}
+
+func (p *retryPolicy) set(interval time.Duration, maxRetries int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.interval = interval
+	p.maxRetries = maxRetries
+}
+
+// featureFlags выключенные функции сервиса. Общие для всех копий сервиса, поэтому хранятся по указателю
+type featureFlags struct {
+	mu       sync.RWMutex
+	disabled feature.Disabled
+}
+
+func (f *featureFlags) enabled(name feature.Feature) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.disabled.Enabled(name)
+}
+
+func (f *featureFlags) set(disabled feature.Disabled) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.disabled = disabled
+}
+
+// checkFeature возвращает ErrFeatureDisabled, если функция выключена в конфигурации
+func (s GophermartService) checkFeature(name feature.Feature) error {
+	if !s.features.enabled(name) {
+		return fmt.Errorf("%w: %s", ErrFeatureDisabled, name)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package gophermartservice
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/providers/accrual"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/feature"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/ratelimit"
+)
+
+type rateLimitProvider struct {
+	accrual.Provider
+	perMinute *int
+}
+
+func (p rateLimitProvider) SetRateLimit(perMinute int) {
+	*p.perMinute = perMinute
+}
+
+func TestGophermartService_ApplySettings(t *testing.T) {
+	perMinute := 0
+	s := GophermartService{
+		accrualProvider: rateLimitProvider{perMinute: &perMinute},
+		accrualRetry:    newRetryPolicy(time.Second, accrualDefaultMaxRetries),
+		rateLimitRules:  &rateLimitRules{},
+		loginLimit:      &loginLimit{},
+		features:        &featureFlags{},
+	}
+	// копия сервиса, как в фоновой обработке заказа, видит новые настройки
+	running := s
+
+	s.ApplySettings(RuntimeSettings{
+		AccrualRateLimit:     100,
+		AccrualRetryInterval: time.Minute,
+		AccrualMaxRetries:    2,
+		RateLimitRules:       []ratelimit.Rule{{Method: ratelimit.Any, Route: ratelimit.Any, Limit: ratelimit.Limit{Requests: 1, Period: time.Second}}},
+		LoginLimit:           ratelimit.Limit{Requests: 5, Period: time.Minute},
+		DisabledFeatures:     feature.Disabled{feature.Withdrawals: {}},
+	})
+
+	assert.Equal(t, 100, perMinute)
+	interval, maxRetries := running.accrualRetry.get()
+	assert.Equal(t, time.Minute, interval)
+	assert.Equal(t, 2, maxRetries)
+	assert.Equal(t, time.Minute, running.calcNext(&accrual.GetAccrualResponse{}))
+	assert.Len(t, running.rateLimitRules.get(), 1)
+	assert.Equal(t, 5, running.loginLimit.get().Requests)
+	assert.ErrorIs(t, running.checkFeature(feature.Withdrawals), ErrFeatureDisabled)
+	assert.NoError(t, running.checkFeature(feature.Registration))
+}
+
+// validSettings настройки, которые пропускает проверка конфигурации
+func validSettings() RuntimeSettings {
+	return RuntimeSettings{AccrualRateLimit: 100, AccrualRetryInterval: time.Second, AccrualMaxRetries: 1}
+}
+
+func TestGophermartService_DisabledFeatures(t *testing.T) {
+	ctx := context.Background()
+	s, err := New(nil, WithMemoryStorage())
+	require.NoError(t, err)
+	session, err := s.RegisterUser(ctx, "user1", "secret")
+	require.NoError(t, err)
+
+	settings := validSettings()
+	settings.DisabledFeatures = feature.Disabled{feature.Registration: {}, feature.Withdrawals: {}}
+	s.ApplySettings(settings)
+
+	_, err = s.RegisterUser(ctx, "user2", "secret")
+	assert.ErrorIs(t, err, ErrFeatureDisabled)
+	err = s.UploadWithdrawal(ctx, session.UID, "2377225624", 1)
+	assert.ErrorIs(t, err, ErrFeatureDisabled)
+	// вход не зависит от флагов
+	_, err = s.LoginUser(ctx, "user1", "secret")
+	assert.NoError(t, err)
+
+	s.ApplySettings(validSettings())
+	_, err = s.RegisterUser(ctx, "user2", "secret")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,104 @@
+package gophermartservice
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zaz600/go-musthave-diploma/internal/entity"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/migration"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/sqlitedb"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/random"
+)
+
+func newSQLiteTestService(t *testing.T, path string) (*GophermartService, *sql.DB) {
+	t.Helper()
+	db, err := sql.Open(sqlitedb.DriverName, sqlitedb.DSN(path))
+	require.NoError(t, err)
+	require.NoError(t, migration.Migrate(db, migration.SQLite))
+	require.NoError(t, migration.Check(db, migration.SQLite))
+	s, err := New(nil, WithSQLiteStorage(db))
+	require.NoError(t, err)
+	return s, db
+}
+
+func TestGophermartService_SQLiteStorageSurvivesRestart(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "gophermart.db")
+	login, password := random.String(8), random.String(8)
+
+	s, db := newSQLiteTestService(t, path)
+	session, err := s.RegisterUser(ctx, login, password)
+	require.NoError(t, err)
+	_, err = s.RegisterUser(ctx, login, password)
+	assert.ErrorIs(t, err, ErrUserExists)
+
+	order := entity.NewOrder(session.UID, random.OrderID())
+	require.NoError(t, s.repo.OrderRepo.AddOrder(ctx, order))
+	require.NoError(t, s.repo.OrderRepo.SetOrderStatusAndAccrual(ctx, order.OrderID, entity.OrderStatusProcessed, 100))
+	require.NoError(t, s.repo.AccountRepo.RefillAmount(ctx, session.UID, 100))
+	require.NoError(t, s.UploadWithdrawal(ctx, session.UID, random.OrderID(), 30))
+
+	events, err := s.repo.EventRepo.GetUnpublishedEvents(ctx, "test", 10)
+	require.NoError(t, err)
+	require.NotEmpty(t, events)
+	require.NoError(t, s.repo.EventRepo.MarkPublished(ctx, "test", []int64{events[0].Seq}))
+
+	s.Shutdown()
+	require.NoError(t, db.Close())
+
+	s, db = newSQLiteTestService(t, path)
+	defer db.Close()
+	defer s.Shutdown()
+
+	_, err = s.LoginUser(ctx, login, password)
+	require.NoError(t, err)
+
+	orders, err := s.repo.OrderRepo.GetOrdersByStatus(ctx, entity.OrderStatusNew, entity.OrderStatusProcessed)
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+	assert.Equal(t, order.OrderID, orders[0].OrderID)
+	assert.Equal(t, float32(100), orders[0].Accrual)
+	assert.WithinDuration(t, order.UploadedAt, orders[0].UploadedAt, time.Millisecond)
+
+	current, withdrawn, err := s.GetUserBalance(ctx, session.UID)
+	require.NoError(t, err)
+	assert.Equal(t, float32(70), current)
+	assert.Equal(t, float32(30), withdrawn)
+
+	unpublished, err := s.repo.EventRepo.GetUnpublishedEvents(ctx, "test", 10)
+	require.NoError(t, err)
+	assert.Len(t, unpublished, len(events)-1)
+}
+
+func TestGophermartService_SQLiteClaimDueDeliveries(t *testing.T) {
+	ctx := context.Background()
+	s, db := newSQLiteTestService(t, filepath.Join(t.TempDir(), "gophermart.db"))
+	defer db.Close()
+	defer s.Shutdown()
+
+	subscriber := entity.NewWebhookSubscriber("http://localhost/hook", []entity.EventType{entity.EventUserRegistered}, "secret")
+	require.NoError(t, s.repo.WebhookRepo.AddSubscriber(ctx, subscriber))
+	event, err := entity.NewEvent(entity.EventUserRegistered, random.String(8), entity.UserEventPayload{Login: "user"})
+	require.NoError(t, err)
+	delivery := entity.NewWebhookDelivery(subscriber.ID, event)
+	require.NoError(t, s.repo.WebhookRepo.AddDelivery(ctx, delivery))
+
+	now := time.Now().Add(time.Second)
+	claimed, err := s.repo.WebhookRepo.ClaimDueDeliveries(ctx, now, time.Minute, 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	assert.JSONEq(t, string(event.Payload), string(claimed[0].Event.Payload))
+
+	// доставка отложена на время аренды
+	claimed, err = s.repo.WebhookRepo.ClaimDueDeliveries(ctx, now, time.Minute, 10)
+	require.NoError(t, err)
+	assert.Empty(t, claimed)
+	claimed, err = s.repo.WebhookRepo.ClaimDueDeliveries(ctx, now.Add(2*time.Minute), time.Minute, 10)
+	require.NoError(t, err)
+	assert.Len(t, claimed, 1)
+}
@@ -0,0 +1,33 @@
+package gophermartservice
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/zaz600/go-musthave-diploma/internal/service/gophermartservice"
+
+var tracer = otel.Tracer(instrumentationName)
+
+var (
+	attrUserID  = attribute.Key("user.id")
+	attrOrderID = attribute.Key("order.id")
+)
+
+// startSpan открывает спан метода сервиса
+func startSpan(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "GophermartService."+method, trace.WithAttributes(attrs...))
+}
+
+// endSpan завершает спан, отмечая в нем ошибку, если она есть
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
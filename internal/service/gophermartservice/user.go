@@ -2,40 +2,72 @@ package gophermartservice
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/zaz600/go-musthave-diploma/internal/entity"
 	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/userrepository"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/feature"
 	"github.com/zaz600/go-musthave-diploma/internal/pkg/hasher"
 )
 
-func (s GophermartService) RegisterUser(ctx context.Context, login string, password string) (*entity.Session, error) {
-	// TODO создавать сессию и открывать счет надо в одной транзакции с регистрацией
+// RegisterUser регистрирует пользователя и создает его первую сессию. opts дополняют сессию, например устройством
+func (s GophermartService) RegisterUser(ctx context.Context, login string, password string, opts ...entity.SessionOption) (_ *entity.Session, err error) {
+	ctx, span := startSpan(ctx, "RegisterUser")
+	defer func() { endSpan(span, err) }()
 
-	hashedPassword, err := hasher.HashPassword(password)
-	if err != nil {
+	if err := s.checkFeature(feature.Registration); err != nil {
 		return nil, err
 	}
-	user := entity.NewUserEntity(login, hashedPassword)
-	err = s.repo.UserRepo.AddUser(ctx, user)
+	// иначе можно занять логин, который получит удаленный пользователь, и сорвать удаление
+	if strings.HasPrefix(login, deletedLoginPrefix) {
+		return nil, fmt.Errorf("%w: prefix %q is used for deleted users", ErrReservedLogin, deletedLoginPrefix)
+	}
+	hashedPassword, err := hasher.HashPassword(password, s.passwordHashCost)
 	if err != nil {
-		if errors.Is(err, userrepository.ErrUserExists) {
-			return nil, ErrUserExists
-		}
 		return nil, err
 	}
+	user := entity.NewUserEntity(login, hashedPassword)
+
+	err = s.repo.Transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := s.repo.UserRepo.AddUser(ctx, user)
+		if err != nil {
+			if errors.Is(err, userrepository.ErrUserExists) {
+				return ErrUserExists
+			}
+			return err
+		}
 
-	account := entity.NewAccount(user.UID)
-	err = s.repo.AccountRepo.AddAccount(ctx, account)
+		account := entity.NewAccount(user.UID)
+		err = s.repo.AccountRepo.AddAccount(ctx, account)
+		if err != nil {
+			return err
+		}
+
+		return s.addEvent(ctx, entity.EventUserRegistered, user.UID, entity.UserEventPayload{Login: user.Login})
+	})
 	if err != nil {
 		return nil, err
 	}
-
-	return s.createSession(ctx, user)
+	// сессия создается после фиксации транзакции: если сессии хранятся вне транзакции, например в Redis,
+	// после ошибки фиксации не останется сессии незарегистрированного пользователя.
+	// Если сессию создать не удалось, пользователь уже зарегистрирован и может войти
+	return s.createSession(ctx, user, opts...)
 }
 
-func (s GophermartService) LoginUser(ctx context.Context, login string, password string) (*entity.Session, error) {
+// deletedLoginPrefix префикс логина удаленного пользователя, за ним следует uid
+const deletedLoginPrefix = "deleted:"
+
+func (s GophermartService) LoginUser(ctx context.Context, login string, password string, opts ...entity.SessionOption) (_ *entity.Session, err error) {
+	ctx, span := startSpan(ctx, "LoginUser")
+	defer func() { endSpan(span, err) }()
+
+	if err := s.takeLoginAttempt(ctx, login); err != nil {
+		return nil, err
+	}
+
 	user, err := s.repo.UserRepo.GetUser(ctx, login)
 	if err != nil {
 		if errors.Is(err, userrepository.ErrUserNotFound) {
@@ -48,11 +80,54 @@ func (s GophermartService) LoginUser(ctx context.Context, login string, password
 		return nil, ErrAuth
 	}
 
-	return s.createSession(ctx, user)
+	return s.createSession(ctx, user, opts...)
+}
+
+// DeleteUser удаляет учетную запись по запросу пользователя после проверки пароля.
+// Логин обезличивается, хэш пароля, сессии и ключи идемпотентности удаляются, логин убирается из событий.
+// Заказы, списания и счет остаются: их нужно хранить для финансовой отчетности, но с человеком они больше не связаны
+func (s GophermartService) DeleteUser(ctx context.Context, uid string, password string) (err error) {
+	ctx, span := startSpan(ctx, "DeleteUser", attrUserID.String(uid))
+	defer func() { endSpan(span, err) }()
+
+	user, err := s.repo.UserRepo.GetUserByID(ctx, uid)
+	if err != nil {
+		return err
+	}
+	if !hasher.CheckPasswordHash(password, user.Password) {
+		return ErrAuth
+	}
+
+	login := deletedLoginPrefix + uid
+	redacted, err := json.Marshal(entity.UserEventPayload{Login: login})
+	if err != nil {
+		return err
+	}
+	return s.repo.Transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.UserRepo.AnonymizeUser(ctx, uid, login); err != nil {
+			return err
+		}
+		if _, err := s.repo.IdempotencyRepo.DelUserRecords(ctx, uid); err != nil {
+			return err
+		}
+		if err := s.repo.EventRepo.RedactUserEvents(ctx, uid, entity.EventUserRegistered, redacted); err != nil {
+			return err
+		}
+		if err := s.repo.WebhookRepo.RedactUserDeliveries(ctx, uid, entity.EventUserRegistered, redacted); err != nil {
+			return err
+		}
+		if err := s.addEvent(ctx, entity.EventUserDeleted, uid, entity.UserEventPayload{Login: login}); err != nil {
+			return err
+		}
+		// сессии удаляются последними: если они хранятся вне транзакции, например в Redis,
+		// после ошибки в транзакции пользователь останется со своими сессиями
+		_, err := s.repo.SessionRepo.DelUserSessions(ctx, uid)
+		return err
+	})
 }
 
-func (s GophermartService) createSession(ctx context.Context, user entity.UserEntity) (*entity.Session, error) {
-	session := entity.NewRandomSession(user.UID)
+func (s GophermartService) createSession(ctx context.Context, user entity.UserEntity, opts ...entity.SessionOption) (*entity.Session, error) {
+	session := entity.New(user.UID, opts...)
 	if err := s.repo.SessionRepo.AddSession(ctx, session); err != nil {
 		return nil, fmt.Errorf("error creating user session: %w", err)
 	}
@@ -0,0 +1,68 @@
+package gophermartservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/zaz600/go-musthave-diploma/internal/entity"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/webhookrepository"
+)
+
+func (s GophermartService) AddWebhookSubscriber(ctx context.Context, subscriberURL string, eventTypes []entity.EventType, secret string) (entity.WebhookSubscriber, error) {
+	u, err := url.Parse(subscriberURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return entity.WebhookSubscriber{}, fmt.Errorf("%w: invalid url %q", ErrInvalidWebhook, subscriberURL)
+	}
+	if len(eventTypes) == 0 {
+		return entity.WebhookSubscriber{}, fmt.Errorf("%w: empty event types", ErrInvalidWebhook)
+	}
+	for _, eventType := range eventTypes {
+		if !entity.IsKnownEventType(eventType) {
+			return entity.WebhookSubscriber{}, fmt.Errorf("%w: unknown event type %q", ErrInvalidWebhook, eventType)
+		}
+	}
+	if secret == "" {
+		return entity.WebhookSubscriber{}, fmt.Errorf("%w: empty secret", ErrInvalidWebhook)
+	}
+
+	subscriber := entity.NewWebhookSubscriber(subscriberURL, eventTypes, secret)
+	if err := s.repo.WebhookRepo.AddSubscriber(ctx, subscriber); err != nil {
+		return entity.WebhookSubscriber{}, err
+	}
+	return subscriber, nil
+}
+
+func (s GophermartService) GetWebhookSubscribers(ctx context.Context) ([]entity.WebhookSubscriber, error) {
+	return s.repo.WebhookRepo.GetSubscribers(ctx)
+}
+
+func (s GophermartService) DeleteWebhookSubscriber(ctx context.Context, subscriberID string) error {
+	err := s.repo.WebhookRepo.DelSubscriber(ctx, subscriberID)
+	if errors.Is(err, webhookrepository.ErrSubscriberNotFound) {
+		return ErrWebhookNotFound
+	}
+	return err
+}
+
+// GetWebhookDeadLetters доставки, для которых исчерпаны все попытки
+func (s GophermartService) GetWebhookDeadLetters(ctx context.Context, limit int) ([]entity.WebhookDelivery, error) {
+	return s.repo.WebhookRepo.GetDeliveries(ctx, entity.DeliveryStatusDead, limit)
+}
+
+// RedeliverWebhook возвращает доставку из dead-letter в очередь с обнуленным счетчиком попыток
+func (s GophermartService) RedeliverWebhook(ctx context.Context, deliveryID string) error {
+	delivery, err := s.repo.WebhookRepo.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		if errors.Is(err, webhookrepository.ErrDeliveryNotFound) {
+			return ErrDeliveryNotFound
+		}
+		return err
+	}
+	delivery.Status = entity.DeliveryStatusPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = time.Now()
+	return s.repo.WebhookRepo.UpdateDelivery(ctx, delivery)
+}
@@ -0,0 +1,135 @@
+package gophermartservice
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zaz600/go-musthave-diploma/internal/entity"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/webhookrepository"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/signature"
+)
+
+const (
+	webhookBatchSize = 100
+	// webhookMaxRetryInterval верхняя граница интервала между попытками доставки
+	webhookMaxRetryInterval = 1 * time.Hour
+)
+
+// RunWebhookDispatcher раскладывает события из outbox по подписчикам и доставляет их,
+// пока не будет отменен ctx
+func (s GophermartService) RunWebhookDispatcher(ctx context.Context) {
+	ticker := time.NewTicker(s.webhookPollInterval)
+	defer ticker.Stop()
+	sink := webhookSink{repo: s.repo.WebhookRepo}
+	for {
+		if err := s.relayEvents(ctx, sink); err != nil {
+			log.Err(err).Msg("webhook fan-out error")
+		}
+		if err := s.deliverWebhooks(ctx); err != nil {
+			log.Err(err).Msg("webhook delivery error")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s GophermartService) deliverWebhooks(ctx context.Context) error {
+	now := time.Now()
+	deliveries, err := s.repo.WebhookRepo.ClaimDueDeliveries(ctx, now, s.webhookClient.Timeout+s.webhookPollInterval, webhookBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, delivery := range deliveries {
+		subscriber, err := s.repo.WebhookRepo.GetSubscriber(ctx, delivery.SubscriberID)
+		switch {
+		case errors.Is(err, webhookrepository.ErrSubscriberNotFound):
+			delivery.Status = entity.DeliveryStatusDead
+			delivery.LastError = err.Error()
+		case err != nil:
+			return err
+		default:
+			s.deliverWebhook(ctx, subscriber, &delivery)
+		}
+
+		if err := s.repo.WebhookRepo.UpdateDelivery(ctx, delivery); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deliverWebhook делает одну попытку доставки и вычисляет новое состояние delivery
+func (s GophermartService) deliverWebhook(ctx context.Context, subscriber entity.WebhookSubscriber, delivery *entity.WebhookDelivery) {
+	delivery.Attempts++
+	err := s.postWebhook(ctx, subscriber, delivery)
+	if err == nil {
+		delivery.Status = entity.DeliveryStatusDelivered
+		delivery.LastError = ""
+		log.Info().Str("deliveryID", delivery.ID).Str("eventID", delivery.Event.EventID).Str("url", subscriber.URL).Msg("webhook delivered")
+		return
+	}
+
+	delivery.LastError = err.Error()
+	if delivery.Attempts >= s.webhookMaxAttempts {
+		delivery.Status = entity.DeliveryStatusDead
+		log.Warn().Err(err).Str("deliveryID", delivery.ID).Int("attempts", delivery.Attempts).Msg("webhook moved to dead-letter")
+		return
+	}
+	delivery.NextAttemptAt = time.Now().Add(s.webhookBackoff(delivery.Attempts))
+	log.Info().Err(err).Str("deliveryID", delivery.ID).Int("attempts", delivery.Attempts).Time("nextAttemptAt", delivery.NextAttemptAt).Msg("webhook delivery failed")
+}
+
+func (s GophermartService) postWebhook(ctx context.Context, subscriber entity.WebhookSubscriber, delivery *entity.WebhookDelivery) error {
+	body, err := json.Marshal(delivery.Event)
+	if err != nil {
+		return err
+	}
+	timestamp := time.Now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscriber.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gophermart-Event", string(delivery.Event.Type))
+	req.Header.Set("X-Gophermart-Delivery", delivery.ID)
+	req.Header.Set("X-Gophermart-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("X-Gophermart-Signature", signature.Sign(subscriber.Secret, timestamp, body))
+
+	resp, err := s.webhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// webhookBackoff экспоненциальный интервал до следующей попытки
+func (s GophermartService) webhookBackoff(attempts int) time.Duration {
+	next := s.webhookRetryInterval
+	for i := 1; i < attempts && next < webhookMaxRetryInterval; i++ {
+		next *= 2
+	}
+	if next > webhookMaxRetryInterval {
+		next = webhookMaxRetryInterval
+	}
+	return next
+}
@@ -0,0 +1,17 @@
+package gophermartservice
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGophermartService_webhookBackoff(t *testing.T) {
+	s := GophermartService{webhookRetryInterval: time.Second}
+
+	assert.Equal(t, 1*time.Second, s.webhookBackoff(1))
+	assert.Equal(t, 2*time.Second, s.webhookBackoff(2))
+	assert.Equal(t, 4*time.Second, s.webhookBackoff(3))
+	assert.Equal(t, webhookMaxRetryInterval, s.webhookBackoff(100))
+}
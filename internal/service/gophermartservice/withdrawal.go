@@ -2,21 +2,59 @@ package gophermartservice
 
 import (
 	"context"
+	"errors"
 
 	"github.com/zaz600/go-musthave-diploma/internal/entity"
+	"github.com/zaz600/go-musthave-diploma/internal/infrastructure/repository/accountrepository"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/feature"
+	"github.com/zaz600/go-musthave-diploma/internal/pkg/luhn"
 )
 
-func (s GophermartService) UploadWithdrawal(ctx context.Context, userID string, orderID string, sum float32) error {
-	// TODO баланс изменять надо в одной транзакции с регистрацией списания
+// UploadWithdrawal списывает баллы со счета пользователя в счет заказа orderID
+func (s GophermartService) UploadWithdrawal(ctx context.Context, userID string, orderID string, sum float32) (err error) {
+	ctx, span := startSpan(ctx, "UploadWithdrawal", attrUserID.String(userID), attrOrderID.String(orderID))
+	defer func() { endSpan(span, err) }()
 
-	withdrawal := entity.NewWithdrawal(userID, orderID, sum)
-	err := s.repo.WithdrawalRepo.AddWithdrawal(ctx, withdrawal)
-	if err != nil {
+	if err := s.checkFeature(feature.Withdrawals); err != nil {
 		return err
 	}
-	return s.repo.AccountRepo.WithdrawalAmount(ctx, userID, sum)
+	if ok := luhn.CheckLuhn(orderID); !ok {
+		return ErrInvalidOrderFormat
+	}
+
+	withdrawal := entity.NewWithdrawal(userID, orderID, sum)
+	return s.repo.Transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		account, err := s.repo.AccountRepo.GetAccount(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if account.Balance < sum {
+			return ErrInsufficientFunds
+		}
+
+		err = s.repo.WithdrawalRepo.AddWithdrawal(ctx, withdrawal)
+		if err != nil {
+			return err
+		}
+		err = s.repo.AccountRepo.WithdrawalAmount(ctx, userID, sum)
+		if errors.Is(err, accountrepository.ErrInsufficientBalance) {
+			// баланс изменился после проверки, списание отклонило ограничение хранилища
+			return ErrInsufficientFunds
+		}
+		if err != nil {
+			return err
+		}
+		return s.addEvent(ctx, entity.EventWithdrawalCreated, userID, entity.WithdrawalEventPayload{
+			OrderID:     withdrawal.OrderID,
+			Sum:         withdrawal.Sum,
+			ProcessedAt: withdrawal.ProcessedAt,
+		})
+	})
 }
 
-func (s GophermartService) GetUserWithdrawals(ctx context.Context, userID string) ([]entity.Withdrawal, error) {
+func (s GophermartService) GetUserWithdrawals(ctx context.Context, userID string) (_ []entity.Withdrawal, err error) {
+	ctx, span := startSpan(ctx, "GetUserWithdrawals", attrUserID.String(userID))
+	defer func() { endSpan(span, err) }()
+
 	return s.repo.WithdrawalRepo.GetUserWithdrawals(ctx, userID)
 }